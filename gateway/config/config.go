@@ -18,6 +18,31 @@ type Config struct {
 type ClusterConfig struct {
 	LetsEncryptEmail string `json:"letsencryptEmail" yaml:"letsencryptEmail" mapstructure:"letsencryptEmail"`
 	EnableTelemetry  bool   `json:"enableTelemetry" yaml:"enableTelemetry" mapstructure:"enableTelemetry"`
+
+	Compression *CompressionConfig `json:"compression,omitempty" yaml:"compression,omitempty" mapstructure:"compression"`
+
+	Audit *AuditConfig `json:"audit,omitempty" yaml:"audit,omitempty" mapstructure:"audit"`
+}
+
+// AuditConfig describes where the audit trail of config changes is stored. The trail is stored in files
+// in local mode if a database isn't provided.
+type AuditConfig struct {
+	Project string `json:"project,omitempty" yaml:"project,omitempty" mapstructure:"project"`
+	DBAlias string `json:"dbAlias,omitempty" yaml:"dbAlias,omitempty" mapstructure:"dbAlias"`
+
+	// Col is the table the entries are stored in, default value is config_audit_logs if not provided
+	Col string `json:"col,omitempty" yaml:"col,omitempty" mapstructure:"col"`
+}
+
+// CompressionConfig describes the config for compressing http responses of the gateway
+type CompressionConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+
+	// MinSize is the minimum size of the response in bytes for it to get compressed, default value is 1024 bytes if not provided
+	MinSize int `json:"minSize,omitempty" yaml:"minSize,omitempty" mapstructure:"minSize"`
+
+	// ContentTypes is the list of content types (or their prefixes like `text/`) which are to be compressed
+	ContentTypes []string `json:"contentTypes,omitempty" yaml:"contentTypes,omitempty" mapstructure:"contentTypes"`
 }
 
 // Projects is a map which stores config information of all project in a cluster
@@ -41,6 +66,12 @@ type EventingSchemas map[string]*EventingSchema // Key here is resource id --> c
 // EventingRules is a map which stores database config information
 type EventingRules map[string]*Rule // Key here is resource id --> clusterId--projectId--resourceType--ruleId
 
+// SecurityPolicies is a map which stores the rego policies used by security rules
+type SecurityPolicies map[string]*SecurityPolicy // Key here is resource id --> clusterId--projectId--resourceType--policyId
+
+// APIKeys is a map which stores the api keys of a project
+type APIKeys map[string]*APIKey // Key here is resource id --> clusterId--projectId--resourceType--keyId
+
 // EventingTriggers is a map which stores database config information
 type EventingTriggers map[string]*EventingTrigger // Key here is resource id --> clusterId--projectId--resourceType--triggerId
 
@@ -75,6 +106,9 @@ type Project struct {
 	IngressGlobal *GlobalRoutesConfig `json:"ingressGlobal" yaml:"ingressGlobal" mapstructure:"ingressGlobal"`
 
 	RemoteService Services `json:"remoteServices" yaml:"remoteServices" mapstructure:"remoteServices"`
+
+	SecurityPolicies SecurityPolicies `json:"securityPolicies" yaml:"securityPolicies" mapstructure:"securityPolicies"`
+	APIKeys          APIKeys          `json:"apiKeys" yaml:"apiKeys" mapstructure:"apiKeys"`
 }
 
 // ProjectConfig stores information of individual project
@@ -84,9 +118,27 @@ type ProjectConfig struct {
 	Secrets            []*Secret `json:"secrets,omitempty" yaml:"secrets,omitempty" mapstructure:"secrets"`
 	SecretSource       string    `json:"secretSource,omitempty" yaml:"secretSource,omitempty" mapstructure:"secretSource"`
 	IsIntegration      bool      `json:"isIntegration,omitempty" yaml:"isIntegration,omitempty" mapstructure:"isIntegration"`
-	AESKey             string    `json:"aesKey,omitempty" yaml:"aesKey,omitempty" mapstructure:"aesKey"`
+	AESKey             string    `json:"aesKey,omitempty" yaml:"aesKey,omitempty" mapstructure:"aesKey"` // legacy key used in aes cfb mode when the key ring is empty
+	AESKeys            []*AESKey `json:"aesKeys,omitempty" yaml:"aesKeys,omitempty" mapstructure:"aesKeys"`
 	DockerRegistry     string    `json:"dockerRegistry,omitempty" yaml:"dockerRegistry,omitempty" mapstructure:"dockerRegistry"`
 	ContextTimeGraphQL int       `json:"contextTimeGraphQL,omitempty" yaml:"contextTimeGraphQL,omitempty" mapstructure:"contextTimeGraphQL"` // contextTime sets the timeout of query
+
+	KeyRotation *KeyRotation `json:"keyRotation,omitempty" yaml:"keyRotation,omitempty" mapstructure:"keyRotation"`
+}
+
+// KeyRotation is the policy to periodically replace the primary secret with a newly generated RS256 key pair
+type KeyRotation struct {
+	Enabled     bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	Interval    string `json:"interval,omitempty" yaml:"interval,omitempty" mapstructure:"interval"`          // time after which the primary key is replaced, defaults to 720h
+	GracePeriod string `json:"gracePeriod,omitempty" yaml:"gracePeriod,omitempty" mapstructure:"gracePeriod"` // time for which a new key is published before it becomes primary, defaults to 24h
+	MaxTokenAge string `json:"maxTokenAge,omitempty" yaml:"maxTokenAge,omitempty" mapstructure:"maxTokenAge"` // time after which a replaced key is retired, defaults to the longest ttl of the tokens issued by the gateway
+}
+
+// AESKey is a versioned key of the key ring used by the encrypt and decrypt rules
+type AESKey struct {
+	KID       string `json:"kid" yaml:"kid" mapstructure:"kid"`                   // embedded in the values encrypted with this key
+	Key       string `json:"key" yaml:"key" mapstructure:"key"`                   // base64 encoded key of 16, 24 or 32 bytes
+	IsPrimary bool   `json:"isPrimary" yaml:"isPrimary" mapstructure:"isPrimary"` // new values are encrypted with the primary key
 }
 
 // DriverConfig stores the parameters for drivers of Databases.
@@ -180,6 +232,11 @@ type Secret struct {
 	// Use for RSA256
 	PublicKey  string `json:"publicKey" yaml:"publicKey" mapstructure:"publicKey"`
 	PrivateKey string `json:"privateKey" yaml:"privateKey" mapstructure:"privateKey"`
+
+	// Used by key rotation to track the lifecycle of a key. All values are in RFC3339 format.
+	CreatedAt  string `json:"createdAt,omitempty" yaml:"createdAt,omitempty" mapstructure:"createdAt"`
+	PromotedAt string `json:"promotedAt,omitempty" yaml:"promotedAt,omitempty" mapstructure:"promotedAt"`
+	DemotedAt  string `json:"demotedAt,omitempty" yaml:"demotedAt,omitempty" mapstructure:"demotedAt"`
 }
 
 // JWTAlg is type of method used for signing token
@@ -210,6 +267,40 @@ type AdminUser struct {
 	User   string `json:"user" yaml:"user" mapstructure:"user"`
 	Pass   string `json:"pass" yaml:"pass" mapstructure:"pass"`
 	Secret string `json:"secret" yaml:"secret" mapstructure:"secret"`
+
+	// The admin above always has full access. The admins below only get the permissions of their roles.
+	Accounts []*AdminAccount `json:"accounts,omitempty" yaml:"accounts,omitempty" mapstructure:"accounts"`
+	OIDC     *AdminOIDC      `json:"oidc,omitempty" yaml:"oidc,omitempty" mapstructure:"oidc"`
+	Roles    AdminRoles      `json:"roles,omitempty" yaml:"roles,omitempty" mapstructure:"roles"`
+}
+
+// AdminAccount is a named admin account which signs in with a password
+type AdminAccount struct {
+	User string `json:"user" yaml:"user" mapstructure:"user"`
+	// Pass is the hash of the password generated with bcrypt, argon2id or scrypt
+	Pass  string   `json:"pass" yaml:"pass" mapstructure:"pass"`
+	Roles []string `json:"roles" yaml:"roles" mapstructure:"roles"`
+}
+
+// AdminOIDC lets admins sign in with the id token issued to them by an openid connect provider
+type AdminOIDC struct {
+	Issuer   string `json:"issuer" yaml:"issuer" mapstructure:"issuer"`
+	ClientID string `json:"clientId" yaml:"clientId" mapstructure:"clientId"`
+	// Admins maps the emails of the admins to their roles
+	Admins map[string][]string `json:"admins,omitempty" yaml:"admins,omitempty" mapstructure:"admins"`
+	// RolesClaim is the claim of the id token (e.g. `groups`) holding the names of additional roles of the admin
+	RolesClaim string `json:"rolesClaim,omitempty" yaml:"rolesClaim,omitempty" mapstructure:"rolesClaim"`
+}
+
+// AdminRoles holds the permissions of the admin roles. The key here is the name of the role.
+type AdminRoles map[string][]*AdminPermission
+
+// AdminPermission grants the verbs (e.g. read, modify, delete) on the config resources (e.g. db-schema) of the
+// projects listed. A `*` matches everything.
+type AdminPermission struct {
+	Projects  []string `json:"projects" yaml:"projects" mapstructure:"projects"`
+	Resources []string `json:"resources" yaml:"resources" mapstructure:"resources"`
+	Verbs     []string `json:"verbs" yaml:"verbs" mapstructure:"verbs"`
 }
 
 // SSL holds the certificate and key file locations
@@ -262,6 +353,8 @@ type Rule struct {
 	ID       string                 `json:"id,omitempty" yaml:"id,omitempty" mapstructure:"id"`
 	Rule     string                 `json:"rule" yaml:"rule" mapstructure:"rule"`
 	Eval     string                 `json:"eval,omitempty" yaml:"eval,omitempty" mapstructure:"eval"`
+	Policy   string                 `json:"policy,omitempty" yaml:"policy,omitempty" mapstructure:"policy"`
+	Expr     string                 `json:"expr,omitempty" yaml:"expr,omitempty" mapstructure:"expr"`
 	Type     string                 `json:"type,omitempty" yaml:"type,omitempty" mapstructure:"type"`
 	F1       interface{}            `json:"f1,omitempty" yaml:"f1,omitempty" mapstructure:"f1"`
 	F2       interface{}            `json:"f2,omitempty" yaml:"f2,omitempty" mapstructure:"f2"`
@@ -282,6 +375,30 @@ type Rule struct {
 	ReqTmpl  string                 `json:"requestTemplate,omitempty" yaml:"requestTemplate,omitempty" mapstructure:"requestTemplate"`
 	OpFormat string                 `json:"outputFormat,omitempty" yaml:"outputFormat,omitempty" mapstructure:"outputFormat"`
 	Cache    *ReadCacheOptions      `json:"cache,omitempty" yaml:"cache,omitempty" mapstructure:"cache"`
+
+	// Algorithm is the algorithm used by the hash rule. It can be sha256 (default), bcrypt, argon2id or scrypt
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty" mapstructure:"algorithm"`
+}
+
+// SecurityPolicy holds a rego policy module evaluated by the rego security rules. The package of the
+// module must define an `allow` rule and can optionally define a `where` object for filtering reads.
+type SecurityPolicy struct {
+	ID     string `json:"id" yaml:"id" mapstructure:"id"`
+	Module string `json:"module" yaml:"module" mapstructure:"module"`
+}
+
+// APIKey holds a key used by servers to access the apis of a project. Only the hash of the key is stored.
+// Scopes are of the form `db:<dbAlias>:<table>:<op>`, `prepared-query:<dbAlias>:<id>`, `eventing:<type>`,
+// `services:<service>:<endpoint>`, `file:<op>` and `routes`. Any segment can be `*` and a scope with fewer
+// segments grants access to everything below it.
+type APIKey struct {
+	ID         string                 `json:"id" yaml:"id" mapstructure:"id"`
+	Name       string                 `json:"name,omitempty" yaml:"name,omitempty" mapstructure:"name"`
+	Hash       string                 `json:"hash,omitempty" yaml:"hash" mapstructure:"hash"`
+	Scopes     []string               `json:"scopes" yaml:"scopes" mapstructure:"scopes"`
+	Claims     map[string]interface{} `json:"claims,omitempty" yaml:"claims,omitempty" mapstructure:"claims"`
+	AllowedIPs []string               `json:"allowedIPs,omitempty" yaml:"allowedIPs,omitempty" mapstructure:"allowedIPs"` // IPs or CIDR ranges
+	ExpiresAt  string                 `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty" mapstructure:"expiresAt"`    // RFC3339 timestamp
 }
 
 // Auths holds the mapping of the sign in method
@@ -292,6 +409,88 @@ type AuthStub struct {
 	ID      string `json:"id" yaml:"id" mapstructure:"id"`
 	Enabled bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
 	Secret  string `json:"secret" yaml:"secret" mapstructure:"secret"`
+
+	// HashAlgorithm is the algorithm used to hash passwords. It can be bcrypt (default), argon2id or scrypt
+	HashAlgorithm string `json:"hashAlgorithm,omitempty" yaml:"hashAlgorithm,omitempty" mapstructure:"hashAlgorithm"`
+
+	// Used by OpenID Connect providers. An auth stub having an issuer is considered to be an OpenID Connect provider
+	// and the secret is used as its client secret.
+	Issuer         string   `json:"issuer,omitempty" yaml:"issuer,omitempty" mapstructure:"issuer"`
+	ClientID       string   `json:"clientId,omitempty" yaml:"clientId,omitempty" mapstructure:"clientId"`
+	RedirectURL    string   `json:"redirectUrl,omitempty" yaml:"redirectUrl,omitempty" mapstructure:"redirectUrl"`          // callback url of the gateway registered with the provider
+	Scopes         []string `json:"scopes,omitempty" yaml:"scopes,omitempty" mapstructure:"scopes"`                         // defaults to openid, email and profile
+	AppRedirectURL string   `json:"appRedirectUrl,omitempty" yaml:"appRedirectUrl,omitempty" mapstructure:"appRedirectUrl"` // url the token is sent to in the fragment after signing in
+
+	// Used by the email method to verify emails and reset passwords
+	RequireVerifiedEmail  bool    `json:"requireVerifiedEmail,omitempty" yaml:"requireVerifiedEmail,omitempty" mapstructure:"requireVerifiedEmail"`
+	VerificationTokenTTL  string  `json:"verificationTokenTTL,omitempty" yaml:"verificationTokenTTL,omitempty" mapstructure:"verificationTokenTTL"`    // defaults to 24h
+	PasswordResetTokenTTL string  `json:"passwordResetTokenTTL,omitempty" yaml:"passwordResetTokenTTL,omitempty" mapstructure:"passwordResetTokenTTL"` // defaults to 1h
+	Mailer                *Mailer `json:"mailer,omitempty" yaml:"mailer,omitempty" mapstructure:"mailer"`
+
+	// Sessions issues short lived access tokens along with rotating refresh tokens on signing in with this method
+	Sessions *Sessions `json:"sessions,omitempty" yaml:"sessions,omitempty" mapstructure:"sessions"`
+
+	// MFA lets the users of the email method enroll for totp based multi factor authentication
+	MFA *MFA `json:"mfa,omitempty" yaml:"mfa,omitempty" mapstructure:"mfa"`
+
+	// BruteForce limits the failed sign in attempts of the email method
+	BruteForce *BruteForce `json:"bruteForce,omitempty" yaml:"bruteForce,omitempty" mapstructure:"bruteForce"`
+}
+
+// BruteForce describes the protection against guessing passwords. Failed attempts are counted per account and per
+// ip. Sign ins get delayed progressively as failures pile up and get refused while the account or ip is locked.
+type BruteForce struct {
+	Enabled            bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	MaxAccountAttempts int    `json:"maxAccountAttempts,omitempty" yaml:"maxAccountAttempts,omitempty" mapstructure:"maxAccountAttempts"` // defaults to 5
+	MaxIPAttempts      int    `json:"maxIpAttempts,omitempty" yaml:"maxIpAttempts,omitempty" mapstructure:"maxIpAttempts"`                // defaults to 20
+	Window             string `json:"window,omitempty" yaml:"window,omitempty" mapstructure:"window"`                                     // failures older than this are forgotten, defaults to 15m
+	LockoutDuration    string `json:"lockoutDuration,omitempty" yaml:"lockoutDuration,omitempty" mapstructure:"lockoutDuration"`          // defaults to 15m
+	MaxDelay           string `json:"maxDelay,omitempty" yaml:"maxDelay,omitempty" mapstructure:"maxDelay"`                               // cap of the progressive delay, defaults to 5s
+	// LockoutEventType is the type of the event queued when an account gets locked
+	LockoutEventType string `json:"lockoutEventType,omitempty" yaml:"lockoutEventType,omitempty" mapstructure:"lockoutEventType"`
+}
+
+// MFA describes the totp based multi factor authentication of the email method. Users who have enrolled need to
+// verify a code after signing in with their password. Tokens carry the `amr` and `mfa` claims which security
+// rules can match on.
+type MFA struct {
+	Enabled bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	Issuer  string `json:"issuer,omitempty" yaml:"issuer,omitempty" mapstructure:"issuer"` // shown in authenticator apps, defaults to the project id
+}
+
+// Sessions describes the server side sessions created on signing in. Sessions are stored in the sessions table
+// of the database the user signed in with.
+type Sessions struct {
+	Enabled         bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	AccessTokenTTL  string `json:"accessTokenTTL,omitempty" yaml:"accessTokenTTL,omitempty" mapstructure:"accessTokenTTL"`    // defaults to 15m
+	RefreshTokenTTL string `json:"refreshTokenTTL,omitempty" yaml:"refreshTokenTTL,omitempty" mapstructure:"refreshTokenTTL"` // defaults to 720h, extended on every refresh
+	MaxAge          string `json:"maxAge,omitempty" yaml:"maxAge,omitempty" mapstructure:"maxAge"`                            // defaults to 2160h, the session cannot be extended beyond it
+}
+
+// Mailer sends the emails of user management either over smtp or by queueing an event for a trigger
+type Mailer struct {
+	Type string `json:"type" yaml:"type" mapstructure:"type"` // smtp or eventing
+
+	// Used by the smtp mailer
+	Host     string `json:"host,omitempty" yaml:"host,omitempty" mapstructure:"host"`
+	Port     int    `json:"port,omitempty" yaml:"port,omitempty" mapstructure:"port"`
+	Username string `json:"username,omitempty" yaml:"username,omitempty" mapstructure:"username"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty" mapstructure:"password"`
+	From     string `json:"from,omitempty" yaml:"from,omitempty" mapstructure:"from"`
+
+	// Used by the eventing mailer
+	EventType string `json:"eventType,omitempty" yaml:"eventType,omitempty" mapstructure:"eventType"`
+
+	// The key here is either verify-email or reset-password
+	Templates map[string]*MailTemplate `json:"templates,omitempty" yaml:"templates,omitempty" mapstructure:"templates"`
+}
+
+// MailTemplate is the go template of an email. The token, its expiry and the email and name of the user are
+// available in the template.
+type MailTemplate struct {
+	Subject string `json:"subject" yaml:"subject" mapstructure:"subject"`
+	Body    string `json:"body" yaml:"body" mapstructure:"body"`
+	IsHTML  bool   `json:"isHtml,omitempty" yaml:"isHtml,omitempty" mapstructure:"isHtml"`
 }
 
 // ServicesModule holds the config for the service module
@@ -31,5 +31,7 @@ func GenerateEmptyProject(project *ProjectConfig) *Project {
 		IngressRoutes:           make(IngressRoutes),
 		IngressGlobal:           new(GlobalRoutesConfig),
 		RemoteService:           make(Services),
+		SecurityPolicies:        make(SecurityPolicies),
+		APIKeys:                 make(APIKeys),
 	}
 }
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/spaceuptech/helpers"
+)
+
+// placeholderRegex matches the ${env:NAME} and ${secret:name.key} placeholders which can be used in any string field of the config
+var placeholderRegex = regexp.MustCompile(`\$\{(env|secret):([^}]*)\}`)
+
+// SecretResolver returns the value stored in the key of a secret
+type SecretResolver func(secretName, key string) (string, error)
+
+// HasPlaceholders checks if the string has any placeholders in it
+func HasPlaceholders(s string) bool {
+	return placeholderRegex.MatchString(s)
+}
+
+// Interpolate resolves the placeholders in the string fields of the value ptr points to. The value is replaced with a
+// copy having the placeholders resolved, leaving the one it pointed to untouched so that the placeholders are what
+// gets stored and returned by the config apis. Secrets cannot be used if getSecret is nil.
+func Interpolate(ctx context.Context, ptr interface{}, getSecret SecretResolver) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to resolve placeholders in config", fmt.Errorf("expected a pointer but got (%T)", ptr), nil)
+	}
+
+	value, changed, err := interpolateValue(v.Elem(), getSecret)
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to resolve placeholders in config", err, nil)
+	}
+	if changed {
+		v.Elem().Set(value)
+	}
+	return nil
+}
+
+// InterpolateString resolves the placeholders in the string provided
+func InterpolateString(ctx context.Context, s string, getSecret SecretResolver) (string, error) {
+	value, err := interpolateString(s, getSecret)
+	if err != nil {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to resolve placeholders in config", err, nil)
+	}
+	return value, nil
+}
+
+// interpolateValue returns a copy of the value with the placeholders resolved. The value itself is returned
+// when it doesn't have any placeholders, so that only the parts of the config using them get copied.
+func interpolateValue(v reflect.Value, getSecret SecretResolver) (reflect.Value, bool, error) {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := interpolateString(v.String(), getSecret)
+		if err != nil || s == v.String() {
+			return v, false, err
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.SetString(s)
+		return out, true, nil
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false, nil
+		}
+		elem, changed, err := interpolateValue(v.Elem(), getSecret)
+		if err != nil || !changed {
+			return v, false, err
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(elem)
+		return out, true, nil
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false, nil
+		}
+		elem, changed, err := interpolateValue(v.Elem(), getSecret)
+		if err != nil || !changed {
+			return v, false, err
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(elem)
+		return out, true, nil
+
+	case reflect.Struct:
+		var out reflect.Value
+		for i := 0; i < v.NumField(); i++ {
+			// Unexported fields cannot be set
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			value, changed, err := interpolateValue(v.Field(i), getSecret)
+			if err != nil {
+				return v, false, fmt.Errorf("%s: %v", v.Type().Field(i).Name, err)
+			}
+			if !changed {
+				continue
+			}
+			if !out.IsValid() {
+				out = reflect.New(v.Type()).Elem()
+				out.Set(v)
+			}
+			out.Field(i).Set(value)
+		}
+		if !out.IsValid() {
+			return v, false, nil
+		}
+		return out, true, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v, false, nil
+		}
+		changes := map[interface{}]reflect.Value{}
+		iter := v.MapRange()
+		for iter.Next() {
+			value, changed, err := interpolateValue(iter.Value(), getSecret)
+			if err != nil {
+				return v, false, fmt.Errorf("%v: %v", iter.Key().Interface(), err)
+			}
+			if changed {
+				changes[iter.Key().Interface()] = value
+			}
+		}
+		if len(changes) == 0 {
+			return v, false, nil
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter = v.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			if changed, ok := changes[iter.Key().Interface()]; ok {
+				value = changed
+			}
+			out.SetMapIndex(iter.Key(), value)
+		}
+		return out, true, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v, false, nil
+		}
+		var out reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			elem, changed, err := interpolateValue(v.Index(i), getSecret)
+			if err != nil {
+				return v, false, fmt.Errorf("[%d]: %v", i, err)
+			}
+			if !changed {
+				continue
+			}
+			if !out.IsValid() {
+				if v.Kind() == reflect.Slice {
+					out = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+					reflect.Copy(out, v)
+				} else {
+					out = reflect.New(v.Type()).Elem()
+					out.Set(v)
+				}
+			}
+			out.Index(i).Set(elem)
+		}
+		if !out.IsValid() {
+			return v, false, nil
+		}
+		return out, true, nil
+	}
+	return v, false, nil
+}
+
+func interpolateString(s string, getSecret SecretResolver) (string, error) {
+	var resolveErr error
+	value := placeholderRegex.ReplaceAllStringFunc(s, func(placeholder string) string {
+		if resolveErr != nil {
+			return placeholder
+		}
+		match := placeholderRegex.FindStringSubmatch(placeholder)
+		value, err := resolvePlaceholder(match[1], match[2], getSecret)
+		if err != nil {
+			resolveErr = err
+			return placeholder
+		}
+		return value
+	})
+	return value, resolveErr
+}
+
+func resolvePlaceholder(kind, name string, getSecret SecretResolver) (string, error) {
+	switch kind {
+	case "env":
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable (%s) used in config is not set", name)
+		}
+		return value, nil
+
+	case "secret":
+		arr := strings.SplitN(name, ".", 2)
+		if len(arr) != 2 || arr[0] == "" || arr[1] == "" {
+			return "", fmt.Errorf("invalid secret placeholder (${secret:%s}) provided - it should be of the form ${secret:name.key}", name)
+		}
+		if getSecret == nil {
+			return "", fmt.Errorf("secret placeholder (${secret:%s}) cannot be used in cluster config", name)
+		}
+		return getSecret(arr[0], arr[1])
+	}
+	return "", fmt.Errorf("invalid placeholder kind (%s) provided", kind)
+}
@@ -56,3 +56,27 @@ func LoadConfigFromFile(path string) (*Config, error) {
 	loadEnvironmentVariable(conf)
 	return conf, nil
 }
+
+// LoadAdminConfigFromFile loads the admin accounts, the openid connect provider and the roles of the admins from
+// the provided file path into the admin user
+func LoadAdminConfigFromFile(path string, user *AdminUser) error {
+	dat, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	conf := new(AdminUser)
+	if strings.HasSuffix(path, "json") {
+		err = json.Unmarshal(dat, conf)
+	} else {
+		err = yaml.Unmarshal(dat, conf)
+	}
+	if err != nil {
+		return err
+	}
+
+	user.Accounts = conf.Accounts
+	user.OIDC = conf.OIDC
+	user.Roles = conf.Roles
+	return nil
+}
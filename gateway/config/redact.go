@@ -0,0 +1,134 @@
+package config
+
+import "encoding/json"
+
+// RedactedValue replaces the secrets removed from the config by Redact
+const RedactedValue = "[REDACTED]"
+
+// secretFields are the json fields of the config which hold passwords, keys, connection strings and hashes
+var secretFields = map[string]bool{
+	"conn":       true,
+	"secret":     true,
+	"privateKey": true,
+	"aesKey":     true,
+	"pass":       true,
+	"password":   true,
+	"hash":       true,
+	"token":      true,
+}
+
+// listItemKeys are the fields identifying the objects stored in a list, in the order they are looked for
+var listItemKeys = []string{"id", "name", "kid"}
+
+// isSecretField checks if the json field of the config holds a secret. Parent is the name of the field the object
+// holding the field is stored in.
+func isSecretField(parent, field string) bool {
+	// The keys of the aes key ring are stored in a field named `key`
+	if parent == "aesKeys" && field == "key" {
+		return true
+	}
+	return secretFields[field]
+}
+
+// Redact returns a copy of the json representation of a resource (as produced by json.Unmarshal into an interface)
+// with the secrets replaced by RedactedValue. Field is the name of the field holding the value, if any. Values made
+// up of placeholders alone are kept since they don't reveal the secret they resolve to.
+func Redact(field string, value interface{}) interface{} {
+	return redactValue("", field, value)
+}
+
+// RedactConfig returns the json representation of the config provided with the secrets replaced by RedactedValue.
+// It is used to return the config through the config apis.
+func RedactConfig(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// Never let the config out unredacted
+		return RedactedValue
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return RedactedValue
+	}
+	return Redact("", value)
+}
+
+func redactValue(parent, field string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, fieldValue := range v {
+			out[key] = redactValue(field, key, fieldValue)
+		}
+		return out
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			// Items of a list get redacted as if they were the list itself
+			out[i] = redactValue(parent, field, item)
+		}
+		return out
+
+	case string:
+		if !isSecretField(parent, field) || v == "" || placeholderRegex.ReplaceAllString(v, "") == "" {
+			return v
+		}
+		return RedactedValue
+	}
+	return value
+}
+
+// RestoreRedacted returns a copy of the json representation of a resource with the secrets which were read back
+// as RedactedValue replaced by the ones in the resource stored. This lets a config read through the config apis be
+// applied as it is. The fields of objects are matched by their names. The items of lists are matched by their id,
+// name or kid, and by their position if they don't have any of them.
+func RestoreRedacted(value, stored interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		storedMap, _ := stored.(map[string]interface{})
+		out := make(map[string]interface{}, len(v))
+		for key, fieldValue := range v {
+			out[key] = RestoreRedacted(fieldValue, storedMap[key])
+		}
+		return out
+
+	case []interface{}:
+		storedArr, _ := stored.([]interface{})
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = RestoreRedacted(item, findStoredItem(item, i, storedArr))
+		}
+		return out
+
+	case string:
+		if storedValue, ok := stored.(string); ok && v == RedactedValue {
+			return storedValue
+		}
+	}
+	return value
+}
+
+// findStoredItem returns the item of the stored list the item at index i of a list read back corresponds to. Items
+// having a key only match the stored item with the same key, so that reordering or adding items to a list never
+// restores the secret of another item.
+func findStoredItem(item interface{}, i int, stored []interface{}) interface{} {
+	if obj, ok := item.(map[string]interface{}); ok {
+		for _, key := range listItemKeys {
+			id, ok := obj[key].(string)
+			if !ok || id == "" {
+				continue
+			}
+			for _, storedItem := range stored {
+				if storedObj, ok := storedItem.(map[string]interface{}); ok && storedObj[key] == id {
+					return storedItem
+				}
+			}
+			return nil
+		}
+	}
+
+	if i < len(stored) {
+		return stored[i]
+	}
+	return nil
+}
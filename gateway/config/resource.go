@@ -8,6 +8,8 @@ import (
 // ResourceFetchingOrder order is according to space cli apply functionality
 var ResourceFetchingOrder = []Resource{
 	ResourceProject,
+	ResourceSecurityPolicy,
+	ResourceAPIKey,
 	ResourceDatabaseConfig,
 	ResourceDatabaseRule,
 	ResourceDatabaseSchema,
@@ -39,6 +41,11 @@ const (
 	// ResourceProject is a resource
 	ResourceProject Resource = "project"
 
+	// ResourceSecurityPolicy is a resource
+	ResourceSecurityPolicy Resource = "security-policy"
+	// ResourceAPIKey is a resource
+	ResourceAPIKey Resource = "api-key"
+
 	// ResourceDatabaseConfig is a resource
 	ResourceDatabaseConfig Resource = "db-config"
 	// ResourceDatabaseSchema is a resource
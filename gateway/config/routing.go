@@ -48,6 +48,8 @@ type Route struct {
 	Project          string        `json:"project" yaml:"project" mapstructure:"project"`
 	Source           RouteSource   `json:"source" yaml:"source" mapstructure:"source"`
 	Targets          []RouteTarget `json:"targets" yaml:"targets" mapstructure:"targets"`
+	Mirrors          []RouteMirror `json:"mirrors,omitempty" yaml:"mirrors,omitempty" mapstructure:"mirrors"`
+	LoadBalancer     *LoadBalancer `json:"loadBalancer,omitempty" yaml:"loadBalancer,omitempty" mapstructure:"loadBalancer"`
 	Rule             *Rule         `json:"rule" yaml:"rule" mapstructure:"rule"`
 	IsRouteCacheable bool          `json:"isRouteCacheable" yaml:"isRouteCacheable" mapstructure:"isRouteCacheable"`
 	CacheOptions     []string      `json:"cacheOptions" yaml:"cacheOptions" mapstructure:"cacheOptions"`
@@ -103,6 +105,79 @@ type RouteTarget struct {
 	Type    RouteTargetType `json:"type" yaml:"type" mapstructure:"type"`
 }
 
+// Schemes supported by a route target in addition to `http` and `https`
+const (
+	// RouteSchemeH2C is used to proxy requests over cleartext http2
+	RouteSchemeH2C = "h2c"
+
+	// RouteSchemeGRPC is used to proxy grpc calls over cleartext http2
+	RouteSchemeGRPC = "grpc"
+
+	// RouteSchemeGRPCS is used to proxy grpc calls over http2 with tls
+	RouteSchemeGRPCS = "grpcs"
+)
+
+// LoadBalancer describes the strategy used to pick a target of a route
+type LoadBalancer struct {
+	Strategy LoadBalancingStrategy `json:"strategy" yaml:"strategy" mapstructure:"strategy"`
+
+	// HashOn and HashKey are used by the consistent hash strategy. The key is the name of the header,
+	// cookie or claim whose value is used to pin a client to a target.
+	HashOn  HashSource `json:"hashOn,omitempty" yaml:"hashOn,omitempty" mapstructure:"hashOn"`
+	HashKey string     `json:"hashKey,omitempty" yaml:"hashKey,omitempty" mapstructure:"hashKey"`
+}
+
+// LoadBalancingStrategy describes how a target is selected for a request
+type LoadBalancingStrategy string
+
+const (
+	// LoadBalancingWeighted selects a target randomly based on the weights assigned. This is the default strategy.
+	LoadBalancingWeighted LoadBalancingStrategy = "weighted"
+
+	// LoadBalancingRoundRobin selects the targets one after the other
+	LoadBalancingRoundRobin LoadBalancingStrategy = "round-robin"
+
+	// LoadBalancingLeastRequests selects the target with the least number of outstanding requests
+	LoadBalancingLeastRequests LoadBalancingStrategy = "least-requests"
+
+	// LoadBalancingConsistentHash selects the target based on the hash of a header, cookie or claim
+	LoadBalancingConsistentHash LoadBalancingStrategy = "consistent-hash"
+)
+
+// HashSource describes where the value to be hashed is picked from
+type HashSource string
+
+const (
+	// HashOnHeader hashes the value of a request header
+	HashOnHeader HashSource = "header"
+
+	// HashOnCookie hashes the value of a cookie
+	HashOnCookie HashSource = "cookie"
+
+	// HashOnClaim hashes the value of a claim of the token
+	HashOnClaim HashSource = "claim"
+)
+
+// RouteMirror is a shadow target which receives a copy of the requests made to a route. The responses of a
+// mirror are discarded.
+type RouteMirror struct {
+	Host       string `json:"host" yaml:"host" mapstructure:"host"`
+	Port       int32  `json:"port" yaml:"port" mapstructure:"port"`
+	Scheme     string `json:"scheme" yaml:"scheme" mapstructure:"scheme"`
+	Percentage int32  `json:"percentage" yaml:"percentage" mapstructure:"percentage"`
+}
+
+// SelectMirrors returns the mirrors which should receive a copy of the current request
+func (r *Route) SelectMirrors() []RouteMirror {
+	mirrors := make([]RouteMirror, 0)
+	for _, mirror := range r.Mirrors {
+		if mirror.Percentage >= 100 || rand.Int31n(100) < mirror.Percentage {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+	return mirrors
+}
+
 // RouteURLType describes how the url should be evaluated / matched
 type RouteURLType string
 
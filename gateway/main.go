@@ -55,7 +55,7 @@ var essentialFlags = []cli.Flag{
 	},
 	cli.StringFlag{
 		Name:   "store-type",
-		Usage:  "The config store to use for storing project configs and other meta data",
+		Usage:  "The config store to use for storing project configs and other meta data (local, kube or git)",
 		EnvVar: "STORE_TYPE",
 		Value:  "local",
 	},
@@ -113,6 +113,12 @@ var essentialFlags = []cli.Flag{
 		EnvVar: "ADMIN_SECRET",
 		Value:  "",
 	},
+	cli.StringFlag{
+		Name:   "admin-config",
+		Usage:  "Load the admin accounts and their roles from `FILE`",
+		EnvVar: "ADMIN_CONFIG",
+		Value:  "",
+	},
 
 	// Flags for the metrics module
 	cli.BoolFlag{
@@ -186,6 +192,7 @@ func actionRun(c *cli.Context) error {
 	adminUser := c.String("admin-user")
 	adminPass := c.String("admin-pass")
 	adminSecret := c.String("admin-secret")
+	adminConfig := c.String("admin-config")
 
 	// Load flags related to clustering
 	clusterID := c.String("cluster")
@@ -220,6 +227,11 @@ func actionRun(c *cli.Context) error {
 		adminSecret = "some-secret"
 	}
 	adminUserInfo := &config.AdminUser{User: adminUser, Pass: adminPass, Secret: adminSecret}
+	if adminConfig != "" {
+		if err := config.LoadAdminConfigFromFile(adminConfig, adminUserInfo); err != nil {
+			return helpers.Logger.LogError("start", fmt.Sprintf("Unable to load admin config from file (%s)", adminConfig), err, nil)
+		}
+	}
 	s, err := server.New(nodeID, clusterID, storeType, runnerAddr, isDev, adminUserInfo, ssl)
 	if err != nil {
 		return err
@@ -7,6 +7,8 @@ import (
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils/oidc"
+	"github.com/spaceuptech/space-cloud/gateway/utils/pubsub"
 )
 
 // Manager manages all admin transactions
@@ -22,6 +24,14 @@ type Manager struct {
 	integrationMan IntegrationInterface
 
 	nodeID, clusterID string
+
+	// Keys of the openid connect provider admins sign in with
+	oidcProviders oidc.Providers
+
+	// Nonces of openid connect which have been used. They are tracked in redis if the client has been set.
+	oidcLock       sync.Mutex
+	pubsubClient   *pubsub.Module
+	usedOIDCNonces map[string]time.Time
 }
 
 // New creates a new admin manager instance
@@ -43,6 +53,14 @@ func (m *Manager) SetSyncMan(s model.SyncManAdminInterface) {
 	m.syncMan = s
 }
 
+// SetPubsubClient makes the nonces of openid connect get tracked in redis so that an id token used on one gateway
+// cannot be used again on another
+func (m *Manager) SetPubsubClient(client *pubsub.Module) {
+	m.oidcLock.Lock()
+	defer m.oidcLock.Unlock()
+	m.pubsubClient = client
+}
+
 // SetIntegrationMan sets integration manager
 func (m *Manager) SetIntegrationMan(i IntegrationInterface) {
 	m.lock.Lock()
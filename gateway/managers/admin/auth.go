@@ -34,14 +34,16 @@ func (m *Manager) GenerateToken(ctx context.Context, token string, tokenClaims m
 }
 
 func (m *Manager) createToken(tokenClaims map[string]interface{}) (string, error) {
+	// Add expiry of one week
+	return m.createTokenWithTTL(tokenClaims, 24*7*time.Hour)
+}
 
+func (m *Manager) createTokenWithTTL(tokenClaims map[string]interface{}, ttl time.Duration) (string, error) {
 	claims := jwt.MapClaims{}
 	for k, v := range tokenClaims {
 		claims[k] = v
 	}
-
-	// Add expiry of one week
-	claims["exp"] = time.Now().Add(24 * 7 * time.Hour).Unix()
+	claims["exp"] = time.Now().Add(ttl).Unix()
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	token.Header["kid"] = utils.AdminSecretKID
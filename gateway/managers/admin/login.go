@@ -5,7 +5,9 @@ import (
 	"net/http"
 
 	"github.com/spaceuptech/helpers"
+
 	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
 )
 
 // Login handles the admin login operation
@@ -44,5 +46,16 @@ func (m *Manager) Login(ctx context.Context, user, pass string) (int, string, er
 		return http.StatusOK, token, nil
 	}
 
+	// Named admins only get the permissions of their roles
+	if account := m.getAdminAccount(user); account != nil {
+		if matched, err := utils.VerifyHash(account.Pass, pass); err == nil && matched {
+			token, err := m.createToken(map[string]interface{}{"id": user, "role": "admin", adminKindClaim: adminKindAccount})
+			if err != nil {
+				return http.StatusInternalServerError, "", err
+			}
+			return http.StatusOK, token, nil
+		}
+	}
+
 	return http.StatusUnauthorized, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid username or password provided", nil, map[string]interface{}{"user": user})
 }
@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+const (
+	// oidcNonceTTL is the time within which the id token needs to be presented after creating the nonce
+	oidcNonceTTL = 10 * time.Minute
+
+	// oidcAdminTokenTTL is the lifetime of the tokens of admins signed in with openid connect. The roles read from
+	// the id token are frozen in the token, hence it is short lived and cannot be refreshed.
+	oidcAdminTokenTTL = 1 * time.Hour
+)
+
+// CreateOIDCNonce returns the nonce to be sent in the authentication request to the openid connect provider. The
+// nonce is signed with the admin secret so that any gateway can verify it, while it is accepted only once across
+// the cluster.
+func (m *Manager) CreateOIDCNonce(ctx context.Context) (int, string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if c := m.user.OIDC; c == nil || c.Issuer == "" {
+		return http.StatusNotFound, "", errors.New("Admins cannot sign in with openid connect")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return http.StatusInternalServerError, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate nonce", err, nil)
+	}
+	value := base64.RawURLEncoding.EncodeToString(b) + "." + strconv.FormatInt(time.Now().Add(oidcNonceTTL).Unix(), 10)
+	return http.StatusOK, value + "." + signOIDCNonce(m.user.Secret, value), nil
+}
+
+// LoginWithOIDC signs in an admin with the id token issued by the openid connect provider. The id token must carry
+// a nonce created by CreateOIDCNonce along with a verified email. The admin needs to have at least one role, either
+// through the configured emails or the roles claim of the id token.
+func (m *Manager) LoginWithOIDC(ctx context.Context, idToken string) (int, string, error) {
+	// The config is copied so that the lock isn't held while talking to the provider
+	m.lock.RLock()
+	c, secret, roles := m.user.OIDC, m.user.Secret, m.user.Roles
+	m.lock.RUnlock()
+
+	if c == nil || c.Issuer == "" {
+		return http.StatusNotFound, "", errors.New("Admins cannot sign in with openid connect")
+	}
+
+	claims, err := m.oidcProviders.VerifyIDToken(ctx, c.Issuer, c.ClientID, idToken)
+	if err != nil {
+		return http.StatusUnauthorized, "", err
+	}
+
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return http.StatusUnauthorized, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Id token does not have the email of the admin", nil, nil)
+	}
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return http.StatusUnauthorized, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Email of the admin has not been verified by the openid connect provider", nil, map[string]interface{}{"email": email})
+	}
+	nonce, _ := claims["nonce"].(string)
+	if err := m.verifyOIDCNonce(ctx, secret, nonce); err != nil {
+		return http.StatusUnauthorized, "", err
+	}
+
+	// Only the roles which have been defined are carried over from the id token
+	idpRoles := []string{}
+	if c.RolesClaim != "" {
+		arr, _ := claims[c.RolesClaim].([]interface{})
+		for _, role := range arr {
+			if r, ok := role.(string); ok {
+				if _, p := roles[r]; p {
+					idpRoles = append(idpRoles, r)
+				}
+			}
+		}
+	}
+	if len(c.Admins[email]) == 0 && len(idpRoles) == 0 {
+		return http.StatusForbidden, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("User (%s) is not an admin", email), nil, nil)
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	token, err := m.createTokenWithTTL(map[string]interface{}{"id": email, "role": "admin", adminKindClaim: adminKindOIDC, oidcRolesClaim: idpRoles}, oidcAdminTokenTTL)
+	if err != nil {
+		return http.StatusInternalServerError, "", err
+	}
+	return http.StatusOK, token, nil
+}
+
+// verifyOIDCNonce checks that the nonce has been created by this cluster, hasn't expired and hasn't been used. Used
+// nonces are tracked in redis, when available, so that a nonce used on one gateway gets rejected by the others as well.
+func (m *Manager) verifyOIDCNonce(ctx context.Context, secret, nonce string) error {
+	i := strings.LastIndex(nonce, ".")
+	if i < 0 || !hmac.Equal([]byte(nonce[i+1:]), []byte(signOIDCNonce(secret, nonce[:i]))) {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Id token does not have a valid nonce", nil, nil)
+	}
+	arr := strings.Split(nonce[:i], ".")
+	exp, err := strconv.ParseInt(arr[len(arr)-1], 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Nonce of id token has expired", nil, nil)
+	}
+
+	m.oidcLock.Lock()
+	client := m.pubsubClient
+	m.oidcLock.Unlock()
+	if client != nil {
+		// The nonce is only valid till it expires, hence it needs to be remembered till then
+		ttl := time.Until(time.Unix(exp, 0)) + time.Second
+		isNew, err := client.SetKeyIfNotExists(ctx, fmt.Sprintf("%s-admin-oidc-nonce-%s", m.clusterID, utils.HashString(nonce)), "1", ttl)
+		if err != nil {
+			return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to check if the nonce of id token has been used", err, nil)
+		}
+		if !isNew {
+			return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Nonce of id token has already been used", nil, nil)
+		}
+		return nil
+	}
+
+	m.oidcLock.Lock()
+	defer m.oidcLock.Unlock()
+
+	now := time.Now()
+	if m.usedOIDCNonces == nil {
+		m.usedOIDCNonces = map[string]time.Time{}
+	}
+	for n, expiresAt := range m.usedOIDCNonces {
+		if now.After(expiresAt) {
+			delete(m.usedOIDCNonces, n)
+		}
+	}
+	if _, p := m.usedOIDCNonces[nonce]; p {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Nonce of id token has already been used", nil, nil)
+	}
+	m.usedOIDCNonces[nonce] = time.Unix(exp, 0)
+	return nil
+}
+
+func signOIDCNonce(secret, value string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("oidc-nonce:" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
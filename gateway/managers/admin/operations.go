@@ -3,7 +3,6 @@ package admin
 import (
 	"context"
 	"net/http"
-	"strings"
 
 	"github.com/spaceuptech/helpers"
 
@@ -40,35 +39,32 @@ func (m *Manager) IsTokenValid(ctx context.Context, token, resource, op string,
 		return model.RequestParams{}, res.Error()
 	}
 
+	// Check the roles of named admins the same way
+	if !res.CheckResponse() {
+		if err := m.checkAdminPermissions(ctx, claims, resource, op, attr); err != nil {
+			return model.RequestParams{}, err
+		}
+	}
+
 	// Otherwise just return nil for backward compatibility
 	return model.RequestParams{Resource: resource, Op: op, Attributes: attr, Claims: claims}, nil
 }
 
-// CheckIfAdmin simply checks the token
-func (m *Manager) CheckIfAdmin(ctx context.Context, token string) error {
+// GetActor returns the id of the admin or integration the token has been issued to. An empty string is
+// returned if the token is invalid.
+func (m *Manager) GetActor(ctx context.Context, token string) string {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
-	if !m.isProd {
-		return nil
+	if token == "" {
+		return ""
 	}
-
 	claims, err := m.parseToken(ctx, token)
 	if err != nil {
-		return err
-	}
-
-	// Check if role is admin
-	role, p := claims["role"]
-	if !p {
-		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid token provided. Claim `role` is absent.", nil, nil)
-	}
-
-	if !strings.Contains(role.(string), "admin") {
-		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Only admins are authorised to make this request.", nil, nil)
+		return ""
 	}
-
-	return nil
+	id, _ := claims["id"].(string)
+	return id
 }
 
 // IsDBConfigValid checks if the database config is valid
@@ -136,6 +132,14 @@ func (m *Manager) RefreshToken(ctx context.Context, token string) (string, error
 	if err != nil {
 		return "", err
 	}
+	// Admins who have been removed cannot refresh their tokens
+	if _, _, err := m.getAdminPermissions(ctx, tokenClaims); err != nil {
+		return "", err
+	}
+	// The roles of admins signed in with openid connect are only as fresh as the id token they signed in with
+	if kind, _ := tokenClaims[adminKindClaim].(string); kind == adminKindOIDC {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Admins signed in with openid connect need to sign in again instead of refreshing the token", nil, nil)
+	}
 	// Create a new token
 	newToken, err := m.createToken(tokenClaims)
 	if err != nil {
@@ -171,5 +175,26 @@ func (m *Manager) GetPermissions(ctx context.Context, params model.RequestParams
 		return hookResponse.Status(), hookResponse.Result(), nil
 	}
 
-	return http.StatusOK, []interface{}{map[string]interface{}{"project": "*", "resource": "*", "verb": "*"}}, nil
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	permissions, full, err := m.getAdminPermissions(ctx, params.Claims)
+	if err != nil {
+		return http.StatusUnauthorized, nil, err
+	}
+	if full {
+		return http.StatusOK, []interface{}{map[string]interface{}{"project": "*", "resource": "*", "verb": "*"}}, nil
+	}
+
+	result := []interface{}{}
+	for _, permission := range permissions {
+		for _, project := range permission.Projects {
+			for _, resource := range permission.Resources {
+				for _, verb := range permission.Verbs {
+					result = append(result, map[string]interface{}{"project": project, "resource": resource, "verb": verb})
+				}
+			}
+		}
+	}
+	return http.StatusOK, result, nil
 }
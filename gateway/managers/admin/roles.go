@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// adminKindClaim tells how a named admin has signed in. Tokens without it belong to the admin with full access.
+const adminKindClaim = "adminKind"
+
+const (
+	adminKindAccount = "account"
+	adminKindOIDC    = "oidc"
+
+	// oidcRolesClaim holds the roles read from the id token of admins signing in with openid connect
+	oidcRolesClaim = "idpRoles"
+)
+
+// checkAdminPermissions checks if the roles of the admin grant the op on the resource. The project is read from
+// the attributes; requests which aren't scoped to a project need a permission on all projects.
+func (m *Manager) checkAdminPermissions(ctx context.Context, claims map[string]interface{}, resource, op string, attr map[string]string) error {
+	permissions, full, err := m.getAdminPermissions(ctx, claims)
+	if err != nil {
+		return err
+	}
+	if full {
+		return nil
+	}
+
+	project := attr["project"]
+	for _, permission := range permissions {
+		if isPermitted(permission, project, resource, op) {
+			return nil
+		}
+	}
+	return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Admin (%v) does not have the permission to %s resource (%s)", claims["id"], op, resource), nil, map[string]interface{}{"project": project})
+}
+
+// getAdminPermissions returns the permissions of the roles the admin currently has. The roles are looked up on
+// every request so that changes apply to tokens which have already been issued.
+func (m *Manager) getAdminPermissions(ctx context.Context, claims map[string]interface{}) ([]*config.AdminPermission, bool, error) {
+	kind, _ := claims[adminKindClaim].(string)
+	id, _ := claims["id"].(string)
+
+	var roles []string
+	switch kind {
+	case "":
+		return nil, true, nil
+
+	case adminKindAccount:
+		account := m.getAdminAccount(id)
+		if account == nil {
+			return nil, false, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Admin account (%s) does not exist", id), nil, nil)
+		}
+		roles = account.Roles
+
+	case adminKindOIDC:
+		if m.user.OIDC == nil {
+			return nil, false, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Admins cannot sign in with openid connect", nil, nil)
+		}
+		roles = append(roles, m.user.OIDC.Admins[id]...)
+		if arr, ok := claims[oidcRolesClaim].([]interface{}); ok {
+			for _, role := range arr {
+				if r, ok := role.(string); ok {
+					roles = append(roles, r)
+				}
+			}
+		}
+
+	default:
+		return nil, false, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Invalid admin kind (%s) provided in token", kind), nil, nil)
+	}
+
+	permissions := []*config.AdminPermission{}
+	for _, role := range roles {
+		permissions = append(permissions, m.user.Roles[role]...)
+	}
+	return permissions, false, nil
+}
+
+func (m *Manager) getAdminAccount(user string) *config.AdminAccount {
+	for _, account := range m.user.Accounts {
+		if account.User == user {
+			return account
+		}
+	}
+	return nil
+}
+
+func isPermitted(permission *config.AdminPermission, project, resource, op string) bool {
+	if project == "" {
+		if !utils.StringExists(permission.Projects, "*") {
+			return false
+		}
+	} else if !utils.StringExists(permission.Projects, "*", project) {
+		return false
+	}
+	return utils.StringExists(permission.Resources, "*", resource) && utils.StringExists(permission.Verbs, "*", op)
+}
@@ -0,0 +1,193 @@
+package admin
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+func newRolesManager(t *testing.T) *Manager {
+	hash, err := utils.HashValue(utils.HashAlgorithmBcrypt, "viewer-pass")
+	if err != nil {
+		t.Fatalf("Unable to hash password - %v", err)
+	}
+
+	integrationMan := &mockIntegrationManager{}
+	integrationMan.On("HandleConfigAuth", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockIntegrationResponse{})
+	integrationMan.On("InvokeHook", mock.Anything).Return(mockIntegrationResponse{})
+
+	m := New("node", "cluster", false, &config.AdminUser{
+		User:   "admin",
+		Pass:   "123",
+		Secret: "some-secret",
+		Accounts: []*config.AdminAccount{
+			{User: "viewer", Pass: hash, Roles: []string{"prod-viewer", "staging-editor"}},
+		},
+		Roles: config.AdminRoles{
+			"prod-viewer":    {{Projects: []string{"prod"}, Resources: []string{"*"}, Verbs: []string{"read"}}},
+			"staging-editor": {{Projects: []string{"staging"}, Resources: []string{"db-schema", "db-rule"}, Verbs: []string{"*"}}},
+		},
+	})
+	m.SetIntegrationMan(integrationMan)
+	return m
+}
+
+func TestManager_IsTokenValid_roles(t *testing.T) {
+	ctx := context.Background()
+	m := newRolesManager(t)
+
+	status, token, err := m.Login(ctx, "viewer", "viewer-pass")
+	if status != http.StatusOK {
+		t.Fatalf("Login() status = %v, error = %v", status, err)
+	}
+	if status, _, _ := m.Login(ctx, "viewer", "wrong-pass"); status != http.StatusUnauthorized {
+		t.Errorf("Login() status = %v with an invalid password, want %v", status, http.StatusUnauthorized)
+	}
+	_, adminToken, _ := m.Login(ctx, "admin", "123")
+
+	tests := []struct {
+		name     string
+		token    string
+		resource string
+		op       string
+		attr     map[string]string
+		wantErr  bool
+	}{
+		{name: "read on a read only project", token: token, resource: "db-config", op: "read", attr: map[string]string{"project": "prod"}},
+		{name: "modify on a read only project", token: token, resource: "db-config", op: "modify", attr: map[string]string{"project": "prod"}, wantErr: true},
+		{name: "modify a granted resource", token: token, resource: "db-schema", op: "modify", attr: map[string]string{"project": "staging"}},
+		{name: "modify a resource which is not granted", token: token, resource: "eventing-config", op: "modify", attr: map[string]string{"project": "staging"}, wantErr: true},
+		{name: "project without any role", token: token, resource: "db-config", op: "read", attr: map[string]string{"project": "dev"}, wantErr: true},
+		{name: "request not scoped to a project", token: token, resource: "cluster", op: "read", wantErr: true},
+		{name: "default admin has full access", token: adminToken, resource: "cluster", op: "modify"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.IsTokenValid(ctx, tt.token, tt.resource, tt.op, tt.attr); (err != nil) != tt.wantErr {
+				t.Errorf("IsTokenValid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Removing the account revokes the access of the tokens issued to it
+	m.user.Accounts = nil
+	if _, err := m.IsTokenValid(ctx, token, "db-config", "read", map[string]string{"project": "prod"}); err == nil {
+		t.Errorf("IsTokenValid() succeeded for an account which has been removed")
+	}
+	if _, err := m.RefreshToken(ctx, token); err == nil {
+		t.Errorf("RefreshToken() succeeded for an account which has been removed")
+	}
+}
+
+func TestManager_LoginWithOIDC(t *testing.T) {
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unable to generate key - %v", err)
+	}
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{map[string]interface{}{
+			"kty": "RSA",
+			"kid": "key-1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	m := newRolesManager(t)
+	m.user.OIDC = &config.AdminOIDC{Issuer: server.URL, ClientID: "client-id", Admins: map[string][]string{"jon@example.com": {"prod-viewer"}}, RolesClaim: "groups"}
+
+	signIDToken := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key-1"
+		s, _ := token.SignedString(key)
+		return s
+	}
+	claims := func(email, aud string, groups ...string) jwt.MapClaims {
+		_, nonce, err := m.CreateOIDCNonce(ctx)
+		if err != nil {
+			t.Fatalf("CreateOIDCNonce() error = %v", err)
+		}
+		return jwt.MapClaims{
+			"iss":            server.URL,
+			"aud":            aud,
+			"email":          email,
+			"email_verified": true,
+			"nonce":          nonce,
+			"groups":         groups,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		}
+	}
+	idToken := func(email, aud string, groups ...string) string {
+		return signIDToken(claims(email, aud, groups...))
+	}
+	without := func(field string) string {
+		c := claims("jon@example.com", "client-id")
+		delete(c, field)
+		return signIDToken(c)
+	}
+	forgedNonce := claims("jon@example.com", "client-id")
+	forgedNonce["nonce"] = "nonce.4102444800.signature"
+	replayed := idToken("jon@example.com", "client-id")
+	if status, _, err := m.LoginWithOIDC(ctx, replayed); status != http.StatusOK {
+		t.Fatalf("LoginWithOIDC() status = %v, error = %v", status, err)
+	}
+
+	tests := []struct {
+		name       string
+		idToken    string
+		wantStatus int
+		project    string
+	}{
+		{name: "configured admin", idToken: idToken("jon@example.com", "client-id"), wantStatus: http.StatusOK, project: "prod"},
+		{name: "admin through the roles claim", idToken: idToken("arya@example.com", "client-id", "staging-editor", "unknown-role"), wantStatus: http.StatusOK, project: "staging"},
+		{name: "user who is not an admin", idToken: idToken("arya@example.com", "client-id", "unknown-role"), wantStatus: http.StatusForbidden},
+		{name: "token issued for another client", idToken: idToken("jon@example.com", "other-client"), wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", idToken: "invalid", wantStatus: http.StatusUnauthorized},
+		{name: "email not verified", idToken: without("email_verified"), wantStatus: http.StatusUnauthorized},
+		{name: "nonce not provided", idToken: without("nonce"), wantStatus: http.StatusUnauthorized},
+		{name: "nonce not created by the gateway", idToken: signIDToken(forgedNonce), wantStatus: http.StatusUnauthorized},
+		{name: "replayed id token", idToken: replayed, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, token, err := m.LoginWithOIDC(ctx, tt.idToken)
+			if status != tt.wantStatus {
+				t.Fatalf("LoginWithOIDC() status = %v, error = %v, want %v", status, err, tt.wantStatus)
+			}
+			if status != http.StatusOK {
+				return
+			}
+			if _, err := m.IsTokenValid(ctx, token, "db-schema", "read", map[string]string{"project": tt.project}); err != nil {
+				t.Errorf("IsTokenValid() error = %v for the project of the role", err)
+			}
+			if _, err := m.IsTokenValid(ctx, token, "db-schema", "read", map[string]string{"project": "dev"}); err == nil {
+				t.Errorf("IsTokenValid() succeeded for a project without any role")
+			}
+			if _, err := m.RefreshToken(ctx, token); err == nil {
+				t.Errorf("RefreshToken() refreshed the token of an admin signed in with openid connect")
+			}
+		})
+	}
+}
@@ -0,0 +1,472 @@
+package syncman
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/segmentio/ksuid"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+const (
+	// defaultAuditCol is the table the audit trail is stored in if one isn't provided
+	defaultAuditCol = "config_audit_logs"
+
+	// auditTimeFormat has a fixed width so that the timestamps can be compared as strings
+	auditTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+	defaultAuditLimit = 50
+	maxAuditLimit     = 1000
+)
+
+// auditStore records every change made through the store it wraps in the audit trail. It keeps a copy of all the
+// resources since the config held by the sync manager has already been modified when the store gets called.
+type auditStore struct {
+	Store
+
+	lock      sync.Mutex
+	clusterID string
+	resources map[string]interface{}
+	config    *config.AuditConfig
+
+	// file is used to store the trail if a database isn't configured. It is only available in local mode.
+	file    *fileAuditSink
+	getCrud func(projectID string) (model.CrudSyncManInterface, error)
+}
+
+func newAuditStore(clusterID string, store Store, file *fileAuditSink) *auditStore {
+	return &auditStore{Store: store, clusterID: clusterID, resources: map[string]interface{}{}, file: file}
+}
+
+// GetGlobalConfig gets the config from the underlying store and takes a copy of it
+func (s *auditStore) GetGlobalConfig() (*config.Config, error) {
+	globalConfig, err := s.Store.GetGlobalConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.resources = map[string]interface{}{}
+	for resourceID, resource := range getConfigResources(s.clusterID, globalConfig) {
+		s.resources[resourceID] = toAuditValue(resource)
+	}
+	if globalConfig.ClusterConfig != nil {
+		s.config = globalConfig.ClusterConfig.Audit
+	}
+	return globalConfig, nil
+}
+
+// WatchResources keeps the copy of the resources in sync with the changes made by other gateways
+func (s *auditStore) WatchResources(cb func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error) error {
+	return s.Store.WatchResources(func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error {
+		if err := cb(eventType, resourceID, resourceType, resource); err != nil {
+			return err
+		}
+
+		s.lock.Lock()
+		s.setResource(eventType, resourceID, toAuditValue(resource))
+		s.lock.Unlock()
+		return nil
+	})
+}
+
+// SetResource sets the resource in the underlying store and records the change
+func (s *auditStore) SetResource(ctx context.Context, resourceID string, resource interface{}) error {
+	if err := s.Store.SetResource(ctx, resourceID, resource); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	after := toAuditValue(resource)
+	before, p := s.resources[resourceID]
+	eventType := config.ResourceUpdateEvent
+	if !p {
+		eventType = config.ResourceAddEvent
+	}
+	s.setResource(eventType, resourceID, after)
+	c, entry := s.newEntry(ctx, eventType, resourceID, before, after)
+	s.lock.Unlock()
+
+	s.record(ctx, c, entry)
+	return nil
+}
+
+// DeleteResource deletes the resource from the underlying store and records the change
+func (s *auditStore) DeleteResource(ctx context.Context, resourceID string) error {
+	if err := s.Store.DeleteResource(ctx, resourceID); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	before := s.resources[resourceID]
+	s.setResource(config.ResourceDeleteEvent, resourceID, nil)
+	c, entry := s.newEntry(ctx, config.ResourceDeleteEvent, resourceID, before, nil)
+	s.lock.Unlock()
+
+	s.record(ctx, c, entry)
+	return nil
+}
+
+// DeleteProject deletes the project from the underlying store and records it as a deletion of the project resource
+func (s *auditStore) DeleteProject(ctx context.Context, projectID string) error {
+	if err := s.Store.DeleteProject(ctx, projectID); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	projectResourceID := config.GenerateResourceID(s.clusterID, projectID, config.ResourceProject, projectID)
+	before := s.resources[projectResourceID]
+	for resourceID := range s.resources {
+		if strings.HasPrefix(resourceID, fmt.Sprintf("%s--%s--", s.clusterID, projectID)) {
+			delete(s.resources, resourceID)
+		}
+	}
+	c, entry := s.newEntry(ctx, config.ResourceDeleteEvent, projectResourceID, before, nil)
+	s.lock.Unlock()
+
+	s.record(ctx, c, entry)
+	return nil
+}
+
+// query returns the entries of the audit trail matching the query, latest first. The time range of the query
+// must be in the format of the trail.
+func (s *auditStore) query(ctx context.Context, q *model.ConfigAuditQuery) ([]*model.ConfigAuditEntry, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultAuditLimit
+	}
+	if q.Limit > maxAuditLimit {
+		q.Limit = maxAuditLimit
+	}
+
+	s.lock.Lock()
+	c := s.config
+	s.lock.Unlock()
+
+	if c != nil && c.DBAlias != "" {
+		return s.queryDatabase(ctx, c, q)
+	}
+	if s.file != nil {
+		return s.file.query(q)
+	}
+	return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Audit trail is not being stored, provide a database for it in the cluster config", nil, nil)
+}
+
+// setResource updates the copy of the resource. The lock must be held by the caller.
+func (s *auditStore) setResource(eventType, resourceID string, resource interface{}) {
+	if eventType == config.ResourceDeleteEvent {
+		delete(s.resources, resourceID)
+		return
+	}
+	s.resources[resourceID] = resource
+
+	// The location of the trail is a part of the cluster config
+	if _, _, resourceType, err := splitResourceID(context.TODO(), resourceID); err == nil && resourceType == config.ResourceCluster {
+		clusterConfig := new(config.ClusterConfig)
+		if err := mapstructure.Decode(resource, clusterConfig); err == nil {
+			s.config = clusterConfig.Audit
+		}
+	}
+}
+
+// newEntry returns the entry recording the change along with the config of the trail it has to be stored in.
+// Secrets are redacted from the entry since the trail can be read by anyone having access to its table or files.
+// The lock must be held by the caller.
+func (s *auditStore) newEntry(ctx context.Context, eventType, resourceID string, before, after interface{}) (*config.AuditConfig, *model.ConfigAuditEntry) {
+	_, projectID, resourceType, _ := splitResourceID(ctx, resourceID)
+	if projectID == "noProject" {
+		projectID = ""
+	}
+
+	actor := utils.GetActorFromContext(ctx)
+	if actor == "" {
+		actor = utils.InternalUserID
+	}
+
+	// The diff is taken before redacting so that a changed secret still shows up in it
+	diff := getAuditDiff("", before, after)
+	for _, change := range diff {
+		field := change.Path[strings.LastIndex(change.Path, ".")+1:]
+		change.Before = config.Redact(field, change.Before)
+		change.After = config.Redact(field, change.After)
+	}
+
+	return s.config, &model.ConfigAuditEntry{
+		ID:           ksuid.New().String(),
+		Time:         time.Now().UTC().Format(auditTimeFormat),
+		Actor:        actor,
+		RequestID:    helpers.GetRequestID(ctx),
+		Event:        eventType,
+		ResourceID:   resourceID,
+		ResourceType: string(resourceType),
+		Project:      projectID,
+		Before:       config.Redact("", before),
+		After:        config.Redact("", after),
+		Diff:         diff,
+	}
+}
+
+// record stores the entry in the audit trail. A change which cannot be recorded doesn't fail the request since it
+// has already been applied. It is called without holding the lock so that a slow trail doesn't hold up other changes.
+func (s *auditStore) record(ctx context.Context, c *config.AuditConfig, entry *model.ConfigAuditEntry) {
+	var err error
+	switch {
+	case c != nil && c.DBAlias != "":
+		err = s.writeDatabase(ctx, c, entry)
+	case s.file != nil:
+		err = s.file.write(entry)
+	default:
+		helpers.Logger.LogInfo(entry.RequestID, "Config changed", map[string]interface{}{"actor": entry.Actor, "event": entry.Event, "resourceId": entry.ResourceID, "diff": entry.Diff})
+	}
+	if err != nil {
+		_ = helpers.Logger.LogError(entry.RequestID, fmt.Sprintf("Unable to record the change of resource (%s) in the audit trail", entry.ResourceID), err, nil)
+	}
+}
+
+func (s *auditStore) writeDatabase(ctx context.Context, c *config.AuditConfig, entry *model.ConfigAuditEntry) error {
+	crud, err := s.getAuditCrud(c)
+	if err != nil {
+		return err
+	}
+
+	doc, _ := toAuditValue(entry).(map[string]interface{})
+	return crud.InternalCreate(ctx, c.DBAlias, c.Project, getAuditCol(c), &model.CreateRequest{Document: doc, Operation: utils.One}, false)
+}
+
+func (s *auditStore) queryDatabase(ctx context.Context, c *config.AuditConfig, q *model.ConfigAuditQuery) ([]*model.ConfigAuditEntry, error) {
+	crud, err := s.getAuditCrud(c)
+	if err != nil {
+		return nil, err
+	}
+
+	find := map[string]interface{}{}
+	for field, value := range map[string]string{"project": q.Project, "resourceType": q.ResourceType, "resourceId": q.ResourceID, "actor": q.Actor, "requestId": q.RequestID} {
+		if value != "" {
+			find[field] = value
+		}
+	}
+	timeFilter := map[string]interface{}{}
+	if q.Since != "" {
+		timeFilter["$gte"] = q.Since
+	}
+	if q.Until != "" {
+		timeFilter["$lte"] = q.Until
+	}
+	if len(timeFilter) > 0 {
+		find["time"] = timeFilter
+	}
+
+	col := getAuditCol(c)
+	limit := q.Limit
+	params := model.RequestParams{Resource: "db-read", Op: "access", Attributes: map[string]string{"project": c.Project, "db": c.DBAlias, "col": col}}
+	result, _, err := crud.Read(ctx, c.DBAlias, col, &model.ReadRequest{Find: find, Operation: utils.All, Options: &model.ReadOptions{Sort: []string{"-time"}, Limit: &limit}}, params)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*model.ConfigAuditEntry{}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *auditStore) getAuditCrud(c *config.AuditConfig) (model.CrudSyncManInterface, error) {
+	if s.getCrud == nil {
+		return nil, fmt.Errorf("database of project (%s) is not available", c.Project)
+	}
+	return s.getCrud(c.Project)
+}
+
+func getAuditCol(c *config.AuditConfig) string {
+	if c.Col == "" {
+		return defaultAuditCol
+	}
+	return c.Col
+}
+
+// toAuditValue converts the resource to its json representation. This also makes a copy of the resource.
+func toAuditValue(resource interface{}) interface{} {
+	if resource == nil {
+		return nil
+	}
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// getAuditDiff returns the fields which differ between the json representations of a resource. Objects are
+// compared field by field while other values are compared as a whole.
+func getAuditDiff(path string, before, after interface{}) []*model.ConfigAuditChange {
+	beforeObj, ok1 := before.(map[string]interface{})
+	afterObj, ok2 := after.(map[string]interface{})
+	if !ok1 || !ok2 {
+		if reflect.DeepEqual(before, after) {
+			return []*model.ConfigAuditChange{}
+		}
+		return []*model.ConfigAuditChange{{Path: path, Before: before, After: after}}
+	}
+
+	keys := map[string]struct{}{}
+	for key := range beforeObj {
+		keys[key] = struct{}{}
+	}
+	for key := range afterObj {
+		keys[key] = struct{}{}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	changes := []*model.ConfigAuditChange{}
+	for _, key := range sortedKeys {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		changes = append(changes, getAuditDiff(fieldPath, beforeObj[key], afterObj[key])...)
+	}
+	return changes
+}
+
+// fileAuditSink stores the audit trail as append only files, one per day, having one json entry per line
+type fileAuditSink struct {
+	lock sync.Mutex
+	dir  string
+}
+
+func newFileAuditSink(dir string) *fileAuditSink {
+	return &fileAuditSink{dir: dir}
+}
+
+func (f *fileAuditSink) write(entry *model.ConfigAuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err := os.MkdirAll(f.dir, 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(f.dir, fmt.Sprintf("audit-%s.log", entry.Time[:10])), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseTheCloser(file)
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+func (f *fileAuditSink) query(q *model.ConfigAuditQuery) ([]*model.ConfigAuditEntry, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	files, err := ioutil.ReadDir(f.dir)
+	if os.IsNotExist(err) {
+		return []*model.ConfigAuditEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Go through the files of the latest days first. The file names sort by date.
+	names := []string{}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, "audit-") || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, "audit-"), ".log")
+		if (q.Since != "" && day < q.Since[:10]) || (q.Until != "" && day > q.Until[:10]) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	entries := []*model.ConfigAuditEntry{}
+	for _, name := range names {
+		dayEntries, err := f.readFile(filepath.Join(f.dir, name), q)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(dayEntries) - 1; i >= 0; i-- {
+			entries = append(entries, dayEntries[i])
+			if int64(len(entries)) == q.Limit {
+				return entries, nil
+			}
+		}
+	}
+	return entries, nil
+}
+
+// readFile returns the entries of the file which match the query in the order they were written
+func (f *fileAuditSink) readFile(path string, q *model.ConfigAuditQuery) ([]*model.ConfigAuditEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.CloseTheCloser(file)
+
+	entries := []*model.ConfigAuditEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		entry := new(model.ConfigAuditEntry)
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			// Skip partially written lines
+			continue
+		}
+		if isAuditEntryMatching(entry, q) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func isAuditEntryMatching(entry *model.ConfigAuditEntry, q *model.ConfigAuditQuery) bool {
+	for _, f := range [][2]string{{q.Project, entry.Project}, {q.ResourceType, entry.ResourceType}, {q.ResourceID, entry.ResourceID}, {q.Actor, entry.Actor}, {q.RequestID, entry.RequestID}} {
+		if f[0] != "" && f[0] != f[1] {
+			return false
+		}
+	}
+	if q.Since != "" && entry.Time < q.Since {
+		return false
+	}
+	if q.Until != "" && entry.Time > q.Until {
+		return false
+	}
+	return true
+}
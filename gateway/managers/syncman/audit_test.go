@@ -0,0 +1,126 @@
+package syncman
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+func TestAuditStore(t *testing.T) {
+	dir := t.TempDir()
+	globalConfig := config.GenerateEmptyConfig()
+	globalConfig.Projects = config.Projects{"myproject": {ProjectConfig: &config.ProjectConfig{ID: "myproject"}}}
+	policyID := config.GenerateResourceID("chicago", "myproject", config.ResourceSecurityPolicy, "is-admin")
+	globalConfig.Projects["myproject"].SecurityPolicies = config.SecurityPolicies{policyID: {ID: "is-admin", Module: "package v1"}}
+
+	s := newAuditStore("chicago", &LocalStore{configPath: filepath.Join(dir, "config.yaml"), globalConfig: globalConfig}, newFileAuditSink(filepath.Join(dir, "audit")))
+	if _, err := s.GetGlobalConfig(); err != nil {
+		t.Fatalf("GetGlobalConfig() error = %v", err)
+	}
+
+	ctx := utils.SetActorInContext(context.Background(), "jon")
+	if err := s.SetResource(ctx, policyID, &config.SecurityPolicy{ID: "is-admin", Module: "package v2"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+	if err := s.DeleteResource(context.Background(), policyID); err != nil {
+		t.Fatalf("DeleteResource() error = %v", err)
+	}
+	keyID := config.GenerateResourceID("chicago", "myproject", config.ResourceAPIKey, "backend")
+	if err := s.SetResource(ctx, keyID, &config.APIKey{ID: "backend"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+
+	entries, err := s.query(ctx, &model.ConfigAuditQuery{ResourceID: policyID})
+	if err != nil {
+		t.Fatalf("query() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("query() returned (%d) entries, want 2", len(entries))
+	}
+
+	// Latest entries are returned first
+	deleted, updated := entries[0], entries[1]
+	if deleted.Event != config.ResourceDeleteEvent || deleted.Actor != utils.InternalUserID || deleted.After != nil {
+		t.Errorf("query() delete entry = %+v", deleted)
+	}
+	if updated.Event != config.ResourceUpdateEvent || updated.Actor != "jon" || updated.Project != "myproject" || updated.ResourceType != string(config.ResourceSecurityPolicy) {
+		t.Errorf("query() update entry = %+v", updated)
+	}
+	wantDiff := []*model.ConfigAuditChange{{Path: "module", Before: "package v1", After: "package v2"}}
+	if !reflect.DeepEqual(updated.Diff, wantDiff) {
+		t.Errorf("query() update diff = %v, want %v", updated.Diff, wantDiff)
+	}
+
+	if entries, _ := s.query(ctx, &model.ConfigAuditQuery{Actor: "jon", Limit: 1}); len(entries) != 1 || entries[0].ResourceID != keyID || entries[0].Event != config.ResourceAddEvent {
+		t.Errorf("query() with a limit returned %v, want the latest change made by the actor", entries)
+	}
+
+	// Secrets never make it to the trail
+	dbID := config.GenerateResourceID("chicago", "myproject", config.ResourceDatabaseConfig, "db")
+	if err := s.SetResource(ctx, dbID, &config.DatabaseConfig{DbAlias: "db", Conn: "postgres://postgres:v1@localhost"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+	if err := s.SetResource(ctx, dbID, &config.DatabaseConfig{DbAlias: "db", Conn: "postgres://postgres:v2@localhost"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+	entries, err = s.query(ctx, &model.ConfigAuditQuery{ResourceID: dbID, Limit: 1})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("query() = %v, %v, want one entry", entries, err)
+	}
+	wantDiff = []*model.ConfigAuditChange{{Path: "conn", Before: config.RedactedValue, After: config.RedactedValue}}
+	if !reflect.DeepEqual(entries[0].Diff, wantDiff) {
+		t.Errorf("query() db config diff = %v, want %v", entries[0].Diff, wantDiff)
+	}
+	if after, _ := entries[0].After.(map[string]interface{}); after["conn"] != config.RedactedValue {
+		t.Errorf("query() db config after = %v, want the connection string to be redacted", entries[0].After)
+	}
+}
+
+func Test_getAuditDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   []*model.ConfigAuditChange
+	}{
+		{
+			name:  "resource added",
+			after: map[string]interface{}{"id": "1"},
+			want:  []*model.ConfigAuditChange{{After: map[string]interface{}{"id": "1"}}},
+		},
+		{
+			name:   "nested field changed",
+			before: map[string]interface{}{"id": "1", "rules": map[string]interface{}{"read": map[string]interface{}{"rule": "allow"}}},
+			after:  map[string]interface{}{"id": "1", "rules": map[string]interface{}{"read": map[string]interface{}{"rule": "deny"}}},
+			want:   []*model.ConfigAuditChange{{Path: "rules.read.rule", Before: "allow", After: "deny"}},
+		},
+		{
+			name:   "fields added and removed",
+			before: map[string]interface{}{"a": 1.0, "list": []interface{}{"x"}},
+			after:  map[string]interface{}{"b": 2.0, "list": []interface{}{"x", "y"}},
+			want: []*model.ConfigAuditChange{
+				{Path: "a", Before: 1.0},
+				{Path: "b", After: 2.0},
+				{Path: "list", Before: []interface{}{"x"}, After: []interface{}{"x", "y"}},
+			},
+		},
+		{
+			name:   "nothing changed",
+			before: map[string]interface{}{"id": "1"},
+			after:  map[string]interface{}{"id": "1"},
+			want:   []*model.ConfigAuditChange{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getAuditDiff("", tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getAuditDiff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
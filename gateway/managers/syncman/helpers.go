@@ -2,9 +2,11 @@ package syncman
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -58,6 +60,25 @@ func (s *Manager) getConfigWithoutLock(ctx context.Context, projectID string) (*
 	return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unknown project (%s) provided", projectID), nil, nil)
 }
 
+// restoreRedacted puts the secrets of the stored resource back in the resource v points to wherever they were
+// provided as config.RedactedValue, which is how the config apis return them
+func restoreRedacted(ctx context.Context, v, stored interface{}) error {
+	value := toAuditValue(v)
+	restored := config.RestoreRedacted(value, toAuditValue(stored))
+	if reflect.DeepEqual(value, restored) {
+		return nil
+	}
+
+	data, err := json.Marshal(restored)
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to restore the redacted secrets of resource", err, nil)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to restore the redacted secrets of resource", err, nil)
+	}
+	return nil
+}
+
 func (s *Manager) checkIfDbAliasExists(dbConfigs config.DatabaseConfigs, dbAlias string) (*config.DatabaseConfig, bool) {
 	for _, databaseConfig := range dbConfigs {
 		if dbAlias == databaseConfig.DbAlias {
@@ -265,6 +286,34 @@ func (s *Manager) validateResource(ctx context.Context, eventType string, resour
 			}
 		}
 		return false, nil
+
+	case config.ResourceSecurityPolicy:
+		switch eventType {
+		case config.ResourceAddEvent, config.ResourceUpdateEvent:
+			value := new(config.SecurityPolicy)
+			if err := mapstructure.Decode(resource, value); err != nil {
+				return false, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("invalid type provided for resource (%s) expecting (%v) got (%v)", resourceType, "config.SecurityPolicy{}", reflect.TypeOf(resource)), nil, nil)
+			}
+
+			if reflect.DeepEqual(project.SecurityPolicies[resourceID], value) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case config.ResourceAPIKey:
+		switch eventType {
+		case config.ResourceAddEvent, config.ResourceUpdateEvent:
+			value := new(config.APIKey)
+			if err := mapstructure.Decode(resource, value); err != nil {
+				return false, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("invalid type provided for resource (%s) expecting (%v) got (%v)", resourceType, "config.APIKey{}", reflect.TypeOf(resource)), nil, nil)
+			}
+
+			if reflect.DeepEqual(project.APIKeys[resourceID], value) {
+				return true, nil
+			}
+		}
+		return false, nil
 	case config.ResourceEventingTrigger:
 		switch eventType {
 		case config.ResourceAddEvent, config.ResourceUpdateEvent:
@@ -621,6 +670,44 @@ func updateResource(ctx context.Context, eventType string, globalConfig *config.
 
 		return nil
 
+	case config.ResourceSecurityPolicy:
+		switch eventType {
+		case config.ResourceAddEvent, config.ResourceUpdateEvent:
+			value := new(config.SecurityPolicy)
+			if err := mapstructure.Decode(resource, value); err != nil {
+				return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("invalid type provided for resource (%s) expecting (%v) got (%v)", resourceType, "config.SecurityPolicy{}", reflect.TypeOf(resource)), nil, nil)
+			}
+
+			if project.SecurityPolicies == nil {
+				project.SecurityPolicies = config.SecurityPolicies{resourceID: value}
+			} else {
+				project.SecurityPolicies[resourceID] = value
+			}
+		case config.ResourceDeleteEvent:
+			delete(project.SecurityPolicies, resourceID)
+		}
+
+		return nil
+
+	case config.ResourceAPIKey:
+		switch eventType {
+		case config.ResourceAddEvent, config.ResourceUpdateEvent:
+			value := new(config.APIKey)
+			if err := mapstructure.Decode(resource, value); err != nil {
+				return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("invalid type provided for resource (%s) expecting (%v) got (%v)", resourceType, "config.APIKey{}", reflect.TypeOf(resource)), nil, nil)
+			}
+
+			if project.APIKeys == nil {
+				project.APIKeys = config.APIKeys{resourceID: value}
+			} else {
+				project.APIKeys[resourceID] = value
+			}
+		case config.ResourceDeleteEvent:
+			delete(project.APIKeys, resourceID)
+		}
+
+		return nil
+
 	case config.ResourceEventingTrigger:
 		switch eventType {
 		case config.ResourceAddEvent, config.ResourceUpdateEvent:
@@ -744,6 +831,132 @@ func updateResource(ctx context.Context, eventType string, globalConfig *config.
 	}
 }
 
+// getConfigResources returns all the resources of the config keyed by their resource id
+func getConfigResources(clusterID string, c *config.Config) map[string]interface{} {
+	resources := map[string]interface{}{}
+	if c == nil {
+		return resources
+	}
+
+	if c.ClusterConfig != nil {
+		resources[config.GenerateResourceID(clusterID, "noProject", config.ResourceCluster, "cluster")] = c.ClusterConfig
+	}
+	if c.CacheConfig != nil {
+		resources[config.GenerateResourceID(clusterID, "noProject", config.ResourceCacheConfig, "cache")] = c.CacheConfig
+	}
+	for id, v := range c.Integrations {
+		resources[id] = v
+	}
+	for id, v := range c.IntegrationHooks {
+		resources[id] = v
+	}
+
+	for projectID, p := range c.Projects {
+		if p.ProjectConfig != nil {
+			resources[config.GenerateResourceID(clusterID, projectID, config.ResourceProject, projectID)] = p.ProjectConfig
+		}
+		if p.EventingConfig != nil {
+			resources[config.GenerateResourceID(clusterID, projectID, config.ResourceEventingConfig, "eventing")] = p.EventingConfig
+		}
+		if p.FileStoreConfig != nil {
+			resources[config.GenerateResourceID(clusterID, projectID, config.ResourceFileStoreConfig, "filestore")] = p.FileStoreConfig
+		}
+		if p.LetsEncrypt != nil {
+			resources[config.GenerateResourceID(clusterID, projectID, config.ResourceProjectLetsEncrypt, "letsencrypt")] = p.LetsEncrypt
+		}
+		if p.IngressGlobal != nil {
+			resources[config.GenerateResourceID(clusterID, projectID, config.ResourceIngressGlobal, "global")] = p.IngressGlobal
+		}
+		for id, v := range p.DatabaseConfigs {
+			resources[id] = v
+		}
+		for id, v := range p.DatabaseSchemas {
+			resources[id] = v
+		}
+		for id, v := range p.DatabaseRules {
+			resources[id] = v
+		}
+		for id, v := range p.DatabasePreparedQueries {
+			resources[id] = v
+		}
+		for id, v := range p.EventingSchemas {
+			resources[id] = v
+		}
+		for id, v := range p.EventingRules {
+			resources[id] = v
+		}
+		for id, v := range p.EventingTriggers {
+			resources[id] = v
+		}
+		for id, v := range p.FileStoreRules {
+			resources[id] = v
+		}
+		for id, v := range p.Auths {
+			resources[id] = v
+		}
+		for id, v := range p.IngressRoutes {
+			resources[id] = v
+		}
+		for id, v := range p.RemoteService {
+			resources[id] = v
+		}
+		for id, v := range p.SecurityPolicies {
+			resources[id] = v
+		}
+		for id, v := range p.APIKeys {
+			resources[id] = v
+		}
+	}
+	return resources
+}
+
+// resourceChange is a change made to a resource outside of space cloud, like an edit made to a config file
+type resourceChange struct {
+	eventType    string
+	resourceID   string
+	resourceType config.Resource
+	resource     interface{}
+}
+
+// getResourceChanges returns the changes needed to go from the old resources to the new ones. The resources
+// are set in the order space cloud expects them and deleted in the reverse order.
+func getResourceChanges(ctx context.Context, oldResources, newResources map[string]interface{}) []*resourceChange {
+	sets, deletes := map[config.Resource][]*resourceChange{}, map[config.Resource][]*resourceChange{}
+	for resourceID, resource := range newResources {
+		old, p := oldResources[resourceID]
+		if p && reflect.DeepEqual(old, resource) {
+			continue
+		}
+		_, _, resourceType, _ := splitResourceID(ctx, resourceID)
+		eventType := config.ResourceAddEvent
+		if p {
+			eventType = config.ResourceUpdateEvent
+		}
+		sets[resourceType] = append(sets[resourceType], &resourceChange{eventType: eventType, resourceID: resourceID, resourceType: resourceType, resource: resource})
+	}
+	for resourceID := range oldResources {
+		if _, p := newResources[resourceID]; p {
+			continue
+		}
+		_, _, resourceType, _ := splitResourceID(ctx, resourceID)
+		deletes[resourceType] = append(deletes[resourceType], &resourceChange{eventType: config.ResourceDeleteEvent, resourceID: resourceID, resourceType: resourceType})
+	}
+
+	changes := make([]*resourceChange, 0)
+	for _, resourceType := range config.ResourceFetchingOrder {
+		changes = append(changes, sortResourceChanges(sets[resourceType])...)
+	}
+	for i := len(config.ResourceFetchingOrder) - 1; i >= 0; i-- {
+		changes = append(changes, sortResourceChanges(deletes[config.ResourceFetchingOrder[i]])...)
+	}
+	return changes
+}
+
+func sortResourceChanges(changes []*resourceChange) []*resourceChange {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].resourceID < changes[j].resourceID })
+	return changes
+}
+
 // CheckIfLeaderGateway tells if the provided gateway is the current leader gateway or not
 func (s *Manager) CheckIfLeaderGateway(nodeID string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -305,3 +305,42 @@ func TestManager_getConfigWithoutLock(t *testing.T) {
 		})
 	}
 }
+
+func Test_restoreRedacted(t *testing.T) {
+	stored := &config.ProjectConfig{ID: "myproject", AESKey: "c29tZS1rZXk=", Secrets: []*config.Secret{{KID: "1", Secret: "some-secret", IsPrimary: true}}}
+	tests := []struct {
+		name   string
+		value  *config.ProjectConfig
+		stored *config.ProjectConfig
+		want   *config.ProjectConfig
+	}{
+		{
+			name:   "config read back through the config apis",
+			value:  &config.ProjectConfig{ID: "myproject", AESKey: config.RedactedValue, Secrets: []*config.Secret{{KID: "1", Secret: config.RedactedValue, IsPrimary: true}}, ContextTimeGraphQL: 20},
+			stored: stored,
+			want:   &config.ProjectConfig{ID: "myproject", AESKey: "c29tZS1rZXk=", Secrets: []*config.Secret{{KID: "1", Secret: "some-secret", IsPrimary: true}}, ContextTimeGraphQL: 20},
+		},
+		{
+			name:   "new secrets provided",
+			value:  &config.ProjectConfig{ID: "myproject", AESKey: "bmV3LWtleQ==", Secrets: []*config.Secret{{KID: "1", Secret: "new-secret", IsPrimary: true}}},
+			stored: stored,
+			want:   &config.ProjectConfig{ID: "myproject", AESKey: "bmV3LWtleQ==", Secrets: []*config.Secret{{KID: "1", Secret: "new-secret", IsPrimary: true}}},
+		},
+		{
+			name:  "resource which isn't stored",
+			value: &config.ProjectConfig{ID: "myproject", AESKey: config.RedactedValue},
+			want:  &config.ProjectConfig{ID: "myproject", AESKey: config.RedactedValue},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := restoreRedacted(context.Background(), tt.value, tt.stored); err != nil {
+				t.Errorf("restoreRedacted() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(tt.value, tt.want) {
+				t.Errorf("restoreRedacted() = %v, want %v", tt.value, tt.want)
+			}
+		})
+	}
+}
@@ -106,6 +106,9 @@ func (s *Manager) SetClusterConfig(ctx context.Context, req *config.ClusterConfi
 	// Acquire a lock
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	if err := restoreRedacted(ctx, req, s.projectConfig.ClusterConfig); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	s.projectConfig.ClusterConfig = req
 	resourceID := config.GenerateResourceID(s.clusterID, "noProject", config.ResourceCluster, "cluster")
 	if err := s.store.SetResource(ctx, resourceID, s.projectConfig.ClusterConfig); err != nil {
@@ -114,6 +117,7 @@ func (s *Manager) SetClusterConfig(ctx context.Context, req *config.ClusterConfi
 
 	s.globalModules.SetMetricsConfig(s.projectConfig.ClusterConfig.EnableTelemetry)
 	s.modules.LetsEncrypt().SetLetsEncryptEmail(req.LetsEncryptEmail)
+	s.modules.Compression().SetConfig(req.Compression)
 
 	return http.StatusOK, nil
 }
@@ -10,7 +10,9 @@ import (
 // Store abstracts the implementation of letsencrypt storage operations
 type Store interface {
 	WatchServices(cb func(eventType string, serviceID string, projects model.ScServices)) error
-	WatchResources(cb func(eventType, resourceId string, resourceType config.Resource, resource interface{})) error
+	// WatchResources passes the changes made to the resources from outside the gateway on to the callback. The
+	// callback returns an error if it couldn't apply the change.
+	WatchResources(cb func(eventType, resourceId string, resourceType config.Resource, resource interface{}) error) error
 
 	Register()
 
@@ -21,4 +23,8 @@ type Store interface {
 	DeleteProject(ctx context.Context, projectID string) error
 
 	GetGlobalConfig() (*config.Config, error)
+
+	// GetResourceHistory returns the revisions of a resource, oldest first. The revisions of all the
+	// resources of the project are returned if a resource id isn't provided.
+	GetResourceHistory(ctx context.Context, projectID, resourceID string) ([]*model.ConfigRevision, error)
 }
@@ -0,0 +1,655 @@
+package syncman
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+const (
+	// gitCloneTimeout is the time given to fetch the repository when the store is created
+	gitCloneTimeout = 5 * time.Minute
+
+	// gitPullTimeout is the time given to pull the changes made upstream
+	gitPullTimeout = 1 * time.Minute
+
+	// gitRetryDelay is the time to wait before passing on the changes pulled again when one of them could not be applied
+	gitRetryDelay = 10 * time.Second
+)
+
+// gitStoreOptions describes the repository the git store keeps the config in
+type gitStoreOptions struct {
+	// URL of the remote repository. It can be the path of a local bare repository as well.
+	URL    string
+	Branch string
+
+	// Path is the directory the repository is cloned in
+	Path string
+
+	// Push makes every commit get pushed to the remote
+	Push bool
+
+	// PollInterval is the time between fetching the changes made to the branch upstream
+	PollInterval time.Duration
+
+	// AllowSecrets lets resources having secrets in them be stored. The files are stored in plain text, so secrets
+	// should otherwise be provided as ${secret:name.key} or ${env:NAME} placeholders. Key rotation requires it since
+	// the private keys it generates are stored in the project config.
+	AllowSecrets bool
+}
+
+// GitStore keeps the config as spec objects in a git repository. Every resource is stored in a file of
+// its own at <project>/<resource type>/<id>.yaml, and every change made to it is a commit. Resources having
+// literal secrets (connection strings, jwt secrets, passwords and the like) are refused unless GIT_ALLOW_SECRETS
+// is set, since the files get pushed as they are. The same goes for projects having key rotation enabled.
+type GitStore struct {
+	lock           sync.Mutex
+	clusterID      string
+	opts           *gitStoreOptions
+	projectsConfig *config.Config
+	services       model.ScServices
+
+	// pending holds the changes made upstream which were picked up while pushing a commit. They are passed on
+	// to the callback along with the ones pulled next.
+	pending []*resourceChange
+}
+
+// NewGitStore creates a new git store
+func NewGitStore(clusterID string) (*GitStore, error) {
+	opts := &gitStoreOptions{
+		URL:          os.Getenv("GIT_URL"),
+		Branch:       os.Getenv("GIT_BRANCH"),
+		Path:         os.Getenv("GIT_PATH"),
+		Push:         os.Getenv("GIT_PUSH") == "true",
+		AllowSecrets: os.Getenv("GIT_ALLOW_SECRETS") == "true",
+		PollInterval: 30 * time.Second,
+	}
+	if v := os.Getenv("GIT_POLL_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), fmt.Sprintf("Invalid git poll interval (%s) provided", v), err, nil)
+		}
+		opts.PollInterval = d
+	}
+	return newGitStore(clusterID, opts)
+}
+
+func newGitStore(clusterID string, opts *gitStoreOptions) (*GitStore, error) {
+	if opts.URL == "" {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Url of the git repository must be provided to use the git store", nil, nil)
+	}
+	if opts.Branch == "" {
+		opts.Branch = "main"
+	}
+	if opts.Path == "" {
+		opts.Path = "config-repo"
+	}
+
+	s := &GitStore{clusterID: clusterID, opts: opts, projectsConfig: config.GenerateEmptyConfig(), services: model.ScServices{&model.Service{ID: "single-node-cluster"}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gitCloneTimeout)
+	defer cancel()
+	if err := s.clone(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// clone clones the repository if it hasn't been cloned already and checks out the branch
+func (s *GitStore) clone(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(s.opts.Path, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(s.opts.Path, 0755); err != nil {
+			return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to create the directory of the git repository", err, nil)
+		}
+		if _, err := s.git(ctx, "init"); err != nil {
+			return err
+		}
+		if _, err := s.git(ctx, "remote", "add", "origin", s.opts.URL); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.git(ctx, "fetch", "origin"); err != nil {
+		return err
+	}
+
+	// The branch gets created with the first commit if it doesn't exist upstream
+	if _, err := s.git(ctx, "rev-parse", "--verify", "--quiet", "origin/"+s.opts.Branch); err != nil {
+		_, err = s.git(ctx, "checkout", "-B", s.opts.Branch)
+		return err
+	}
+	if _, err := s.git(ctx, "checkout", "-B", s.opts.Branch, "origin/"+s.opts.Branch); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Register registers space cloud to the git store
+func (s *GitStore) Register() {}
+
+// WatchServices maintains consistency over all services
+func (s *GitStore) WatchServices(cb func(string, string, model.ScServices)) error {
+	cb(config.ResourceAddEvent, s.services[0].ID, s.services)
+	return nil
+}
+
+// WatchResources polls the branch for the changes made upstream and passes them on to the callback
+func (s *GitStore) WatchResources(cb func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error) error {
+	go func() {
+		ticker := time.NewTicker(s.opts.PollInterval)
+		defer ticker.Stop()
+
+		var retry <-chan time.Time
+		for {
+			select {
+			case <-ticker.C:
+			case <-retry:
+			}
+			retry = nil
+			if s.sync(cb) {
+				retry = time.After(gitRetryDelay)
+			}
+		}
+	}()
+	return nil
+}
+
+// sync pulls the changes made upstream and passes them on to the callback. A change is only taken as applied once the
+// callback succeeds, the ones which haven't been applied are queued up to be passed on again. It returns true if a
+// change failed to apply and needs to be retried.
+func (s *GitStore) sync(cb func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), gitPullTimeout)
+	defer cancel()
+
+	changes, err := s.pull(ctx)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to pull the changes made to the git repository", err, nil)
+	}
+	for i, change := range changes {
+		helpers.Logger.LogInfo(helpers.GetRequestID(ctx), "Applying change made to the git repository", map[string]interface{}{"event": change.eventType, "resourceId": change.resourceID})
+		if err := cb(change.eventType, change.resourceID, change.resourceType, change.resource); err != nil {
+			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to apply change made to the git repository, it will be retried", err, map[string]interface{}{"event": change.eventType, "resourceId": change.resourceID})
+			s.requeue(changes[i:])
+			return true
+		}
+	}
+	return false
+}
+
+// requeue puts the changes back in front of the pending ones so that they are passed on in order with the next pull
+func (s *GitStore) requeue(changes []*resourceChange) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pending = append(append([]*resourceChange{}, changes...), s.pending...)
+}
+
+// pull brings the branch up to date with the remote and returns the resources which changed
+func (s *GitStore) pull(ctx context.Context) ([]*resourceChange, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// The changes picked up while pushing are returned even if there is nothing new to pull
+	pending := s.pending
+	s.pending = nil
+
+	changes, err := s.pullWithoutLock(ctx)
+	return append(pending, changes...), err
+}
+
+func (s *GitStore) pullWithoutLock(ctx context.Context) ([]*resourceChange, error) {
+	if _, err := s.git(ctx, "fetch", "origin", s.opts.Branch); err != nil {
+		return nil, err
+	}
+	if _, err := s.git(ctx, "rev-parse", "--verify", "--quiet", "origin/"+s.opts.Branch); err != nil {
+		// Nothing has been pushed to the branch yet
+		return nil, nil
+	}
+
+	// The branch is only checked out when it was pushed for the first time after the repository got cloned
+	head, err := s.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	checkout := err != nil
+	if !checkout {
+		out, err := s.git(ctx, "rev-list", "--count", "HEAD..origin/"+s.opts.Branch)
+		if err != nil || strings.TrimSpace(out) == "0" {
+			return nil, err
+		}
+	}
+
+	oldResources, err := s.readResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkout {
+		if _, err := s.git(ctx, "checkout", "-B", s.opts.Branch, "origin/"+s.opts.Branch); err != nil {
+			return nil, err
+		}
+	} else if _, err := s.git(ctx, "rebase", "origin/"+s.opts.Branch); err != nil {
+		// Commits which haven't been pushed are replayed on top of the upstream ones
+		_, _ = s.git(ctx, "rebase", "--abort")
+		return nil, err
+	}
+
+	newResources, err := s.readResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLocalResources(ctx, newResources); err != nil {
+		// The branch is moved back so that the changes keep getting rejected till they are fixed upstream
+		if !checkout {
+			_, _ = s.git(ctx, "reset", "--hard", "--quiet", strings.TrimSpace(head))
+		}
+		return nil, err
+	}
+
+	changes := getResourceChanges(ctx, oldResources, newResources)
+	for _, change := range changes {
+		if err := updateResource(ctx, change.eventType, s.projectsConfig, change.resourceID, change.resourceType, change.resource); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// SetResource stores the resource in its file and commits it
+func (s *GitStore) SetResource(ctx context.Context, resourceID string, resource interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.opts.AllowSecrets {
+		if v := toAuditValue(resource); !reflect.DeepEqual(config.Redact("", v), v) {
+			return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Resource (%s) has secrets in it which cannot be stored in the git repository, provide them as ${secret:name.key} or ${env:NAME} placeholders instead", resourceID), nil, nil)
+		}
+		// The keys generated by the rotation would get refused otherwise, leaving the project with its old keys
+		if p, ok := resource.(*config.ProjectConfig); ok && p.KeyRotation != nil && p.KeyRotation.Enabled {
+			return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Project (%s) has key rotation enabled which stores the generated private keys in the project config, set GIT_ALLOW_SECRETS to use it with the git store", p.ID), nil, nil)
+		}
+	}
+
+	// The config and the working tree are restored to the last commit if the resource cannot be committed
+	prev := s.head(ctx)
+
+	// Validate if the resource value is according to the resource type
+	if err := updateResource(ctx, config.ResourceAddEvent, s.projectsConfig, resourceID, "", resource); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to validate resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+
+	if err := s.writeResource(ctx, resourceID, resource); err != nil {
+		s.rollback(ctx, prev)
+		return err
+	}
+	return s.commit(ctx, fmt.Sprintf("Set %s", resourceID))
+}
+
+// writeResource writes the resource to its file and stages it
+func (s *GitStore) writeResource(ctx context.Context, resourceID string, resource interface{}) error {
+	path, spec, err := resourceToSpec(ctx, resourceID, resource)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to marshal resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+	if err := os.MkdirAll(filepath.Join(s.opts.Path, filepath.Dir(path)), 0755); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to create the directory of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+	if err := ioutil.WriteFile(filepath.Join(s.opts.Path, path), data, 0644); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to write the file of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+
+	_, err = s.git(ctx, "add", "--", path)
+	return err
+}
+
+// DeleteResource removes the file of the resource and commits it
+func (s *GitStore) DeleteResource(ctx context.Context, resourceID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	path, _, err := resourceToSpec(ctx, resourceID, nil)
+	if err != nil {
+		return err
+	}
+
+	prev := s.head(ctx)
+	if err := updateResource(ctx, config.ResourceDeleteEvent, s.projectsConfig, resourceID, "", nil); err != nil {
+		return err
+	}
+	if _, err := s.git(ctx, "rm", "--quiet", "--ignore-unmatch", "--", path); err != nil {
+		s.rollback(ctx, prev)
+		return err
+	}
+	return s.commit(ctx, fmt.Sprintf("Delete %s", resourceID))
+}
+
+// DeleteProject removes the directory of the project and commits it
+func (s *GitStore) DeleteProject(ctx context.Context, projectID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	prev := s.head(ctx)
+	delete(s.projectsConfig.Projects, projectID)
+	if _, err := s.git(ctx, "rm", "-r", "--quiet", "--ignore-unmatch", "--", url.PathEscape(projectID)); err != nil {
+		s.rollback(ctx, prev)
+		return err
+	}
+	return s.commit(ctx, fmt.Sprintf("Delete project %s", projectID))
+}
+
+// GetGlobalConfig gets the config of all the resources stored in the branch
+func (s *GitStore) GetGlobalConfig() (*config.Config, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ctx := context.TODO()
+	resources, err := s.readResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	globalConfig, err := resourcesToConfig(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validation of the resources is done on a config of its own since the one returned gets modified by the sync manager
+	if s.projectsConfig, err = resourcesToConfig(ctx, resources); err != nil {
+		return nil, err
+	}
+	return globalConfig, nil
+}
+
+// GetResourceHistory returns the revisions of a resource, or of all the resources of the project, from the
+// commits made to their files
+func (s *GitStore) GetResourceHistory(ctx context.Context, projectID, resourceID string) ([]*model.ConfigRevision, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	path := url.PathEscape(projectID)
+	if resourceID != "" {
+		p, _, err := resourceToSpec(ctx, resourceID, nil)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+
+	out, err := s.git(ctx, "log", "--reverse", "--no-renames", "--name-status", "--format=commit %H %cI", "--", path)
+	if err != nil {
+		// The branch doesn't have any commits yet
+		return []*model.ConfigRevision{}, nil
+	}
+
+	revisions := make([]*model.ConfigRevision, 0)
+	byResource := map[string][]*model.ConfigRevision{}
+	var hash, commitTime string
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "commit ") {
+			arr := strings.Fields(line)
+			hash = arr[1]
+			if t, err := time.Parse(time.RFC3339, arr[2]); err == nil {
+				commitTime = t.UTC().Format(time.RFC3339Nano)
+			}
+			continue
+		}
+
+		arr := strings.SplitN(line, "\t", 2)
+		if len(arr) != 2 || !isSpecFile(arr[1]) {
+			continue
+		}
+		id, err := s.pathToResourceID(arr[1])
+		if err != nil {
+			continue
+		}
+
+		revision := &model.ConfigRevision{ResourceID: id, Time: commitTime, Event: config.ResourceDeleteEvent}
+		if arr[0] != "D" {
+			data, err := s.git(ctx, "show", fmt.Sprintf("%s:%s", hash, arr[1]))
+			if err != nil {
+				return nil, err
+			}
+			spec := new(model.SpecObject)
+			if err := yaml.Unmarshal([]byte(data), spec); err != nil {
+				return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to unmarshal the file of resource", err, map[string]interface{}{"resourceId": id, "commit": hash})
+			}
+			revision.Event = getRevisionEvent(byResource[id])
+			revision.Resource = toAuditValue(spec.Spec)
+		}
+		revision.Revision = len(byResource[id]) + 1
+		byResource[id] = append(byResource[id], revision)
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// readResources reads all the resources from the files of the working tree
+func (s *GitStore) readResources(ctx context.Context) (map[string]interface{}, error) {
+	resources := map[string]interface{}{}
+	err := filepath.Walk(s.opts.Path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isSpecFile(path) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.opts.Path, path)
+		if err != nil {
+			return err
+		}
+		id, err := s.pathToResourceID(rel)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		spec := new(model.SpecObject)
+		if err := yaml.Unmarshal(data, spec); err != nil {
+			return fmt.Errorf("invalid spec object in file (%s) - %v", rel, err)
+		}
+		resources[id] = toAuditValue(spec.Spec)
+		return nil
+	})
+	if err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to read the resources of the git repository", err, nil)
+	}
+	return resources, nil
+}
+
+// resourcesToConfig generates the config made up of the resources provided
+func resourcesToConfig(ctx context.Context, resources map[string]interface{}) (*config.Config, error) {
+	globalConfig := config.GenerateEmptyConfig()
+	for _, change := range getResourceChanges(ctx, map[string]interface{}{}, resources) {
+		if err := updateResource(ctx, config.ResourceAddEvent, globalConfig, change.resourceID, change.resourceType, change.resource); err != nil {
+			return nil, err
+		}
+	}
+	return globalConfig, nil
+}
+
+// pathToResourceID converts the path of the file of a resource to its id
+func (s *GitStore) pathToResourceID(path string) (string, error) {
+	arr := strings.Split(filepath.ToSlash(path), "/")
+	if len(arr) != 3 {
+		return "", fmt.Errorf("file (%s) isn't stored at <project>/<resource type>/<id>.yaml", path)
+	}
+	for i, v := range arr {
+		s, err := url.PathUnescape(v)
+		if err != nil {
+			return "", err
+		}
+		arr[i] = s
+	}
+	return config.GenerateResourceID(s.clusterID, arr[0], config.Resource(arr[1]), strings.TrimSuffix(arr[2], filepath.Ext(arr[2]))), nil
+}
+
+// commit commits the staged changes, if any, and pushes them if required. The actor who made the change
+// and the id of the request are added to the message. The repository and the config are restored to the
+// previous commit if the change cannot be pushed.
+func (s *GitStore) commit(ctx context.Context, message string) error {
+	if _, err := s.git(ctx, "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	// The branch doesn't have a previous commit to go back to if this is its first one
+	prev := s.head(ctx)
+
+	actor := utils.GetActorFromContext(ctx)
+	if actor == "" {
+		actor = utils.InternalUserID
+	}
+	message = fmt.Sprintf("%s\n\nActor: %s\nRequest-Id: %s", message, actor, helpers.GetRequestID(ctx))
+	if _, err := s.git(ctx, "commit", "--quiet", "-m", message); err != nil {
+		s.rollback(ctx, prev)
+		return err
+	}
+	if !s.opts.Push {
+		return nil
+	}
+
+	if _, err := s.git(ctx, "push", "--quiet", "origin", s.opts.Branch); err == nil {
+		return nil
+	}
+
+	// Try once more after replaying the commit on top of the changes made upstream. The changes brought in by
+	// the rebase are passed on like the ones pulled.
+	oldResources, err := s.readResources(ctx)
+	if err != nil {
+		s.rollback(ctx, prev)
+		return err
+	}
+	if _, err := s.git(ctx, "pull", "--quiet", "--rebase", "origin", s.opts.Branch); err != nil {
+		_, _ = s.git(ctx, "rebase", "--abort")
+		s.rollback(ctx, prev)
+		return err
+	}
+	newResources, err := s.readResources(ctx)
+	if err == nil {
+		err = validateLocalResources(ctx, newResources)
+	}
+	if err != nil {
+		s.rollback(ctx, prev)
+		return err
+	}
+	if _, err := s.git(ctx, "push", "--quiet", "origin", s.opts.Branch); err != nil {
+		s.rollback(ctx, prev)
+		return err
+	}
+
+	changes := getResourceChanges(ctx, oldResources, newResources)
+	for _, change := range changes {
+		if err := updateResource(ctx, change.eventType, s.projectsConfig, change.resourceID, change.resourceType, change.resource); err != nil {
+			return err
+		}
+	}
+	s.pending = append(s.pending, changes...)
+	return nil
+}
+
+// head returns the commit the branch points to, it's empty if the branch doesn't have any commits yet
+func (s *GitStore) head(ctx context.Context) string {
+	out, _ := s.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	return strings.TrimSpace(out)
+}
+
+// rollback moves the branch back to the commit provided, discarding the commits made on top of it along with
+// the changes in the working tree, and reloads the config from it. The branch is emptied if no commit is provided.
+func (s *GitStore) rollback(ctx context.Context, commit string) {
+	var err error
+	if commit != "" {
+		_, err = s.git(ctx, "reset", "--hard", "--quiet", commit)
+	} else if _, err = s.git(ctx, "update-ref", "-d", "HEAD"); err == nil {
+		if _, err = s.git(ctx, "read-tree", "--empty"); err == nil {
+			_, err = s.git(ctx, "clean", "-d", "--force", "--quiet")
+		}
+	}
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to roll back the git repository to the previous commit", err, map[string]interface{}{"commit": commit})
+		return
+	}
+
+	resources, err := s.readResources(ctx)
+	if err != nil {
+		return
+	}
+	globalConfig, err := resourcesToConfig(ctx, resources)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to restore the config of the git store", err, nil)
+		return
+	}
+	s.projectsConfig = globalConfig
+}
+
+// git runs a git command in the repository and returns its output
+func (s *GitStore) git(ctx context.Context, args ...string) (string, error) {
+	// The identity is provided so that commits can be made without any git config. It can be overridden
+	// with the GIT_AUTHOR_* and GIT_COMMITTER_* environment variables.
+	args = append([]string{"-c", "user.name=Space Cloud", "-c", "user.email=space-cloud@" + s.clusterID}, args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.opts.Path
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed - %v: %s", args[4], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// resourceToSpec returns the path of the file a resource is stored in, relative to the repository, and the spec
+// object stored in it. The spec objects of the resources space cli knows about can be applied with it as well.
+func resourceToSpec(ctx context.Context, resourceID string, resource interface{}) (string, *model.SpecObject, error) {
+	arr := strings.SplitN(resourceID, "--", 4)
+	if len(arr) != 4 {
+		return "", nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Invalid resource id (%s) provided", resourceID), nil, nil)
+	}
+	projectID, resourceType, id := arr[1], config.Resource(arr[2]), arr[3]
+	path := filepath.Join(url.PathEscape(projectID), url.PathEscape(string(resourceType)), url.PathEscape(id)+".yaml")
+
+	spec := &model.SpecObject{Type: string(resourceType), Meta: map[string]string{"project": projectID, "id": id}, Spec: toAuditValue(resource)}
+	for api, r := range specResources {
+		if r.resourceType != resourceType {
+			continue
+		}
+		spec.API = api
+		if v, ok := spec.Spec.(map[string]interface{}); ok {
+			for field, key := range r.fields {
+				if value, ok := v[field].(string); ok && value != "" {
+					spec.Meta[key] = value
+				}
+			}
+		}
+		break
+	}
+	return path, spec, nil
+}
@@ -0,0 +1,297 @@
+package syncman
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+func newGitTestStores(t *testing.T) (*GitStore, *GitStore) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init error = %v: %s", err, out)
+	}
+
+	stores := make([]*GitStore, 2)
+	for i := range stores {
+		s, err := newGitStore("chicago", &gitStoreOptions{URL: remote, Branch: "main", Path: t.TempDir(), Push: true, PollInterval: 100 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("newGitStore() error = %v", err)
+		}
+		if _, err := s.GetGlobalConfig(); err != nil {
+			t.Fatalf("GetGlobalConfig() error = %v", err)
+		}
+		stores[i] = s
+	}
+	return stores[0], stores[1]
+}
+
+func TestGitStore_SetResource(t *testing.T) {
+	ctx := context.Background()
+	a, b := newGitTestStores(t)
+	projectID := config.GenerateResourceID("chicago", "myproject", config.ResourceProject, "myproject")
+	policyID := config.GenerateResourceID("chicago", "myproject", config.ResourceSecurityPolicy, "is-admin")
+
+	// A resource of a project which doesn't exist is rejected
+	if err := a.SetResource(ctx, policyID, &config.SecurityPolicy{ID: "is-admin", Module: "package v1"}); err == nil {
+		t.Errorf("SetResource() expected error for a project which doesn't exist")
+	}
+
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject", Name: "myproject"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+	if err := a.SetResource(ctx, policyID, &config.SecurityPolicy{ID: "is-admin", Module: "package v1"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+
+	// The other store reads the resources pushed to the branch
+	c, err := b.GetGlobalConfig()
+	if err != nil {
+		t.Fatalf("GetGlobalConfig() error = %v", err)
+	}
+	if len(c.Projects) != 0 {
+		t.Fatalf("GetGlobalConfig() returned resources which haven't been pulled yet")
+	}
+	if _, err := b.pull(ctx); err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	c, err = b.GetGlobalConfig()
+	if err != nil {
+		t.Fatalf("GetGlobalConfig() error = %v", err)
+	}
+	p, ok := c.Projects["myproject"]
+	if !ok || p.ProjectConfig.Name != "myproject" || p.SecurityPolicies[policyID] == nil || p.SecurityPolicies[policyID].Module != "package v1" {
+		t.Errorf("GetGlobalConfig() = %v, want the project and the policy", p)
+	}
+
+	// Deleting the project removes all its resources
+	if err := a.DeleteProject(ctx, "myproject"); err != nil {
+		t.Fatalf("DeleteProject() error = %v", err)
+	}
+	if c, err := a.GetGlobalConfig(); err != nil || len(c.Projects) != 0 {
+		t.Errorf("GetGlobalConfig() = %v, %v, want no projects", c, err)
+	}
+}
+
+func TestGitStore_SetResource_secrets(t *testing.T) {
+	ctx := context.Background()
+	a, _ := newGitTestStores(t)
+	projectID := config.GenerateResourceID("chicago", "myproject", config.ResourceProject, "myproject")
+
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject", Secrets: []*config.Secret{{KID: "1", Secret: "some-secret"}}}); err == nil {
+		t.Errorf("SetResource() expected error for a literal secret")
+	}
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject", Secrets: []*config.Secret{{KID: "1", Secret: "${secret:jwt.secret}"}}}); err != nil {
+		t.Errorf("SetResource() error = %v, want placeholders to be allowed", err)
+	}
+
+	// The private keys generated by the rotation would get refused
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject", KeyRotation: &config.KeyRotation{Enabled: true}}); err == nil {
+		t.Errorf("SetResource() expected error for key rotation")
+	}
+
+	a.opts.AllowSecrets = true
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject", Secrets: []*config.Secret{{KID: "1", Secret: "some-secret"}}}); err != nil {
+		t.Errorf("SetResource() error = %v, want literal secrets to be allowed when enabled", err)
+	}
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject", KeyRotation: &config.KeyRotation{Enabled: true}}); err != nil {
+		t.Errorf("SetResource() error = %v, want key rotation to be allowed when secrets are", err)
+	}
+}
+
+func TestGitStore_SetResource_writeFailed(t *testing.T) {
+	ctx := context.Background()
+	a, _ := newGitTestStores(t)
+	projectID := config.GenerateResourceID("chicago", "myproject", config.ResourceProject, "myproject")
+	otherID := config.GenerateResourceID("chicago", "otherproject", config.ResourceProject, "otherproject")
+
+	// A file in place of the directory of the project makes writing its resources fail
+	if err := ioutil.WriteFile(filepath.Join(a.opts.Path, "myproject"), []byte{}, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// The config is restored both before and after the branch has its first commit
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject"}); err == nil {
+		t.Fatalf("SetResource() expected error for a file which cannot be written")
+	}
+	if _, p := a.projectsConfig.Projects["myproject"]; p {
+		t.Errorf("SetResource() left the project in the config of the store")
+	}
+	// The working tree is restored as well
+	if _, err := os.Stat(filepath.Join(a.opts.Path, "myproject")); !os.IsNotExist(err) {
+		t.Errorf("SetResource() left the working tree as is, error = %v", err)
+	}
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(a.opts.Path, "otherproject"), []byte{}, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := a.SetResource(ctx, otherID, &config.ProjectConfig{ID: "otherproject"}); err == nil {
+		t.Fatalf("SetResource() expected error for a file which cannot be written")
+	}
+	if _, p := a.projectsConfig.Projects["otherproject"]; p {
+		t.Errorf("SetResource() left the project in the config of the store")
+	}
+	if _, p := a.projectsConfig.Projects["myproject"]; !p {
+		t.Errorf("SetResource() removed the committed project from the config of the store")
+	}
+}
+
+func TestGitStore_commit_rejected(t *testing.T) {
+	ctx := context.Background()
+	a, b := newGitTestStores(t)
+	projectID := config.GenerateResourceID("chicago", "myproject", config.ResourceProject, "myproject")
+	otherID := config.GenerateResourceID("chicago", "otherproject", config.ResourceProject, "otherproject")
+
+	// The push of the stale store is retried on top of the upstream commit, which gets passed on like a pull
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+	if err := b.SetResource(ctx, otherID, &config.ProjectConfig{ID: "otherproject"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+	changes, err := b.pull(ctx)
+	if err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].resourceID != projectID || changes[0].eventType != config.ResourceAddEvent {
+		t.Errorf("pull() = %v, want the project added upstream", changes)
+	}
+	if _, p := b.projectsConfig.Projects["myproject"]; !p {
+		t.Errorf("commit() didn't add the project added upstream to the config")
+	}
+
+	// A change which cannot be pushed is rolled back
+	hook := filepath.Join(a.opts.URL, "hooks", "pre-receive")
+	if err := ioutil.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	head, _ := a.git(ctx, "rev-parse", "HEAD")
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject", Name: "renamed"}); err == nil {
+		t.Fatalf("SetResource() expected error when the push is rejected")
+	}
+	if got, _ := a.git(ctx, "rev-parse", "HEAD"); got != head {
+		t.Errorf("SetResource() left the branch at (%s), want (%s)", got, head)
+	}
+	if name := a.projectsConfig.Projects["myproject"].ProjectConfig.Name; name != "" {
+		t.Errorf("SetResource() left the project name as (%s) in the config, want it to be restored", name)
+	}
+}
+
+func TestGitStore_WatchResources(t *testing.T) {
+	ctx := context.Background()
+	a, b := newGitTestStores(t)
+	projectID := config.GenerateResourceID("chicago", "myproject", config.ResourceProject, "myproject")
+
+	type event struct {
+		eventType  string
+		resourceID string
+	}
+	events := make(chan event, 10)
+	if err := b.WatchResources(func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error {
+		events <- event{eventType, resourceID}
+		return nil
+	}); err != nil {
+		t.Fatalf("WatchResources() error = %v", err)
+	}
+
+	wantEvents := []event{{config.ResourceAddEvent, projectID}, {config.ResourceUpdateEvent, projectID}}
+	for i, name := range []string{"myproject", "renamed"} {
+		if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject", Name: name}); err != nil {
+			t.Fatalf("SetResource() error = %v", err)
+		}
+		select {
+		case got := <-events:
+			if got != wantEvents[i] {
+				t.Errorf("WatchResources() event = %v, want %v", got, wantEvents[i])
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("WatchResources() did not report the change pushed to the branch")
+		}
+	}
+}
+
+func TestGitStore_sync_retry(t *testing.T) {
+	ctx := context.Background()
+	a, b := newGitTestStores(t)
+	projectID := config.GenerateResourceID("chicago", "myproject", config.ResourceProject, "myproject")
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+
+	var got []string
+	fail := true
+	cb := func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error {
+		if fail {
+			return errors.New("unable to apply change")
+		}
+		got = append(got, resourceID)
+		return nil
+	}
+
+	// The change which failed to apply is passed on again even though there is nothing new to pull
+	if retry := b.sync(cb); !retry {
+		t.Errorf("sync() = false, want the failed change to be retried")
+	}
+	fail = false
+	if retry := b.sync(cb); retry {
+		t.Errorf("sync() = true, want the change to be applied")
+	}
+	if len(got) != 1 || got[0] != projectID {
+		t.Errorf("sync() applied (%v), want the project", got)
+	}
+}
+
+func TestGitStore_GetResourceHistory(t *testing.T) {
+	ctx := context.Background()
+	a, _ := newGitTestStores(t)
+	projectID := config.GenerateResourceID("chicago", "myproject", config.ResourceProject, "myproject")
+	policyID := config.GenerateResourceID("chicago", "myproject", config.ResourceSecurityPolicy, "is-admin")
+
+	if err := a.SetResource(ctx, projectID, &config.ProjectConfig{ID: "myproject", Name: "myproject"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+	for _, module := range []string{"package v1", "package v2"} {
+		if err := a.SetResource(ctx, policyID, &config.SecurityPolicy{ID: "is-admin", Module: module}); err != nil {
+			t.Fatalf("SetResource() error = %v", err)
+		}
+	}
+	if err := a.DeleteResource(ctx, policyID); err != nil {
+		t.Fatalf("DeleteResource() error = %v", err)
+	}
+
+	revisions, err := a.GetResourceHistory(ctx, "myproject", policyID)
+	if err != nil {
+		t.Fatalf("GetResourceHistory() error = %v", err)
+	}
+	wantEvents := []string{config.ResourceAddEvent, config.ResourceUpdateEvent, config.ResourceDeleteEvent}
+	if len(revisions) != len(wantEvents) {
+		t.Fatalf("GetResourceHistory() returned %d revisions, want %d", len(revisions), len(wantEvents))
+	}
+	for i, rev := range revisions {
+		if rev.Revision != i+1 || rev.Event != wantEvents[i] || rev.ResourceID != policyID {
+			t.Errorf("GetResourceHistory() revision %d = %v", i, rev)
+		}
+	}
+	if policy, ok := revisions[1].Resource.(map[string]interface{}); !ok || policy["module"] != "package v2" {
+		t.Errorf("GetResourceHistory() resource = %v, want the updated policy", revisions[1].Resource)
+	}
+
+	revisions, err = a.GetResourceHistory(ctx, "myproject", "")
+	if err != nil || len(revisions) != 4 {
+		t.Errorf("GetResourceHistory() = %v, %v, want the revisions of the project and the policy", revisions, err)
+	}
+}
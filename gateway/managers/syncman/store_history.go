@@ -0,0 +1,63 @@
+package syncman
+
+import (
+	"sort"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+// maxResourceRevisions is the number of revisions kept for every resource. The oldest ones are dropped first.
+const maxResourceRevisions = 100
+
+// addRevision appends a revision for the change made to the resource and drops the revisions exceeding the limit
+func addRevision(revisions []*model.ConfigRevision, eventType, resourceID string, resource interface{}) []*model.ConfigRevision {
+	revision := 1
+	if len(revisions) > 0 {
+		revision = revisions[len(revisions)-1].Revision + 1
+	}
+
+	// A delete event doesn't need to store the resource
+	if eventType == config.ResourceDeleteEvent {
+		resource = nil
+	}
+
+	revisions = append(revisions, &model.ConfigRevision{
+		Revision:   revision,
+		ResourceID: resourceID,
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Event:      eventType,
+		Resource:   toAuditValue(resource),
+	})
+	if len(revisions) > maxResourceRevisions {
+		revisions = revisions[len(revisions)-maxResourceRevisions:]
+	}
+	return revisions
+}
+
+// getRevisionEvent returns the event of a revision which sets a resource
+func getRevisionEvent(revisions []*model.ConfigRevision) string {
+	if len(revisions) == 0 || revisions[len(revisions)-1].Event == config.ResourceDeleteEvent {
+		return config.ResourceAddEvent
+	}
+	return config.ResourceUpdateEvent
+}
+
+// sortRevisions sorts the revisions of multiple resources by the time they were made, oldest first
+func sortRevisions(revisions []*model.ConfigRevision) {
+	sort.SliceStable(revisions, func(i, j int) bool {
+		if revisions[i].Time == revisions[j].Time {
+			if revisions[i].ResourceID == revisions[j].ResourceID {
+				return revisions[i].Revision < revisions[j].Revision
+			}
+			return revisions[i].ResourceID < revisions[j].ResourceID
+		}
+		return revisionTime(revisions[i]).Before(revisionTime(revisions[j]))
+	})
+}
+
+func revisionTime(revision *model.ConfigRevision) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, revision.Time)
+	return t
+}
@@ -18,6 +18,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/model"
@@ -32,6 +33,9 @@ type KubeStore struct {
 
 const spaceCloud string = "space-cloud"
 
+// maxHistoryConfigMapSize keeps the config maps storing the history of resources under the size limit of kubernetes
+const maxHistoryConfigMapSize = 900 * 1024
+
 // NewKubeStore creates a new Kube store
 func NewKubeStore(clusterID string) (*KubeStore, error) {
 	// Create the kubernetes client
@@ -82,7 +86,7 @@ func onAddOrUpdateResource(eventType string, obj interface{}) (string, string, c
 }
 
 // WatchResources maintains consistency over all projects
-func (s *KubeStore) WatchResources(cb func(eventType, resourceID string, resourceType config.Resource, resource interface{})) error {
+func (s *KubeStore) WatchResources(cb func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error) error {
 	go func() {
 		var options internalinterfaces.TweakListOptionsFunc = func(options *v12.ListOptions) {
 			options.LabelSelector = fmt.Sprintf("clusterId=%s", s.clusterID)
@@ -92,27 +96,28 @@ func (s *KubeStore) WatchResources(cb func(eventType, resourceID string, resourc
 		defer close(stopper)
 		defer runtime.HandleCrash() // handles a crash & logs an error
 
+		// Changes which fail to apply are passed on again when the informer resyncs
 		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
 				evenType, resourceID, resourceType, resource := onAddOrUpdateResource(config.ResourceAddEvent, obj)
 				if resource == nil || resourceID == "" {
 					return
 				}
-				cb(evenType, resourceID, resourceType, resource)
+				_ = cb(evenType, resourceID, resourceType, resource)
 			},
 			UpdateFunc: func(old, obj interface{}) {
 				evenType, resourceID, resourceType, resource := onAddOrUpdateResource(config.ResourceUpdateEvent, obj)
 				if resource == nil || resourceID == "" {
 					return
 				}
-				cb(evenType, resourceID, resourceType, resource)
+				_ = cb(evenType, resourceID, resourceType, resource)
 			},
 			DeleteFunc: func(obj interface{}) {
 				evenType, resourceID, resourceType, resource := onAddOrUpdateResource(config.ResourceDeleteEvent, obj)
 				if resource == nil || resourceID == "" {
 					return
 				}
-				cb(evenType, resourceID, resourceType, resource)
+				_ = cb(evenType, resourceID, resourceType, resource)
 			},
 		})
 
@@ -246,8 +251,10 @@ func (s *KubeStore) SetResource(ctx context.Context, resourceID string, resource
 		_, err = s.kube.CoreV1().ConfigMaps(spaceCloud).Create(ctx, configMap, v12.CreateOptions{})
 		if err != nil {
 			_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to set project in kube store couldn't create config map", err, nil)
+			return err
 		}
-		return err
+		s.recordRevision(ctx, config.ResourceAddEvent, resourceID, resource)
+		return nil
 	} else if err != nil {
 		_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to set project in kube store couldn't set config map", err, nil)
 		return err
@@ -258,8 +265,10 @@ func (s *KubeStore) SetResource(ctx context.Context, resourceID string, resource
 	_, err = s.kube.CoreV1().ConfigMaps(spaceCloud).Update(ctx, configMap, v12.UpdateOptions{})
 	if err != nil {
 		_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to set project in kube store couldn't update config map", err, nil)
+		return err
 	}
-	return err
+	s.recordRevision(ctx, config.ResourceUpdateEvent, resourceID, resource)
+	return nil
 }
 
 // DeleteResource deletes a resource from cluster
@@ -270,8 +279,10 @@ func (s *KubeStore) DeleteResource(ctx context.Context, resourceID string) error
 		return nil
 	} else if err != nil {
 		_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to delete project in kube store couldn't get config map", err, nil)
+		return err
 	}
-	return err
+	s.recordRevision(ctx, config.ResourceDeleteEvent, resourceID, nil)
+	return nil
 }
 
 // DeleteProject deletes all the config resources which matches label projectId
@@ -284,11 +295,27 @@ func (s *KubeStore) DeleteProject(ctx context.Context, projectID string) error {
 			return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to list config maps which has label projectId = (%s)", projectID), err, nil)
 		}
 		for _, item := range list.Items {
-			if err := s.DeleteResource(ctx, item.Name); err != nil {
+			// The id is used so that the deletion gets recorded in the history of the resource
+			resourceID, ok := item.Data["id"]
+			if !ok {
+				resourceID = item.Name
+			}
+			if err := s.DeleteResource(ctx, resourceID); err != nil {
 				return err
 			}
 		}
 	}
+
+	// The history of a deleted project cannot be rolled back to, so it gets deleted as well
+	list, err := s.kube.CoreV1().ConfigMaps(spaceCloud).List(ctx, v12.ListOptions{LabelSelector: fmt.Sprintf("historyClusterId=%s,historyProjectId=%s", s.clusterID, projectID)})
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to list the history config maps of project (%s)", projectID), err, nil)
+	}
+	for _, item := range list.Items {
+		if err := s.kube.CoreV1().ConfigMaps(spaceCloud).Delete(ctx, item.Name, v12.DeleteOptions{}); err != nil && !kubeErrors.IsNotFound(err) {
+			return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to delete the history config map of resource", err, map[string]interface{}{"resourceId": item.Data["id"]})
+		}
+	}
 	return nil
 }
 
@@ -312,6 +339,112 @@ func (s *KubeStore) GetGlobalConfig() (*config.Config, error) {
 	return globalConfig, nil
 }
 
+// GetResourceHistory returns the revisions of a resource, or of all the resources of the project, oldest first
+func (s *KubeStore) GetResourceHistory(ctx context.Context, projectID, resourceID string) ([]*model.ConfigRevision, error) {
+	if resourceID != "" {
+		revisions, _, err := s.getRevisions(ctx, resourceID)
+		return revisions, err
+	}
+
+	list, err := s.kube.CoreV1().ConfigMaps(spaceCloud).List(ctx, v12.ListOptions{LabelSelector: fmt.Sprintf("historyClusterId=%s,historyProjectId=%s", s.clusterID, projectID)})
+	if err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to list the history config maps of project (%s)", projectID), err, nil)
+	}
+
+	revisions := []*model.ConfigRevision{}
+	for _, item := range list.Items {
+		arr := []*model.ConfigRevision{}
+		if err := json.Unmarshal([]byte(item.Data["revisions"]), &arr); err != nil {
+			return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to unmarshal the history of resource", err, map[string]interface{}{"resourceId": item.Data["id"]})
+		}
+		revisions = append(revisions, arr...)
+	}
+	sortRevisions(revisions)
+	return revisions, nil
+}
+
+// getRevisions returns the revisions of the resource along with the config map they are stored in. The config map is nil if the resource has no history.
+func (s *KubeStore) getRevisions(ctx context.Context, resourceID string) ([]*model.ConfigRevision, *v1.ConfigMap, error) {
+	configMap, err := s.kube.CoreV1().ConfigMaps(spaceCloud).Get(ctx, makeHistoryConfigMapName(resourceID), v12.GetOptions{})
+	if kubeErrors.IsNotFound(err) {
+		return []*model.ConfigRevision{}, nil, nil
+	} else if err != nil {
+		return nil, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to get the history config map of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+
+	revisions := []*model.ConfigRevision{}
+	if err := json.Unmarshal([]byte(configMap.Data["revisions"]), &revisions); err != nil {
+		return nil, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to unmarshal the history of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+	return revisions, configMap, nil
+}
+
+// recordRevision adds a revision to the history of the resource. The change has already been stored by the time it
+// gets called, so failing to record it is only logged.
+func (s *KubeStore) recordRevision(ctx context.Context, eventType, resourceID string, resource interface{}) {
+	// Gateways changing the same resource together make the update of the history config map fail with a conflict
+	err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return kubeErrors.IsConflict(err) || kubeErrors.IsAlreadyExists(err)
+	}, func() error {
+		return s.addRevision(ctx, eventType, resourceID, resource)
+	})
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to record the change in the history of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+}
+
+// addRevision adds a revision to the history of the resource. The history is stored in a config map of its own which
+// doesn't carry the labels of resources, so that it isn't picked up while watching or deleting them. The config map
+// is updated with the resource version it was read at, so a concurrent change of the history results in a conflict.
+func (s *KubeStore) addRevision(ctx context.Context, eventType, resourceID string, resource interface{}) error {
+	clusterID, projectID, _, err := splitResourceID(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+
+	revisions, configMap, err := s.getRevisions(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+	revisions = addRevision(revisions, eventType, resourceID, resource)
+
+	// Drop the oldest revisions till the history fits in a config map
+	var data []byte
+	for {
+		data, err = json.Marshal(revisions)
+		if err != nil {
+			return err
+		}
+		if len(data) <= maxHistoryConfigMapSize || len(revisions) == 1 {
+			break
+		}
+		revisions = revisions[1:]
+	}
+
+	if configMap == nil {
+		configMap = &v1.ConfigMap{
+			ObjectMeta: v12.ObjectMeta{
+				Name: makeHistoryConfigMapName(resourceID),
+				Labels: map[string]string{
+					"historyClusterId": clusterID,
+					"historyProjectId": projectID,
+				},
+			},
+			Data: map[string]string{"id": resourceID, "revisions": string(data)},
+		}
+		_, err = s.kube.CoreV1().ConfigMaps(spaceCloud).Create(ctx, configMap, v12.CreateOptions{})
+		return err
+	}
+
+	configMap.Data["revisions"] = string(data)
+	_, err = s.kube.CoreV1().ConfigMaps(spaceCloud).Update(ctx, configMap, v12.UpdateOptions{})
+	return err
+}
+
+func makeHistoryConfigMapName(resourceID string) string {
+	return makeIDConfigMapCompatible(resourceID) + "--history"
+}
+
 // name cannot contain have underscore (_) but some resource have underscore in their name e.g --> event_logs, invocation_logs
 // NOTE: use labels for getting the correct resource id
 func makeIDConfigMapCompatible(resourceID string) string {
@@ -1,8 +1,15 @@
 package syncman
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
+	"github.com/spaceuptech/helpers"
 	"golang.org/x/net/context"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
@@ -11,43 +18,62 @@ import (
 
 // LocalStore is an object for storing localstore information
 type LocalStore struct {
+	lock         sync.Mutex
+	clusterID    string
 	configPath   string
 	globalConfig *config.Config
 	services     model.ScServices
+
+	// specsPath is an optional directory of spec objects which are loaded along with the config file. The resources
+	// created from them are kept out of the config file so that the spec objects remain their source of truth.
+	specsPath     string
+	specResources map[string]bool
+
+	// resources is a copy of the resources as they were last read or written. It is used to find the changes made to the files.
+	resources map[string]interface{}
+
+	// historyPath is the directory the revisions of every resource are stored in. History isn't kept if it is empty.
+	historyPath string
 }
 
 // NewLocalStore creates a new local store
-func NewLocalStore(nodeID string, ssl *config.SSL) (*LocalStore, error) {
+func NewLocalStore(nodeID, clusterID string, ssl *config.SSL) (*LocalStore, error) {
 	configPath := os.Getenv("CONFIG")
 	if configPath == "" {
 		configPath = "config.yaml"
 	}
 	// Load the configFile from path if provided
-	conf, err := config.LoadConfigFromFile(configPath)
+	conf, err := loadLocalConfigFile(configPath)
 	if err != nil {
 		conf = config.GenerateEmptyConfig()
-	}
-
-	// For compatibility with v18
-	if conf.ClusterConfig == nil {
 		conf.ClusterConfig = &config.ClusterConfig{EnableTelemetry: true}
 	}
 
 	if ssl.Enabled {
 		conf.SSL = ssl
 	}
+
+	historyPath := os.Getenv("HISTORY_PATH")
+	if historyPath == "" {
+		historyPath = filepath.Join(filepath.Dir(configPath), "history")
+	}
+
 	services := model.ScServices{}
-	return &LocalStore{configPath: configPath, globalConfig: conf, services: append(services, &model.Service{ID: "single-node-cluster"})}, nil
+	s := &LocalStore{clusterID: clusterID, configPath: configPath, globalConfig: conf, services: append(services, &model.Service{ID: "single-node-cluster"}), historyPath: historyPath, specsPath: os.Getenv("SPECS_PATH")}
+
+	// Add the resources of the spec objects to the config
+	specResources, err := s.loadSpecResources(context.Background(), conf)
+	if err != nil {
+		return nil, err
+	}
+	s.specResources = specResources
+	s.resources = getLocalResources(clusterID, conf)
+	return s, nil
 }
 
 // Register registers space cloud to the local store
 func (s *LocalStore) Register() {}
 
-// WatchResources maintains consistency over all projects
-func (s *LocalStore) WatchResources(cb func(eventType, resourceId string, resourceType config.Resource, resource interface{})) error {
-	return nil
-}
-
 // WatchServices maintains consistency over all services
 func (s *LocalStore) WatchServices(cb func(string, string, model.ScServices)) error {
 	cb(config.ResourceAddEvent, s.services[0].ID, s.services)
@@ -56,27 +82,171 @@ func (s *LocalStore) WatchServices(cb func(string, string, model.ScServices)) er
 
 // SetResource sets the project of the local globalConfig
 func (s *LocalStore) SetResource(ctx context.Context, resourceID string, resource interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	if err := updateResource(ctx, config.ResourceAddEvent, s.globalConfig, resourceID, "", resource); err != nil {
 		return err
 	}
-	return config.StoreConfigToFile(s.globalConfig, s.configPath)
+	if err := s.storeConfig(ctx); err != nil {
+		return err
+	}
+
+	// The change has already been stored, so failing to record it in the history only gets logged by addRevision
+	_ = s.addRevision(ctx, "", resourceID, resource)
+	return nil
 }
 
 // DeleteResource deletes the project from the local gloablConfig
 func (s *LocalStore) DeleteResource(ctx context.Context, resourceID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	if err := updateResource(ctx, config.ResourceDeleteEvent, s.globalConfig, resourceID, "", nil); err != nil {
 		return err
 	}
-	return config.StoreConfigToFile(s.globalConfig, s.configPath)
+	if err := s.storeConfig(ctx); err != nil {
+		return err
+	}
+
+	// The change has already been stored, so failing to record it in the history only gets logged by addRevision
+	_ = s.addRevision(ctx, config.ResourceDeleteEvent, resourceID, nil)
+	return nil
 }
 
 // DeleteProject deletes all the config resources which matches label projectId
 func (s *LocalStore) DeleteProject(ctx context.Context, projectID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	delete(s.globalConfig.Projects, projectID)
-	return config.StoreConfigToFile(s.globalConfig, s.configPath)
+	if err := s.storeConfig(ctx); err != nil {
+		return err
+	}
+
+	// Record the deletion of every resource of the project which still exists in the history. The project has
+	// already been deleted, so failing to do so only gets logged.
+	revisions, err := s.getProjectHistory(ctx, projectID)
+	if err != nil {
+		return nil
+	}
+	latest := map[string]*model.ConfigRevision{}
+	for _, revision := range revisions {
+		latest[revision.ResourceID] = revision
+	}
+	for resourceID, revision := range latest {
+		if revision.Event == config.ResourceDeleteEvent {
+			continue
+		}
+		_ = s.addRevision(ctx, config.ResourceDeleteEvent, resourceID, nil)
+	}
+	return nil
 }
 
-// GetGlobalConfig gets config all projects
+// GetGlobalConfig gets config all projects. A copy is returned since the config of the store must only change
+// once a change has been stored.
 func (s *LocalStore) GetGlobalConfig() (*config.Config, error) {
-	return s.globalConfig, nil
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return cloneConfig(s.globalConfig)
+}
+
+// GetResourceHistory returns the revisions of a resource, or of all the resources of the project, oldest first
+func (s *LocalStore) GetResourceHistory(ctx context.Context, projectID, resourceID string) ([]*model.ConfigRevision, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if resourceID != "" {
+		return s.getRevisions(ctx, resourceID)
+	}
+	return s.getProjectHistory(ctx, projectID)
+}
+
+func (s *LocalStore) getProjectHistory(ctx context.Context, projectID string) ([]*model.ConfigRevision, error) {
+	if s.historyPath == "" {
+		return []*model.ConfigRevision{}, nil
+	}
+
+	files, err := ioutil.ReadDir(s.historyPath)
+	if os.IsNotExist(err) {
+		return []*model.ConfigRevision{}, nil
+	} else if err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to read the history directory", err, nil)
+	}
+
+	revisions := []*model.ConfigRevision{}
+	for _, file := range files {
+		id, err := url.PathUnescape(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil || file.IsDir() {
+			continue
+		}
+		if _, p, _, err := splitResourceID(ctx, id); err != nil || p != projectID {
+			continue
+		}
+		arr, err := s.getRevisions(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, arr...)
+	}
+	sortRevisions(revisions)
+	return revisions, nil
+}
+
+func (s *LocalStore) getRevisions(ctx context.Context, resourceID string) ([]*model.ConfigRevision, error) {
+	if s.historyPath == "" {
+		return []*model.ConfigRevision{}, nil
+	}
+
+	data, err := ioutil.ReadFile(s.getHistoryFilePath(resourceID))
+	if os.IsNotExist(err) {
+		return []*model.ConfigRevision{}, nil
+	} else if err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to read the history of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+
+	revisions := []*model.ConfigRevision{}
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to unmarshal the history of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+	return revisions, nil
+}
+
+// addRevision adds a revision to the history of the resource. The event is derived from the history if it isn't provided.
+func (s *LocalStore) addRevision(ctx context.Context, eventType, resourceID string, resource interface{}) error {
+	if s.historyPath == "" {
+		return nil
+	}
+
+	revisions, err := s.getRevisions(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+	if eventType == "" {
+		eventType = getRevisionEvent(revisions)
+	}
+	revisions = addRevision(revisions, eventType, resourceID, resource)
+
+	data, err := json.Marshal(revisions)
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to marshal the history of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+	// The revisions hold the secrets of the resources
+	if err := os.MkdirAll(s.historyPath, 0700); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to create the history directory", err, nil)
+	}
+	path := s.getHistoryFilePath(resourceID)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to store the history of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+	// Files written by earlier versions were readable by everyone, writing a file doesn't change its permissions
+	if err := os.Chmod(path, 0600); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to restrict the permissions of the history of resource", err, map[string]interface{}{"resourceId": resourceID})
+	}
+	return nil
+}
+
+func (s *LocalStore) getHistoryFilePath(resourceID string) string {
+	return filepath.Join(s.historyPath, url.PathEscape(resourceID)+".json")
 }
@@ -0,0 +1,410 @@
+package syncman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ghodss/yaml"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+// localReloadDelay is the time to wait after the last change to the files before reloading them. Editors
+// tend to write a file in multiple steps.
+const localReloadDelay = 500 * time.Millisecond
+
+// localRetryDelay is the time to wait before reloading the files again when a change could not be applied
+const localRetryDelay = 10 * time.Second
+
+var specDocumentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// specResource describes how a spec object is converted to a resource. The resource id is generated from
+// the meta keys in idKeys, while fields holds the fields of the resource which are taken from the meta.
+type specResource struct {
+	resourceType config.Resource
+	idKeys       []string
+	fields       map[string]string
+}
+
+// specResources is keyed by the api of the spec objects as generated by space cli
+var specResources = map[string]specResource{
+	"/v1/config/projects/{project}":                                                    {config.ResourceProject, []string{"project"}, map[string]string{"id": "project"}},
+	"/v1/config/projects/{project}/database/{dbAlias}/config/{id}":                     {config.ResourceDatabaseConfig, []string{"dbAlias"}, map[string]string{"dbAlias": "dbAlias"}},
+	"/v1/config/projects/{project}/database/{dbAlias}/collections/{col}/rules":         {config.ResourceDatabaseRule, []string{"dbAlias", "col", "=rule"}, map[string]string{"dbAlias": "dbAlias", "col": "col"}},
+	"/v1/config/projects/{project}/database/{dbAlias}/collections/{col}/schema/mutate": {config.ResourceDatabaseSchema, []string{"dbAlias", "col"}, map[string]string{"dbAlias": "dbAlias", "col": "col"}},
+	"/v1/config/projects/{project}/database/{db}/prepared-queries/{id}":                {config.ResourceDatabasePreparedQuery, []string{"db", "id"}, map[string]string{"dbAlias": "db", "id": "id"}},
+	"/v1/config/projects/{project}/user-management/provider/{id}":                      {config.ResourceAuthProvider, []string{"id"}, map[string]string{"id": "id"}},
+	"/v1/config/projects/{project}/eventing/config/{id}":                               {config.ResourceEventingConfig, []string{"=eventing"}, nil},
+	"/v1/config/projects/{project}/eventing/triggers/{id}":                             {config.ResourceEventingTrigger, []string{"id"}, map[string]string{"id": "id"}},
+	"/v1/config/projects/{project}/eventing/schema/{id}":                               {config.ResourceEventingSchema, []string{"id"}, map[string]string{"id": "id"}},
+	"/v1/config/projects/{project}/eventing/rules/{id}":                                {config.ResourceEventingRule, []string{"id"}, map[string]string{"id": "id"}},
+	"/v1/config/projects/{project}/file-storage/config/{id}":                           {config.ResourceFileStoreConfig, []string{"=filestore"}, nil},
+	"/v1/config/projects/{project}/file-storage/rules/{id}":                            {config.ResourceFileStoreRule, []string{"id"}, map[string]string{"id": "id"}},
+	"/v1/config/projects/{project}/letsencrypt/config/{id}":                            {config.ResourceProjectLetsEncrypt, []string{"=letsencrypt"}, nil},
+	"/v1/config/projects/{project}/routing/ingress/global":                             {config.ResourceIngressGlobal, []string{"=global"}, nil},
+	"/v1/config/projects/{project}/routing/ingress/{id}":                               {config.ResourceIngressRoute, []string{"id"}, map[string]string{"id": "id", "project": "project"}},
+	"/v1/config/projects/{project}/remote-service/service/{id}":                        {config.ResourceRemoteService, []string{"id"}, map[string]string{"id": "id"}},
+	"/v1/config/projects/{project}/security/policies/{id}":                             {config.ResourceSecurityPolicy, []string{"id"}, map[string]string{"id": "id"}},
+}
+
+// WatchResources watches the config file and the spec objects for changes. The changes are validated and
+// passed on to the callback. Invalid edits are logged and ignored. Space cloud keeps running without hot
+// reload if the files cannot be watched.
+func (s *LocalStore) WatchResources(cb func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to create watcher for the config file, changes made to it will only be picked up on restart", err, nil)
+		return nil
+	}
+
+	// The directories are watched since editors usually replace the file instead of writing to it
+	paths := []string{filepath.Dir(s.configPath)}
+	if s.specsPath != "" {
+		paths = append(paths, s.specsPath)
+	}
+	for _, path := range paths {
+		if err := watcher.Add(path); err != nil {
+			_ = watcher.Close()
+			_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to watch the config files, changes made to them will only be picked up on restart", err, map[string]interface{}{"path": path})
+			return nil
+		}
+	}
+
+	go func() {
+		defer func() { _ = watcher.Close() }()
+
+		var reload <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod || !s.isWatchedFile(event.Name) {
+					continue
+				}
+				reload = time.After(localReloadDelay)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Error while watching the config file", err, nil)
+
+			case <-reload:
+				reload = nil
+				if retry := s.reload(context.Background(), cb); retry {
+					reload = time.After(localRetryDelay)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *LocalStore) isWatchedFile(path string) bool {
+	if filepath.Clean(path) == filepath.Clean(s.configPath) {
+		return true
+	}
+	return s.specsPath != "" && filepath.Dir(filepath.Clean(path)) == filepath.Clean(s.specsPath) && isSpecFile(path)
+}
+
+// reload reads the config file and the spec objects and passes the resources which changed to the callback. A change
+// is only taken as applied once the callback succeeds. It returns true if a change failed to apply and the files
+// need to be reloaded again.
+func (s *LocalStore) reload(ctx context.Context, cb func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error) bool {
+	changes, err := s.loadChanges(ctx)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Rejecting the changes made to the config files", err, map[string]interface{}{"configPath": s.configPath, "specsPath": s.specsPath})
+		return false
+	}
+
+	for _, change := range changes {
+		helpers.Logger.LogInfo(helpers.GetRequestID(ctx), "Applying change made to the config files", map[string]interface{}{"event": change.eventType, "resourceId": change.resourceID})
+		if err := cb(change.eventType, change.resourceID, change.resourceType, change.resource); err != nil {
+			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to apply change made to the config files, it will be retried", err, map[string]interface{}{"event": change.eventType, "resourceId": change.resourceID})
+			return true
+		}
+		s.commitChange(ctx, change)
+	}
+	return false
+}
+
+// loadChanges loads the config files and returns the resources which differ from the ones last read or written.
+// The changes are returned in the order they need to be applied. They are validated against a copy of the config
+// but none of them are committed to the store, that is up to the caller once a change has been applied.
+func (s *LocalStore) loadChanges(ctx context.Context) ([]*resourceChange, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	conf, err := loadLocalConfigFile(s.configPath)
+	if err != nil {
+		return nil, err
+	}
+	specResources, err := s.loadSpecResources(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	resources := getLocalResources(s.clusterID, conf)
+	if err := validateLocalResources(ctx, resources); err != nil {
+		return nil, err
+	}
+
+	changes := getResourceChanges(ctx, s.resources, resources)
+	scratch, err := cloneConfig(s.globalConfig)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if err := updateResource(ctx, change.eventType, scratch, change.resourceID, change.resourceType, change.resource); err != nil {
+			return nil, fmt.Errorf("invalid change of resource (%s) - %v", change.resourceID, err)
+		}
+	}
+	s.specResources = specResources
+	return changes, nil
+}
+
+// commitChange updates the config and the history of the store with a change made to the files which has been applied
+func (s *LocalStore) commitChange(ctx context.Context, change *resourceChange) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := updateResource(ctx, change.eventType, s.globalConfig, change.resourceID, change.resourceType, change.resource); err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to update the config of the store with change made to the config files", err, map[string]interface{}{"resourceId": change.resourceID})
+		return
+	}
+	if change.eventType == config.ResourceDeleteEvent {
+		delete(s.resources, change.resourceID)
+	} else {
+		s.resources[change.resourceID] = change.resource
+	}
+
+	// Failing to record the change in the history only gets logged by addRevision
+	_ = s.addRevision(ctx, change.eventType, change.resourceID, change.resource)
+}
+
+// validateLocalResources makes sure that the resources of a project are stored under it
+func validateLocalResources(ctx context.Context, resources map[string]interface{}) error {
+	for resourceID, resource := range resources {
+		_, projectID, resourceType, err := splitResourceID(ctx, resourceID)
+		if err != nil {
+			return err
+		}
+		if resourceType == config.ResourceProject {
+			if id, _ := resource.(map[string]interface{})["id"].(string); id != projectID {
+				return fmt.Errorf("id (%s) of project does not match the project (%s) it is stored under", id, projectID)
+			}
+		}
+	}
+	return nil
+}
+
+// loadSpecResources adds the resources of the spec objects to the config. It returns the ids of the resources added.
+func (s *LocalStore) loadSpecResources(ctx context.Context, conf *config.Config) (map[string]bool, error) {
+	ids := map[string]bool{}
+	if s.specsPath == "" {
+		return ids, nil
+	}
+
+	specs, err := loadSpecObjects(s.specsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	type resource struct {
+		id    string
+		value interface{}
+	}
+	byType := map[config.Resource][]*resource{}
+	for _, spec := range specs {
+		resourceID, resourceType, value, err := specToResource(s.clusterID, spec)
+		if err != nil {
+			return nil, err
+		}
+		if ids[resourceID] {
+			return nil, fmt.Errorf("resource (%s) is provided by multiple spec objects", resourceID)
+		}
+		ids[resourceID] = true
+		byType[resourceType] = append(byType[resourceType], &resource{resourceID, value})
+	}
+
+	// Projects need to be added before their resources
+	for _, resourceType := range config.ResourceFetchingOrder {
+		for _, r := range byType[resourceType] {
+			if err := updateResource(ctx, config.ResourceAddEvent, conf, r.id, resourceType, r.value); err != nil {
+				return nil, fmt.Errorf("invalid spec object for resource (%s) - %v", r.id, err)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// loadSpecObjects reads the spec objects of all the yaml files in the directory. A file can hold multiple
+// spec objects separated by ---.
+func loadSpecObjects(dir string) ([]*model.SpecObject, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]*model.SpecObject, 0)
+	for _, file := range files {
+		if file.IsDir() || !isSpecFile(file.Name()) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range specDocumentSeparator.Split(string(data), -1) {
+			if strings.TrimSpace(doc) == "" {
+				continue
+			}
+			spec := new(model.SpecObject)
+			if err := yaml.Unmarshal([]byte(doc), spec); err != nil {
+				return nil, fmt.Errorf("invalid spec object in file (%s) - %v", file.Name(), err)
+			}
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
+}
+
+func isSpecFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// specToResource converts a spec object to the resource it describes
+func specToResource(clusterID string, spec *model.SpecObject) (string, config.Resource, interface{}, error) {
+	r, ok := specResources[spec.API]
+	if !ok {
+		return "", "", nil, fmt.Errorf("spec object of type (%s) with api (%s) cannot be loaded from a file", spec.Type, spec.API)
+	}
+
+	project := spec.Meta["project"]
+	if project == "" {
+		return "", "", nil, fmt.Errorf("spec object of type (%s) does not have a project in its meta", spec.Type)
+	}
+
+	ids := make([]string, len(r.idKeys))
+	for i, key := range r.idKeys {
+		if strings.HasPrefix(key, "=") {
+			ids[i] = strings.TrimPrefix(key, "=")
+			continue
+		}
+		v := spec.Meta[key]
+		if v == "" {
+			return "", "", nil, fmt.Errorf("spec object of type (%s) does not have (%s) in its meta", spec.Type, key)
+		}
+		ids[i] = v
+	}
+
+	resource := map[string]interface{}{}
+	if spec.Spec != nil {
+		v, ok := spec.Spec.(map[string]interface{})
+		if !ok {
+			return "", "", nil, fmt.Errorf("spec of spec object of type (%s) must be an object", spec.Type)
+		}
+		for key, value := range v {
+			resource[key] = value
+		}
+	}
+	for field, key := range r.fields {
+		resource[field] = spec.Meta[key]
+	}
+
+	return config.GenerateResourceID(clusterID, project, r.resourceType, ids...), r.resourceType, resource, nil
+}
+
+// storeConfig writes the config to the file without the resources of the spec objects. It also takes a
+// copy of the resources so that the write doesn't get mistaken for an edit.
+func (s *LocalStore) storeConfig(ctx context.Context) error {
+	s.resources = getLocalResources(s.clusterID, s.globalConfig)
+	if len(s.specResources) == 0 {
+		return config.StoreConfigToFile(s.globalConfig, s.configPath)
+	}
+
+	conf, err := cloneConfig(s.globalConfig)
+	if err != nil {
+		return err
+	}
+	for resourceID := range s.specResources {
+		removeConfigResource(ctx, conf, resourceID)
+	}
+	return config.StoreConfigToFile(conf, s.configPath)
+}
+
+// cloneConfig returns a deep copy of the config
+func cloneConfig(c *config.Config) (*config.Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	conf := new(config.Config)
+	if err := json.Unmarshal(data, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// removeConfigResource removes a resource from the config. Unlike a delete event, the resources a project
+// has only one of are removed as well.
+func removeConfigResource(ctx context.Context, conf *config.Config, resourceID string) {
+	_, projectID, resourceType, err := splitResourceID(ctx, resourceID)
+	if err != nil {
+		return
+	}
+	project, ok := conf.Projects[projectID]
+	if !ok {
+		return
+	}
+
+	switch resourceType {
+	case config.ResourceEventingConfig:
+		project.EventingConfig = nil
+	case config.ResourceFileStoreConfig:
+		project.FileStoreConfig = nil
+	case config.ResourceProjectLetsEncrypt:
+		project.LetsEncrypt = nil
+	case config.ResourceIngressGlobal:
+		project.IngressGlobal = nil
+	default:
+		_ = updateResource(ctx, config.ResourceDeleteEvent, conf, resourceID, resourceType, nil)
+	}
+}
+
+// loadLocalConfigFile loads the config file
+func loadLocalConfigFile(path string) (*config.Config, error) {
+	conf, err := config.LoadConfigFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// For compatibility with v18
+	if conf.ClusterConfig == nil {
+		conf.ClusterConfig = &config.ClusterConfig{EnableTelemetry: true}
+	}
+	if conf.Projects == nil {
+		conf.Projects = config.Projects{}
+	}
+	return conf, nil
+}
+
+// getLocalResources returns a copy of all the resources of the config in their json representation
+func getLocalResources(clusterID string, conf *config.Config) map[string]interface{} {
+	resources := map[string]interface{}{}
+	for resourceID, resource := range getConfigResources(clusterID, conf) {
+		resources[resourceID] = toAuditValue(resource)
+	}
+	return resources
+}
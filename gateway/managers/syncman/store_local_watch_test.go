@@ -0,0 +1,262 @@
+package syncman
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+func newWatchTestStore(t *testing.T, specsPath string) *LocalStore {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeTestFile(t, configPath, watchTestConfig)
+	conf, err := loadLocalConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("loadLocalConfigFile() error = %v", err)
+	}
+
+	s := &LocalStore{clusterID: "chicago", configPath: configPath, globalConfig: conf, specsPath: specsPath}
+	if s.specResources, err = s.loadSpecResources(context.Background(), conf); err != nil {
+		t.Fatalf("loadSpecResources() error = %v", err)
+	}
+	s.resources = getLocalResources("chicago", conf)
+	return s
+}
+
+func writeTestFile(t *testing.T, path, data string) {
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+const watchTestConfig = `projects:
+  myproject:
+    projectConfig:
+      id: myproject
+clusterConfig:
+  enableTelemetry: true
+`
+
+func TestLocalStore_loadChanges(t *testing.T) {
+	ctx := context.Background()
+	s := newWatchTestStore(t, "")
+	policyID := config.GenerateResourceID("chicago", "myproject", config.ResourceSecurityPolicy, "is-admin")
+
+	// A write made through the store isn't reported as a change
+	if err := s.SetResource(ctx, policyID, &config.SecurityPolicy{ID: "is-admin", Module: "package v1"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+	if changes, err := s.loadChanges(ctx); err != nil || len(changes) != 0 {
+		t.Fatalf("loadChanges() = %v, %v, want no changes", changes, err)
+	}
+
+	// An invalid edit is rejected and the resources stay as they were
+	writeTestFile(t, s.configPath, "projects: [")
+	if _, err := s.loadChanges(ctx); err == nil {
+		t.Errorf("loadChanges() expected error for invalid config file")
+	}
+	writeTestFile(t, s.configPath, "projects:\n  myproject:\n    projectConfig:\n      id: otherproject\n")
+	if _, err := s.loadChanges(ctx); err == nil {
+		t.Errorf("loadChanges() expected error for project stored under another id")
+	}
+
+	// Removing the policy from the file deletes it
+	writeTestFile(t, s.configPath, watchTestConfig)
+	changes, err := s.loadChanges(ctx)
+	if err != nil {
+		t.Fatalf("loadChanges() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].eventType != config.ResourceDeleteEvent || changes[0].resourceID != policyID {
+		t.Errorf("loadChanges() = %v, want a deletion of the policy", changes)
+	}
+}
+
+func TestLocalStore_reload(t *testing.T) {
+	ctx := context.Background()
+	s := newWatchTestStore(t, "")
+	policyID := config.GenerateResourceID("chicago", "myproject", config.ResourceSecurityPolicy, "is-admin")
+	writeTestFile(t, s.configPath, `projects:
+  myproject:
+    projectConfig:
+      id: myproject
+    securityPolicies:
+      chicago--myproject--security-policy--is-admin:
+        id: is-admin
+        module: package v1
+clusterConfig:
+  enableTelemetry: true
+`)
+
+	// A change which fails to apply isn't committed, so it gets passed on again
+	failing := func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error {
+		return errors.New("unable to apply")
+	}
+	if retry := s.reload(ctx, failing); !retry {
+		t.Errorf("reload() = false, want a retry when the change fails to apply")
+	}
+	if _, p := s.globalConfig.Projects["myproject"].SecurityPolicies[policyID]; p {
+		t.Errorf("reload() committed the policy which failed to apply")
+	}
+
+	applied := []string{}
+	succeeding := func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error {
+		applied = append(applied, resourceID)
+		return nil
+	}
+	if retry := s.reload(ctx, succeeding); retry || len(applied) != 1 || applied[0] != policyID {
+		t.Fatalf("reload() = %v applying %v, want the policy to be applied", retry, applied)
+	}
+	if policy := s.globalConfig.Projects["myproject"].SecurityPolicies[policyID]; policy == nil || policy.Module != "package v1" {
+		t.Errorf("reload() policy of the store = %v, want it to be committed", policy)
+	}
+	if changes, err := s.loadChanges(ctx); err != nil || len(changes) != 0 {
+		t.Errorf("loadChanges() = %v, %v, want no changes once applied", changes, err)
+	}
+
+	// The config handed out is a copy of the one held by the store
+	conf, err := s.GetGlobalConfig()
+	if err != nil {
+		t.Fatalf("GetGlobalConfig() error = %v", err)
+	}
+	delete(conf.Projects["myproject"].SecurityPolicies, policyID)
+	if _, p := s.globalConfig.Projects["myproject"].SecurityPolicies[policyID]; !p {
+		t.Errorf("GetGlobalConfig() returned the config held by the store")
+	}
+}
+
+func TestLocalStore_specObjects(t *testing.T) {
+	ctx := context.Background()
+	specsPath := t.TempDir()
+	writeTestFile(t, filepath.Join(specsPath, "rules.yaml"), `api: /v1/config/projects/{project}/database/{dbAlias}/collections/{col}/rules
+type: db-rules
+meta:
+  project: myproject
+  dbAlias: mydb
+  col: users
+spec:
+  rules:
+    read:
+      rule: allow
+---
+api: /v1/config/projects/{project}/eventing/config/{id}
+type: eventing-config
+meta:
+  project: myproject
+  id: eventing-config
+spec:
+  enabled: true
+  dbAlias: mydb
+`)
+	s := newWatchTestStore(t, specsPath)
+	ruleID := config.GenerateResourceID("chicago", "myproject", config.ResourceDatabaseRule, "mydb", "users", "rule")
+	if rule := s.globalConfig.Projects["myproject"].DatabaseRules[ruleID]; rule == nil || rule.Table != "users" || rule.DbAlias != "mydb" {
+		t.Fatalf("LocalStore database rule = %v, want the rule of the spec object", rule)
+	}
+
+	// The resources of the spec objects are kept out of the config file
+	policyID := config.GenerateResourceID("chicago", "myproject", config.ResourceSecurityPolicy, "is-admin")
+	if err := s.SetResource(ctx, policyID, &config.SecurityPolicy{ID: "is-admin", Module: "package v1"}); err != nil {
+		t.Fatalf("SetResource() error = %v", err)
+	}
+	conf, _ := loadLocalConfigFile(s.configPath)
+	if p := conf.Projects["myproject"]; len(p.DatabaseRules) != 0 || p.EventingConfig != nil || len(p.SecurityPolicies) != 1 {
+		t.Errorf("LocalStore stored the resources of the spec objects in the config file")
+	}
+
+	// Removing a spec object deletes its resource
+	writeTestFile(t, filepath.Join(specsPath, "rules.yaml"), "")
+	changes, err := s.loadChanges(ctx)
+	if err != nil {
+		t.Fatalf("loadChanges() error = %v", err)
+	}
+	if len(changes) != 2 || changes[0].resourceType != config.ResourceEventingConfig || changes[1].resourceID != ruleID {
+		t.Errorf("loadChanges() = %v, want deletions of the eventing config and the rule", changes)
+	}
+}
+
+func Test_specToResource(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       *model.SpecObject
+		wantID     string
+		wantResult interface{}
+		wantErr    bool
+	}{
+		{
+			name:       "prepared query",
+			spec:       &model.SpecObject{API: "/v1/config/projects/{project}/database/{db}/prepared-queries/{id}", Meta: map[string]string{"project": "myproject", "db": "mydb", "id": "getUsers"}, Spec: map[string]interface{}{"sql": "select 1"}},
+			wantID:     "chicago--myproject--db-prepared-query--mydb-getUsers",
+			wantResult: map[string]interface{}{"sql": "select 1", "dbAlias": "mydb", "id": "getUsers"},
+		},
+		{
+			name:       "ingress global config",
+			spec:       &model.SpecObject{API: "/v1/config/projects/{project}/routing/ingress/global", Meta: map[string]string{"project": "myproject"}},
+			wantID:     "chicago--myproject--ingress-global--global",
+			wantResult: map[string]interface{}{},
+		},
+		{
+			name:    "api keys aren't supported",
+			spec:    &model.SpecObject{API: "/v1/config/projects/{project}/security/api-keys/{id}", Meta: map[string]string{"project": "myproject", "id": "backend"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing meta",
+			spec:    &model.SpecObject{API: "/v1/config/projects/{project}/eventing/rules/{id}", Meta: map[string]string{"project": "myproject"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, _, got, err := specToResource("chicago", tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("specToResource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if id != tt.wantID || (!tt.wantErr && !reflect.DeepEqual(got, tt.wantResult)) {
+				t.Errorf("specToResource() = %v, %v, want %v, %v", id, got, tt.wantID, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestLocalStore_WatchResources(t *testing.T) {
+	s := newWatchTestStore(t, "")
+	type event struct {
+		eventType  string
+		resourceID string
+	}
+	events := make(chan event, 10)
+	if err := s.WatchResources(func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error {
+		events <- event{eventType, resourceID}
+		return nil
+	}); err != nil {
+		t.Fatalf("WatchResources() error = %v", err)
+	}
+
+	writeTestFile(t, s.configPath, `projects:
+  myproject:
+    projectConfig:
+      id: myproject
+    securityPolicies:
+      chicago--myproject--security-policy--is-admin:
+        id: is-admin
+        module: package v1
+clusterConfig:
+  enableTelemetry: true
+`)
+	want := event{config.ResourceAddEvent, config.GenerateResourceID("chicago", "myproject", config.ResourceSecurityPolicy, "is-admin")}
+	select {
+	case got := <-events:
+		if got != want {
+			t.Errorf("WatchResources() event = %v, want %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("WatchResources() did not report the change made to the config file")
+	}
+}
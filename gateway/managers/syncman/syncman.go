@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -34,6 +35,7 @@ type Manager struct {
 	// Configuration for clustering
 	storeType string
 	store     Store
+	audit     *auditStore
 	services  model.ScServices
 
 	// For authentication
@@ -43,6 +45,9 @@ type Manager struct {
 	// Modules
 	modules       ModulesInterface
 	globalModules GlobalModulesInterface
+
+	// Closed to stop the rotation of signing keys
+	stopKeyRotation chan struct{}
 }
 
 // New creates a new instance of the sync manager
@@ -56,9 +61,11 @@ func New(nodeID, clusterID, storeType, runnerAddr string, adminMan AdminSyncmanI
 	var err error
 	switch storeType {
 	case "local":
-		s, err = NewLocalStore(nodeID, ssl)
+		s, err = NewLocalStore(nodeID, clusterID, ssl)
 	case "kube":
 		s, err = NewKubeStore(clusterID)
+	case "git":
+		s, err = NewGitStore(clusterID)
 	default:
 		return nil, helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), fmt.Sprintf("Cannot initialize syncaman as invalid store type (%v) provided", storeType), nil, nil)
 	}
@@ -66,7 +73,18 @@ func New(nodeID, clusterID, storeType, runnerAddr string, adminMan AdminSyncmanI
 	if err != nil {
 		return nil, err
 	}
-	m.store = s
+
+	// Record all the changes made through the store. The trail is stored in files in local mode unless a database is configured.
+	var file *fileAuditSink
+	if local, ok := s.(*LocalStore); ok {
+		auditPath := os.Getenv("AUDIT_PATH")
+		if auditPath == "" {
+			auditPath = filepath.Join(filepath.Dir(local.configPath), "audit")
+		}
+		file = newFileAuditSink(auditPath)
+	}
+	m.audit = newAuditStore(clusterID, s, file)
+	m.store = m.audit
 	m.store.Register()
 
 	pubsubClient, err := pubsub.New("license-manager", os.Getenv("REDIS_CONN"))
@@ -141,6 +159,9 @@ func (s *Manager) Start(port int) error {
 		s.modules.LetsEncrypt().SetLetsEncryptEmail(globalConfig.ClusterConfig.LetsEncryptEmail)
 	}
 
+	// Set compression config
+	s.modules.Compression().SetConfig(globalConfig.ClusterConfig.Compression)
+
 	s.projectConfig = globalConfig
 
 	// Set initial project config
@@ -148,118 +169,149 @@ func (s *Manager) Start(port int) error {
 		return err
 	}
 
+	// Start routine to rotate the signing keys of projects
+	s.stopKeyRotation = make(chan struct{})
+	go s.routineRotateSigningKeys(s.stopKeyRotation)
+
 	// Start routine to observe space cloud project level resources
-	if err := s.store.WatchResources(func(eventType, resourceID string, resourceType config.Resource, resource interface{}) {
+	if err := s.store.WatchResources(func(eventType, resourceID string, resourceType config.Resource, resource interface{}) error {
 		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 		defer cancel()
 		isSkip, err := s.validateResource(ctx, eventType, resourceID, resourceType, resource)
 		if err != nil {
-			_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to update resources", err, nil)
-			return
+			return helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to update resources", err, nil)
 		}
 		if isSkip {
 			helpers.Logger.LogInfo(helpers.GetRequestID(ctx), "Found duplicate resource, skipping the resource", map[string]interface{}{"event": eventType, "resourceId": resourceID, "resource": resource, "resourceType": resourceType})
-			return
+			return nil
 		}
 
 		_, projectID, _, err := splitResourceID(ctx, resourceID)
 		if err != nil {
-			_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to split resource id in watch resources", err, nil)
-			return
+			return helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to split resource id in watch resources", err, nil)
 		}
 
 		helpers.Logger.LogDebug(helpers.GetRequestID(context.TODO()), "Updating resources", map[string]interface{}{"event": eventType, "resourceId": resourceID, "resource": resource, "projectId": projectID, "resourceType": resourceType})
 
 		s.lock.Lock()
 		defer s.lock.Unlock()
-		if err := updateResource(ctx, eventType, s.projectConfig, resourceID, resourceType, resource); err != nil {
-			_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to update resources", err, nil)
-			return
+		return s.applyResourceWithoutLock(ctx, eventType, resourceID, resourceType, resource)
+	}); err != nil {
+		return err
+	}
+
+	helpers.Logger.LogDebug(helpers.GetRequestID(context.TODO()), "Exiting syncman start", nil)
+	return nil
+}
+
+// Close stops the background routines of the sync manager
+func (s *Manager) Close() {
+	if s.stopKeyRotation != nil {
+		close(s.stopKeyRotation)
+		s.stopKeyRotation = nil
+	}
+}
+
+// applyResourceWithoutLock updates the resource in the config held by the sync manager and applies the
+// config of the modules using it. The lock must be held by the caller.
+func (s *Manager) applyResourceWithoutLock(ctx context.Context, eventType, resourceID string, resourceType config.Resource, resource interface{}) error {
+	_, projectID, rt, err := splitResourceID(ctx, resourceID)
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to split resource id", err, nil)
+	}
+	if resourceType == "" {
+		resourceType = rt
+	}
+
+	if err := updateResource(ctx, eventType, s.projectConfig, resourceID, resourceType, resource); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to update resources", err, nil)
+	}
+
+	switch resourceType {
+	case config.ResourceProject:
+		if eventType == config.ResourceDeleteEvent {
+			s.modules.Delete(projectID)
+			return nil
 		}
+		err = s.modules.SetProjectConfig(ctx, s.projectConfig.Projects[projectID].ProjectConfig)
 
-		switch resourceType {
-		case config.ResourceProject:
-			if eventType == config.ResourceDeleteEvent {
-				s.modules.Delete(projectID)
-				return
-			}
-			_ = s.modules.SetProjectConfig(ctx, s.projectConfig.Projects[projectID].ProjectConfig)
+	case config.ResourceAuthProvider:
+		err = s.modules.SetUsermanConfig(ctx, projectID, s.projectConfig.Projects[projectID].Auths)
 
-		case config.ResourceAuthProvider:
-			_ = s.modules.SetUsermanConfig(ctx, projectID, s.projectConfig.Projects[projectID].Auths)
+	case config.ResourceDatabaseConfig:
+		p := s.projectConfig.Projects[projectID]
+		err = s.modules.SetDatabaseConfig(ctx, projectID, p.DatabaseConfigs, p.DatabaseSchemas, p.DatabaseRules, p.DatabasePreparedQueries)
 
-		case config.ResourceDatabaseConfig:
-			p := s.projectConfig.Projects[projectID]
-			_ = s.modules.SetDatabaseConfig(ctx, projectID, p.DatabaseConfigs, p.DatabaseSchemas, p.DatabaseRules, p.DatabasePreparedQueries)
+	case config.ResourceDatabaseSchema:
+		err = s.modules.SetDatabaseSchemaConfig(ctx, projectID, s.projectConfig.Projects[projectID].DatabaseSchemas)
 
-		case config.ResourceDatabaseSchema:
-			_ = s.modules.SetDatabaseSchemaConfig(ctx, projectID, s.projectConfig.Projects[projectID].DatabaseSchemas)
+	case config.ResourceDatabaseRule:
+		err = s.modules.SetDatabaseRulesConfig(ctx, projectID, s.projectConfig.Projects[projectID].DatabaseRules)
 
-		case config.ResourceDatabaseRule:
-			_ = s.modules.SetDatabaseRulesConfig(ctx, projectID, s.projectConfig.Projects[projectID].DatabaseRules)
+	case config.ResourceDatabasePreparedQuery:
+		err = s.modules.SetDatabasePreparedQueryConfig(ctx, projectID, s.projectConfig.Projects[projectID].DatabasePreparedQueries)
 
-		case config.ResourceDatabasePreparedQuery:
-			_ = s.modules.SetDatabasePreparedQueryConfig(ctx, projectID, s.projectConfig.Projects[projectID].DatabasePreparedQueries)
+	case config.ResourceEventingConfig:
+		p := s.projectConfig.Projects[projectID]
+		err = s.modules.SetEventingConfig(ctx, projectID, p.EventingConfig, p.EventingRules, p.EventingSchemas, p.EventingTriggers)
 
-		case config.ResourceEventingConfig:
-			p := s.projectConfig.Projects[projectID]
-			_ = s.modules.SetEventingConfig(ctx, projectID, p.EventingConfig, p.EventingRules, p.EventingSchemas, p.EventingTriggers)
+	case config.ResourceEventingSchema:
+		err = s.modules.SetEventingSchemaConfig(ctx, projectID, s.projectConfig.Projects[projectID].EventingSchemas)
 
-		case config.ResourceEventingSchema:
-			_ = s.modules.SetEventingSchemaConfig(ctx, projectID, s.projectConfig.Projects[projectID].EventingSchemas)
+	case config.ResourceEventingRule:
+		err = s.modules.SetEventingRuleConfig(ctx, projectID, s.projectConfig.Projects[projectID].EventingRules)
 
-		case config.ResourceEventingRule:
-			_ = s.modules.SetEventingRuleConfig(ctx, projectID, s.projectConfig.Projects[projectID].EventingRules)
+	case config.ResourceSecurityPolicy:
+		err = s.modules.SetSecurityPolicyConfig(ctx, projectID, s.projectConfig.Projects[projectID].SecurityPolicies)
 
-		case config.ResourceEventingTrigger:
-			_ = s.modules.SetEventingTriggerConfig(ctx, projectID, s.projectConfig.Projects[projectID].EventingTriggers)
+	case config.ResourceAPIKey:
+		err = s.modules.SetAPIKeyConfig(ctx, projectID, s.projectConfig.Projects[projectID].APIKeys)
 
-		case config.ResourceFileStoreConfig:
-			_ = s.modules.SetFileStoreConfig(ctx, projectID, s.projectConfig.Projects[projectID].FileStoreConfig)
+	case config.ResourceEventingTrigger:
+		err = s.modules.SetEventingTriggerConfig(ctx, projectID, s.projectConfig.Projects[projectID].EventingTriggers)
 
-		case config.ResourceFileStoreRule:
-			_ = s.modules.SetFileStoreSecurityRuleConfig(ctx, projectID, s.projectConfig.Projects[projectID].FileStoreRules)
+	case config.ResourceFileStoreConfig:
+		err = s.modules.SetFileStoreConfig(ctx, projectID, s.projectConfig.Projects[projectID].FileStoreConfig)
 
-		case config.ResourceProjectLetsEncrypt:
-			_ = s.modules.SetLetsencryptConfig(ctx, projectID, s.projectConfig.Projects[projectID].LetsEncrypt)
+	case config.ResourceFileStoreRule:
+		err = s.modules.SetFileStoreSecurityRuleConfig(ctx, projectID, s.projectConfig.Projects[projectID].FileStoreRules)
 
-		case config.ResourceIngressRoute:
-			_ = s.modules.SetIngressRouteConfig(ctx, projectID, s.projectConfig.Projects[projectID].IngressRoutes)
+	case config.ResourceProjectLetsEncrypt:
+		err = s.modules.SetLetsencryptConfig(ctx, projectID, s.projectConfig.Projects[projectID].LetsEncrypt)
 
-		case config.ResourceIngressGlobal:
-			_ = s.modules.SetIngressGlobalRouteConfig(ctx, projectID, s.projectConfig.Projects[projectID].IngressGlobal)
+	case config.ResourceIngressRoute:
+		err = s.modules.SetIngressRouteConfig(ctx, projectID, s.projectConfig.Projects[projectID].IngressRoutes)
 
-		case config.ResourceRemoteService:
-			_ = s.modules.SetRemoteServiceConfig(ctx, projectID, s.projectConfig.Projects[projectID].RemoteService)
+	case config.ResourceIngressGlobal:
+		err = s.modules.SetIngressGlobalRouteConfig(ctx, projectID, s.projectConfig.Projects[projectID].IngressGlobal)
 
-		case config.ResourceCluster:
-			s.globalModules.SetMetricsConfig(s.projectConfig.ClusterConfig.EnableTelemetry)
-			s.modules.LetsEncrypt().SetLetsEncryptEmail(s.projectConfig.ClusterConfig.LetsEncryptEmail)
+	case config.ResourceRemoteService:
+		err = s.modules.SetRemoteServiceConfig(ctx, projectID, s.projectConfig.Projects[projectID].RemoteService)
 
-		case config.ResourceIntegration:
-			if err := s.integrationMan.SetIntegrations(s.projectConfig.Integrations); err != nil {
-				_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to apply integration config", err, nil)
-				return
-			}
-			s.adminMan.SetIntegrationConfig(s.projectConfig.Integrations)
+	case config.ResourceCluster:
+		s.globalModules.SetMetricsConfig(s.projectConfig.ClusterConfig.EnableTelemetry)
+		s.modules.LetsEncrypt().SetLetsEncryptEmail(s.projectConfig.ClusterConfig.LetsEncryptEmail)
+		s.modules.Compression().SetConfig(s.projectConfig.ClusterConfig.Compression)
+
+	case config.ResourceIntegration:
+		if err := s.integrationMan.SetIntegrations(s.projectConfig.Integrations); err != nil {
+			return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to apply integration config", err, nil)
+		}
+		s.adminMan.SetIntegrationConfig(s.projectConfig.Integrations)
 
-		case config.ResourceIntegrationHook:
-			s.integrationMan.SetIntegrationHooks(s.projectConfig.IntegrationHooks)
+	case config.ResourceIntegrationHook:
+		s.integrationMan.SetIntegrationHooks(s.projectConfig.IntegrationHooks)
 
-		case config.ResourceCacheConfig:
-			if err := s.modules.Caching().SetCachingConfig(ctx, s.projectConfig.CacheConfig); err != nil {
-				_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to apply admin config provided by other space cloud service", err, map[string]interface{}{})
-				return
-			}
-		default:
-			_ = helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unknown resource type provided", err, map[string]interface{}{"resourceType": resourceType})
-			return
+	case config.ResourceCacheConfig:
+		if err := s.modules.Caching().SetCachingConfig(ctx, s.projectConfig.CacheConfig); err != nil {
+			return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to apply admin config provided by other space cloud service", err, map[string]interface{}{})
 		}
-	}); err != nil {
-		return err
+	default:
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unknown resource type provided", nil, map[string]interface{}{"resourceType": resourceType})
+	}
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to apply the config of resource (%s) to the modules", resourceID), err, nil)
 	}
-
-	helpers.Logger.LogDebug(helpers.GetRequestID(context.TODO()), "Exiting syncman start", nil)
 	return nil
 }
 
@@ -280,6 +332,9 @@ func (s *Manager) GetGlobalConfig() *config.Config {
 // SetModules sets all the modules
 func (s *Manager) SetModules(modulesInterface ModulesInterface) {
 	s.modules = modulesInterface
+	if s.audit != nil {
+		s.audit.getCrud = modulesInterface.GetCrudModuleForSyncMan
+	}
 }
 
 // SetGlobalModules sets all the modules
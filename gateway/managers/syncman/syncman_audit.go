@@ -0,0 +1,54 @@
+package syncman
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+// GetConfigAudit returns the entries of the audit trail of config changes matching the query, latest first
+func (s *Manager) GetConfigAudit(ctx context.Context, q *model.ConfigAuditQuery, params model.RequestParams) (int, []interface{}, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), nil, err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), hookResponse.Result().([]interface{}), nil
+	}
+
+	if s.audit == nil {
+		return http.StatusNotFound, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Audit trail is not available", nil, nil)
+	}
+
+	// Convert the time range to the format of the trail
+	for _, ptr := range []*string{&q.Since, &q.Until} {
+		if *ptr == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, *ptr)
+		if err != nil {
+			return http.StatusBadRequest, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Invalid timestamp (%s) provided, expecting RFC3339", *ptr), err, nil)
+		}
+		*ptr = t.UTC().Format(auditTimeFormat)
+	}
+
+	entries, err := s.audit.query(ctx, q)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	result := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		result[i] = entry
+	}
+	return http.StatusOK, result, nil
+}
@@ -36,6 +36,9 @@ func (s *Manager) SetUserManagement(ctx context.Context, project, provider strin
 	}
 
 	resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceAuthProvider, provider)
+	if err := restoreRedacted(ctx, value, projectConfig.Auths[resourceID]); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	if projectConfig.Auths == nil {
 		projectConfig.Auths = config.Auths{resourceID: value}
 	} else {
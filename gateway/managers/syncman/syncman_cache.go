@@ -29,6 +29,10 @@ func (s *Manager) SetCacheConfig(ctx context.Context, cacheConfig *config.CacheC
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if err := restoreRedacted(ctx, cacheConfig, s.projectConfig.CacheConfig); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
 	if cacheConfig.DefaultTTL == 0 {
 		cacheConfig.DefaultTTL = utils.DefaultCacheTTLTimeout
 	}
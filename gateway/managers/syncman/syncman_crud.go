@@ -97,6 +97,9 @@ func (s *Manager) SetDatabaseConnection(ctx context.Context, project, dbAlias st
 
 	v.DbAlias = dbAlias
 	resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceDatabaseConfig, dbAlias)
+	if err := restoreRedacted(ctx, v, projectConfig.DatabaseConfigs[resourceID]); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	if projectConfig.DatabaseConfigs == nil {
 		projectConfig.DatabaseConfigs = config.DatabaseConfigs{resourceID: v}
 	} else {
@@ -871,6 +874,13 @@ func (s *Manager) GetSecrets(project, secretName, key string) (string, error) {
 	if err := s.MakeHTTPRequest(ctx, "GET", url, token, "", map[string]interface{}{}, &vPtr); err != nil {
 		return "", err
 	}
+	if len(vPtr.Result) == 0 {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Secret (%s) not found in runner", secretName), nil, map[string]interface{}{"project": project})
+	}
 
-	return vPtr.Result[0].Data[key], nil
+	value, ok := vPtr.Result[0].Data[key]
+	if !ok {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Key (%s) not found in secret (%s)", key, secretName), nil, map[string]interface{}{"project": project})
+	}
+	return value, nil
 }
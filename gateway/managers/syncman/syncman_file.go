@@ -34,6 +34,9 @@ func (s *Manager) SetFileStore(ctx context.Context, project string, value *confi
 		return http.StatusBadRequest, err
 	}
 
+	if err := restoreRedacted(ctx, value, projectConfig.FileStoreConfig); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	projectConfig.FileStoreConfig = value
 
 	if err := s.modules.SetFileStoreConfig(ctx, project, projectConfig.FileStoreConfig); err != nil {
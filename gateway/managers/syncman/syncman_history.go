@@ -0,0 +1,292 @@
+package syncman
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+// ResourcePermission checks if the caller has been granted a permission on the resources of the type provided
+type ResourcePermission func(resourceType config.Resource) error
+
+// GetConfigHistory returns the revisions of a resource of the project, oldest first. The revisions of all the
+// resources of the project are returned if a resource id isn't provided. Only the revisions of the resources the
+// caller is permitted to read are returned.
+func (s *Manager) GetConfigHistory(ctx context.Context, project, resourceID string, isPermitted ResourcePermission, params model.RequestParams) (int, []interface{}, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), nil, err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), hookResponse.Result().([]interface{}), nil
+	}
+
+	if err := validateHistoryResourceID(ctx, project, resourceID); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if resourceID != "" {
+		if err := checkResourcePermission(ctx, resourceID, isPermitted, map[config.Resource]error{}); err != nil {
+			return http.StatusUnauthorized, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to get config history", err, nil)
+		}
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	revisions, err := s.store.GetResourceHistory(ctx, project, resourceID)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	// The revisions are stored along with their secrets so that they can be rolled back to, but they are never returned
+	result := make([]interface{}, 0, len(revisions))
+	checked := map[config.Resource]error{}
+	for _, revision := range revisions {
+		// Revisions of the resources the caller cannot read are left out
+		if err := checkResourcePermission(ctx, revision.ResourceID, isPermitted, checked); err != nil {
+			continue
+		}
+		redacted := *revision
+		redacted.Resource = config.Redact("", revision.Resource)
+		result = append(result, &redacted)
+	}
+	return http.StatusOK, result, nil
+}
+
+// RollbackResource sets a resource of the project to the value it had in a revision. The resource gets deleted if the revision deleted it.
+// The caller needs to be permitted to modify resources of its type.
+func (s *Manager) RollbackResource(ctx context.Context, project, resourceID string, revision int, isPermitted ResourcePermission, params model.RequestParams) (int, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), nil
+	}
+
+	if err := validateHistoryResourceID(ctx, project, resourceID); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if resourceID == "" {
+		return http.StatusBadRequest, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Resource id must be provided to rollback a resource", nil, nil)
+	}
+	if err := checkResourcePermission(ctx, resourceID, isPermitted, map[config.Resource]error{}); err != nil {
+		return http.StatusUnauthorized, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to rollback resource", err, nil)
+	}
+
+	// Acquire a lock
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	revisions, err := s.store.GetResourceHistory(ctx, project, resourceID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	var target *model.ConfigRevision
+	for _, r := range revisions {
+		if r.Revision == revision {
+			target = r
+			break
+		}
+	}
+	if target == nil {
+		return http.StatusNotFound, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Revision (%d) of resource (%s) does not exist", revision, resourceID), nil, nil)
+	}
+
+	current, exists := getConfigResources(s.clusterID, s.projectConfig)[resourceID]
+	if target.Event == config.ResourceDeleteEvent {
+		if _, _, resourceType, _ := splitResourceID(ctx, resourceID); resourceType == config.ResourceProject {
+			return http.StatusBadRequest, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Project cannot be deleted by a rollback, use the delete project endpoint instead", nil, nil)
+		}
+		if !exists {
+			return http.StatusOK, nil
+		}
+		return s.rollbackResourceWithoutLock(ctx, resourceID, nil)
+	}
+
+	if exists && reflect.DeepEqual(toAuditValue(current), target.Resource) {
+		return http.StatusOK, nil
+	}
+	return s.rollbackResourceWithoutLock(ctx, resourceID, target.Resource)
+}
+
+// RollbackProject sets all the resources of the project to the state they were in at the provided time. Resources
+// without any history are left as is since their state at that time isn't known. The same goes for resources whose
+// history starts with an update made after that time. The caller needs to be permitted to modify every type of
+// resource which gets changed, otherwise nothing is rolled back.
+func (s *Manager) RollbackProject(ctx context.Context, project string, t time.Time, isPermitted ResourcePermission, params model.RequestParams) (int, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), nil
+	}
+
+	// Acquire a lock
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	revisions, err := s.store.GetResourceHistory(ctx, project, "")
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	// Find the latest revision of every resource made till the provided time. Resources which were only added
+	// after that time are marked for deletion.
+	targets := map[string]*model.ConfigRevision{}
+	seen := map[string]bool{}
+	for _, revision := range revisions {
+		isFirst := !seen[revision.ResourceID]
+		seen[revision.ResourceID] = true
+		if !revisionTime(revision).After(t) {
+			targets[revision.ResourceID] = revision
+			continue
+		}
+		if isFirst && revision.Event == config.ResourceAddEvent {
+			targets[revision.ResourceID] = &model.ConfigRevision{ResourceID: revision.ResourceID, Event: config.ResourceDeleteEvent}
+		}
+	}
+
+	projectResourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceProject, project)
+	if target, p := targets[projectResourceID]; !p || target.Event == config.ResourceDeleteEvent {
+		return http.StatusBadRequest, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Project (%s) did not exist at (%s)", project, t.Format(time.RFC3339)), nil, nil)
+	}
+
+	// Group the resources by type so that they can be applied in the order space cloud expects
+	ids := make([]string, 0, len(targets))
+	for id := range targets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	byType := map[config.Resource][]string{}
+	for _, id := range ids {
+		_, _, resourceType, err := splitResourceID(ctx, id)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		byType[resourceType] = append(byType[resourceType], id)
+	}
+
+	current := getConfigResources(s.clusterID, s.projectConfig)
+
+	// Set the resources first, followed by deleting them in the reverse order
+	type change struct {
+		id       string
+		resource interface{}
+	}
+	changes := []change{}
+	for _, resourceType := range config.ResourceFetchingOrder {
+		for _, id := range byType[resourceType] {
+			target := targets[id]
+			if target.Event == config.ResourceDeleteEvent {
+				continue
+			}
+			if resource, p := current[id]; p && reflect.DeepEqual(toAuditValue(resource), target.Resource) {
+				continue
+			}
+			changes = append(changes, change{id: id, resource: target.Resource})
+		}
+	}
+	for i := len(config.ResourceFetchingOrder) - 1; i >= 0; i-- {
+		for _, id := range byType[config.ResourceFetchingOrder[i]] {
+			if _, p := current[id]; !p || targets[id].Event != config.ResourceDeleteEvent {
+				continue
+			}
+			changes = append(changes, change{id: id})
+		}
+	}
+
+	// Nothing is changed unless all the changes are permitted
+	checked := map[config.Resource]error{}
+	for _, c := range changes {
+		if err := checkResourcePermission(ctx, c.id, isPermitted, checked); err != nil {
+			return http.StatusUnauthorized, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to rollback project", err, nil)
+		}
+	}
+
+	for _, c := range changes {
+		if status, err := s.rollbackResourceWithoutLock(ctx, c.id, c.resource); err != nil {
+			return status, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// rollbackResourceWithoutLock applies the resource to the modules and stores it. The resource is deleted if it is nil.
+func (s *Manager) rollbackResourceWithoutLock(ctx context.Context, resourceID string, resource interface{}) (int, error) {
+	eventType := config.ResourceAddEvent
+	if resource == nil {
+		eventType = config.ResourceDeleteEvent
+	}
+
+	if err := s.applyResourceWithoutLock(ctx, eventType, resourceID, "", resource); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if resource == nil {
+		if err := s.store.DeleteResource(ctx, resourceID); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusOK, nil
+	}
+
+	if err := s.store.SetResource(ctx, resourceID, resource); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// validateHistoryResourceID makes sure that the resource belongs to the project
+func validateHistoryResourceID(ctx context.Context, project, resourceID string) error {
+	if resourceID == "" {
+		return nil
+	}
+	_, projectID, _, err := splitResourceID(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+	if projectID != project {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Resource (%s) does not belong to project (%s)", resourceID, project), nil, nil)
+	}
+	return nil
+}
+
+// checkResourcePermission checks the permission on the type of the resource. The results are cached in checked
+// since a permission applies to all the resources of a type.
+func checkResourcePermission(ctx context.Context, resourceID string, isPermitted ResourcePermission, checked map[config.Resource]error) error {
+	_, _, resourceType, err := splitResourceID(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+	err, p := checked[resourceType]
+	if !p {
+		err = isPermitted(resourceType)
+		checked[resourceType] = err
+	}
+	if err != nil {
+		return fmt.Errorf("not permitted to access resources of type (%s) - %v", resourceType, err)
+	}
+	return nil
+}
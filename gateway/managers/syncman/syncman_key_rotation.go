@@ -0,0 +1,260 @@
+package syncman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+	jwtUtils "github.com/spaceuptech/space-cloud/gateway/utils/jwt"
+)
+
+const (
+	keyRotationCheckInterval = 1 * time.Minute
+	defaultKeyRotationPeriod = 30 * 24 * time.Hour
+	defaultKeyRotationGrace  = 24 * time.Hour
+
+	// defaultSessionAccessTokenTTL is the ttl of the access tokens of user management sessions
+	defaultSessionAccessTokenTTL = 15 * time.Minute
+)
+
+type keyRotationPolicy struct {
+	interval    time.Duration
+	gracePeriod time.Duration
+	maxTokenAge time.Duration // zero if the age has to be derived from the ttl of the tokens issued
+}
+
+// keyRotationProject is the config of a project to rotate the keys of along with the longest ttl of the tokens
+// issued by the gateway for it
+type keyRotationProject struct {
+	config   *config.ProjectConfig
+	tokenTTL time.Duration
+}
+
+// routineRotateSigningKeys periodically rotates the signing keys of the projects having a rotation policy.
+// Only the leader gateway performs the rotation. The rest get the updated secrets from the store.
+func (s *Manager) routineRotateSigningKeys(stop <-chan struct{}) {
+	ticker := time.NewTicker(keyRotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case t := <-ticker.C:
+			isLeader, err := s.CheckIfLeaderGateway(s.nodeID)
+			if err != nil || !isLeader {
+				continue
+			}
+
+			s.rotateSigningKeys(t)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// rotateSigningKeys rotates the secrets of the projects due for rotation. The rotated config is applied
+// only if it hasn't been changed in the meantime, and is stored without holding the lock.
+func (s *Manager) rotateSigningKeys(now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(utils.DefaultContextTime)*time.Second)
+	defer cancel()
+
+	for projectID, project := range s.getKeyRotationProjects() {
+		current := project.config
+		secrets, changed, err := rotateSecrets(current.Secrets, current.KeyRotation, project.tokenTTL, now)
+		if err != nil {
+			_ = helpers.Logger.LogError("key-rotation", "Unable to rotate signing keys of project", err, map[string]interface{}{"project": projectID})
+			continue
+		}
+		if !changed {
+			continue
+		}
+		rotated := *current
+		rotated.Secrets = secrets
+
+		if !s.swapProjectConfig(ctx, projectID, current, &rotated) {
+			continue
+		}
+
+		if err := s.store.SetResource(ctx, config.GenerateResourceID(s.clusterID, projectID, config.ResourceProject, projectID), &rotated); err != nil {
+			_ = helpers.Logger.LogError("key-rotation", "Unable to store rotated signing keys of project", err, map[string]interface{}{"project": projectID})
+			// Go back to the config which is in the store
+			s.swapProjectConfig(ctx, projectID, &rotated, current)
+			continue
+		}
+		helpers.Logger.LogInfo("key-rotation", "Rotated signing keys of project", map[string]interface{}{"project": projectID})
+	}
+}
+
+// getKeyRotationProjects returns the config of the projects having key rotation enabled
+func (s *Manager) getKeyRotationProjects() map[string]keyRotationProject {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	projects := map[string]keyRotationProject{}
+	for projectID, project := range s.projectConfig.Projects {
+		if project.ProjectConfig == nil || project.ProjectConfig.KeyRotation == nil || !project.ProjectConfig.KeyRotation.Enabled {
+			continue
+		}
+		projects[projectID] = keyRotationProject{config: project.ProjectConfig, tokenTTL: longestTokenTTL(project.Auths)}
+	}
+	return projects
+}
+
+// longestTokenTTL returns the longest ttl of the tokens signed with the secrets of a project. These are the
+// tokens created without a ttl and the access tokens of sessions. Refresh tokens aren't signed by the secrets.
+func longestTokenTTL(auths config.Auths) time.Duration {
+	longest := jwtUtils.DefaultTokenTTL
+	for _, auth := range auths {
+		if auth == nil || auth.Sessions == nil || !auth.Sessions.Enabled {
+			continue
+		}
+		ttl := defaultSessionAccessTokenTTL
+		if auth.Sessions.AccessTokenTTL != "" {
+			d, err := time.ParseDuration(auth.Sessions.AccessTokenTTL)
+			if err != nil {
+				continue
+			}
+			ttl = d
+		}
+		if ttl > longest {
+			longest = ttl
+		}
+	}
+	return longest
+}
+
+// swapProjectConfig applies the config `to` to the project if its current config is still `from`
+func (s *Manager) swapProjectConfig(ctx context.Context, projectID string, from, to *config.ProjectConfig) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	project, p := s.projectConfig.Projects[projectID]
+	if !p || project.ProjectConfig != from {
+		helpers.Logger.LogInfo("key-rotation", "Config of project changed while rotating its signing keys, skipping", map[string]interface{}{"project": projectID})
+		return false
+	}
+
+	if err := s.modules.SetProjectConfig(ctx, to); err != nil {
+		_ = helpers.Logger.LogError("key-rotation", "Unable to apply signing keys of project", err, map[string]interface{}{"project": projectID})
+		return false
+	}
+	project.ProjectConfig = to
+	return true
+}
+
+// rotateSecrets moves the secrets of a project through the stages of key rotation. A new key pair is published
+// when the primary key is due for rotation, promoted to primary once the grace period is over and the key it
+// replaced is retired once the tokens signed by it have expired. Unless the policy has a max token age, tokens
+// are considered expired after the token ttl provided. The secrets provided are never modified, the rotated
+// ones are copies.
+func rotateSecrets(secrets []*config.Secret, rotation *config.KeyRotation, tokenTTL time.Duration, now time.Time) (newSecrets []*config.Secret, changed bool, err error) {
+	policy, err := parseKeyRotationPolicy(rotation)
+	if err != nil {
+		return nil, false, err
+	}
+	if policy.maxTokenAge == 0 {
+		policy.maxTokenAge = tokenTTL
+	}
+
+	var primary, pending *config.Secret
+	for _, secret := range secrets {
+		// Retire the demoted keys once the tokens signed by them have expired
+		if demotedAt, ok := parseSecretTime(secret.DemotedAt); ok && !now.Before(demotedAt.Add(policy.maxTokenAge)) {
+			changed = true
+			continue
+		}
+		copied := *secret
+		secret = &copied
+		newSecrets = append(newSecrets, secret)
+
+		switch {
+		case secret.IsPrimary:
+			primary = secret
+		case secret.Alg == config.RS256 && secret.CreatedAt != "" && secret.PromotedAt == "" && secret.DemotedAt == "":
+			pending = secret
+		}
+	}
+
+	// Promote the pending key once it has been published for the grace period
+	if pending != nil {
+		createdAt, _ := parseSecretTime(pending.CreatedAt)
+		if now.Before(createdAt.Add(policy.gracePeriod)) {
+			return newSecrets, changed, nil
+		}
+		if primary != nil {
+			primary.IsPrimary = false
+			primary.DemotedAt = now.UTC().Format(time.RFC3339)
+		}
+		pending.IsPrimary = true
+		pending.PromotedAt = now.UTC().Format(time.RFC3339)
+		return newSecrets, true, nil
+	}
+
+	if primary != nil {
+		// Keys which weren't promoted by the rotation, like the ones configured before it was enabled,
+		// are considered to be promoted now so that the tokens signed by them keep working
+		promotedAt, ok := parseSecretTime(primary.PromotedAt)
+		if !ok {
+			primary.PromotedAt = now.UTC().Format(time.RFC3339)
+			return newSecrets, true, nil
+		}
+		if now.Before(promotedAt.Add(policy.interval)) {
+			return newSecrets, changed, nil
+		}
+	}
+
+	secret, err := jwtUtils.GenerateRS256Secret(now)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// A key can be used right away if there is no primary key whose tokens need to keep working
+	if primary == nil {
+		secret.IsPrimary = true
+		secret.PromotedAt = secret.CreatedAt
+	}
+	return append(newSecrets, secret), true, nil
+}
+
+func parseKeyRotationPolicy(rotation *config.KeyRotation) (*keyRotationPolicy, error) {
+	policy := &keyRotationPolicy{interval: defaultKeyRotationPeriod, gracePeriod: defaultKeyRotationGrace}
+
+	for _, v := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"interval", rotation.Interval, &policy.interval},
+		{"gracePeriod", rotation.GracePeriod, &policy.gracePeriod},
+		{"maxTokenAge", rotation.MaxTokenAge, &policy.maxTokenAge},
+	} {
+		if v.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v.value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration (%s) provided for (%s) of key rotation", v.value, v.name)
+		}
+		*v.dest = d
+	}
+
+	if policy.gracePeriod >= policy.interval {
+		return nil, errors.New("grace period of key rotation must be less than its interval")
+	}
+	return policy, nil
+}
+
+func parseSecretTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
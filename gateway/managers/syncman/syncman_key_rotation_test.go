@@ -0,0 +1,256 @@
+package syncman
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+func Test_rotateSecrets(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+	rotation := &config.KeyRotation{Enabled: true, Interval: "720h", GracePeriod: "24h", MaxTokenAge: "1h"}
+
+	tests := []struct {
+		name        string
+		secrets     []*config.Secret
+		rotation    *config.KeyRotation
+		wantChanged bool
+		wantKIDs    []string // kids of the secrets which must be retained in order, a generated key is denoted by an empty kid
+		wantPrimary string
+		wantErr     bool
+	}{
+		{
+			name:        "no secrets",
+			rotation:    rotation,
+			wantChanged: true,
+			wantKIDs:    []string{""},
+			wantPrimary: "",
+		},
+		{
+			name:        "manually configured primary is considered promoted now",
+			secrets:     []*config.Secret{{KID: "hs256", Alg: config.HS256, IsPrimary: true}},
+			rotation:    rotation,
+			wantChanged: true,
+			wantKIDs:    []string{"hs256"},
+			wantPrimary: "hs256",
+		},
+		{
+			name:        "primary within interval",
+			secrets:     []*config.Secret{{KID: "k1", Alg: config.RS256, IsPrimary: true, CreatedAt: at(-48 * time.Hour), PromotedAt: at(-24 * time.Hour)}},
+			rotation:    rotation,
+			wantKIDs:    []string{"k1"},
+			wantPrimary: "k1",
+		},
+		{
+			name:        "primary due for rotation",
+			secrets:     []*config.Secret{{KID: "k1", Alg: config.RS256, IsPrimary: true, PromotedAt: at(-721 * time.Hour)}},
+			rotation:    rotation,
+			wantChanged: true,
+			wantKIDs:    []string{"k1", ""},
+			wantPrimary: "k1",
+		},
+		{
+			name: "pending key within grace period",
+			secrets: []*config.Secret{
+				{KID: "k1", Alg: config.RS256, IsPrimary: true, PromotedAt: at(-721 * time.Hour)},
+				{KID: "k2", Alg: config.RS256, CreatedAt: at(-1 * time.Hour)},
+			},
+			rotation:    rotation,
+			wantKIDs:    []string{"k1", "k2"},
+			wantPrimary: "k1",
+		},
+		{
+			name: "pending key gets promoted after grace period",
+			secrets: []*config.Secret{
+				{KID: "k1", Alg: config.RS256, IsPrimary: true, PromotedAt: at(-745 * time.Hour)},
+				{KID: "k2", Alg: config.RS256, CreatedAt: at(-25 * time.Hour)},
+			},
+			rotation:    rotation,
+			wantChanged: true,
+			wantKIDs:    []string{"k1", "k2"},
+			wantPrimary: "k2",
+		},
+		{
+			name: "demoted key gets retired once tokens expire",
+			secrets: []*config.Secret{
+				{KID: "k1", Alg: config.RS256, DemotedAt: at(-2 * time.Hour)},
+				{KID: "k2", Alg: config.RS256, IsPrimary: true, CreatedAt: at(-26 * time.Hour), PromotedAt: at(-2 * time.Hour)},
+				{KID: "jwk", Alg: config.JwkURL, JwkURL: "https://example.com/jwks.json"},
+			},
+			rotation:    rotation,
+			wantChanged: true,
+			wantKIDs:    []string{"k2", "jwk"},
+			wantPrimary: "k2",
+		},
+		{
+			name: "demoted key is retained till tokens expire",
+			secrets: []*config.Secret{
+				{KID: "k1", Alg: config.RS256, DemotedAt: at(-30 * time.Minute)},
+				{KID: "k2", Alg: config.RS256, IsPrimary: true, CreatedAt: at(-25 * time.Hour), PromotedAt: at(-30 * time.Minute)},
+			},
+			rotation:    rotation,
+			wantKIDs:    []string{"k1", "k2"},
+			wantPrimary: "k2",
+		},
+		{
+			name: "demoted key gets retired once tokens of the longest ttl expire",
+			secrets: []*config.Secret{
+				{KID: "k1", Alg: config.RS256, DemotedAt: at(-45 * time.Minute)},
+				{KID: "k2", Alg: config.RS256, IsPrimary: true, CreatedAt: at(-25 * time.Hour), PromotedAt: at(-45 * time.Minute)},
+			},
+			rotation:    &config.KeyRotation{Enabled: true},
+			wantChanged: true,
+			wantKIDs:    []string{"k2"},
+			wantPrimary: "k2",
+		},
+		{
+			name:     "invalid duration",
+			rotation: &config.KeyRotation{Enabled: true, Interval: "monthly"},
+			wantErr:  true,
+		},
+		{
+			name:     "grace period longer than interval",
+			rotation: &config.KeyRotation{Enabled: true, Interval: "1h", GracePeriod: "2h"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := make([]config.Secret, len(tt.secrets))
+			for i, secret := range tt.secrets {
+				original[i] = *secret
+			}
+
+			got, changed, err := rotateSecrets(tt.secrets, tt.rotation, 30*time.Minute, now)
+			for i, secret := range tt.secrets {
+				if !reflect.DeepEqual(*secret, original[i]) {
+					t.Errorf("rotateSecrets() modified the secret (%s) provided", secret.KID)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rotateSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("rotateSecrets() changed = %v, want %v", changed, tt.wantChanged)
+			}
+
+			if len(got) != len(tt.wantKIDs) {
+				t.Fatalf("rotateSecrets() returned (%d) secrets, want (%d)", len(got), len(tt.wantKIDs))
+			}
+			primaries := 0
+			for i, secret := range got {
+				if secret.IsPrimary && secret.PromotedAt == "" {
+					t.Errorf("rotateSecrets() primary (%s) doesn't have the time it was promoted at", secret.KID)
+				}
+				if tt.wantKIDs[i] == "" {
+					if secret.Alg != config.RS256 || secret.PrivateKey == "" || secret.CreatedAt != now.Format(time.RFC3339) {
+						t.Errorf("rotateSecrets() generated invalid secret = %v", secret)
+					}
+				} else if secret.KID != tt.wantKIDs[i] {
+					t.Errorf("rotateSecrets() secret kid = %v, want %v", secret.KID, tt.wantKIDs[i])
+				}
+
+				if secret.IsPrimary {
+					primaries++
+					if secret.KID != tt.wantPrimary && tt.wantKIDs[i] != tt.wantPrimary {
+						t.Errorf("rotateSecrets() primary = %v, want %v", secret.KID, tt.wantPrimary)
+					}
+				}
+			}
+			if primaries != 1 {
+				t.Errorf("rotateSecrets() returned (%d) primary secrets", primaries)
+			}
+		})
+	}
+}
+
+func Test_longestTokenTTL(t *testing.T) {
+	tests := []struct {
+		name  string
+		auths config.Auths
+		want  time.Duration
+	}{
+		{
+			name: "no sessions",
+			want: 30 * time.Minute,
+		},
+		{
+			name: "sessions with the default access token ttl",
+			auths: config.Auths{
+				"email": {ID: "email", Enabled: true, Sessions: &config.Sessions{Enabled: true}},
+			},
+			want: 30 * time.Minute,
+		},
+		{
+			name: "longest access token ttl of the sessions enabled",
+			auths: config.Auths{
+				"email":  {ID: "email", Enabled: true, Sessions: &config.Sessions{Enabled: true, AccessTokenTTL: "1h", RefreshTokenTTL: "720h"}},
+				"google": {ID: "google", Enabled: true, Sessions: &config.Sessions{Enabled: true, AccessTokenTTL: "2h"}},
+				"github": {ID: "github", Enabled: true, Sessions: &config.Sessions{Enabled: false, AccessTokenTTL: "4h"}},
+			},
+			want: 2 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longestTokenTTL(tt.auths); got != tt.want {
+				t.Errorf("longestTokenTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_rotateSigningKeys(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	resourceID := config.GenerateResourceID("chicago", "1", config.ResourceProject, "1")
+
+	tests := []struct {
+		name     string
+		storeErr error
+	}{
+		{name: "rotated secrets get applied"},
+		{name: "rotated secrets are rolled back if they cannot be stored", storeErr: errors.New("store unavailable")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &config.Secret{KID: "hs256", Alg: config.HS256, IsPrimary: true, Secret: "mySecretKey"}
+			projectConfig := &config.ProjectConfig{ID: "1", Secrets: []*config.Secret{secret}, KeyRotation: &config.KeyRotation{Enabled: true}}
+			s := &Manager{clusterID: "chicago", projectConfig: &config.Config{Projects: config.Projects{"1": &config.Project{ProjectConfig: projectConfig}}}}
+
+			mockModules := mockModulesInterface{}
+			mockStore := mockStoreInterface{}
+			mockModules.On("SetProjectConfig", mock.Anything, mock.Anything).Return(nil)
+			mockStore.On("SetResource", mock.Anything, resourceID, mock.Anything).Return(tt.storeErr)
+			s.modules = &mockModules
+			s.store = &mockStore
+
+			s.rotateSigningKeys(now)
+
+			got := s.projectConfig.Projects["1"].ProjectConfig
+			if secret.PromotedAt != "" {
+				t.Errorf("rotateSigningKeys() modified the secrets of the config it started with")
+			}
+			if tt.storeErr != nil {
+				if got != projectConfig {
+					t.Errorf("rotateSigningKeys() did not restore the config after failing to store it")
+				}
+				mockModules.AssertNumberOfCalls(t, "SetProjectConfig", 2)
+				return
+			}
+			if got == projectConfig || got.Secrets[0].PromotedAt != now.Format(time.RFC3339) {
+				t.Errorf("rotateSigningKeys() did not apply the rotated secrets")
+			}
+			mockModules.AssertExpectations(t)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
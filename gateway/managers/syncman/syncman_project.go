@@ -38,6 +38,12 @@ func (s *Manager) ApplyProjectConfig(ctx context.Context, project *config.Projec
 		project.ContextTimeGraphQL = 10
 	}
 
+	if project.KeyRotation != nil {
+		if _, err := parseKeyRotationPolicy(project.KeyRotation); err != nil {
+			return http.StatusBadRequest, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid key rotation policy provided", err, nil)
+		}
+	}
+
 	// Generate internal access token
 	token, err := s.adminMan.GetInternalAccessToken()
 	if err != nil {
@@ -45,6 +51,9 @@ func (s *Manager) ApplyProjectConfig(ctx context.Context, project *config.Projec
 	}
 	p, ok := s.projectConfig.Projects[project.ID]
 	if ok {
+		if err := restoreRedacted(ctx, project, p.ProjectConfig); err != nil {
+			return http.StatusInternalServerError, err
+		}
 		p.ProjectConfig = project
 	} else {
 		s.projectConfig.Projects[project.ID] = config.GenerateEmptyProject(project)
@@ -0,0 +1,332 @@
+package syncman
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	authHelpers "github.com/spaceuptech/space-cloud/gateway/modules/auth/helpers"
+)
+
+// SetSecurityPolicy sets the rego policy which can be referred by the security rules
+func (s *Manager) SetSecurityPolicy(ctx context.Context, project, id string, policy *config.SecurityPolicy, params model.RequestParams) (int, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), nil
+	}
+
+	// Make sure the policy compiles before storing it
+	policy.ID = id
+	if _, err := authHelpers.PrepareRegoPolicy(ctx, policy); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	// Acquire a lock
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	projectConfig, err := s.getConfigWithoutLock(ctx, project)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceSecurityPolicy, id)
+	if projectConfig.SecurityPolicies == nil {
+		projectConfig.SecurityPolicies = config.SecurityPolicies{resourceID: policy}
+	} else {
+		projectConfig.SecurityPolicies[resourceID] = policy
+	}
+
+	if err := s.modules.SetSecurityPolicyConfig(ctx, project, projectConfig.SecurityPolicies); err != nil {
+		return http.StatusInternalServerError, helpers.Logger.LogError(helpers.GetRequestID(ctx), "error setting security policies", err, nil)
+	}
+
+	if err := s.store.SetResource(ctx, resourceID, policy); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// DeleteSecurityPolicy deletes the rego policy with the provided id
+func (s *Manager) DeleteSecurityPolicy(ctx context.Context, project, id string, params model.RequestParams) (int, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), nil
+	}
+
+	// Acquire a lock
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	projectConfig, err := s.getConfigWithoutLock(ctx, project)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceSecurityPolicy, id)
+	if _, p := projectConfig.SecurityPolicies[resourceID]; !p {
+		return http.StatusBadRequest, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Security policy (%s) does not exist", id), nil, nil)
+	}
+	delete(projectConfig.SecurityPolicies, resourceID)
+
+	if err := s.modules.SetSecurityPolicyConfig(ctx, project, projectConfig.SecurityPolicies); err != nil {
+		return http.StatusInternalServerError, helpers.Logger.LogError(helpers.GetRequestID(ctx), "error setting security policies", err, nil)
+	}
+
+	if err := s.store.DeleteResource(ctx, resourceID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// GetSecurityPolicies gets the rego policies from config
+func (s *Manager) GetSecurityPolicies(ctx context.Context, project, id string, params model.RequestParams) (int, []interface{}, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), nil, err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), hookResponse.Result().([]interface{}), nil
+	}
+
+	// Acquire a lock
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	projectConfig, err := s.getConfigWithoutLock(ctx, project)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if id != "*" {
+		resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceSecurityPolicy, id)
+		policy, ok := projectConfig.SecurityPolicies[resourceID]
+		if !ok {
+			return http.StatusBadRequest, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Security policy (%s) does not exist", id), nil, nil)
+		}
+		return http.StatusOK, []interface{}{policy}, nil
+	}
+
+	policies := []interface{}{}
+	for _, value := range projectConfig.SecurityPolicies {
+		policies = append(policies, value)
+	}
+	return http.StatusOK, policies, nil
+}
+
+// SetAPIKey adds or updates an api key. A new key is generated only when the api key doesn't exist already,
+// in which case it is returned. Only the hash of the key is stored.
+func (s *Manager) SetAPIKey(ctx context.Context, project, id string, apiKey *config.APIKey, params model.RequestParams) (int, string, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), "", err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), "", nil
+	}
+
+	apiKey.ID = id
+	if err := authHelpers.ValidateAPIKey(apiKey); err != nil {
+		return http.StatusBadRequest, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid api key provided", err, nil)
+	}
+
+	// Acquire a lock
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	projectConfig, err := s.getConfigWithoutLock(ctx, project)
+	if err != nil {
+		return http.StatusBadRequest, "", err
+	}
+
+	// The hash can never be set by the user
+	var key string
+	resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceAPIKey, id)
+	if existing, p := projectConfig.APIKeys[resourceID]; p {
+		apiKey.Hash = existing.Hash
+	} else {
+		key, apiKey.Hash, err = authHelpers.GenerateAPIKey(id)
+		if err != nil {
+			return http.StatusInternalServerError, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate api key", err, nil)
+		}
+	}
+
+	if status, err := s.setAPIKey(ctx, project, resourceID, projectConfig, apiKey); err != nil {
+		return status, "", err
+	}
+	return http.StatusOK, key, nil
+}
+
+// RotateAPIKey replaces the key of an existing api key. The new key is returned.
+func (s *Manager) RotateAPIKey(ctx context.Context, project, id string, params model.RequestParams) (int, string, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), "", err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), "", nil
+	}
+
+	// Acquire a lock
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	projectConfig, err := s.getConfigWithoutLock(ctx, project)
+	if err != nil {
+		return http.StatusBadRequest, "", err
+	}
+
+	resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceAPIKey, id)
+	existing, p := projectConfig.APIKeys[resourceID]
+	if !p {
+		return http.StatusBadRequest, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Api key (%s) does not exist", id), nil, nil)
+	}
+
+	apiKey := *existing
+	key, hash, err := authHelpers.GenerateAPIKey(id)
+	if err != nil {
+		return http.StatusInternalServerError, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate api key", err, nil)
+	}
+	apiKey.Hash = hash
+
+	if status, err := s.setAPIKey(ctx, project, resourceID, projectConfig, &apiKey); err != nil {
+		return status, "", err
+	}
+	return http.StatusOK, key, nil
+}
+
+func (s *Manager) setAPIKey(ctx context.Context, project, resourceID string, projectConfig *config.Project, apiKey *config.APIKey) (int, error) {
+	if projectConfig.APIKeys == nil {
+		projectConfig.APIKeys = config.APIKeys{resourceID: apiKey}
+	} else {
+		projectConfig.APIKeys[resourceID] = apiKey
+	}
+
+	if err := s.modules.SetAPIKeyConfig(ctx, project, projectConfig.APIKeys); err != nil {
+		return http.StatusInternalServerError, helpers.Logger.LogError(helpers.GetRequestID(ctx), "error setting api keys", err, nil)
+	}
+
+	if err := s.store.SetResource(ctx, resourceID, apiKey); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// DeleteAPIKey deletes the api key with the provided id
+func (s *Manager) DeleteAPIKey(ctx context.Context, project, id string, params model.RequestParams) (int, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), nil
+	}
+
+	// Acquire a lock
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	projectConfig, err := s.getConfigWithoutLock(ctx, project)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceAPIKey, id)
+	if _, p := projectConfig.APIKeys[resourceID]; !p {
+		return http.StatusBadRequest, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Api key (%s) does not exist", id), nil, nil)
+	}
+	delete(projectConfig.APIKeys, resourceID)
+
+	if err := s.modules.SetAPIKeyConfig(ctx, project, projectConfig.APIKeys); err != nil {
+		return http.StatusInternalServerError, helpers.Logger.LogError(helpers.GetRequestID(ctx), "error setting api keys", err, nil)
+	}
+
+	if err := s.store.DeleteResource(ctx, resourceID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// GetAPIKeys gets the api keys from config
+func (s *Manager) GetAPIKeys(ctx context.Context, project, id string, params model.RequestParams) (int, []interface{}, error) {
+	// Check if the request has been hijacked
+	hookResponse := s.integrationMan.InvokeHook(ctx, params)
+	if hookResponse.CheckResponse() {
+		// Check if an error occurred
+		if err := hookResponse.Error(); err != nil {
+			return hookResponse.Status(), nil, err
+		}
+
+		// Gracefully return
+		return hookResponse.Status(), hookResponse.Result().([]interface{}), nil
+	}
+
+	// Acquire a lock
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	projectConfig, err := s.getConfigWithoutLock(ctx, project)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	if id != "*" {
+		resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceAPIKey, id)
+		apiKey, ok := projectConfig.APIKeys[resourceID]
+		if !ok {
+			return http.StatusBadRequest, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Api key (%s) does not exist", id), nil, nil)
+		}
+		return http.StatusOK, []interface{}{withoutHash(apiKey)}, nil
+	}
+
+	apiKeys := []interface{}{}
+	for _, value := range projectConfig.APIKeys {
+		apiKeys = append(apiKeys, withoutHash(value))
+	}
+	return http.StatusOK, apiKeys, nil
+}
+
+// withoutHash returns a copy of the api key without its hash so that it isn't exposed by the config apis
+func withoutHash(apiKey *config.APIKey) *config.APIKey {
+	key := *apiKey
+	key.Hash = ""
+	return &key
+}
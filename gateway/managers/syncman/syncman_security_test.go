@@ -0,0 +1,153 @@
+package syncman
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	authHelpers "github.com/spaceuptech/space-cloud/gateway/modules/auth/helpers"
+)
+
+func TestManager_SetAPIKey(t *testing.T) {
+	resourceID := config.GenerateResourceID("chicago", "1", config.ResourceAPIKey, "backend")
+	tests := []struct {
+		name       string
+		apiKeys    config.APIKeys
+		id         string
+		apiKey     *config.APIKey
+		wantKey    bool
+		wantHash   string
+		wantErr    bool
+		setsConfig bool
+	}{
+		{
+			name:       "new api key gets generated",
+			id:         "backend",
+			apiKey:     &config.APIKey{Scopes: []string{"db:mydb"}, Hash: "user-provided-hash"},
+			wantKey:    true,
+			setsConfig: true,
+		},
+		{
+			name:       "existing api key keeps its hash",
+			apiKeys:    config.APIKeys{resourceID: {ID: "backend", Hash: "existing-hash", Scopes: []string{"*"}}},
+			id:         "backend",
+			apiKey:     &config.APIKey{Scopes: []string{"db:mydb"}, Hash: "user-provided-hash"},
+			wantHash:   "existing-hash",
+			setsConfig: true,
+		},
+		{
+			name:    "invalid api key",
+			id:      "back.end",
+			apiKey:  &config.APIKey{Scopes: []string{"*"}},
+			wantErr: true,
+		},
+		{
+			name:    "api key without scopes",
+			id:      "backend",
+			apiKey:  &config.APIKey{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Manager{clusterID: "chicago", projectConfig: &config.Config{Projects: config.Projects{"1": &config.Project{ProjectConfig: &config.ProjectConfig{ID: "1"}, APIKeys: tt.apiKeys}}}}
+
+			mockModules := mockModulesInterface{}
+			mockStore := mockStoreInterface{}
+			if tt.setsConfig {
+				mockModules.On("SetAPIKeyConfig", mock.Anything, "1", mock.Anything).Return(nil)
+				mockStore.On("SetResource", mock.Anything, resourceID, mock.Anything).Return(nil)
+			}
+
+			s.modules = &mockModules
+			s.store = &mockStore
+			s.integrationMan = &mockIntegrationManager{skip: true}
+
+			_, key, err := s.SetAPIKey(context.Background(), "1", tt.id, tt.apiKey, model.RequestParams{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Manager.SetAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantKey {
+				if id, ok := authHelpers.ParseAPIKeyID(key); !ok || id != tt.id {
+					t.Errorf("Manager.SetAPIKey() returned invalid key (%s)", key)
+				}
+				if tt.apiKey.Hash != authHelpers.HashAPIKey(key) {
+					t.Errorf("Manager.SetAPIKey() stored hash doesn't match the key returned")
+				}
+			} else if key != "" {
+				t.Errorf("Manager.SetAPIKey() returned key (%s) for existing api key", key)
+			}
+			if tt.wantHash != "" && tt.apiKey.Hash != tt.wantHash {
+				t.Errorf("Manager.SetAPIKey() hash = %s, want %s", tt.apiKey.Hash, tt.wantHash)
+			}
+
+			mockModules.AssertExpectations(t)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestManager_RotateAPIKey(t *testing.T) {
+	resourceID := config.GenerateResourceID("chicago", "1", config.ResourceAPIKey, "backend")
+	s := &Manager{clusterID: "chicago", projectConfig: &config.Config{Projects: config.Projects{"1": &config.Project{ProjectConfig: &config.ProjectConfig{ID: "1"}, APIKeys: config.APIKeys{resourceID: {ID: "backend", Hash: "old-hash", Scopes: []string{"*"}}}}}}}
+
+	mockModules := mockModulesInterface{}
+	mockStore := mockStoreInterface{}
+	// The project config gets updated only once the store notifies, so the api key is captured from the store
+	var apiKey *config.APIKey
+	mockModules.On("SetAPIKeyConfig", mock.Anything, "1", mock.Anything).Return(nil)
+	mockStore.On("SetResource", mock.Anything, resourceID, mock.MatchedBy(func(resource *config.APIKey) bool {
+		apiKey = resource
+		return true
+	})).Return(nil)
+	s.modules = &mockModules
+	s.store = &mockStore
+	s.integrationMan = &mockIntegrationManager{skip: true}
+
+	if _, _, err := s.RotateAPIKey(context.Background(), "1", "unknown", model.RequestParams{}); err == nil {
+		t.Errorf("Manager.RotateAPIKey() expected error for unknown api key")
+	}
+
+	_, key, err := s.RotateAPIKey(context.Background(), "1", "backend", model.RequestParams{})
+	if err != nil {
+		t.Fatalf("Manager.RotateAPIKey() error = %v", err)
+	}
+	if apiKey == nil || apiKey.Hash != authHelpers.HashAPIKey(key) {
+		t.Fatalf("Manager.RotateAPIKey() stored hash doesn't match the key returned")
+	}
+	if len(apiKey.Scopes) != 1 || apiKey.Scopes[0] != "*" {
+		t.Errorf("Manager.RotateAPIKey() didn't retain the scopes of the api key")
+	}
+
+	mockModules.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+}
+
+func TestManager_GetAPIKeys(t *testing.T) {
+	resourceID := config.GenerateResourceID("chicago", "1", config.ResourceAPIKey, "backend")
+	apiKey := &config.APIKey{ID: "backend", Hash: "hash", Scopes: []string{"*"}}
+	s := &Manager{clusterID: "chicago", projectConfig: &config.Config{Projects: config.Projects{"1": &config.Project{ProjectConfig: &config.ProjectConfig{ID: "1"}, APIKeys: config.APIKeys{resourceID: apiKey}}}}}
+	s.integrationMan = &mockIntegrationManager{skip: true}
+
+	for _, id := range []string{"backend", "*"} {
+		_, apiKeys, err := s.GetAPIKeys(context.Background(), "1", id, model.RequestParams{})
+		if err != nil {
+			t.Fatalf("Manager.GetAPIKeys() error = %v", err)
+		}
+		want := []interface{}{&config.APIKey{ID: "backend", Scopes: []string{"*"}}}
+		if !reflect.DeepEqual(apiKeys, want) {
+			t.Errorf("Manager.GetAPIKeys() = %v, want %v", apiKeys, want)
+		}
+	}
+
+	// The hash stored in the config is left as it is
+	if apiKey.Hash != "hash" {
+		t.Errorf("Manager.GetAPIKeys() modified the stored api key")
+	}
+}
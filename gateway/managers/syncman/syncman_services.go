@@ -36,6 +36,9 @@ func (s *Manager) SetService(ctx context.Context, project, service string, value
 	}
 
 	resourceID := config.GenerateResourceID(s.clusterID, project, config.ResourceRemoteService, service)
+	if err := restoreRedacted(ctx, value, projectConfig.RemoteService[resourceID]); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	if projectConfig.RemoteService == nil {
 		projectConfig.RemoteService = config.Services{resourceID: value}
 	} else {
@@ -6,6 +6,7 @@ import (
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/model"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/caching"
+	"github.com/spaceuptech/space-cloud/gateway/modules/global/compression"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/letsencrypt"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/routing"
 )
@@ -61,15 +62,23 @@ type ModulesInterface interface {
 	SetEventingTriggerConfig(ctx context.Context, projectID string, triggerObj config.EventingTriggers) error
 	SetEventingRuleConfig(ctx context.Context, projectID string, secureObj config.EventingRules) error
 
+	// SetSecurityPolicyConfig sets the rego policies used by the security rules
+	SetSecurityPolicyConfig(ctx context.Context, projectID string, policies config.SecurityPolicies) error
+
+	// SetAPIKeyConfig sets the api keys of the project
+	SetAPIKeyConfig(ctx context.Context, projectID string, apiKeys config.APIKeys) error
+
 	// SetUsermanConfig set the config of the userman module
 	SetUsermanConfig(ctx context.Context, projectID string, auth config.Auths) error
 
 	// Getters
 	GetSchemaModuleForSyncMan(projectID string) (model.SchemaEventingInterface, error)
 	GetAuthModuleForSyncMan(projectID string) (model.AuthSyncManInterface, error)
+	GetCrudModuleForSyncMan(projectID string) (model.CrudSyncManInterface, error)
 	LetsEncrypt() *letsencrypt.LetsEncrypt
 	Routing() *routing.Routing
 	Caching() *caching.Cache
+	Compression() *compression.Compression
 
 	// Delete
 	Delete(projectID string)
@@ -9,6 +9,7 @@ import (
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/model"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/caching"
+	"github.com/spaceuptech/space-cloud/gateway/modules/global/compression"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/letsencrypt"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/routing"
 )
@@ -195,6 +196,14 @@ func (m *mockModulesInterface) SetEventingRuleConfig(ctx context.Context, projec
 	return m.Called(ctx, projectID, secureObj).Error(0)
 }
 
+func (m *mockModulesInterface) SetSecurityPolicyConfig(ctx context.Context, projectID string, policies config.SecurityPolicies) error {
+	return m.Called(ctx, projectID, policies).Error(0)
+}
+
+func (m *mockModulesInterface) SetAPIKeyConfig(ctx context.Context, projectID string, apiKeys config.APIKeys) error {
+	return m.Called(ctx, projectID, apiKeys).Error(0)
+}
+
 func (m *mockModulesInterface) SetUsermanConfig(ctx context.Context, projectID string, auth config.Auths) error {
 	return m.Called(ctx, projectID, auth).Error(0)
 }
@@ -203,6 +212,10 @@ func (m *mockModulesInterface) LetsEncrypt() *letsencrypt.LetsEncrypt {
 	return m.Called().Get(0).(*letsencrypt.LetsEncrypt)
 }
 
+func (m *mockModulesInterface) Compression() *compression.Compression {
+	return m.Called().Get(0).(*compression.Compression)
+}
+
 func (m *mockModulesInterface) Routing() *routing.Routing {
 	return m.Called().Get(0).(*routing.Routing)
 }
@@ -240,6 +253,11 @@ func (m *mockModulesInterface) GetAuthModuleForSyncMan(projectID string) (model.
 	return c.Get(0).(model.AuthSyncManInterface), c.Error(1)
 }
 
+func (m *mockModulesInterface) GetCrudModuleForSyncMan(projectID string) (model.CrudSyncManInterface, error) {
+	c := m.Called(projectID)
+	return c.Get(0).(model.CrudSyncManInterface), c.Error(1)
+}
+
 type mockStoreInterface struct {
 	mock.Mock
 }
@@ -249,7 +267,7 @@ func (m *mockStoreInterface) GetGlobalConfig() (*config.Config, error) {
 	return c.Get(0).(*config.Config), c.Error(1)
 }
 
-func (m *mockStoreInterface) WatchResources(cb func(eventType string, resourceId string, resourceType config.Resource, resource interface{})) error {
+func (m *mockStoreInterface) WatchResources(cb func(eventType string, resourceId string, resourceType config.Resource, resource interface{}) error) error {
 	panic("implement me")
 }
 
@@ -265,6 +283,11 @@ func (m *mockStoreInterface) DeleteProject(ctx context.Context, projectID string
 	return m.Called(ctx, projectID).Error(0)
 }
 
+func (m *mockStoreInterface) GetResourceHistory(ctx context.Context, projectID, resourceID string) ([]*model.ConfigRevision, error) {
+	c := m.Called(ctx, projectID, resourceID)
+	return c.Get(0).([]*model.ConfigRevision), c.Error(1)
+}
+
 func (m *mockStoreInterface) GetProjectsConfig() (config.Projects, error) {
 	c := m.Called()
 	return c.Get(0).(config.Projects), c.Error(1)
@@ -0,0 +1,39 @@
+package model
+
+// ConfigAuditEntry records a single change made to a config resource
+type ConfigAuditEntry struct {
+	ID           string `json:"id"`
+	Time         string `json:"time"`
+	Actor        string `json:"actor"`
+	RequestID    string `json:"requestId"`
+	Event        string `json:"event"`
+	ResourceID   string `json:"resourceId"`
+	ResourceType string `json:"resourceType"`
+	Project      string `json:"project"`
+
+	Before interface{}          `json:"before"`
+	After  interface{}          `json:"after"`
+	Diff   []*ConfigAuditChange `json:"diff"`
+}
+
+// ConfigAuditChange is a field of the resource which was changed. The path is dot separated.
+type ConfigAuditChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// ConfigAuditQuery is used to filter the audit trail. Empty fields aren't used for filtering.
+type ConfigAuditQuery struct {
+	Project      string
+	ResourceType string
+	ResourceID   string
+	Actor        string
+	RequestID    string
+
+	// Since and Until are RFC3339 timestamps
+	Since string
+	Until string
+
+	Limit int64
+}
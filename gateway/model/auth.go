@@ -0,0 +1,49 @@
+package model
+
+// TokenRevocationRequest describes the tokens to be revoked. Either the token, its id or the subject whose tokens
+// need to be revoked must be provided.
+type TokenRevocationRequest struct {
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+	JTI   string `json:"jti,omitempty" yaml:"jti,omitempty"`
+	// ExpiresAt is the expiry of the token having the jti provided in RFC3339 format
+	ExpiresAt string `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+	Subject   string `json:"subject,omitempty" yaml:"subject,omitempty"`
+}
+
+// OIDCLoginState is generated when a user starts signing in with an OpenID Connect provider. It is held by the
+// user agent till the provider redirects back to the gateway.
+type OIDCLoginState struct {
+	State        string `json:"state"`
+	CodeVerifier string `json:"codeVerifier"`
+	Nonce        string `json:"nonce"`
+}
+
+// SessionDevice describes the device a session was created from
+type SessionDevice struct {
+	UserAgent string `json:"userAgent"`
+	IP        string `json:"ip"`
+}
+
+// SignInUnlockRequest is the request to unlock the sign in of an account or an ip locked due to failed attempts
+type SignInUnlockRequest struct {
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+	IP    string `json:"ip,omitempty" yaml:"ip,omitempty"`
+}
+
+// UserAdminRequest is the request of an admin to create a user or to change the role of a user
+type UserAdminRequest struct {
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
+	Pass  string `json:"pass,omitempty" yaml:"pass,omitempty"`
+	Role  string `json:"role,omitempty" yaml:"role,omitempty"`
+}
+
+// UserSearchRequest describes the filters and the page of the users searched by an admin
+type UserSearchRequest struct {
+	// Email matches the users whose email starts with it
+	Email    string `json:"email,omitempty" yaml:"email,omitempty"`
+	Role     string `json:"role,omitempty" yaml:"role,omitempty"`
+	Disabled *bool  `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Limit    int64  `json:"limit,omitempty" yaml:"limit,omitempty"`
+	Skip     int64  `json:"skip,omitempty" yaml:"skip,omitempty"`
+}
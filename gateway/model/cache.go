@@ -16,8 +16,10 @@ type CachePurgeRequest struct {
 
 // CacheIngressRoute corresponds to a value of ingress route key
 type CacheIngressRoute struct {
-	Body    []byte      `json:"body"`
-	Headers http.Header `json:"headers"`
+	Body      []byte      `json:"body"`
+	Headers   http.Header `json:"headers"`
+	ETag      string      `json:"etag"`
+	ExpiresAt int64       `json:"expiresAt"` // Unix timestamp in seconds
 }
 
 // CacheDatabaseResult is used to store cached database result
@@ -0,0 +1,32 @@
+package model
+
+// Statuses of a re-encryption job
+const (
+	ReEncryptionJobRunning   = "running"
+	ReEncryptionJobCompleted = "completed"
+	ReEncryptionJobFailed    = "failed"
+)
+
+// ReEncryptionRequest describes the encrypted columns of a table which need to be migrated to the primary aes key
+type ReEncryptionRequest struct {
+	DbAlias string   `json:"dbAlias" yaml:"dbAlias"`
+	Col     string   `json:"col" yaml:"col"`
+	Fields  []string `json:"fields" yaml:"fields"`
+	// IDField uniquely identifies a row. It is used to page through and update the rows. Defaults to `_id` for mongo and `id` otherwise.
+	IDField   string `json:"idField,omitempty" yaml:"idField,omitempty"`
+	BatchSize int64  `json:"batchSize,omitempty" yaml:"batchSize,omitempty"`
+}
+
+// ReEncryptionJob describes the progress of a re-encryption job
+type ReEncryptionJob struct {
+	ID          string               `json:"id" yaml:"id"`
+	Status      string               `json:"status" yaml:"status"`
+	Request     *ReEncryptionRequest `json:"request" yaml:"request"`
+	KID         string               `json:"kid" yaml:"kid"` // kid of the key the values are being migrated to
+	Scanned     int64                `json:"scanned" yaml:"scanned"`
+	Migrated    int64                `json:"migrated" yaml:"migrated"`
+	Skipped     int64                `json:"skipped" yaml:"skipped"` // rows which were modified while being re-encrypted
+	Error       string               `json:"error,omitempty" yaml:"error,omitempty"`
+	StartedAt   string               `json:"startedAt" yaml:"startedAt"`
+	CompletedAt string               `json:"completedAt,omitempty" yaml:"completedAt,omitempty"`
+}
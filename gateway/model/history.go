@@ -0,0 +1,19 @@
+package model
+
+// ConfigRevision is a version of a config resource kept by the store. The resource is nil if the revision deleted it.
+type ConfigRevision struct {
+	Revision   int         `json:"revision"`
+	ResourceID string      `json:"resourceId"`
+	Time       string      `json:"time"`
+	Event      string      `json:"event"`
+	Resource   interface{} `json:"resource"`
+}
+
+// ConfigRollbackRequest rolls back a single resource to a revision, or the entire project to the state it was in at a time
+type ConfigRollbackRequest struct {
+	ResourceID string `json:"resourceId"`
+	Revision   int    `json:"revision"`
+
+	// Time is an RFC3339 timestamp. It is only used if a resource id isn't provided.
+	Time string `json:"time"`
+}
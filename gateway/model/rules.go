@@ -0,0 +1,90 @@
+package model
+
+import "github.com/spaceuptech/space-cloud/gateway/config"
+
+// Results of a clause in a rule trace
+const (
+	RuleTraceAllowed = "allowed"
+	RuleTraceDenied  = "denied"
+)
+
+// RuleSimulationRequest describes a security rule to be evaluated against mocked arguments and auth claims.
+// Either the rule itself or a reference to an existing rule must be provided.
+type RuleSimulationRequest struct {
+	Rule        *config.Rule           `json:"rule,omitempty" yaml:"rule,omitempty"`
+	Ref         *RuleReference         `json:"ref,omitempty" yaml:"ref,omitempty"`
+	Args        map[string]interface{} `json:"args,omitempty" yaml:"args,omitempty"`
+	Auth        map[string]interface{} `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Mocks       *RuleMocks             `json:"mocks,omitempty" yaml:"mocks,omitempty"`
+	ReturnWhere bool                   `json:"returnWhere,omitempty" yaml:"returnWhere,omitempty"`
+}
+
+// RuleReference points to a security rule already present in the project config
+type RuleReference struct {
+	// Type is the resource type of the rule. It can be one of db-rule, db-prepared-query, filestore-rule, eventing-rule or remote-service
+	Type string `json:"type" yaml:"type"`
+
+	// Used by db rules & prepared queries
+	DB  string `json:"db,omitempty" yaml:"db,omitempty"`
+	Col string `json:"col,omitempty" yaml:"col,omitempty"`
+	Op  string `json:"op,omitempty" yaml:"op,omitempty"`
+	ID  string `json:"id,omitempty" yaml:"id,omitempty"`
+
+	// Used by file rules
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Used by eventing rules
+	EventType string `json:"eventType,omitempty" yaml:"eventType,omitempty"`
+
+	// Used by remote service endpoints
+	Service  string `json:"service,omitempty" yaml:"service,omitempty"`
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+}
+
+// RuleMocks holds the responses returned to the query and webhook rules during a simulation
+type RuleMocks struct {
+	Crud     []*RuleCrudMock    `json:"crud,omitempty" yaml:"crud,omitempty"`
+	Webhooks []*RuleWebhookMock `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+}
+
+// RuleCrudMock is the result of a read operation made by a query rule. The find clause is matched only if provided.
+type RuleCrudMock struct {
+	DB     string                 `json:"db" yaml:"db"`
+	Col    string                 `json:"col" yaml:"col"`
+	Find   map[string]interface{} `json:"find,omitempty" yaml:"find,omitempty"`
+	Result interface{}            `json:"result,omitempty" yaml:"result,omitempty"`
+	Error  string                 `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// RuleWebhookMock is the response of the url invoked by a webhook rule
+type RuleWebhookMock struct {
+	URL    string      `json:"url" yaml:"url"`
+	Result interface{} `json:"result,omitempty" yaml:"result,omitempty"`
+	Error  string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// RuleSimulationResponse is the outcome of a rule simulation
+type RuleSimulationResponse struct {
+	Allowed     bool                   `json:"allowed" yaml:"allowed"`
+	Error       string                 `json:"error,omitempty" yaml:"error,omitempty"`
+	Trace       *RuleTrace             `json:"trace,omitempty" yaml:"trace,omitempty"`
+	PostProcess []*RulePostProcess     `json:"postProcess,omitempty" yaml:"postProcess,omitempty"`
+	Where       map[string]interface{} `json:"where,omitempty" yaml:"where,omitempty"`
+	Args        map[string]interface{} `json:"args,omitempty" yaml:"args,omitempty"`
+}
+
+// RulePostProcess is a post process action returned by a simulated rule
+type RulePostProcess struct {
+	Action string      `json:"action" yaml:"action"`
+	Field  string      `json:"field" yaml:"field"`
+	Value  interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// RuleTrace is the evaluation result of a single clause of a security rule
+type RuleTrace struct {
+	Rule    string       `json:"rule" yaml:"rule"`
+	Name    string       `json:"name,omitempty" yaml:"name,omitempty"`
+	Result  string       `json:"result" yaml:"result"`
+	Error   string       `json:"error,omitempty" yaml:"error,omitempty"`
+	Clauses []*RuleTrace `json:"clauses,omitempty" yaml:"clauses,omitempty"`
+}
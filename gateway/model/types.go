@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 )
@@ -26,6 +27,12 @@ type CrudAuthInterface interface {
 	Read(ctx context.Context, dbAlias, col string, req *ReadRequest, params RequestParams) (interface{}, *SQLMetaData, error)
 }
 
+// CrudSyncManInterface is an interface consisting of functions of crud module used by sync man
+type CrudSyncManInterface interface {
+	InternalCreate(ctx context.Context, dbAlias, project, col string, req *CreateRequest, isIgnoreMetrics bool) error
+	Read(ctx context.Context, dbAlias, col string, req *ReadRequest, params RequestParams) (interface{}, *SQLMetaData, error)
+}
+
 // SchemaEventingInterface is an interface consisting of functions of schema module used by eventing module
 type SchemaEventingInterface interface {
 	SchemaModifyAll(ctx context.Context, dbAlias, logicalDBName string, dbSchemas config.DatabaseSchemas) error
@@ -111,13 +118,24 @@ type CrudUserInterface interface {
 	Read(ctx context.Context, dbAlias, col string, req *ReadRequest, params RequestParams) (interface{}, *SQLMetaData, error)
 	Create(ctx context.Context, dbAlias, col string, req *CreateRequest, params RequestParams) error
 	Update(ctx context.Context, dbAlias, col string, req *UpdateRequest, params RequestParams) error
+	UpdateAndCount(ctx context.Context, dbAlias, col string, req *UpdateRequest, params RequestParams) (int64, error)
 }
 
 // AuthUserInterface is an interface consisting of functions of auth module used by User module
 type AuthUserInterface interface {
 	IsReadOpAuthorised(ctx context.Context, project, dbType, col, token string, req *ReadRequest, stub ReturnWhereStub) (*PostProcess, RequestParams, error)
 	CreateToken(ctx context.Context, tokenClaims TokenClaims) (string, error)
+	CreateTokenWithTTL(ctx context.Context, tokenClaims TokenClaims, ttl time.Duration) (string, error)
 	IsUpdateOpAuthorised(ctx context.Context, project, dbType, col, token string, req *UpdateRequest) (RequestParams, error)
+	GetInternalAccessToken(ctx context.Context) (string, error)
+	ParseToken(ctx context.Context, token string) (map[string]interface{}, error)
+	RevokeTokenID(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeSubject(ctx context.Context, subject string) error
+}
+
+// EventingUserInterface is an interface consisting of functions of eventing module used by User module
+type EventingUserInterface interface {
+	QueueEvent(ctx context.Context, project, token string, req *QueueEventRequest) (interface{}, error)
 }
 
 // SyncmanEventingInterface is an interface consisting of functions of syncman module used by eventing module
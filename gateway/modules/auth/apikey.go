@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	authHelpers "github.com/spaceuptech/space-cloud/gateway/modules/auth/helpers"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// SetAPIKeys sets the api keys of the project
+func (m *Module) SetAPIKeys(apiKeys config.APIKeys) {
+	m.Lock()
+	defer m.Unlock()
+
+	keys := make(map[string]*config.APIKey, len(apiKeys))
+	for _, key := range apiKeys {
+		keys[key.ID] = key
+	}
+	m.apiKeys = keys
+}
+
+// parseToken returns the claims of the jwt or api key provided. Api keys additionally need to have
+// the scope provided, if any.
+func (m *Module) parseToken(ctx context.Context, token string, scope ...string) (map[string]interface{}, error) {
+	id, ok := authHelpers.ParseAPIKeyID(token)
+	if !ok {
+		return m.jwt.ParseToken(ctx, token)
+	}
+
+	key, p := m.apiKeys[id]
+	if !p || subtle.ConstantTimeCompare([]byte(key.Hash), []byte(authHelpers.HashAPIKey(token))) != 1 {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid api key provided", nil, nil)
+	}
+
+	if key.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, key.ExpiresAt)
+		if err != nil {
+			return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Invalid expiry set for api key (%s)", id), err, nil)
+		}
+		if time.Now().After(expiresAt) {
+			return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Api key (%s) has expired", id), nil, nil)
+		}
+	}
+
+	if len(key.AllowedIPs) > 0 {
+		if ip := utils.GetClientIPFromContext(ctx); !authHelpers.IsIPAllowed(ip, key.AllowedIPs) {
+			return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Api key (%s) cannot be used from ip (%s)", id, ip), nil, nil)
+		}
+	}
+
+	if len(scope) > 0 && !authHelpers.HasScope(key.Scopes, scope) {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Api key (%s) does not have the scope (%s)", id, strings.Join(scope, ":")), nil, nil)
+	}
+
+	// The claims configured for the key are made available to the rules along with the key details
+	scopes := make([]interface{}, len(key.Scopes))
+	for i, s := range key.Scopes {
+		scopes[i] = s
+	}
+	claims := make(map[string]interface{}, len(key.Claims)+1)
+	for k, v := range key.Claims {
+		claims[k] = v
+	}
+	claims["apiKey"] = map[string]interface{}{"id": key.ID, "name": key.Name, "scopes": scopes}
+	return claims, nil
+}
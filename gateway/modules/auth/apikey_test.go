@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	authHelpers "github.com/spaceuptech/space-cloud/gateway/modules/auth/helpers"
+	"github.com/spaceuptech/space-cloud/gateway/modules/crud"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+func TestModule_parseToken(t *testing.T) {
+	key, hash, err := authHelpers.GenerateAPIKey("backend")
+	if err != nil {
+		t.Fatalf("unable to generate api key - %s", err.Error())
+	}
+	expiredKey, expiredHash, _ := authHelpers.GenerateAPIKey("expired")
+	restrictedKey, restrictedHash, _ := authHelpers.GenerateAPIKey("restricted")
+
+	apiKeys := config.APIKeys{
+		"chicago--project--api-key--backend": {
+			ID:     "backend",
+			Name:   "Backend",
+			Hash:   hash,
+			Scopes: []string{"db:mydb:orders", "services:payments:*"},
+			Claims: map[string]interface{}{"role": "service"},
+		},
+		"chicago--project--api-key--expired": {
+			ID:        "expired",
+			Hash:      expiredHash,
+			Scopes:    []string{"*"},
+			ExpiresAt: time.Now().Add(-time.Hour).Format(time.RFC3339),
+		},
+		"chicago--project--api-key--restricted": {
+			ID:         "restricted",
+			Hash:       restrictedHash,
+			Scopes:     []string{"*"},
+			AllowedIPs: []string{"10.0.0.0/8", "203.0.113.7"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		ip      string
+		scope   []string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:  "valid key with table scope",
+			token: key,
+			scope: []string{"db", "mydb", "orders", "read"},
+			want:  map[string]interface{}{"role": "service", "apiKey": map[string]interface{}{"id": "backend", "name": "Backend", "scopes": []interface{}{"db:mydb:orders", "services:payments:*"}}},
+		},
+		{
+			name:  "valid key with wildcard scope",
+			token: key,
+			scope: []string{"services", "payments", "charge"},
+			want:  map[string]interface{}{"role": "service", "apiKey": map[string]interface{}{"id": "backend", "name": "Backend", "scopes": []interface{}{"db:mydb:orders", "services:payments:*"}}},
+		},
+		{
+			name:    "key without the scope",
+			token:   key,
+			scope:   []string{"db", "mydb", "users", "read"},
+			wantErr: true,
+		},
+		{
+			name:    "key with wrong secret",
+			token:   key[:len(key)-2] + "xx",
+			scope:   []string{"db", "mydb", "orders", "read"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			token:   authHelpers.APIKeyPrefix + "unknown.secret",
+			wantErr: true,
+		},
+		{
+			name:    "expired key",
+			token:   expiredKey,
+			wantErr: true,
+		},
+		{
+			name:  "key used from allowed range",
+			token: restrictedKey,
+			ip:    "10.2.3.4",
+			want:  map[string]interface{}{"apiKey": map[string]interface{}{"id": "restricted", "name": "", "scopes": []interface{}{"*"}}},
+		},
+		{
+			name:    "key used from ip not allowed",
+			token:   restrictedKey,
+			ip:      "198.51.100.1",
+			wantErr: true,
+		},
+	}
+
+	auth := Init("chicago", "1", &crud.Module{}, nil, nil)
+	auth.SetAPIKeys(apiKeys)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := utils.SetClientIPInContext(context.Background(), tt.ip)
+			got, err := auth.parseToken(ctx, tt.token, tt.scope...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseToken() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
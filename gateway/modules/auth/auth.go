@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
+	"github.com/google/cel-go/cel"
+	"github.com/open-policy-agent/opa/rego"
 	"github.com/spaceuptech/helpers"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
@@ -12,11 +15,12 @@ import (
 
 	"github.com/spaceuptech/space-cloud/gateway/utils"
 	jwtUtils "github.com/spaceuptech/space-cloud/gateway/utils/jwt"
+	"github.com/spaceuptech/space-cloud/gateway/utils/pubsub"
 )
 
 // Module is responsible for authentication and authorisation
 type Module struct {
-	sync.RWMutex
+	*sync.RWMutex
 	dbRules          config.DatabaseRules
 	dbPrepQueryRules config.DatabasePreparedQueries
 	clusterID        string
@@ -29,16 +33,36 @@ type Module struct {
 	project          string
 	fileStoreType    string
 	makeHTTPRequest  utils.TypeMakeHTTPRequest
-	aesKey           []byte
+	aesKeys          *utils.AESKeyRing
+
+	// Compiled cel expressions of the rules loaded
+	celPrograms map[string]cel.Program
+
+	// Compiled rego policies used by the rules
+	regoPolicies map[string]rego.PreparedEvalQuery
+
+	// Api keys of the project indexed by their id
+	apiKeys map[string]*config.APIKey
+
+	// Pubsub client used to store and propagate jwt revocations
+	pubsubClient *pubsub.Module
 
 	// Admin Manager
 	adminMan       adminMan
 	integrationMan integrationManagerInterface
 }
 
+// clone returns a copy of the module sharing its config. The setters replace the config instead of modifying it in
+// place and the copy is only used while the module is read locked, so nothing but the lock needs to be copied.
+func (m *Module) clone() *Module {
+	c := *m
+	c.RWMutex = new(sync.RWMutex)
+	return &c
+}
+
 // Init creates a new instance of the auth object
 func Init(clusterID, nodeID string, crud model.CrudAuthInterface, adminMan adminMan, integrationMan integrationManagerInterface) *Module {
-	return &Module{clusterID: clusterID, nodeID: nodeID, dbRules: make(config.DatabaseRules), dbPrepQueryRules: make(config.DatabasePreparedQueries), crud: crud, adminMan: adminMan, jwt: jwtUtils.New(), integrationMan: integrationMan}
+	return &Module{RWMutex: new(sync.RWMutex), clusterID: clusterID, nodeID: nodeID, dbRules: make(config.DatabaseRules), dbPrepQueryRules: make(config.DatabasePreparedQueries), crud: crud, adminMan: adminMan, jwt: jwtUtils.New(), integrationMan: integrationMan}
 }
 
 // GetInternalAccessToken returns the token that can be used internally by Space Cloud
@@ -60,7 +84,7 @@ func (m *Module) GetSCAccessToken(ctx context.Context) (string, error) {
 
 // IsSCAccessToken checks if its an SC access token
 func (m *Module) IsSCAccessToken(ctx context.Context, token string) error {
-	claims, err := m.ParseToken(ctx, token)
+	claims, err := m.jwt.ParseToken(ctx, token)
 	if err != nil {
 		return err
 	}
@@ -79,6 +103,11 @@ func (m *Module) CreateToken(ctx context.Context, tokenClaims model.TokenClaims)
 	return m.jwt.CreateToken(ctx, tokenClaims)
 }
 
+// CreateTokenWithTTL generates a new JWT Token with the token claims which expires after the ttl provided
+func (m *Module) CreateTokenWithTTL(ctx context.Context, tokenClaims model.TokenClaims, ttl time.Duration) (string, error) {
+	return m.jwt.CreateTokenWithTTL(ctx, tokenClaims, ttl)
+}
+
 // IsTokenInternal checks if the provided token is internally generated
 func (m *Module) IsTokenInternal(ctx context.Context, token string) error {
 	claims, err := m.jwt.ParseToken(ctx, token)
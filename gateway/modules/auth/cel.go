@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/operators"
+	"github.com/spaceuptech/helpers"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// celEnv declares the variables available to cel expressions. All of them are dynamically typed
+// since the shape of args and auth depends on the request.
+var celEnv, celEnvErr = cel.NewEnv(cel.Declarations(
+	decls.NewVar("args", decls.Dyn),
+	decls.NewVar("auth", decls.Dyn),
+	decls.NewVar("meta", decls.Dyn),
+))
+
+// compileCEL compiles a cel expression which must evaluate to a boolean
+func compileCEL(expr string) (cel.Program, error) {
+	if celEnvErr != nil {
+		return nil, celEnvErr
+	}
+
+	ast, issues := celEnv.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	// Numbers in the request are doubles, so the int literals they get compared with are turned into doubles as well
+	checked, err := cel.AstToCheckedExpr(ast)
+	if err != nil {
+		return nil, err
+	}
+	if coerceNumericLiterals(checked.Expr, checked.TypeMap) {
+		ast, issues = celEnv.Check(cel.ParsedExprToAst(&exprpb.ParsedExpr{Expr: checked.Expr, SourceInfo: checked.SourceInfo}))
+		if issues != nil && issues.Err() != nil {
+			return nil, issues.Err()
+		}
+	}
+	if !proto.Equal(ast.ResultType(), decls.Bool) && !proto.Equal(ast.ResultType(), decls.Dyn) {
+		return nil, fmt.Errorf("expression should evaluate to a bool and not %v", ast.ResultType())
+	}
+
+	return celEnv.Program(ast)
+}
+
+// compileCELRules compiles the cel expressions of all the rules loaded in the module. Invalid
+// expressions are only logged here, the rules using them get denied when evaluated.
+func (m *Module) compileCELRules() {
+	programs := map[string]cel.Program{}
+
+	var walk func(rule *config.Rule)
+	walk = func(rule *config.Rule) {
+		if rule == nil {
+			return
+		}
+		if rule.Rule == "cel" {
+			if _, p := programs[rule.Expr]; !p {
+				prg, err := compileCEL(rule.Expr)
+				if err != nil {
+					_ = helpers.Logger.LogError("compile-cel-rules", fmt.Sprintf("Unable to compile cel expression (%s) of rule (%s)", rule.Expr, rule.Name), err, nil)
+					return
+				}
+				programs[rule.Expr] = prg
+			}
+		}
+		for _, clause := range rule.Clauses {
+			walk(clause)
+		}
+		walk(rule.Clause)
+	}
+
+	for _, dbRule := range m.dbRules {
+		for _, rule := range dbRule.Rules {
+			walk(rule)
+		}
+	}
+	for _, preparedQuery := range m.dbPrepQueryRules {
+		walk(preparedQuery.Rule)
+	}
+	for _, fileRule := range m.fileRules {
+		for _, rule := range fileRule.Rule {
+			walk(rule)
+		}
+	}
+	for _, service := range m.funcRules {
+		for _, endpoint := range service.Endpoints {
+			walk(endpoint.Rule)
+		}
+	}
+	for _, rule := range m.eventingRules {
+		walk(rule)
+	}
+
+	m.celPrograms = programs
+}
+
+func (m *Module) matchCEL(ctx context.Context, project string, rule *config.Rule, args, auth map[string]interface{}) error {
+	prg, p := m.celPrograms[rule.Expr]
+	if !p {
+		// Rules which weren't loaded from the config (eg. while simulating) get compiled on the fly
+		var err error
+		prg, err = compileCEL(rule.Expr)
+		if err != nil {
+			return formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to compile cel expression (%s)", rule.Expr), err, nil))
+		}
+	}
+
+	out, _, err := prg.Eval(map[string]interface{}{
+		"args": normalizeCELValue(args["args"]),
+		"auth": normalizeCELValue(auth),
+		"meta": map[string]interface{}{"project": project, "time": time.Now(), "requestId": helpers.GetRequestID(ctx), "ip": utils.GetClientIPFromContext(ctx)},
+	})
+	if err != nil {
+		return formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to evaluate cel expression (%s)", rule.Expr), err, nil))
+	}
+
+	result, ok := out.Value().(bool)
+	if !ok {
+		return formatError(ctx, rule, fmt.Errorf("cel expression (%s) evaluated to (%v) instead of a bool", rule.Expr, out.Value()))
+	}
+	if !result {
+		return formatError(ctx, rule, errors.New("the cel expression evaluated to false"))
+	}
+	return nil
+}
+
+// comparisonOperators and arithmeticOperators are the operators whose int literals get turned into doubles when the
+// other operand is a double or dynamically typed
+var (
+	comparisonOperators = map[string]bool{
+		operators.Less:          true,
+		operators.LessEquals:    true,
+		operators.Greater:       true,
+		operators.GreaterEquals: true,
+		operators.Equals:        true,
+		operators.NotEquals:     true,
+	}
+	arithmeticOperators = map[string]bool{
+		operators.Add:      true,
+		operators.Subtract: true,
+		operators.Multiply: true,
+		operators.Divide:   true,
+	}
+)
+
+// coerceNumericLiterals turns the int literals used with numbers of the request (eg. `args.doc.amount < 1000`) into
+// doubles. Cel doesn't compare ints with doubles and all the numbers of the request are doubles. Literals used with
+// operands which are known to be ints, like the result of `size`, are left as they are. It reports whether any
+// literal was changed.
+func coerceNumericLiterals(expr *exprpb.Expr, types map[int64]*exprpb.Type) bool {
+	changed, _ := coerceNumericExpr(expr, types)
+	return changed
+}
+
+// coerceNumericExpr coerces the int literals of the expression and reports whether the expression evaluates to a
+// double (or may do so at runtime, in case it is dynamically typed)
+func coerceNumericExpr(expr *exprpb.Expr, types map[int64]*exprpb.Type) (changed, isDouble bool) {
+	if expr == nil {
+		return false, false
+	}
+	isDouble = proto.Equal(types[expr.Id], decls.Dyn) || proto.Equal(types[expr.Id], decls.Double)
+
+	switch e := expr.ExprKind.(type) {
+	case *exprpb.Expr_CallExpr:
+		call := e.CallExpr
+		changed, _ = coerceNumericExpr(call.Target, types)
+		doubleArgs := make([]bool, len(call.Args))
+		for i, arg := range call.Args {
+			argChanged, argDouble := coerceNumericExpr(arg, types)
+			changed = changed || argChanged
+			doubleArgs[i] = argDouble
+		}
+
+		if (comparisonOperators[call.Function] || arithmeticOperators[call.Function]) && len(call.Args) == 2 {
+			for i, arg := range call.Args {
+				if !doubleArgs[1-i] {
+					continue
+				}
+				if c, ok := arg.ExprKind.(*exprpb.Expr_ConstExpr); ok {
+					if v, ok := c.ConstExpr.ConstantKind.(*exprpb.Constant_Int64Value); ok {
+						c.ConstExpr.ConstantKind = &exprpb.Constant_DoubleValue{DoubleValue: float64(v.Int64Value)}
+						changed = true
+					}
+				}
+			}
+			if arithmeticOperators[call.Function] {
+				isDouble = isDouble || doubleArgs[0] || doubleArgs[1]
+			}
+		}
+
+	case *exprpb.Expr_SelectExpr:
+		changed, _ = coerceNumericExpr(e.SelectExpr.Operand, types)
+
+	case *exprpb.Expr_ListExpr:
+		for _, elem := range e.ListExpr.Elements {
+			elemChanged, _ := coerceNumericExpr(elem, types)
+			changed = changed || elemChanged
+		}
+
+	case *exprpb.Expr_StructExpr:
+		for _, entry := range e.StructExpr.Entries {
+			keyChanged, _ := coerceNumericExpr(entry.GetMapKey(), types)
+			valueChanged, _ := coerceNumericExpr(entry.Value, types)
+			changed = changed || keyChanged || valueChanged
+		}
+
+	case *exprpb.Expr_ComprehensionExpr:
+		comp := e.ComprehensionExpr
+		for _, sub := range []*exprpb.Expr{comp.IterRange, comp.AccuInit, comp.LoopCondition, comp.LoopStep, comp.Result} {
+			subChanged, _ := coerceNumericExpr(sub, types)
+			changed = changed || subChanged
+		}
+	}
+	return changed, isDouble
+}
+
+// normalizeCELValue converts all numbers to doubles, the way they get decoded from json. The int literals of the
+// expressions get compared with these as doubles as well (see coerceNumericLiterals).
+func normalizeCELValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			out[key] = normalizeCELValue(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = normalizeCELValue(val)
+		}
+		return out
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
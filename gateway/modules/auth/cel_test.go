@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/modules/crud"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+func TestModule_matchCEL(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		args    map[string]interface{}
+		auth    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "role in list and amount within limit",
+			expr: "auth.role in ['admin', 'ops'] && args.doc.amount < 1000.0",
+			args: map[string]interface{}{"doc": map[string]interface{}{"amount": float64(500)}},
+			auth: map[string]interface{}{"role": "ops"},
+		},
+		{
+			name:    "amount exceeds limit",
+			expr:    "auth.role in ['admin', 'ops'] && args.doc.amount < 1000.0",
+			args:    map[string]interface{}{"doc": map[string]interface{}{"amount": float64(5000)}},
+			auth:    map[string]interface{}{"role": "admin"},
+			wantErr: true,
+		},
+		{
+			name: "fractional amount within limit",
+			expr: "args.doc.amount < 1000.0 && args.doc.quantity == 2.0",
+			args: map[string]interface{}{"doc": map[string]interface{}{"amount": 999.5, "quantity": 2}},
+		},
+		{
+			name: "number compared with an int literal",
+			expr: "args.doc.amount < 1000",
+			args: map[string]interface{}{"doc": map[string]interface{}{"amount": 999.5}},
+		},
+		{
+			name:    "number exceeding an int literal",
+			expr:    "args.doc.amount < 1000",
+			args:    map[string]interface{}{"doc": map[string]interface{}{"amount": float64(5000)}},
+			wantErr: true,
+		},
+		{
+			name: "int literals in equality and arithmetic",
+			expr: "args.doc.quantity == 2 && args.doc.amount * 2 >= 1000 && -1 < args.doc.amount",
+			args: map[string]interface{}{"doc": map[string]interface{}{"amount": float64(500), "quantity": float64(2)}},
+		},
+		{
+			name: "int literals with ints",
+			expr: "size(args.doc.tags) > 1 && args.doc.tags[0] == 'a'",
+			args: map[string]interface{}{"doc": map[string]interface{}{"tags": []interface{}{"a", "b"}}},
+		},
+		{
+			name: "fractional comparison",
+			expr: "double(args.doc.price) < 10.5 && args.doc.discount > 0.25",
+			args: map[string]interface{}{"doc": map[string]interface{}{"price": float64(10), "discount": 0.5}},
+		},
+		{
+			name: "request metadata",
+			expr: "meta.project == 'project' && meta.time > timestamp('2020-01-01T00:00:00Z') && meta.ip == '10.0.0.1' && meta.requestId == 'request'",
+		},
+		{
+			name:    "missing field",
+			expr:    "args.doc.owner == auth.id",
+			args:    map[string]interface{}{"doc": map[string]interface{}{}},
+			auth:    map[string]interface{}{"id": "1"},
+			wantErr: true,
+		},
+		{
+			name:    "expression not evaluating to bool",
+			expr:    "1 + 1",
+			wantErr: true,
+		},
+		{
+			name:    "invalid expression",
+			expr:    "auth.role ==",
+			wantErr: true,
+		},
+	}
+
+	auth := Init("chicago", "1", &crud.Module{}, nil, nil)
+	if err := auth.SetConfig(context.TODO(), "local", &config.ProjectConfig{ID: "project", Secrets: []*config.Secret{{IsPrimary: true, Secret: "mySecretKey"}}}, config.DatabaseRules{}, config.DatabasePreparedQueries{}, config.FileStoreRules{}, config.Services{}, config.EventingRules{}); err != nil {
+		t.Fatalf("error setting config of auth module - %s", err.Error())
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &config.Rule{Rule: "cel", Expr: tt.expr}
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set(helpers.HeaderRequestID, "request")
+			ctx := utils.SetClientIPInContext(helpers.CreateContext(r), "10.0.0.1")
+			_, err := auth.MatchRule(ctx, "project", rule, map[string]interface{}{"args": tt.args}, tt.auth, model.ReturnWhereStub{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MatchRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestModule_compileCELRules(t *testing.T) {
+	dbRules := config.DatabaseRules{
+		"chicago--project--db-rule--mongo-tweet-rule": &config.DatabaseRule{Rules: map[string]*config.Rule{
+			"read":   {Rule: "cel", Expr: "auth.role == 'admin'"},
+			"create": {Rule: "and", Clauses: []*config.Rule{{Rule: "cel", Expr: "args.doc.amount < 1000.0"}, {Rule: "cel", Expr: "auth.role =="}}},
+		}},
+	}
+	eventingRules := config.EventingRules{
+		"chicago--project--eventing-rule--my-event": {Rule: "cel", Expr: "auth.role == 'admin'"},
+	}
+
+	auth := Init("chicago", "1", &crud.Module{}, nil, nil)
+	if err := auth.SetConfig(context.TODO(), "local", &config.ProjectConfig{ID: "project", Secrets: []*config.Secret{{IsPrimary: true, Secret: "mySecretKey"}}}, dbRules, config.DatabasePreparedQueries{}, config.FileStoreRules{}, config.Services{}, eventingRules); err != nil {
+		t.Fatalf("error setting config of auth module - %s", err.Error())
+	}
+
+	// Identical expressions are compiled once while invalid ones are skipped
+	if len(auth.celPrograms) != 2 {
+		t.Errorf("compileCELRules() compiled %d expressions, want 2", len(auth.celPrograms))
+	}
+	for _, expr := range []string{"auth.role == 'admin'", "args.doc.amount < 1000.0"} {
+		if _, p := auth.celPrograms[expr]; !p {
+			t.Errorf("compileCELRules() expression (%s) not compiled", expr)
+		}
+	}
+}
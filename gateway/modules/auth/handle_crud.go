@@ -290,7 +290,7 @@ func (m *Module) authenticateCrudRequest(ctx context.Context, projectID, dbAlias
 	}
 
 	// Parse token
-	auth, err = m.jwt.ParseToken(ctx, token)
+	auth, err = m.parseToken(ctx, token, "db", dbAlias, col, string(op))
 	return
 }
 
@@ -307,7 +307,7 @@ func (m *Module) authenticatePreparedQueryRequest(ctx context.Context, projectID
 	}
 
 	// Parse token
-	auth, err = m.jwt.ParseToken(ctx, token)
+	auth, err = m.parseToken(ctx, token, "prepared-query", dbAlias, id)
 	return
 }
 
@@ -28,7 +28,7 @@ func (m *Module) IsEventingOpAuthorised(ctx context.Context, project, token stri
 
 	var auth map[string]interface{}
 	if rule.Rule != "allow" {
-		auth, err = m.jwt.ParseToken(ctx, token)
+		auth, err = m.parseToken(ctx, token, "eventing", event.Type)
 		if err != nil {
 			return model.RequestParams{}, err
 		}
@@ -31,7 +31,7 @@ func (m *Module) IsFileOpAuthorised(ctx context.Context, project, token, path st
 	}
 
 	var auth map[string]interface{}
-	auth, err = m.jwt.ParseToken(ctx, token)
+	auth, err = m.parseToken(ctx, token, "file", string(op))
 	if err != nil {
 		return nil, err
 	}
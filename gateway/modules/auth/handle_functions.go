@@ -28,7 +28,7 @@ func (m *Module) IsFuncCallAuthorised(ctx context.Context, project, service, fun
 
 	var auth map[string]interface{}
 	if rule.Rule != "allow" {
-		auth, err = m.jwt.ParseToken(ctx, token)
+		auth, err = m.parseToken(ctx, token, "services", service, function)
 		if err != nil {
 			return nil, model.RequestParams{}, err
 		}
@@ -0,0 +1,121 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+// APIKeyPrefix is the prefix of the api keys issued by space cloud. It lets api keys be told apart from jwts.
+const APIKeyPrefix = "sc_"
+
+// GenerateAPIKey generates a new api key for the id provided. It returns the key along with the hash to be stored.
+func GenerateAPIKey(id string) (key, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	key = APIKeyPrefix + id + "." + base64.RawURLEncoding.EncodeToString(secret)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash of the api key stored in the config
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// ParseAPIKeyID returns the id of the api key provided. The boolean returned is false if the token isn't an api key.
+func ParseAPIKeyID(token string) (string, bool) {
+	if !strings.HasPrefix(token, APIKeyPrefix) {
+		return "", false
+	}
+
+	token = strings.TrimPrefix(token, APIKeyPrefix)
+	index := strings.LastIndex(token, ".")
+	if index <= 0 {
+		return "", false
+	}
+	return token[:index], true
+}
+
+// ValidateAPIKey checks if the fields of the api key are valid
+func ValidateAPIKey(key *config.APIKey) error {
+	if key.ID == "" || strings.Contains(key.ID, ".") {
+		return fmt.Errorf("invalid api key id (%s) provided, it cannot be empty or contain a dot", key.ID)
+	}
+	if len(key.Scopes) == 0 {
+		return errors.New("api key should have at least one scope")
+	}
+	if key.ExpiresAt != "" {
+		if _, err := time.Parse(time.RFC3339, key.ExpiresAt); err != nil {
+			return fmt.Errorf("invalid expiry (%s) provided for api key, it should be in RFC3339 format", key.ExpiresAt)
+		}
+	}
+	for _, ip := range key.AllowedIPs {
+		if strings.Contains(ip, "/") {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return fmt.Errorf("invalid cidr range (%s) provided in allowed ips of api key", ip)
+			}
+			continue
+		}
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid ip (%s) provided in allowed ips of api key", ip)
+		}
+	}
+	return nil
+}
+
+// IsIPAllowed checks if the ip provided matches one of the ips or cidr ranges allowed
+func IsIPAllowed(ip string, allowedIPs []string) bool {
+	clientIP := net.ParseIP(ip)
+	if clientIP == nil {
+		return false
+	}
+
+	for _, allowed := range allowedIPs {
+		if strings.Contains(allowed, "/") {
+			if _, ipNet, err := net.ParseCIDR(allowed); err == nil && ipNet.Contains(clientIP) {
+				return true
+			}
+			continue
+		}
+		if clientIP.Equal(net.ParseIP(allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope checks if any of the scopes of an api key grants access to the scope requested. Each segment
+// of a scope either matches the requested segment or is a `*`. Scopes with fewer segments grant access to
+// everything below them.
+func HasScope(scopes []string, requested []string) bool {
+	for _, scope := range scopes {
+		segments := strings.Split(scope, ":")
+		if len(segments) > len(requested) {
+			continue
+		}
+
+		matched := true
+		for i, segment := range segments {
+			if segment != "*" && segment != requested[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+func TestParseAPIKeyID(t *testing.T) {
+	key, hash, err := GenerateAPIKey("backend-1")
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() error = %v", err)
+	}
+	if hash != HashAPIKey(key) {
+		t.Errorf("GenerateAPIKey() returned hash which doesn't match the key")
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		want   string
+		wantOk bool
+	}{
+		{name: "generated key", token: key, want: "backend-1", wantOk: true},
+		{name: "jwt", token: "eyJhbGciOiJIUzI1NiJ9.eyJpZCI6IjEifQ.sig"},
+		{name: "prefix without secret", token: APIKeyPrefix + "backend"},
+		{name: "empty id", token: APIKeyPrefix + ".secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseAPIKeyID(tt.token)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ParseAPIKeyID() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		scopes    []string
+		requested []string
+		want      bool
+	}{
+		{name: "exact scope", scopes: []string{"db:mydb:orders:read"}, requested: []string{"db", "mydb", "orders", "read"}, want: true},
+		{name: "different op", scopes: []string{"db:mydb:orders:read"}, requested: []string{"db", "mydb", "orders", "delete"}, want: false},
+		{name: "scope of whole database", scopes: []string{"db:mydb"}, requested: []string{"db", "mydb", "orders", "delete"}, want: true},
+		{name: "wildcard segment", scopes: []string{"db:*:orders:read"}, requested: []string{"db", "pg", "orders", "read"}, want: true},
+		{name: "everything", scopes: []string{"*"}, requested: []string{"eventing", "user-created"}, want: true},
+		{name: "more specific than requested", scopes: []string{"services:payments:charge"}, requested: []string{"services", "payments"}, want: false},
+		{name: "no scopes", requested: []string{"routes"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasScope(tt.scopes, tt.requested); got != tt.want {
+				t.Errorf("HasScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     *config.APIKey
+		wantErr bool
+	}{
+		{name: "valid key", key: &config.APIKey{ID: "backend", Scopes: []string{"*"}, AllowedIPs: []string{"10.0.0.0/8", "::1"}, ExpiresAt: "2030-01-01T00:00:00Z"}},
+		{name: "id with dot", key: &config.APIKey{ID: "back.end", Scopes: []string{"*"}}, wantErr: true},
+		{name: "no scopes", key: &config.APIKey{ID: "backend"}, wantErr: true},
+		{name: "invalid expiry", key: &config.APIKey{ID: "backend", Scopes: []string{"*"}, ExpiresAt: "tomorrow"}, wantErr: true},
+		{name: "invalid ip", key: &config.APIKey{ID: "backend", Scopes: []string{"*"}, AllowedIPs: []string{"10.0.0.300"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAPIKey(tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,9 +2,7 @@ package helpers
 
 import (
 	"context"
-	"crypto/aes"
 	"crypto/sha256"
-	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -16,7 +14,7 @@ import (
 )
 
 // PostProcessMethod to do processing on result
-func PostProcessMethod(ctx context.Context, aesKey []byte, postProcess *model.PostProcess, result interface{}) error {
+func PostProcessMethod(ctx context.Context, aesKeys *utils.AESKeyRing, postProcess *model.PostProcess, result interface{}) error {
 	// Gracefully exits if the result is nil
 	if result == nil || postProcess == nil {
 		return nil
@@ -56,7 +54,7 @@ func PostProcessMethod(ctx context.Context, aesKey []byte, postProcess *model.Po
 				if !ok {
 					return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid data type found", fmt.Errorf("value should be of type string got (%T)", loadedValue), nil)
 				}
-				encryptedValue, err := utils.Encrypt(aesKey, stringValue)
+				encryptedValue, err := aesKeys.Encrypt(stringValue)
 				if err != nil {
 					return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to encrypt string in post process", err, map[string]interface{}{"valueToEncrypt": stringValue})
 				}
@@ -74,16 +72,11 @@ func PostProcessMethod(ctx context.Context, aesKey []byte, postProcess *model.Po
 				if !ok {
 					return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid data type found", fmt.Errorf("value should be of type string got (%T)", loadedValue), map[string]interface{}{"decrypt": true})
 				}
-				decodedValue, err := base64.StdEncoding.DecodeString(stringValue)
+				decrypted, err := aesKeys.Decrypt(stringValue)
 				if err != nil {
-					return err
-				}
-				decrypted := make([]byte, len(decodedValue))
-				err1 := DecryptAESCFB(decrypted, decodedValue, aesKey, aesKey[:aes.BlockSize])
-				if err1 != nil {
-					return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to decrypt string in post process", err1, map[string]interface{}{"valueToDecrypt": decodedValue})
+					return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to decrypt string in post process", err, map[string]interface{}{"valueToDecrypt": stringValue})
 				}
-				er := utils.StoreValue(ctx, field.Field, string(decrypted), map[string]interface{}{"res": doc})
+				er := utils.StoreValue(ctx, field.Field, decrypted, map[string]interface{}{"res": doc})
 				if er != nil {
 					return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to store value in post process", er, map[string]interface{}{"decrypt": true})
 				}
@@ -97,9 +90,10 @@ func PostProcessMethod(ctx context.Context, aesKey []byte, postProcess *model.Po
 				if !ok {
 					return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid data type found", fmt.Errorf("value should be of type string got (%T)", loadedValue), map[string]interface{}{"hash": true})
 				}
-				h := sha256.New()
-				_, _ = h.Write([]byte(stringValue))
-				hashed := hex.EncodeToString(h.Sum(nil))
+				hashed, err := hashValue(field.Value, stringValue)
+				if err != nil {
+					return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to hash value in post process", err, map[string]interface{}{"hash": true})
+				}
 				er := utils.StoreValue(ctx, field.Field, hashed, map[string]interface{}{"res": doc})
 				if er != nil {
 					return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to store value in post process", er, map[string]interface{}{"hash": true})
@@ -113,3 +107,14 @@ func PostProcessMethod(ctx context.Context, aesKey []byte, postProcess *model.Po
 	}
 	return nil
 }
+
+// hashValue hashes a value of the result with the algorithm of the hash rule. Values are hex encoded sha256
+// digests if an algorithm wasn't provided.
+func hashValue(algorithm interface{}, value string) (string, error) {
+	if algo, ok := algorithm.(string); ok && algo != "" && algo != utils.HashAlgorithmSHA256 {
+		return utils.HashValue(algo, value)
+	}
+	h := sha256.New()
+	_, _ = h.Write([]byte(value))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
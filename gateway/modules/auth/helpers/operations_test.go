@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
 )
 
 func TestPostProcessMethod(t *testing.T) {
@@ -188,7 +189,8 @@ func TestPostProcessMethod(t *testing.T) {
 
 	for _, test := range authMatchQuery {
 		t.Run(test.testName, func(t *testing.T) {
-			err := PostProcessMethod(context.Background(), test.aesKey, test.postProcess, test.result)
+			aesKeys, _ := utils.NewAESKeyRing(test.aesKey, nil)
+			err := PostProcessMethod(context.Background(), aesKeys, test.postProcess, test.result)
 			if (err != nil) != test.IsErrExpected {
 				t.Error("Success GoErr", err, "Want Error", test.IsErrExpected)
 				return
@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+// PrepareRegoPolicy compiles the rego module of a security policy. The query prepared evaluates to the
+// document of the package declared by the module.
+func PrepareRegoPolicy(ctx context.Context, policy *config.SecurityPolicy) (rego.PreparedEvalQuery, error) {
+	module, err := ast.ParseModule(policy.ID+".rego", policy.Module)
+	if err != nil {
+		return rego.PreparedEvalQuery{}, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to parse rego module of security policy (%s)", policy.ID), err, nil)
+	}
+	if module == nil {
+		return rego.PreparedEvalQuery{}, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Empty rego module provided for security policy (%s)", policy.ID), nil, nil)
+	}
+
+	query, err := rego.New(
+		rego.Query(module.Package.Path.String()),
+		rego.ParsedModule(module),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return rego.PreparedEvalQuery{}, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to compile rego module of security policy (%s)", policy.ID), err, nil)
+	}
+	return query, nil
+}
@@ -2,8 +2,6 @@ package auth
 
 import (
 	"context"
-	"crypto/aes"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
@@ -13,7 +11,6 @@ import (
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/model"
-	authHelpers "github.com/spaceuptech/space-cloud/gateway/modules/auth/helpers"
 	"github.com/spaceuptech/space-cloud/gateway/utils"
 )
 
@@ -26,6 +23,24 @@ func (m *Module) MatchRule(ctx context.Context, project string, rule *config.Rul
 }
 
 func (m *Module) matchRule(ctx context.Context, project string, rule *config.Rule, args, auth map[string]interface{}, returnWhere model.ReturnWhereStub) (*model.PostProcess, error) {
+	// Record the result of each clause if the rule is being simulated
+	parent, ok := ctx.Value(ruleTraceKey{}).(*model.RuleTrace)
+	if !ok {
+		return m.evaluateRule(ctx, project, rule, args, auth, returnWhere)
+	}
+
+	trace := &model.RuleTrace{Rule: rule.Rule, Name: rule.Name, Result: model.RuleTraceAllowed}
+	parent.Clauses = append(parent.Clauses, trace)
+
+	postProcess, err := m.evaluateRule(context.WithValue(ctx, ruleTraceKey{}, trace), project, rule, args, auth, returnWhere)
+	if err != nil {
+		trace.Result = model.RuleTraceDenied
+		trace.Error = err.Error()
+	}
+	return postProcess, err
+}
+
+func (m *Module) evaluateRule(ctx context.Context, project string, rule *config.Rule, args, auth map[string]interface{}, returnWhere model.ReturnWhereStub) (*model.PostProcess, error) {
 	if project != m.project {
 		return nil, formatError(ctx, rule, errors.New("invalid project details provided"))
 	}
@@ -53,6 +68,12 @@ func (m *Module) matchRule(ctx context.Context, project string, rule *config.Rul
 	case "or":
 		return m.matchOr(ctx, project, rule, args, auth, returnWhere)
 
+	case "cel":
+		return nil, m.matchCEL(ctx, project, rule, args, auth)
+
+	case "rego":
+		return nil, m.matchRego(ctx, project, rule, args, auth, returnWhere)
+
 	case "webhook":
 		return nil, m.matchFunc(ctx, rule, m.makeHTTPRequest, args)
 
@@ -74,6 +95,9 @@ func (m *Module) matchRule(ctx context.Context, project string, rule *config.Rul
 	case "hash":
 		return m.matchHash(ctx, project, rule, args, auth)
 
+	case "verify-hash":
+		return nil, matchVerifyHash(ctx, rule, args)
+
 	default:
 		return nil, formatError(ctx, rule, fmt.Errorf("invalid rule type (%s) provided", rule.Rule))
 	}
@@ -320,7 +344,7 @@ func (m *Module) matchEncrypt(ctx context.Context, projectID string, rule *confi
 			if !ok {
 				return nil, formatError(ctx, rule, fmt.Errorf("Value should be of type string and not %T", loadedValue))
 			}
-			encryptedValue, err := utils.Encrypt(m.aesKey, stringValue)
+			encryptedValue, err := m.aesKeys.Encrypt(stringValue)
 			if err != nil {
 				return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to encrypt string", err, map[string]interface{}{"valueToEncrypt": stringValue})
 			}
@@ -366,16 +390,11 @@ func (m *Module) matchDecrypt(ctx context.Context, projectID string, rule *confi
 			if !ok {
 				return nil, formatError(ctx, rule, fmt.Errorf("Value should be of type string and not %T", loadedValue))
 			}
-			decodedValue, err := base64.StdEncoding.DecodeString(stringValue)
+			decrypted, err := m.aesKeys.Decrypt(stringValue)
 			if err != nil {
-				return nil, formatError(ctx, rule, err)
-			}
-			decrypted := make([]byte, len(decodedValue))
-			err1 := authHelpers.DecryptAESCFB(decrypted, decodedValue, m.aesKey, m.aesKey[:aes.BlockSize])
-			if err1 != nil {
 				return nil, formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), "error decrypting value in matchDecrypt", err, nil))
 			}
-			er := utils.StoreValue(ctx, field, string(decrypted), args)
+			er := utils.StoreValue(ctx, field, decrypted, args)
 			if er != nil {
 				return nil, formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), "error storing value in matchDecrypt", err, nil))
 			}
@@ -407,6 +426,9 @@ func (m *Module) matchHash(ctx context.Context, projectID string, rule *config.R
 		}
 		if strings.HasPrefix(field, "res") {
 			addToStruct := model.PostProcessAction{Action: "hash", Field: field}
+			if rule.Algorithm != "" {
+				addToStruct.Value = rule.Algorithm
+			}
 			actions.PostProcessAction = append(actions.PostProcessAction, addToStruct)
 		} else if strings.HasPrefix(field, "args") {
 			loadedValue, err := utils.LoadValue(field, args)
@@ -417,7 +439,10 @@ func (m *Module) matchHash(ctx context.Context, projectID string, rule *config.R
 			if !ok {
 				return nil, formatError(ctx, rule, fmt.Errorf("Value should be of type string and not %T", loadedValue))
 			}
-			hashed := utils.HashString(stringValue)
+			hashed, err := utils.HashValue(rule.Algorithm, stringValue)
+			if err != nil {
+				return nil, formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), "error hashing value in matchHash", err, nil))
+			}
 			er := utils.StoreValue(ctx, field, hashed, args)
 			if er != nil {
 				return nil, formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), "error storing value in matchHash", err, nil))
@@ -428,3 +453,33 @@ func (m *Module) matchHash(ctx context.Context, projectID string, rule *config.R
 	}
 	return actions, nil
 }
+
+// matchVerifyHash checks if the value in the first field matches the hash in the second field
+func matchVerifyHash(ctx context.Context, rule *config.Rule, args map[string]interface{}) error {
+	f1, ok := rule.F1.(string)
+	if !ok {
+		return formatError(ctx, rule, ErrIncorrectRuleFieldType)
+	}
+	f2, ok := rule.F2.(string)
+	if !ok {
+		return formatError(ctx, rule, ErrIncorrectRuleFieldType)
+	}
+
+	value, err := utils.LoadStringIfExists(f1, args)
+	if err != nil {
+		return formatError(ctx, rule, err)
+	}
+	hash, err := utils.LoadStringIfExists(f2, args)
+	if err != nil {
+		return formatError(ctx, rule, err)
+	}
+
+	matched, err := utils.VerifyHash(hash, value)
+	if err != nil {
+		return formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to verify hash in security rule (verify-hash)", err, nil))
+	}
+	if !matched {
+		return formatError(ctx, rule, errors.New("provided value does not match the hash"))
+	}
+	return nil
+}
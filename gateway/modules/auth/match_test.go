@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/getlantern/deepcopy"
@@ -337,6 +338,11 @@ func base64DecodeString(key string) []byte {
 	return decodedKey
 }
 
+func legacyAESKeyRing(key string) *utils.AESKeyRing {
+	aesKeys, _ := utils.NewAESKeyRing(base64DecodeString(key), nil)
+	return aesKeys
+}
+
 func TestModule_matchEncrypt(t *testing.T) {
 	type args struct {
 		rule *config.Rule
@@ -352,71 +358,71 @@ func TestModule_matchEncrypt(t *testing.T) {
 	}{
 		{
 			name:    "invalid field",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: []string{"args.abc"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
 		{
 			name:    "invalid value type",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: []string{"args.username"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": 10}}},
 			wantErr: true,
 		},
 		{
 			name:    "invalid key",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: []string{"args.username"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
 		{
 			name:    "invalid field prefix",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: []string{"abc.username"}}, args: map[string]interface{}{"abc": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
 		{
 			name:         "valid args",
-			m:            &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:            &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:         args{rule: &config.Rule{Rule: "encrypt", Fields: []interface{}{"args.username"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "username1"}}},
 			want:         &model.PostProcess{},
 			shouldChange: true,
 		},
 		{
 			name:         "valid res",
-			m:            &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:            &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:         args{rule: &config.Rule{Rule: "encrypt", Fields: []interface{}{"res.username"}}, args: map[string]interface{}{"res": map[string]interface{}{"username": "username1"}}},
 			want:         &model.PostProcess{PostProcessAction: []model.PostProcessAction{{Action: "encrypt", Field: "res.username"}}},
 			shouldChange: false,
 		},
 		{
 			name: "Provide values to encrypt fields from args object",
-			m:    &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:    &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args: args{rule: &config.Rule{Rule: "encrypt", Fields: "args.auth.obj"}, args: map[string]interface{}{"args": map[string]interface{}{"auth": map[string]interface{}{"obj": []interface{}{"res.username"}}}, "res": map[string]interface{}{"username": "username1"}}},
 			want: &model.PostProcess{PostProcessAction: []model.PostProcessAction{{Action: "encrypt", Field: "res.username"}}},
 		},
 		{
 			name:         "valid args with rule allow",
-			m:            &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:            &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:         args{rule: &config.Rule{Rule: "encrypt", Fields: []interface{}{"args.username"}, Clause: &config.Rule{Rule: "allow"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "username1"}}},
 			want:         &model.PostProcess{},
 			shouldChange: true,
 		},
 		{
 			name:         "valid args with rule deny",
-			m:            &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:            &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:         args{rule: &config.Rule{Rule: "encrypt", Fields: []string{"args.username"}, Clause: &config.Rule{Rule: "deny"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "username1"}}},
 			want:         &model.PostProcess{},
 			shouldChange: false,
 		},
 		{
 			name:    "Invalid value provide for get fields",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: 1}, args: map[string]interface{}{"abc": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
 		{
 			name:    "Throw error if fields field contains a value which is not string",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: []interface{}{"res.username", 1}}, args: map[string]interface{}{"res": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
@@ -460,70 +466,70 @@ func TestModule_matchDecrypt(t *testing.T) {
 	}{
 		{
 			name:    "invalid field",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "decrypt", Fields: []string{"args.abc"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
 		{
 			name:    "invalid value type",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g")},
 			args:    args{rule: &config.Rule{Rule: "decrypt", Fields: []string{"args.username"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": 10}}},
 			wantErr: true,
 		},
 		{
 			name:    "invalid key",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g")},
 			args:    args{rule: &config.Rule{Rule: "decrypt", Fields: []string{"args.username"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
 		{
 			name:         "valid args",
-			m:            &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:            &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:         args{rule: &config.Rule{Rule: "decrypt", Fields: []interface{}{"args.username"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "BXioRN4GyvZs"}}},
 			want:         &model.PostProcess{},
 			shouldChange: true,
 		},
 		{
 			name: "valid res",
-			m:    &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:    &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args: args{rule: &config.Rule{Rule: "decrypt", Fields: []interface{}{"res.username"}}, args: map[string]interface{}{"res": map[string]interface{}{"username": "username1"}}},
 			want: &model.PostProcess{PostProcessAction: []model.PostProcessAction{{Action: "decrypt", Field: "res.username"}}},
 		},
 		{
 			name: "Provide values to decrypt fields from args object",
-			m:    &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:    &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args: args{rule: &config.Rule{Rule: "encrypt", Fields: "args.auth.obj"}, args: map[string]interface{}{"args": map[string]interface{}{"auth": map[string]interface{}{"obj": []interface{}{"res.username"}}}, "res": map[string]interface{}{"username": "username1"}}},
 			want: &model.PostProcess{PostProcessAction: []model.PostProcessAction{{Action: "decrypt", Field: "res.username"}}},
 		},
 		{
 			name:    "invalid field prefix",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "decrypt", Fields: []string{"abc.username"}}, args: map[string]interface{}{"abc": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
 		{
 			name:         "valid args with rule allow",
-			m:            &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:            &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:         args{rule: &config.Rule{Rule: "decrypt", Fields: []interface{}{"args.username"}, Clause: &config.Rule{Rule: "allow"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "BXioRN4GyvZs"}}},
 			want:         &model.PostProcess{},
 			shouldChange: true,
 		},
 		{
 			name:         "valid args with rule deny",
-			m:            &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:            &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:         args{rule: &config.Rule{Rule: "decrypt", Fields: []string{"args.username"}, Clause: &config.Rule{Rule: "deny"}}, args: map[string]interface{}{"args": map[string]interface{}{"username": "BXioRN4GyvZs"}}},
 			want:         &model.PostProcess{},
 			shouldChange: false,
 		},
 		{
 			name:    "Invalid value provide for get fields",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: 1}, args: map[string]interface{}{"abc": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
 		{
 			name:    "Throw error if fields field contains a value which is not string",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: []interface{}{"res.username", 1}}, args: map[string]interface{}{"res": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
@@ -567,7 +573,7 @@ func Test_matchHash(t *testing.T) {
 	}{
 		{
 			name:    "invalid field",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "hash", Fields: []string{"args.abc"}}, args: map[string]interface{}{"args": map[string]interface{}{"password": "password"}}},
 			wantErr: true,
 		},
@@ -579,25 +585,41 @@ func Test_matchHash(t *testing.T) {
 		},
 		{
 			name: "valid res",
-			m:    &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:    &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args: args{rule: &config.Rule{Rule: "hash", Fields: []interface{}{"res.password"}}, args: map[string]interface{}{"res": map[string]interface{}{"password": "password"}}},
 			want: &model.PostProcess{PostProcessAction: []model.PostProcessAction{{Action: "hash", Field: "res.password"}}},
 		},
 		{
 			name: "Provide values to hash fields from args object",
-			m:    &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:    &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args: args{rule: &config.Rule{Rule: "encrypt", Fields: "args.auth.obj"}, args: map[string]interface{}{"args": map[string]interface{}{"auth": map[string]interface{}{"obj": []interface{}{"res.username"}}}, "res": map[string]interface{}{"username": "username1"}}},
 			want: &model.PostProcess{PostProcessAction: []model.PostProcessAction{{Action: "hash", Field: "res.username"}}},
 		},
+		{
+			name:         "valid args with algorithm",
+			args:         args{rule: &config.Rule{Rule: "hash", Fields: []interface{}{"args.password"}, Algorithm: "argon2id"}, args: map[string]interface{}{"args": map[string]interface{}{"password": "password"}}},
+			want:         &model.PostProcess{},
+			shouldChange: true,
+		},
+		{
+			name: "valid res with algorithm",
+			args: args{rule: &config.Rule{Rule: "hash", Fields: []interface{}{"res.password"}, Algorithm: "bcrypt"}, args: map[string]interface{}{"res": map[string]interface{}{"password": "password"}}},
+			want: &model.PostProcess{PostProcessAction: []model.PostProcessAction{{Action: "hash", Field: "res.password", Value: "bcrypt"}}},
+		},
+		{
+			name:    "invalid algorithm",
+			args:    args{rule: &config.Rule{Rule: "hash", Fields: []interface{}{"args.password"}, Algorithm: "md5"}, args: map[string]interface{}{"args": map[string]interface{}{"password": "password"}}},
+			wantErr: true,
+		},
 		{
 			name:    "invalid value type",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "hash", Fields: []string{"args.password"}}, args: map[string]interface{}{"args": map[string]interface{}{"password": 123456}}},
 			wantErr: true,
 		},
 		{
 			name:    "invalid field prefix",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "hash", Fields: []string{"abc.password"}}, args: map[string]interface{}{"abc": map[string]interface{}{"password": "password"}}},
 			wantErr: true,
 		},
@@ -615,13 +637,13 @@ func Test_matchHash(t *testing.T) {
 		},
 		{
 			name:    "Invalid value provide for get fields",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: 1}, args: map[string]interface{}{"abc": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
 		{
 			name:    "Throw error if fields field contains a value which is not string",
-			m:       &Module{aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:       &Module{aesKeys: legacyAESKeyRing("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
 			args:    args{rule: &config.Rule{Rule: "encrypt", Fields: []interface{}{"res.username", 1}}, args: map[string]interface{}{"res": map[string]interface{}{"username": "username1"}}},
 			wantErr: true,
 		},
@@ -651,6 +673,56 @@ func Test_matchHash(t *testing.T) {
 	}
 }
 
+func Test_matchVerifyHash(t *testing.T) {
+	argon2Hash, err := utils.HashValue(utils.HashAlgorithmArgon2id, "password")
+	if err != nil {
+		t.Fatalf("HashValue() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		rule    *config.Rule
+		args    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "matching argon2id hash",
+			rule: &config.Rule{Rule: "verify-hash", F1: "args.params.pass", F2: "args.result.pass"},
+			args: map[string]interface{}{"args": map[string]interface{}{"params": map[string]interface{}{"pass": "password"}, "result": map[string]interface{}{"pass": argon2Hash}}},
+		},
+		{
+			name: "matching legacy sha256 hash",
+			rule: &config.Rule{Rule: "verify-hash", F1: "args.params.pass", F2: "args.result.pass"},
+			args: map[string]interface{}{"args": map[string]interface{}{"params": map[string]interface{}{"pass": "password"}, "result": map[string]interface{}{"pass": utils.HashString("password")}}},
+		},
+		{
+			name:    "value not matching hash",
+			rule:    &config.Rule{Rule: "verify-hash", F1: "args.params.pass", F2: "args.result.pass"},
+			args:    map[string]interface{}{"args": map[string]interface{}{"params": map[string]interface{}{"pass": "wrong"}, "result": map[string]interface{}{"pass": argon2Hash}}},
+			wantErr: true,
+		},
+		{
+			name:    "hash field not present",
+			rule:    &config.Rule{Rule: "verify-hash", F1: "args.params.pass", F2: "args.result.pass"},
+			args:    map[string]interface{}{"args": map[string]interface{}{"params": map[string]interface{}{"pass": "password"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid field type",
+			rule:    &config.Rule{Rule: "verify-hash", F1: 1, F2: "args.result.pass"},
+			args:    map[string]interface{}{"args": map[string]interface{}{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := matchVerifyHash(context.Background(), tt.rule, tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("matchVerifyHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestModule_matchFunc(t *testing.T) {
 	type params struct {
 		url               string
@@ -672,7 +744,7 @@ func TestModule_matchFunc(t *testing.T) {
 	}{
 		{
 			name: "Normal webhook call validation should pass",
-			m:    &Module{jwt: jwtUtils.New(), aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:    &Module{RWMutex: new(sync.RWMutex), jwt: jwtUtils.New()},
 			args: args{
 				httpParams: params{
 					url:    "http://localhost/validate",
@@ -686,7 +758,7 @@ func TestModule_matchFunc(t *testing.T) {
 		},
 		{
 			name: "Normal webhook call validation should fail",
-			m:    &Module{jwt: jwtUtils.New(), aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:    &Module{RWMutex: new(sync.RWMutex), jwt: jwtUtils.New()},
 			args: args{
 				httpParams: params{
 					shouldRequestFail: true,
@@ -699,7 +771,7 @@ func TestModule_matchFunc(t *testing.T) {
 		},
 		{
 			name: "Normal webhook call with custom claims",
-			m:    &Module{jwt: jwtUtils.New(), aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:    &Module{RWMutex: new(sync.RWMutex), jwt: jwtUtils.New()},
 			args: args{
 				httpParams: params{
 					url:    "http://localhost/validate",
@@ -713,7 +785,7 @@ func TestModule_matchFunc(t *testing.T) {
 		},
 		{
 			name: "Normal webhook call with custom claims and template",
-			m:    &Module{jwt: jwtUtils.New(), aesKey: base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")},
+			m:    &Module{RWMutex: new(sync.RWMutex), jwt: jwtUtils.New()},
 			args: args{
 				httpParams: params{
 					url:    "http://localhost/validate",
@@ -743,6 +815,8 @@ func TestModule_matchFunc(t *testing.T) {
 					return nil
 				}
 				delete(claims, "exp")
+				delete(claims, "iat")
+				delete(claims, "jti")
 				if !reflect.DeepEqual(tt.args.httpParams.claims, claims) {
 					t.Errorf("matchFunc() token claims mis match in makeHTTPRequest wanted (%s) got (%s)", tt.args.httpParams.claims, claims)
 					return nil
@@ -754,7 +828,7 @@ func TestModule_matchFunc(t *testing.T) {
 				}
 				return nil
 			}
-			_ = tt.m.SetConfig(context.TODO(), "local", &config.ProjectConfig{ID: "project", AESKey: string(tt.m.aesKey), Secrets: []*config.Secret{{IsPrimary: true, Alg: config.HS256, Secret: "some-secret"}}}, config.DatabaseRules{}, config.DatabasePreparedQueries{}, config.FileStoreRules{}, config.Services{}, config.EventingRules{})
+			_ = tt.m.SetConfig(context.TODO(), "local", &config.ProjectConfig{ID: "project", AESKey: "Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=", Secrets: []*config.Secret{{IsPrimary: true, Alg: config.HS256, Secret: "some-secret"}}}, config.DatabaseRules{}, config.DatabasePreparedQueries{}, config.FileStoreRules{}, config.Services{}, config.EventingRules{})
 			if err := tt.m.matchFunc(context.Background(), tt.args.rule, HTTPCall, tt.args.args); (err != nil) != tt.wantErr {
 				t.Errorf("matchFunc() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -6,23 +6,29 @@ import (
 	"github.com/spaceuptech/space-cloud/gateway/utils"
 )
 
-// Encrypt encrypts a value if the aes key present in the config. The result is base64 encoded
+// Encrypt encrypts a value with the primary aes key present in the config. The result is base64 encoded
 // before being returned.
 func (m *Module) Encrypt(value string) (string, error) {
 	m.RLock()
 	defer m.RUnlock()
 
-	return utils.Encrypt(m.aesKey, value)
+	return m.aesKeys.Encrypt(value)
 }
 
-// ParseToken simply parses and returns the claims of a provided token
+// ParseToken simply parses and returns the claims of a provided token. Api keys aren't accepted here
+// since they can only be used for the operations allowed by their scopes.
 func (m *Module) ParseToken(ctx context.Context, token string) (map[string]interface{}, error) {
 	return m.jwt.ParseToken(ctx, token)
 }
 
-// GetAESKey gets aes key
-func (m *Module) GetAESKey() []byte {
+// GetAESKeyRing gets the aes key ring
+func (m *Module) GetAESKeyRing() *utils.AESKeyRing {
 	m.RLock()
 	defer m.RUnlock()
-	return m.aesKey
+	return m.aesKeys
+}
+
+// GetJWKS returns the json web key set of the keys used to sign tokens of the project
+func (m *Module) GetJWKS() map[string]interface{} {
+	return m.jwt.GetJWKS()
 }
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	authHelpers "github.com/spaceuptech/space-cloud/gateway/modules/auth/helpers"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// SetSecurityPolicies compiles the rego policies used by the security rules. Policies which fail to
+// compile are skipped and the first error encountered is returned.
+func (m *Module) SetSecurityPolicies(ctx context.Context, policies config.SecurityPolicies) error {
+	m.Lock()
+	defer m.Unlock()
+
+	var firstErr error
+	queries := make(map[string]rego.PreparedEvalQuery, len(policies))
+	for _, policy := range policies {
+		query, err := authHelpers.PrepareRegoPolicy(ctx, policy)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		queries[policy.ID] = query
+	}
+
+	m.regoPolicies = queries
+	return firstErr
+}
+
+func (m *Module) matchRego(ctx context.Context, project string, rule *config.Rule, args, auth map[string]interface{}, returnWhere model.ReturnWhereStub) error {
+	query, p := m.regoPolicies[rule.Policy]
+	if !p {
+		return formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Security policy (%s) used by rego rule does not exist", rule.Policy), nil, nil))
+	}
+
+	input := map[string]interface{}{"args": args["args"], "auth": auth, "project": project}
+	results, err := query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to evaluate security policy (%s)", rule.Policy), err, nil))
+	}
+
+	decision := map[string]interface{}{}
+	if len(results) > 0 && len(results[0].Expressions) > 0 {
+		// Numbers are returned as json numbers by opa
+		data, err := json.Marshal(results[0].Expressions[0].Value)
+		if err != nil {
+			return formatError(ctx, rule, err)
+		}
+		if err := json.Unmarshal(data, &decision); err != nil {
+			return formatError(ctx, rule, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Security policy (%s) should evaluate to an object", rule.Policy), err, nil))
+		}
+	}
+
+	if allow, _ := decision["allow"].(bool); !allow {
+		return formatError(ctx, rule, errors.New("the operation being performed is denied by the security policy"))
+	}
+
+	where, _ := decision["where"].(map[string]interface{})
+	if len(where) == 0 {
+		return nil
+	}
+
+	// The where clause cannot be added to the query here (eg. reads on mongo and writes), so the request itself
+	// must satisfy it
+	if !returnWhere.ReturnWhere {
+		if !matchRegoWhere(args, where) {
+			return formatError(ctx, rule, errors.New("the operation being performed does not satisfy the where clause of the security policy"))
+		}
+		return nil
+	}
+
+	// Add the where clause returned by the policy to filter the results of the read
+	for key, value := range where {
+		if returnWhere.PrefixColName && !strings.HasPrefix(key, "$") && !strings.Contains(key, ".") {
+			key = returnWhere.Col + "." + key
+		}
+		returnWhere.Where[key] = value
+	}
+	return nil
+}
+
+// matchRegoWhere checks if the documents being written or the filter of the request match the where clause
+// returned by a policy
+func matchRegoWhere(args, where map[string]interface{}) bool {
+	reqArgs, _ := args["args"].(map[string]interface{})
+	if doc, p := reqArgs["doc"]; p {
+		return utils.Validate(model.DefaultValidate, where, doc)
+	}
+	return utils.Validate(model.DefaultValidate, where, reqArgs["find"])
+}
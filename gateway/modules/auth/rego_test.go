@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/modules/crud"
+)
+
+const tenantPolicy = `package tweets
+
+default allow = false
+
+allow {
+	input.auth.role == "admin"
+}
+
+allow {
+	input.auth.role == "user"
+	input.args.op == "read"
+}
+
+where = {"tenant": input.auth.tenant, "views": 10} {
+	input.auth.role == "user"
+}`
+
+func TestModule_matchRego(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		args        map[string]interface{}
+		auth        map[string]interface{}
+		returnWhere model.ReturnWhereStub
+		wantWhere   map[string]interface{}
+		wantErr     bool
+	}{
+		{
+			name:   "admin is allowed",
+			policy: "tweets",
+			args:   map[string]interface{}{"op": "create"},
+			auth:   map[string]interface{}{"role": "admin"},
+		},
+		{
+			name:    "user is denied for writes",
+			policy:  "tweets",
+			args:    map[string]interface{}{"op": "create"},
+			auth:    map[string]interface{}{"role": "user", "tenant": "t1"},
+			wantErr: true,
+		},
+		{
+			name:        "user read gets filtered by tenant",
+			policy:      "tweets",
+			args:        map[string]interface{}{"op": "read"},
+			auth:        map[string]interface{}{"role": "user", "tenant": "t1"},
+			returnWhere: model.ReturnWhereStub{Col: "tweet", PrefixColName: true, ReturnWhere: true, Where: map[string]interface{}{}},
+			wantWhere:   map[string]interface{}{"tweet.tenant": "t1", "tweet.views": float64(10)},
+		},
+		{
+			name:    "user read which cannot be filtered is denied",
+			policy:  "tweets",
+			args:    map[string]interface{}{"op": "read", "find": map[string]interface{}{}},
+			auth:    map[string]interface{}{"role": "user", "tenant": "t1"},
+			wantErr: true,
+		},
+		{
+			name:    "user read of another tenant which cannot be filtered is denied",
+			policy:  "tweets",
+			args:    map[string]interface{}{"op": "read", "find": map[string]interface{}{"tenant": "t2", "views": 10}},
+			auth:    map[string]interface{}{"role": "user", "tenant": "t1"},
+			wantErr: true,
+		},
+		{
+			name:   "user read which cannot be filtered but matches the where clause is allowed",
+			policy: "tweets",
+			args:   map[string]interface{}{"op": "read", "find": map[string]interface{}{"tenant": "t1", "views": 10}},
+			auth:   map[string]interface{}{"role": "user", "tenant": "t1"},
+		},
+		{
+			name:    "policy does not exist",
+			policy:  "unknown",
+			auth:    map[string]interface{}{"role": "admin"},
+			wantErr: true,
+		},
+		{
+			name:    "policy failing to compile is not loaded",
+			policy:  "invalid",
+			auth:    map[string]interface{}{"role": "admin"},
+			wantErr: true,
+		},
+	}
+
+	auth := Init("chicago", "1", &crud.Module{}, nil, nil)
+	if err := auth.SetConfig(context.TODO(), "local", &config.ProjectConfig{ID: "project", Secrets: []*config.Secret{{IsPrimary: true, Secret: "mySecretKey"}}}, config.DatabaseRules{}, config.DatabasePreparedQueries{}, config.FileStoreRules{}, config.Services{}, config.EventingRules{}); err != nil {
+		t.Fatalf("error setting config of auth module - %s", err.Error())
+	}
+	policies := config.SecurityPolicies{
+		"chicago--project--security-policy--tweets":  {ID: "tweets", Module: tenantPolicy},
+		"chicago--project--security-policy--invalid": {ID: "invalid", Module: "package invalid\n\nallow {"},
+	}
+	if err := auth.SetSecurityPolicies(context.TODO(), policies); err == nil {
+		t.Fatalf("SetSecurityPolicies() expected error for invalid policy")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &config.Rule{Rule: "rego", Policy: tt.policy}
+			_, err := auth.MatchRule(context.Background(), "project", rule, map[string]interface{}{"args": tt.args}, tt.auth, tt.returnWhere)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MatchRule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantWhere != nil && !reflect.DeepEqual(tt.returnWhere.Where, tt.wantWhere) {
+				t.Errorf("MatchRule() where = %v, want %v", tt.returnWhere.Where, tt.wantWhere)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+	jwtUtils "github.com/spaceuptech/space-cloud/gateway/utils/jwt"
+	"github.com/spaceuptech/space-cloud/gateway/utils/pubsub"
+)
+
+const (
+	revocationTopic       = "jwt-revocations"
+	revokedTokenIDsKey    = "revoked-token-ids"
+	revokedSubjectsKey    = "revoked-subjects"
+	revocationTypeTokenID = "token-id"
+	revocationTypeSubject = "subject"
+
+	// defaultRevocationTTL is used for token ids revoked without the expiry of the token
+	defaultRevocationTTL = jwtUtils.MaxTokenTTL
+)
+
+type revocationMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	// Time is the expiry of the token in seconds for token ids and the time of revocation in nanoseconds for subjects
+	Time int64 `json:"time"`
+}
+
+// SetPubsubClient sets the client used to store revocations and propagate them across the cluster.
+// Revocations are only kept in memory if a client isn't set.
+func (m *Module) SetPubsubClient(client *pubsub.Module) {
+	m.Lock()
+	m.pubsubClient = client
+	m.Unlock()
+
+	go m.routineHandleRevocations(client)
+}
+
+// Revoke revokes the tokens described by the revocation request
+func (m *Module) Revoke(ctx context.Context, req *model.TokenRevocationRequest) error {
+	if req.Token == "" && req.JTI == "" && req.Subject == "" {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Either the token, its id or the subject must be provided to revoke tokens", nil, nil)
+	}
+
+	if req.Token != "" {
+		if err := m.RevokeToken(ctx, req.Token); err != nil {
+			return err
+		}
+	}
+
+	if req.JTI != "" {
+		var expiresAt time.Time
+		if req.ExpiresAt != "" {
+			t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+			if err != nil {
+				return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid expiry provided for the token id to be revoked", err, nil)
+			}
+			expiresAt = t
+		}
+		if err := m.RevokeTokenID(ctx, req.JTI, expiresAt); err != nil {
+			return err
+		}
+	}
+
+	if req.Subject != "" {
+		return m.RevokeSubject(ctx, req.Subject)
+	}
+	return nil
+}
+
+// RevokeToken revokes the jwt provided. Only tokens having the `jti` claim can be revoked.
+func (m *Module) RevokeToken(ctx context.Context, token string) error {
+	claims, err := m.jwt.ParseToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Token cannot be revoked as it does not have the (jti) claim", nil, nil)
+	}
+
+	expiresAt, ok := jwtUtils.GetExpiry(claims)
+	if !ok {
+		expiresAt = time.Now().Add(defaultRevocationTTL)
+	}
+	return m.RevokeTokenID(ctx, jti, expiresAt)
+}
+
+// RevokeTokenID revokes the token having the jti provided. The zero value of expiresAt retains the revocation
+// for a default duration.
+func (m *Module) RevokeTokenID(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Token id to be revoked not provided", nil, nil)
+	}
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(defaultRevocationTTL)
+	}
+
+	m.jwt.RevokeTokenID(jti, expiresAt)
+	return m.storeRevocation(ctx, &revocationMessage{Type: revocationTypeTokenID, ID: jti, Time: expiresAt.Unix()})
+}
+
+// RevokeSubject revokes all the tokens issued to the subject till now
+func (m *Module) RevokeSubject(ctx context.Context, subject string) error {
+	if subject == "" {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Subject to be revoked not provided", nil, nil)
+	}
+
+	revokedAt := time.Now()
+	m.jwt.RevokeSubject(subject, revokedAt)
+	return m.storeRevocation(ctx, &revocationMessage{Type: revocationTypeSubject, ID: subject, Time: revokedAt.UnixNano()})
+}
+
+// RevokeSubjectOfToken revokes all the tokens issued to the subject of the token provided
+func (m *Module) RevokeSubjectOfToken(ctx context.Context, token string) error {
+	claims, err := m.jwt.ParseToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return m.RevokeSubject(ctx, jwtUtils.GetSubject(claims))
+}
+
+func (m *Module) storeRevocation(ctx context.Context, msg *revocationMessage) error {
+	m.RLock()
+	client := m.pubsubClient
+	m.RUnlock()
+
+	if client == nil {
+		return nil
+	}
+
+	var err error
+	switch msg.Type {
+	case revocationTypeTokenID:
+		err = client.AddToSortedSet(ctx, revokedTokenIDsKey, msg.ID, float64(msg.Time))
+	case revocationTypeSubject:
+		err = client.SetHashField(ctx, revokedSubjectsKey, msg.ID, strconv.FormatInt(msg.Time, 10))
+	}
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to store revocation in redis", err, map[string]interface{}{"type": msg.Type})
+	}
+
+	// Let the other gateways know about the revocation
+	if err := client.Publish(ctx, revocationTopic, msg); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to propagate revocation", err, map[string]interface{}{"type": msg.Type})
+	}
+	return nil
+}
+
+func (m *Module) applyRevocation(msg *revocationMessage) error {
+	switch msg.Type {
+	case revocationTypeTokenID:
+		m.jwt.RevokeTokenID(msg.ID, time.Unix(msg.Time, 0))
+	case revocationTypeSubject:
+		m.jwt.RevokeSubject(msg.ID, time.Unix(0, msg.Time))
+	default:
+		return errors.New("invalid revocation type provided")
+	}
+	return nil
+}
+
+func (m *Module) loadRevocations(ctx context.Context, client *pubsub.Module) error {
+	// Clear the revocations of tokens which have expired
+	now := float64(time.Now().Unix())
+	if err := client.RemoveFromSortedSetBelowScore(ctx, revokedTokenIDsKey, now); err != nil {
+		return err
+	}
+
+	tokenIDs, err := client.GetSortedSetMembers(ctx, revokedTokenIDsKey, now)
+	if err != nil {
+		return err
+	}
+	for _, z := range tokenIDs {
+		if jti, ok := z.Member.(string); ok {
+			m.jwt.RevokeTokenID(jti, time.Unix(int64(z.Score), 0))
+		}
+	}
+
+	subjects, err := client.GetHash(ctx, revokedSubjectsKey)
+	if err != nil {
+		return err
+	}
+	var expired []string
+	for subject, value := range subjects {
+		revokedAt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		// Clear the revocations of subjects whose tokens have expired
+		if t := time.Unix(0, revokedAt); t.Add(jwtUtils.MaxTokenTTL).After(time.Now()) {
+			m.jwt.RevokeSubject(subject, t)
+			continue
+		}
+		expired = append(expired, subject)
+	}
+	if len(expired) > 0 {
+		return client.DeleteHashFields(ctx, revokedSubjectsKey, expired...)
+	}
+	return nil
+}
+
+func (m *Module) routineHandleRevocations(client *pubsub.Module) {
+	// Subscribe before loading the stored revocations to make sure none of them get missed
+	ch, err := client.Subscribe(context.Background(), revocationTopic)
+	if err != nil {
+		_ = helpers.Logger.LogError("jwt-revocations", "Unable to subscribe to jwt revocations", err, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(utils.DefaultContextTime)*time.Second)
+	if err := m.loadRevocations(ctx, client); err != nil {
+		_ = helpers.Logger.LogError("jwt-revocations", "Unable to load jwt revocations from redis", err, nil)
+	}
+	cancel()
+
+	for msg := range ch {
+		revocation := new(revocationMessage)
+		if err := json.Unmarshal([]byte(msg.Payload), revocation); err != nil {
+			_ = helpers.Logger.LogError("jwt-revocations", "Unable to unmarshal incoming revocation", err, map[string]interface{}{"payload": msg.Payload})
+			continue
+		}
+
+		if err := m.applyRevocation(revocation); err != nil {
+			_ = helpers.Logger.LogError("jwt-revocations", "Unable to apply incoming revocation", err, map[string]interface{}{"payload": msg.Payload})
+		}
+	}
+}
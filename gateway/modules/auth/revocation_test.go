@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/modules/crud"
+)
+
+func TestModule_Revoke(t *testing.T) {
+	tests := []struct {
+		name string
+		// revoke returns the request to revoke tokens along with the token expected to be revoked
+		revoke        func(token string, claims map[string]interface{}) *model.TokenRevocationRequest
+		wantRevoked   bool
+		wantOthersOk  bool
+		wantRevokeErr bool
+	}{
+		{
+			name: "revoke token",
+			revoke: func(token string, _ map[string]interface{}) *model.TokenRevocationRequest {
+				return &model.TokenRevocationRequest{Token: token}
+			},
+			wantRevoked:  true,
+			wantOthersOk: true,
+		},
+		{
+			name: "revoke token id",
+			revoke: func(_ string, claims map[string]interface{}) *model.TokenRevocationRequest {
+				return &model.TokenRevocationRequest{JTI: claims["jti"].(string), ExpiresAt: "2100-01-01T00:00:00Z"}
+			},
+			wantRevoked:  true,
+			wantOthersOk: true,
+		},
+		{
+			name: "revoke subject",
+			revoke: func(string, map[string]interface{}) *model.TokenRevocationRequest {
+				return &model.TokenRevocationRequest{Subject: "user-1"}
+			},
+			wantRevoked: true,
+		},
+		{
+			name: "invalid expiry",
+			revoke: func(_ string, claims map[string]interface{}) *model.TokenRevocationRequest {
+				return &model.TokenRevocationRequest{JTI: claims["jti"].(string), ExpiresAt: "tomorrow"}
+			},
+			wantOthersOk:  true,
+			wantRevokeErr: true,
+		},
+		{
+			name: "nothing to revoke",
+			revoke: func(string, map[string]interface{}) *model.TokenRevocationRequest {
+				return &model.TokenRevocationRequest{}
+			},
+			wantOthersOk:  true,
+			wantRevokeErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			auth := Init("chicago", "1", &crud.Module{}, nil, nil)
+			if err := auth.SetConfig(ctx, "local", &config.ProjectConfig{ID: "project", Secrets: []*config.Secret{{IsPrimary: true, Secret: "mySecretKey", Alg: config.HS256}}}, config.DatabaseRules{}, config.DatabasePreparedQueries{}, config.FileStoreRules{}, config.Services{}, config.EventingRules{}); err != nil {
+				t.Fatalf("unable to set auth config - %s", err.Error())
+			}
+
+			token, err := auth.CreateToken(ctx, map[string]interface{}{"id": "user-1"})
+			if err != nil {
+				t.Fatalf("unable to create token - %s", err.Error())
+			}
+			otherToken, err := auth.CreateToken(ctx, map[string]interface{}{"id": "user-1"})
+			if err != nil {
+				t.Fatalf("unable to create token - %s", err.Error())
+			}
+			claims, err := auth.ParseToken(ctx, token)
+			if err != nil {
+				t.Fatalf("unable to parse token - %s", err.Error())
+			}
+
+			if err := auth.Revoke(ctx, tt.revoke(token, claims)); (err != nil) != tt.wantRevokeErr {
+				t.Fatalf("Revoke() error = %v, wantErr %v", err, tt.wantRevokeErr)
+			}
+
+			if _, err := auth.ParseToken(ctx, token); (err != nil) != tt.wantRevoked {
+				t.Errorf("ParseToken() of revoked token error = %v, wantErr %v", err, tt.wantRevoked)
+			}
+			if _, err := auth.ParseToken(ctx, otherToken); (err == nil) != tt.wantOthersOk {
+				t.Errorf("ParseToken() of other token error = %v, wantOk %v", err, tt.wantOthersOk)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"context"
-	"encoding/base64"
+
+	"github.com/google/cel-go/cel"
+	"github.com/open-policy-agent/opa/rego"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/utils"
@@ -22,11 +24,11 @@ func (m *Module) SetProjectConfig(projectConfig *config.ProjectConfig) error {
 		return err
 	}
 
-	decodedAESKey, err := base64.StdEncoding.DecodeString(projectConfig.AESKey)
+	aesKeys, err := utils.NewProjectAESKeyRing(projectConfig)
 	if err != nil {
 		return err
 	}
-	m.aesKey = decodedAESKey
+	m.aesKeys = aesKeys
 	return nil
 }
 
@@ -56,6 +58,15 @@ func (m *Module) CloseConfig() {
 	m.eventingRules = map[string]*config.Rule{}
 	m.fileRules = []*config.FileRule{}
 	m.dbRules = map[string]*config.DatabaseRule{}
+	m.celPrograms = map[string]cel.Program{}
+	m.regoPolicies = map[string]rego.PreparedEvalQuery{}
+	m.apiKeys = map[string]*config.APIKey{}
+
+	// Close the pub sub client
+	if m.pubsubClient != nil {
+		m.pubsubClient.Close()
+		m.pubsubClient = nil
+	}
 }
 
 // SetRemoteServiceConfig sets the service module config
@@ -63,6 +74,7 @@ func (m *Module) SetRemoteServiceConfig(remoteServices config.Services) {
 	m.Lock()
 	defer m.Unlock()
 	m.funcRules = remoteServices
+	m.compileCELRules()
 }
 
 // SetFileStoreRules sets the file store module config
@@ -78,6 +90,7 @@ func (m *Module) SetFileStoreRules(fileRules config.FileStoreRules) {
 	}
 	sortFileRule(temp)
 	m.fileRules = temp
+	m.compileCELRules()
 }
 
 // SetFileStoreType sets file story type
@@ -92,6 +105,7 @@ func (m *Module) SetEventingRules(eventingRules config.EventingRules) {
 	m.Lock()
 	defer m.Unlock()
 	m.eventingRules = eventingRules
+	m.compileCELRules()
 }
 
 // SetDatabaseRules sets the crud module config
@@ -99,6 +113,7 @@ func (m *Module) SetDatabaseRules(dbRules config.DatabaseRules) {
 	m.Lock()
 	defer m.Unlock()
 	m.dbRules = dbRules
+	m.compileCELRules()
 }
 
 // SetDatabasePreparedQueryRules set prepared query rules of auth module
@@ -106,6 +121,7 @@ func (m *Module) SetDatabasePreparedQueryRules(dbPreparedRules config.DatabasePr
 	m.Lock()
 	defer m.Unlock()
 	m.dbPrepQueryRules = dbPreparedRules
+	m.compileCELRules()
 }
 
 // SetMakeHTTPRequest sets the http request
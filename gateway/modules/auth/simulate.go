@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// ruleTraceKey is the context key under which the trace of the clause being evaluated is stored
+type ruleTraceKey struct{}
+
+// SimulateRule evaluates a security rule against mocked arguments and auth claims. Query and webhook
+// rules get their results from the mocks provided instead of hitting the database or the webhook url.
+func (m *Module) SimulateRule(ctx context.Context, req *model.RuleSimulationRequest) (*model.RuleSimulationResponse, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	// Prepare the arguments the same way the request handlers do
+	args := make(map[string]interface{}, len(req.Args)+2)
+	for k, v := range req.Args {
+		args[k] = v
+	}
+	auth := req.Auth
+	if auth == nil {
+		auth = map[string]interface{}{}
+	}
+	args["auth"] = auth
+	if _, p := args["token"]; !p {
+		args["token"] = ""
+	}
+
+	rule, col, err := m.getSimulationRule(ctx, req, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use a module which resolves queries and webhooks from the mocks
+	mocks := req.Mocks
+	if mocks == nil {
+		mocks = new(model.RuleMocks)
+	}
+	sim := m.clone()
+	sim.crud = &simulatedCrud{mocks: mocks.Crud}
+	sim.makeHTTPRequest = simulatedHTTPRequest(mocks.Webhooks)
+
+	root := new(model.RuleTrace)
+	stub := model.ReturnWhereStub{Where: map[string]interface{}{}, ReturnWhere: req.ReturnWhere, Col: col}
+	postProcess, err := sim.matchRule(context.WithValue(ctx, ruleTraceKey{}, root), m.project, rule, map[string]interface{}{"args": args}, auth, stub)
+
+	res := &model.RuleSimulationResponse{Allowed: err == nil, Args: args}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	if len(root.Clauses) > 0 {
+		res.Trace = root.Clauses[0]
+	}
+	if req.ReturnWhere {
+		res.Where = stub.Where
+	}
+	if postProcess != nil {
+		for _, action := range postProcess.PostProcessAction {
+			res.PostProcess = append(res.PostProcess, &model.RulePostProcess{Action: action.Action, Field: action.Field, Value: action.Value})
+		}
+	}
+	return res, nil
+}
+
+// getSimulationRule returns the rule to be simulated along with the collection it applies to
+func (m *Module) getSimulationRule(ctx context.Context, req *model.RuleSimulationRequest, args map[string]interface{}) (*config.Rule, string, error) {
+	if req.Rule != nil {
+		col := ""
+		if req.Ref != nil {
+			col = req.Ref.Col
+		}
+		return req.Rule, col, nil
+	}
+
+	ref := req.Ref
+	if ref == nil {
+		return nil, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Either the rule or a reference to an existing rule must be provided to simulate it", nil, nil)
+	}
+
+	switch config.Resource(ref.Type) {
+	case config.ResourceDatabaseRule:
+		rule, err := m.getCrudRule(ctx, m.project, ref.DB, ref.Col, model.OperationType(ref.Op))
+		return rule, ref.Col, err
+
+	case config.ResourceDatabasePreparedQuery:
+		rule, err := m.getPrepareQueryRule(ctx, m.project, ref.DB, ref.ID)
+		return rule, "", err
+
+	case config.ResourceFileStoreRule:
+		params, fileRule, err := m.getFileRule(ref.Path)
+		if err != nil {
+			return nil, "", err
+		}
+		rule, p := fileRule.Rule[ref.Op]
+		if !p || rule == nil {
+			return nil, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("File rule (%s) does not have a rule for operation (%s)", fileRule.ID, ref.Op), nil, nil)
+		}
+		args["params"] = params
+		return rule, "", nil
+
+	case config.ResourceEventingRule:
+		rule, err := m.getEventingRule(ctx, m.project, ref.EventType)
+		return rule, "", err
+
+	case config.ResourceRemoteService:
+		rule, err := m.getFunctionRule(ctx, m.project, ref.Service, ref.Endpoint)
+		return rule, "", err
+
+	default:
+		return nil, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Invalid rule reference type (%s) provided", ref.Type), nil, nil)
+	}
+}
+
+// simulatedCrud answers the reads made by query rules using the mocks provided
+type simulatedCrud struct {
+	mocks []*model.RuleCrudMock
+}
+
+func (s *simulatedCrud) Read(ctx context.Context, dbAlias, col string, req *model.ReadRequest, _ model.RequestParams) (interface{}, *model.SQLMetaData, error) {
+	find := normalizeMockValue(req.Find)
+	for _, mock := range s.mocks {
+		if mock.DB != dbAlias || mock.Col != col {
+			continue
+		}
+		if mock.Find != nil && !reflect.DeepEqual(normalizeMockValue(mock.Find), find) {
+			continue
+		}
+		if mock.Error != "" {
+			return nil, nil, errors.New(mock.Error)
+		}
+		return mock.Result, nil, nil
+	}
+	return nil, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("No mock provided for query on collection (%s) of database (%s)", col, dbAlias), nil, map[string]interface{}{"find": req.Find})
+}
+
+// simulatedHTTPRequest returns the responses of the webhook urls using the mocks provided
+func simulatedHTTPRequest(mocks []*model.RuleWebhookMock) utils.TypeMakeHTTPRequest {
+	return func(ctx context.Context, method, url, token, scToken string, params, vPtr interface{}) error {
+		for _, mock := range mocks {
+			if mock.URL != url {
+				continue
+			}
+			if mock.Error != "" {
+				return errors.New(mock.Error)
+			}
+			data, err := json.Marshal(mock.Result)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, vPtr)
+		}
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("No mock provided for webhook url (%s)", url), nil, nil)
+	}
+}
+
+// normalizeMockValue converts the value to its json representation so that typed values can be compared with the mocks
+func normalizeMockValue(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return value
+	}
+	return v
+}
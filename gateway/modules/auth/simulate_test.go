@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/modules/crud"
+)
+
+func TestModule_SimulateRule(t *testing.T) {
+	dbRules := config.DatabaseRules{
+		"chicago--project--db-rule--mongo-tweet-rule": &config.DatabaseRule{Rules: map[string]*config.Rule{
+			"read": {Rule: "match", Eval: "==", Type: "string", F1: "args.find.userId", F2: "args.auth.id"},
+		}},
+	}
+	auth := Init("chicago", "1", &crud.Module{}, nil, nil)
+	if err := auth.SetConfig(context.TODO(), "local", &config.ProjectConfig{ID: "project", Secrets: []*config.Secret{{IsPrimary: true, Secret: "mySecretKey"}}}, dbRules, config.DatabasePreparedQueries{}, config.FileStoreRules{}, config.Services{}, config.EventingRules{}); err != nil {
+		t.Fatalf("error setting config of auth module - %s", err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		req     *model.RuleSimulationRequest
+		want    *model.RuleSimulationResponse
+		wantErr bool
+	}{
+		{
+			name: "and rule with a failing clause",
+			req: &model.RuleSimulationRequest{
+				Rule: &config.Rule{Rule: "and", Clauses: []*config.Rule{
+					{Rule: "match", Eval: "==", Type: "string", F1: "args.auth.role", F2: "admin"},
+					{Rule: "deny", Error: "denied"},
+				}},
+				Auth: map[string]interface{}{"role": "admin"},
+			},
+			want: &model.RuleSimulationResponse{
+				Allowed: false,
+				Error:   "denied",
+				Trace: &model.RuleTrace{Rule: "and", Result: model.RuleTraceDenied, Error: "denied", Clauses: []*model.RuleTrace{
+					{Rule: "match", Result: model.RuleTraceAllowed},
+					{Rule: "deny", Result: model.RuleTraceDenied, Error: "denied"},
+				}},
+			},
+		},
+		{
+			name: "query rule resolved from mock",
+			req: &model.RuleSimulationRequest{
+				Rule: &config.Rule{Rule: "query", DB: "mongo", Col: "users", Find: map[string]interface{}{"id": "args.auth.id"}, Clause: &config.Rule{
+					Rule: "match", Eval: "==", Type: "string", F1: "args.result.role", F2: "admin",
+				}},
+				Auth:  map[string]interface{}{"id": "1"},
+				Mocks: &model.RuleMocks{Crud: []*model.RuleCrudMock{{DB: "mongo", Col: "users", Find: map[string]interface{}{"id": "1"}, Result: map[string]interface{}{"role": "admin"}}}},
+			},
+			want: &model.RuleSimulationResponse{
+				Allowed: true,
+				Trace: &model.RuleTrace{Rule: "query", Result: model.RuleTraceAllowed, Clauses: []*model.RuleTrace{
+					{Rule: "match", Result: model.RuleTraceAllowed},
+				}},
+			},
+		},
+		{
+			name: "webhook rule resolved from mock",
+			req: &model.RuleSimulationRequest{
+				Rule:  &config.Rule{Rule: "webhook", URL: "http://localhost/check"},
+				Mocks: &model.RuleMocks{Webhooks: []*model.RuleWebhookMock{{URL: "http://localhost/check", Result: map[string]interface{}{"ok": true}}}},
+			},
+			want: &model.RuleSimulationResponse{
+				Allowed: true,
+				Trace:   &model.RuleTrace{Rule: "webhook", Result: model.RuleTraceAllowed},
+			},
+		},
+		{
+			name: "force rule returns post process action",
+			req: &model.RuleSimulationRequest{
+				Rule: &config.Rule{Rule: "force", Field: "res.age", Value: float64(10)},
+			},
+			want: &model.RuleSimulationResponse{
+				Allowed:     true,
+				Trace:       &model.RuleTrace{Rule: "force", Result: model.RuleTraceAllowed},
+				PostProcess: []*model.RulePostProcess{{Action: "force", Field: "res.age", Value: float64(10)}},
+			},
+		},
+		{
+			name: "referenced db rule",
+			req: &model.RuleSimulationRequest{
+				Ref:  &model.RuleReference{Type: string(config.ResourceDatabaseRule), DB: "mongo", Col: "tweet", Op: "read"},
+				Args: map[string]interface{}{"find": map[string]interface{}{"userId": "2"}},
+				Auth: map[string]interface{}{"id": "1"},
+			},
+			want: &model.RuleSimulationResponse{
+				Allowed: false,
+				Error:   ErrIncorrectMatch.Error(),
+				Trace:   &model.RuleTrace{Rule: "match", Result: model.RuleTraceDenied, Error: ErrIncorrectMatch.Error()},
+			},
+		},
+		{
+			name: "referenced db rule returning where clause",
+			req: &model.RuleSimulationRequest{
+				Ref:         &model.RuleReference{Type: string(config.ResourceDatabaseRule), DB: "mongo", Col: "tweet", Op: "read"},
+				Auth:        map[string]interface{}{"id": "1"},
+				ReturnWhere: true,
+			},
+			want: &model.RuleSimulationResponse{
+				Allowed: true,
+				Trace:   &model.RuleTrace{Rule: "match", Result: model.RuleTraceAllowed},
+				Where:   map[string]interface{}{"userId": map[string]interface{}{"$eq": "1"}},
+			},
+		},
+		{
+			name:    "referenced rule does not exist",
+			req:     &model.RuleSimulationRequest{Ref: &model.RuleReference{Type: string(config.ResourceEventingRule), EventType: "my-event"}},
+			wantErr: true,
+		},
+		{
+			name:    "neither rule nor reference provided",
+			req:     &model.RuleSimulationRequest{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auth.SimulateRule(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SimulateRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// The args are checked separately since they get mutated by the rules
+			got.Args = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SimulateRule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModule_clone(t *testing.T) {
+	auth := Init("chicago", "1", &crud.Module{}, nil, nil)
+	if err := auth.SetConfig(context.TODO(), "local", &config.ProjectConfig{ID: "project", Secrets: []*config.Secret{{IsPrimary: true, Secret: "mySecretKey"}}}, config.DatabaseRules{}, config.DatabasePreparedQueries{}, config.FileStoreRules{}, config.Services{}, config.EventingRules{}); err != nil {
+		t.Fatalf("error setting config of auth module - %s", err.Error())
+	}
+	auth.SetAPIKeys(config.APIKeys{"key": &config.APIKey{ID: "key"}})
+
+	// Every field set on the module must be set on the clone as well
+	clone := auth.clone()
+	if clone.RWMutex == nil || clone.RWMutex == auth.RWMutex {
+		t.Errorf("clone() shares the lock of the module")
+	}
+	got, want := reflect.ValueOf(clone).Elem(), reflect.ValueOf(auth).Elem()
+	for i := 0; i < want.NumField(); i++ {
+		field := want.Type().Field(i)
+		if field.Anonymous || want.Field(i).IsZero() {
+			continue
+		}
+		if got.Field(i).IsZero() {
+			t.Errorf("clone() did not copy the field (%s)", field.Name)
+		}
+	}
+}
@@ -84,6 +84,6 @@ func (b *Bolt) SetQueryFetchLimit(limit int64) {
 	b.queryFetchLimit = &limit
 }
 
-// SetProjectAESKey sets aes key
-func (b *Bolt) SetProjectAESKey(aesKey []byte) {
+// SetProjectAESKey sets aes key ring
+func (b *Bolt) SetProjectAESKey(aesKeys *utils.AESKeyRing) {
 }
@@ -44,6 +44,10 @@ type Module struct {
 
 	// Schema module
 	schemaDoc model.Type
+
+	// Aes key ring of the project along with the re-encryption jobs
+	aesKeys          *utils.AESKeyRing
+	reEncryptionJobs map[string]*reEncryptionJob
 }
 
 type loader struct {
@@ -71,7 +75,7 @@ type Crud interface {
 	Close() error
 	GetConnectionState(ctx context.Context) bool
 	SetQueryFetchLimit(limit int64)
-	SetProjectAESKey(aesKey []byte)
+	SetProjectAESKey(aesKeys *utils.AESKeyRing)
 }
 
 // Init create a new instance of the Module object
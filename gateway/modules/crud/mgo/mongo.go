@@ -154,8 +154,8 @@ func (m *Mongo) SetQueryFetchLimit(limit int64) {
 	m.queryFetchLimit = &limit
 }
 
-// SetProjectAESKey sets aes key
-func (m *Mongo) SetProjectAESKey(aesKey []byte) {
+// SetProjectAESKey sets aes key ring
+func (m *Mongo) SetProjectAESKey(aesKeys *utils.AESKeyRing) {
 }
 
 func (m *Mongo) setClient(c *mongo.Client) {
@@ -166,15 +166,22 @@ func (m *Module) Read(ctx context.Context, dbAlias, col string, req *model.ReadR
 
 // Update updates the documents(s) which match a query from the database based on dbType
 func (m *Module) Update(ctx context.Context, dbAlias, col string, req *model.UpdateRequest, params model.RequestParams) error {
+	_, err := m.UpdateAndCount(ctx, dbAlias, col, req, params)
+	return err
+}
+
+// UpdateAndCount updates the documents(s) which match a query and returns the number of documents updated. It
+// lets the callers which put a condition in the query know if it was met.
+func (m *Module) UpdateAndCount(ctx context.Context, dbAlias, col string, req *model.UpdateRequest, params model.RequestParams) (int64, error) {
 	m.RLock()
 	defer m.RUnlock()
 
 	dbType, err := m.getDBType(dbAlias)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if err := schemaHelpers.ValidateUpdateOperation(ctx, dbAlias, dbType, col, req.Operation, req.Update, req.Find, m.schemaDoc); err != nil {
-		return err
+		return 0, err
 	}
 
 	params.Payload = req
@@ -182,25 +189,25 @@ func (m *Module) Update(ctx context.Context, dbAlias, col string, req *model.Upd
 	if hookResponse.CheckResponse() {
 		// Check if an error occurred
 		if err := hookResponse.Error(); err != nil {
-			return err
+			return 0, err
 		}
 
 		// Gracefully return
-		return nil
+		return 0, nil
 	}
 
 	crud, err := m.getCrudBlock(dbAlias)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if err := crud.IsClientSafe(ctx); err != nil {
-		return err
+		return 0, err
 	}
 
 	// Adjust where clause
 	if err := schemaHelpers.AdjustWhereClause(ctx, dbAlias, model.DBType(dbType), col, m.schemaDoc, req.Find); err != nil {
-		return err
+		return 0, err
 	}
 
 	// Perform the update operation
@@ -211,7 +218,7 @@ func (m *Module) Update(ctx context.Context, dbAlias, col string, req *model.Upd
 		m.metricHook(m.project, dbAlias, col, n, model.Update)
 	}
 
-	return err
+	return n, err
 }
 
 // Delete removes the documents(s) which match a query from the database based on dbType
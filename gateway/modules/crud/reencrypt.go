@@ -0,0 +1,248 @@
+package crud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/ksuid"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+const (
+	defaultReEncryptionBatchSize int64 = 100
+
+	// reEncryptionJobTTL is how long the progress of a finished job can be fetched for
+	reEncryptionJobTTL = 24 * time.Hour
+)
+
+type reEncryptionJob struct {
+	lock       sync.RWMutex
+	job        model.ReEncryptionJob
+	finishedAt time.Time
+}
+
+// StartReEncryption starts a background job which migrates the values of the encrypted columns provided to
+// the primary aes key. The id of the job is returned.
+func (m *Module) StartReEncryption(ctx context.Context, req *model.ReEncryptionRequest, params model.RequestParams) (string, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if len(req.Fields) == 0 {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Fields to be re-encrypted not provided", nil, nil)
+	}
+
+	kid := m.aesKeys.PrimaryKID()
+	if kid == "" {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Aes key ring of the project does not have a primary key to re-encrypt the values with", nil, nil)
+	}
+
+	req.DbAlias = strings.TrimPrefix(req.DbAlias, "sql-")
+	dbType, err := m.getDBType(req.DbAlias)
+	if err != nil {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to start re-encryption job", err, nil)
+	}
+	if req.IDField == "" {
+		req.IDField = "id"
+		if dbType == string(model.Mongo) {
+			req.IDField = "_id"
+		}
+	}
+	if req.BatchSize <= 0 {
+		req.BatchSize = defaultReEncryptionBatchSize
+	}
+
+	job := &reEncryptionJob{job: model.ReEncryptionJob{
+		ID:        ksuid.New().String(),
+		Status:    model.ReEncryptionJobRunning,
+		Request:   req,
+		KID:       kid,
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}}
+	if m.reEncryptionJobs == nil {
+		m.reEncryptionJobs = map[string]*reEncryptionJob{}
+	}
+	m.purgeReEncryptionJobs(time.Now())
+	m.reEncryptionJobs[job.job.ID] = job
+
+	go m.runReEncryptionJob(job, m.aesKeys, params)
+
+	return job.job.ID, nil
+}
+
+// GetReEncryptionJob returns the progress of a re-encryption job
+func (m *Module) GetReEncryptionJob(ctx context.Context, id string) (*model.ReEncryptionJob, error) {
+	m.RLock()
+	job, p := m.reEncryptionJobs[id]
+	m.RUnlock()
+
+	if !p {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Re-encryption job (%s) does not exist", id), nil, nil)
+	}
+
+	job.lock.RLock()
+	defer job.lock.RUnlock()
+
+	j := job.job
+	return &j, nil
+}
+
+// purgeReEncryptionJobs removes the jobs which finished more than the ttl ago. It must be called with the lock held.
+func (m *Module) purgeReEncryptionJobs(t time.Time) {
+	for id, job := range m.reEncryptionJobs {
+		job.lock.RLock()
+		finishedAt := job.finishedAt
+		job.lock.RUnlock()
+
+		if !finishedAt.IsZero() && finishedAt.Add(reEncryptionJobTTL).Before(t) {
+			delete(m.reEncryptionJobs, id)
+		}
+	}
+}
+
+func (m *Module) runReEncryptionJob(job *reEncryptionJob, aesKeys *utils.AESKeyRing, params model.RequestParams) {
+	req := job.job.Request
+	requestID := "re-encryption-" + job.job.ID
+	helpers.Logger.LogInfo(requestID, "Starting re-encryption job", map[string]interface{}{"dbAlias": req.DbAlias, "col": req.Col, "fields": req.Fields, "kid": job.job.KID})
+
+	err := m.reEncrypt(job, aesKeys, params)
+
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	job.finishedAt = time.Now()
+	job.job.CompletedAt = job.finishedAt.UTC().Format(time.RFC3339)
+	if err != nil {
+		job.job.Status = model.ReEncryptionJobFailed
+		job.job.Error = err.Error()
+		_ = helpers.Logger.LogError(requestID, "Re-encryption job failed", err, map[string]interface{}{"scanned": job.job.Scanned, "migrated": job.job.Migrated, "skipped": job.job.Skipped})
+		return
+	}
+	job.job.Status = model.ReEncryptionJobCompleted
+	helpers.Logger.LogInfo(requestID, "Re-encryption job completed", map[string]interface{}{"scanned": job.job.Scanned, "migrated": job.job.Migrated, "skipped": job.job.Skipped})
+}
+
+func (m *Module) reEncrypt(job *reEncryptionJob, aesKeys *utils.AESKeyRing, params model.RequestParams) error {
+	req := job.job.Request
+
+	// Rows are paged through in the order of the id field
+	var lastID interface{}
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(utils.DefaultContextTime)*time.Second)
+
+		find := map[string]interface{}{}
+		if lastID != nil {
+			find[req.IDField] = map[string]interface{}{"$gt": lastID}
+		}
+		limit := req.BatchSize
+		result, _, err := m.Read(ctx, req.DbAlias, req.Col, &model.ReadRequest{
+			Find:      find,
+			Operation: utils.All,
+			Options:   &model.ReadOptions{Sort: []string{req.IDField}, Limit: &limit},
+		}, params)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		docs, err := getReEncryptionDocs(result)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		for _, doc := range docs {
+			id, p := doc[req.IDField]
+			if !p {
+				cancel()
+				return fmt.Errorf("row does not have the id field (%s)", req.IDField)
+			}
+			lastID = id
+
+			updates, err := reEncryptFields(aesKeys, doc, req.Fields)
+			if err != nil {
+				cancel()
+				return fmt.Errorf("unable to re-encrypt row with id (%v) - %v", id, err)
+			}
+
+			var updated int64
+			if len(updates) > 0 {
+				// The values read are part of the query so that a row written to in the meantime doesn't get
+				// overwritten with its old value
+				find := map[string]interface{}{req.IDField: id}
+				for field := range updates {
+					find[field] = doc[field]
+				}
+				updateReq := &model.UpdateRequest{Find: find, Operation: utils.All, Update: map[string]interface{}{"$set": updates}}
+				updated, err = m.UpdateAndCount(ctx, req.DbAlias, req.Col, updateReq, params)
+				if err != nil {
+					cancel()
+					return err
+				}
+			}
+
+			job.lock.Lock()
+			job.job.Scanned++
+			switch {
+			case len(updates) > 0 && updated > 0:
+				job.job.Migrated++
+			case len(updates) > 0:
+				job.job.Skipped++
+			}
+			job.lock.Unlock()
+		}
+		cancel()
+
+		if int64(len(docs)) < req.BatchSize {
+			return nil
+		}
+	}
+}
+
+// reEncryptFields returns the fields of the document which need to be updated with their values encrypted
+// with the primary aes key
+func reEncryptFields(aesKeys *utils.AESKeyRing, doc map[string]interface{}, fields []string) (map[string]interface{}, error) {
+	updates := map[string]interface{}{}
+	for _, field := range fields {
+		value, ok := doc[field].(string)
+		if !ok || value == "" || aesKeys.IsEncryptedWithPrimaryKey(value) {
+			continue
+		}
+
+		decrypted, err := aesKeys.Decrypt(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt field (%s) - %v", field, err)
+		}
+		encrypted, err := aesKeys.Encrypt(decrypted)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encrypt field (%s) - %v", field, err)
+		}
+		updates[field] = encrypted
+	}
+	return updates, nil
+}
+
+func getReEncryptionDocs(result interface{}) ([]map[string]interface{}, error) {
+	switch v := result.(type) {
+	case []map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		docs := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			doc, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid row of type (%T) received", item)
+			}
+			docs[i] = doc
+		}
+		return docs, nil
+	default:
+		return nil, errors.New("invalid result received on reading rows to be re-encrypted")
+	}
+}
@@ -2,7 +2,6 @@ package crud
 
 import (
 	"context"
-	"encoding/base64"
 	"strings"
 
 	"github.com/graph-gophers/dataloader"
@@ -71,6 +70,16 @@ func (m *Module) SetConfig(project string, crud config.DatabaseConfigs) error {
 			if err != nil {
 				return helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to fetch connection string secret from runner", err, map[string]interface{}{"project": project})
 			}
+		} else if config.HasPlaceholders(v.Conn) {
+			// The placeholders are resolved here instead of by the modules package since the defaults set above
+			// have to make it to the config stored by syncman
+			var err error
+			connectionString, err = config.InterpolateString(context.TODO(), v.Conn, func(secretName, key string) (string, error) {
+				return m.getSecrets(project, secretName, key)
+			})
+			if err != nil {
+				return helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to resolve placeholders in connection string", err, map[string]interface{}{"project": project, "dbAlias": v.DbAlias})
+			}
 		}
 
 		if block, p := m.blocks[blockKey]; p {
@@ -105,6 +114,7 @@ func (m *Module) SetConfig(project string, crud config.DatabaseConfigs) error {
 		m.databaseConfigs[blockKey] = v
 		m.blocks[blockKey] = c
 		c.SetQueryFetchLimit(v.Limit)
+		c.SetProjectAESKey(m.aesKeys)
 	}
 
 	return nil
@@ -160,21 +170,15 @@ func (m *Module) SetHooks(metricHook model.MetricCrudHook) {
 	m.metricHook = metricHook
 }
 
-// SetProjectAESKey set aes config for sql databases
-func (m *Module) SetProjectAESKey(aesKey string) error {
-	m.RLock()
-	defer m.RUnlock()
-
-	decodedAESKey, err := base64.StdEncoding.DecodeString(aesKey)
-	if err != nil {
-		return err
-	}
+// SetProjectAESKey set aes key ring for sql databases
+func (m *Module) SetProjectAESKey(aesKeys *utils.AESKeyRing) {
+	m.Lock()
+	defer m.Unlock()
 
+	m.aesKeys = aesKeys
 	for _, block := range m.blocks {
-		block.SetProjectAESKey(decodedAESKey)
+		block.SetProjectAESKey(aesKeys)
 	}
-
-	return nil
 }
 
 // SetAdminManager sets the admin manager
@@ -391,7 +391,7 @@ func (s *SQL) processRows(ctx context.Context, isDebug bool, table []string, isA
 
 		// Perform post processing
 		if postProcess != nil {
-			_ = authHelpers.PostProcessMethod(ctx, s.aesKey, postProcess[table[length]], m)
+			_ = authHelpers.PostProcessMethod(ctx, s.aesKeys, postProcess[table[length]], m)
 		}
 
 		// Process aggregate field only if its the root table that we are processing
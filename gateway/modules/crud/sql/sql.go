@@ -33,7 +33,7 @@ type SQL struct {
 	connRetryCloserChan chan struct{}
 
 	// 	Auth module
-	aesKey []byte
+	aesKeys *utils.AESKeyRing
 }
 
 // Init initialises a new sql instance
@@ -187,9 +187,9 @@ func (s *SQL) SetQueryFetchLimit(limit int64) {
 	s.queryFetchLimit = &limit
 }
 
-// SetProjectAESKey sets aes key
-func (s *SQL) SetProjectAESKey(aesKey []byte) {
-	s.aesKey = aesKey
+// SetProjectAESKey sets aes key ring
+func (s *SQL) SetProjectAESKey(aesKeys *utils.AESKeyRing) {
+	s.aesKeys = aesKeys
 }
 
 func (s *SQL) setClient(c *sqlx.DB) {
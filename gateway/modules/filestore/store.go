@@ -83,6 +83,14 @@ func (m *Module) SetConfig(project string, conf *config.FileStoreConfig) error {
 		return nil
 	}
 
+	// Resolve the placeholders in the config
+	resolved := conf
+	if err := config.Interpolate(context.TODO(), &resolved, func(secretName, key string) (string, error) {
+		return m.getSecrets(project, secretName, key)
+	}); err != nil {
+		return err
+	}
+
 	// create aws and gcp file secret
 	secretName, secretKey, isSecretExists := splitConnectionString(conf.Secret)
 	if isSecretExists {
@@ -93,10 +101,15 @@ func (m *Module) SetConfig(project string, conf *config.FileStoreConfig) error {
 		if err := setFileSecret(utils.FileStoreType(conf.StoreType), secretKey, value); err != nil {
 			return helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to create credential file in gateway", err, nil)
 		}
+	} else if resolved.Secret != conf.Secret {
+		// The resolved placeholders of the secret are the credentials themselves
+		if err := setFileSecret(utils.FileStoreType(conf.StoreType), "", resolved.Secret); err != nil {
+			return helpers.Logger.LogError(helpers.GetRequestID(context.TODO()), "Unable to create credential file in gateway", err, nil)
+		}
 	}
 
 	// Create a new crud blocks
-	s, err := initBlock(conf)
+	s, err := initBlock(resolved)
 	if err != nil {
 		return err
 	}
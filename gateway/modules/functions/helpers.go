@@ -22,7 +22,7 @@ import (
 	"github.com/spaceuptech/space-cloud/gateway/utils"
 )
 
-func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token string, auth, params interface{}, cacheInfo *config.ReadCacheOptions) (int, interface{}, error) {
+func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token string, auth, params interface{}, cacheInfo *config.ReadCacheOptions) (int, interface{}, string, error) {
 	var url string
 	var method string
 	var ogToken string
@@ -38,7 +38,7 @@ func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token st
 	// Adjust the endpointPath to account for variables
 	endpointPath, err := adjustPath(ctx, endpoint.Path, auth, params)
 	if err != nil {
-		return http.StatusBadRequest, nil, err
+		return http.StatusBadRequest, nil, "", err
 	}
 
 	switch endpoint.Kind {
@@ -56,7 +56,7 @@ func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token st
 		url = fmt.Sprintf("http://localhost:4122/v1/api/%s/graphql", m.getProject())
 
 	default:
-		return http.StatusBadRequest, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Invalid endpoint kind (%s) provided", endpoint.Kind), nil, nil)
+		return http.StatusBadRequest, nil, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Invalid endpoint kind (%s) provided", endpoint.Kind), nil, nil)
 	}
 
 	var redisKey string
@@ -67,7 +67,7 @@ func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token st
 		for index, key := range endpoint.CacheOptions {
 			value, err := utils.LoadValue(key, map[string]interface{}{"args": map[string]interface{}{"auth": auth, "token": ogToken, "url": url}})
 			if err != nil {
-				return http.StatusBadRequest, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to extract value for cache option key (%s)", key), err, nil)
+				return http.StatusBadRequest, nil, "", helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to extract value for cache option key (%s)", key), err, nil)
 			}
 			cacheOptionsArray[index] = value
 		}
@@ -75,12 +75,12 @@ func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token st
 		// Check if response is present in the cache
 		cacheResponse, err = m.caching.GetRemoteService(ctx, m.project, serviceID, endpointID, cacheInfo, cacheOptionsArray)
 		if err != nil {
-			return http.StatusInternalServerError, nil, err
+			return http.StatusInternalServerError, nil, "", err
 		}
 
 		// Return if cache is present in response
 		if cacheResponse.IsCacheHit() {
-			return http.StatusOK, cacheResponse.GetResult(), nil
+			return http.StatusOK, cacheResponse.GetResult(), cacheResponse.ETag(), nil
 		}
 
 		// Store the redis key for future use
@@ -99,7 +99,7 @@ func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token st
 
 	newParams, err := m.adjustReqBody(ctx, serviceID, endpointID, ogToken, endpoint, auth, params)
 	if err != nil {
-		return http.StatusBadRequest, nil, err
+		return http.StatusBadRequest, nil, "", err
 	}
 
 	/***************** Set the request token ****************/
@@ -113,7 +113,7 @@ func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token st
 	if endpoint.Claims != "" {
 		newToken, err := m.generateWebhookToken(ctx, serviceID, endpointID, token, endpoint, auth, params)
 		if err != nil {
-			return http.StatusInternalServerError, nil, err
+			return http.StatusInternalServerError, nil, "", err
 		}
 		token = newToken
 	}
@@ -122,7 +122,7 @@ func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token st
 
 	scToken, err := m.auth.GetSCAccessToken(ctx)
 	if err != nil {
-		return http.StatusInternalServerError, nil, err
+		return http.StatusInternalServerError, nil, "", err
 	}
 
 	// Prepare the state object
@@ -137,7 +137,7 @@ func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token st
 	}
 	status, err := utils.MakeHTTPRequest(ctx, req, &res)
 	if err != nil {
-		return status, nil, err
+		return status, nil, "", err
 	}
 
 	/**************** Return the response body ****************/
@@ -147,10 +147,11 @@ func (m *Module) handleCall(ctx context.Context, serviceID, endpointID, token st
 	if cacheInfo != nil && redisKey != "" {
 		// Store the adjusted body in cache for future use
 		if err := m.caching.SetRemoteServiceKey(ctx, redisKey, cacheResponse, cacheInfo, res); err != nil {
-			return 0, nil, err
+			return 0, nil, "", err
 		}
+		return status, res, cacheResponse.ETag(), err
 	}
-	return status, res, err
+	return status, res, "", err
 }
 
 func prepareHeaders(ctx context.Context, headers config.Headers, state map[string]interface{}) config.Headers {
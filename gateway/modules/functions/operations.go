@@ -13,6 +13,13 @@ import (
 // CallWithContext invokes function on a service. The response from the function is returned back along with
 // any errors if they occurred.
 func (m *Module) CallWithContext(ctx context.Context, service, function, token string, reqParams model.RequestParams, req *model.FunctionsRequest) (int, interface{}, error) {
+	status, result, _, err := m.CallWithETag(ctx, service, function, token, reqParams, req)
+	return status, result, err
+}
+
+// CallWithETag calls a function like CallWithContext. It also returns the entity tag of the cache entry the
+// response was read from or stored in, which is empty if the response isn't cached.
+func (m *Module) CallWithETag(ctx context.Context, service, function, token string, reqParams model.RequestParams, req *model.FunctionsRequest) (int, interface{}, string, error) {
 	reqParams.Payload = map[string]interface{}{
 		"service":  service,
 		"endpoint": function,
@@ -22,21 +29,21 @@ func (m *Module) CallWithContext(ctx context.Context, service, function, token s
 	if hookResponse.CheckResponse() {
 		// Check if an error occurred
 		if err := hookResponse.Error(); err != nil {
-			return hookResponse.Status(), nil, err
+			return hookResponse.Status(), nil, "", err
 		}
 
 		// Gracefully return
-		return hookResponse.Status(), hookResponse.Result(), nil
+		return hookResponse.Status(), hookResponse.Result(), "", nil
 	}
 
 	// TODO: Add metric hook for cache
-	status, result, err := m.handleCall(ctx, service, function, token, reqParams.Claims, req.Params, req.Cache)
+	status, result, etag, err := m.handleCall(ctx, service, function, token, reqParams.Claims, req.Params, req.Cache)
 	if err != nil {
-		return status, result, err
+		return status, result, "", err
 	}
 
 	m.metricHook(m.project, service, function)
-	return status, result, nil
+	return status, result, etag, nil
 }
 
 // GetEndpointContextTimeout returns the endpoint timeout of particular remote-service
@@ -52,3 +59,4 @@ func (m *Module) GetEndpointContextTimeout(ctx context.Context, projectID, servi
 	}
 	return 0, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Could not find endpoint (%s) for service (%s)", function, service), nil, nil)
 }
+
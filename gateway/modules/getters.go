@@ -8,6 +8,7 @@ import (
 	"github.com/spaceuptech/space-cloud/gateway/modules/filestore"
 	"github.com/spaceuptech/space-cloud/gateway/modules/functions"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/caching"
+	"github.com/spaceuptech/space-cloud/gateway/modules/global/compression"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/letsencrypt"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/routing"
 	"github.com/spaceuptech/space-cloud/gateway/modules/schema"
@@ -113,6 +114,15 @@ func (m *Modules) GetAuthModuleForSyncMan(projectID string) (model.AuthSyncManIn
 	return module.auth, nil
 }
 
+// GetCrudModuleForSyncMan returns crud module for sync manager
+func (m *Modules) GetCrudModuleForSyncMan(projectID string) (model.CrudSyncManInterface, error) {
+	module, err := m.loadModule(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return module.db, nil
+}
+
 // LetsEncrypt returns the letsencrypt module
 func (m *Modules) LetsEncrypt() *letsencrypt.LetsEncrypt {
 	return m.GlobalMods.LetsEncrypt()
@@ -126,4 +136,9 @@ func (m *Modules) Routing() *routing.Routing {
 // Caching returns the caching module
 func (m *Modules) Caching() *caching.Cache {
 	return m.GlobalMods.Caching()
-}
\ No newline at end of file
+}
+
+// Compression returns the compression module
+func (m *Modules) Compression() *compression.Compression {
+	return m.GlobalMods.Compression()
+}
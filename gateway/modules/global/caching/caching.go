@@ -49,9 +49,15 @@ func (c *Cache) SetCachingConfig(ctx context.Context, cacheConfig *config.CacheC
 	}
 
 	if cacheConfig.Enabled {
+		// Only environment variables can be used in the connection string as secrets belong to a project
+		conn, err := config.InterpolateString(ctx, cacheConfig.Conn, nil)
+		if err != nil {
+			return err
+		}
+
 		// Create a new redis client
 		redisClient := redis.NewClient(&redis.Options{
-			Addr:     cacheConfig.Conn,
+			Addr:     conn,
 			Password: "", // no password set
 			DB:       0,
 		})
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/spaceuptech/helpers"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
 )
 
 // SetRemoteServiceKey set remote service key
@@ -26,7 +28,15 @@ func (c *Cache) SetRemoteServiceKey(ctx context.Context, redisKey string, remote
 	}
 
 	data, _ := json.Marshal(result)
-	return c.set(ctx, redisKey, cache, string(data))
+	if err := c.set(ctx, redisKey, cache, string(data)); err != nil {
+		return err
+	}
+
+	// The entity tag is generated from the value stored so that it matches the one of future cache hits
+	remoteServiceCacheOptions.lock.Lock()
+	remoteServiceCacheOptions.etag = utils.GenerateETag(data)
+	remoteServiceCacheOptions.lock.Unlock()
+	return nil
 }
 
 // GetRemoteService get remote service
@@ -57,6 +67,7 @@ func (c *Cache) GetRemoteService(ctx context.Context, projectID, serviceID, endp
 		return cacheResult, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to json unmarshal result of remote service key", err, map[string]interface{}{"key": key})
 	}
 	cacheResult.result = v
+	cacheResult.etag = utils.GenerateETag(result)
 
 	return cacheResult, nil
 }
@@ -96,6 +107,10 @@ func (c *Cache) SetIngressRouteKey(ctx context.Context, redisKey string, cache *
 		cache.TTL = int64(c.config.DefaultTTL)
 	}
 
+	// Store the entity tag and expiry so that conditional requests can be answered from the cache
+	result.ETag = utils.GenerateETag(result.Body)
+	result.ExpiresAt = time.Now().Unix() + cache.TTL
+
 	data, _ := json.Marshal(result)
 	return c.set(ctx, redisKey, cache, string(data))
 }
@@ -13,6 +13,9 @@ type CacheResult struct {
 	isCacheHit     bool
 	isCacheEnabled bool
 	result         interface{}
+
+	// etag is the entity tag of the cached value
+	etag string
 }
 
 // GetResult gets cache result
@@ -36,6 +39,13 @@ func (d *CacheResult) Key() string {
 	return d.redisKey
 }
 
+// ETag gets the entity tag of the cached value. It is empty if nothing was read from or stored in the cache.
+func (d *CacheResult) ETag() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.etag
+}
+
 // IsCacheHit tells if it's a cache hit or miss
 func (d *CacheResult) IsCacheHit() bool {
 	d.lock.Lock()
@@ -0,0 +1,125 @@
+package compression
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+const (
+	encodingGzip   = "gzip"
+	encodingBrotli = "br"
+
+	defaultMinSize = 1024
+)
+
+var defaultContentTypes = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/graphql",
+	"image/svg+xml",
+}
+
+// Compression compresses the http responses of the gateway based on the encodings accepted by the client
+type Compression struct {
+	lock sync.RWMutex
+
+	enabled      bool
+	minSize      int
+	contentTypes []string
+}
+
+// New creates a new instance of the compression module
+func New() *Compression {
+	return &Compression{minSize: defaultMinSize, contentTypes: defaultContentTypes}
+}
+
+// SetConfig sets the config of the compression module
+func (c *Compression) SetConfig(conf *config.CompressionConfig) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if conf == nil {
+		c.enabled = false
+		return
+	}
+
+	c.enabled = conf.Enabled
+	c.minSize = conf.MinSize
+	if c.minSize <= 0 {
+		c.minSize = defaultMinSize
+	}
+	c.contentTypes = conf.ContentTypes
+	if len(c.contentTypes) == 0 {
+		c.contentTypes = defaultContentTypes
+	}
+}
+
+// Handler returns a middleware which compresses the responses of the provided handler
+func (c *Compression) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.lock.RLock()
+		enabled, minSize, contentTypes := c.enabled, c.minSize, c.contentTypes
+		c.lock.RUnlock()
+
+		// Protocol upgrades and range requests are never compressed
+		if !enabled || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := newResponseWriter(w, encoding, minSize, contentTypes)
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks the encoding with the highest quality value. Brotli is preferred in case of a tie.
+func negotiateEncoding(acceptEncoding string) string {
+	var selected string
+	var selectedQ float64
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		arr := strings.Split(strings.TrimSpace(part), ";")
+		encoding := strings.ToLower(strings.TrimSpace(arr[0]))
+
+		q := 1.0
+		for _, param := range arr[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		if q <= 0 || (encoding != encodingGzip && encoding != encodingBrotli) {
+			continue
+		}
+
+		if q > selectedQ || (q == selectedQ && encoding == encodingBrotli) {
+			selected, selectedQ = encoding, q
+		}
+	}
+	return selected
+}
+
+func matchContentType(contentType string, contentTypes []string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, t := range contentTypes {
+		if strings.HasPrefix(contentType, strings.ToLower(t)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package compression
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+func Test_negotiateEncoding(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		want           string
+	}{
+		{name: "no encodings accepted", acceptEncoding: "", want: ""},
+		{name: "only gzip accepted", acceptEncoding: "gzip, deflate", want: "gzip"},
+		{name: "brotli preferred on tie", acceptEncoding: "gzip, deflate, br", want: "br"},
+		{name: "quality values respected", acceptEncoding: "br;q=0.5, gzip;q=0.8", want: "gzip"},
+		{name: "encoding explicitly disallowed", acceptEncoding: "gzip;q=0", want: ""},
+		{name: "unsupported encodings ignored", acceptEncoding: "deflate, identity", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateEncoding(tt.acceptEncoding); got != tt.want {
+				t.Errorf("negotiateEncoding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompression_Handler(t *testing.T) {
+	largeBody := strings.Repeat(`{"key":"value"}`, 200)
+	tests := []struct {
+		name           string
+		conf           *config.CompressionConfig
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantEncoding   string
+	}{
+		{
+			name:           "compression disabled",
+			conf:           &config.CompressionConfig{Enabled: false},
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           largeBody,
+			wantEncoding:   "",
+		},
+		{
+			name:           "gzip compressed",
+			conf:           &config.CompressionConfig{Enabled: true},
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           largeBody,
+			wantEncoding:   "gzip",
+		},
+		{
+			name:           "brotli compressed",
+			conf:           &config.CompressionConfig{Enabled: true},
+			acceptEncoding: "gzip, br",
+			contentType:    "application/json; charset=utf-8",
+			body:           largeBody,
+			wantEncoding:   "br",
+		},
+		{
+			name:           "below size threshold",
+			conf:           &config.CompressionConfig{Enabled: true, MinSize: 10000},
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           largeBody,
+			wantEncoding:   "",
+		},
+		{
+			name:           "content type not allowed",
+			conf:           &config.CompressionConfig{Enabled: true, ContentTypes: []string{"text/html"}},
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           largeBody,
+			wantEncoding:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			c.SetConfig(tt.conf)
+
+			handler := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != http.StatusCreated {
+				t.Errorf("Handler() status = %d, want %d", recorder.Code, http.StatusCreated)
+			}
+			if got := recorder.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Handler() content encoding = %s, want %s", got, tt.wantEncoding)
+			}
+
+			var data []byte
+			switch tt.wantEncoding {
+			case encodingGzip:
+				reader, err := gzip.NewReader(recorder.Body)
+				if err != nil {
+					t.Fatalf("Handler() invalid gzip response - %v", err)
+				}
+				data, _ = ioutil.ReadAll(reader)
+			case encodingBrotli:
+				data, _ = ioutil.ReadAll(brotli.NewReader(recorder.Body))
+			default:
+				data = recorder.Body.Bytes()
+			}
+			if string(data) != tt.body {
+				t.Errorf("Handler() body mismatch got (%d) bytes, want (%d) bytes", len(data), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestCompression_Handler_informationalStatus(t *testing.T) {
+	body := strings.Repeat(`{"key":"value"}`, 200)
+	c := New()
+	c.SetConfig(&config.CompressionConfig{Enabled: true})
+
+	handler := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(body))
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	recorder := &informationalRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(recorder, request)
+
+	if len(recorder.informational) != 1 || recorder.informational[0] != http.StatusEarlyHints {
+		t.Errorf("Handler() informational statuses = %v, want [%d]", recorder.informational, http.StatusEarlyHints)
+	}
+	if recorder.Code != http.StatusCreated {
+		t.Errorf("Handler() status = %d, want %d", recorder.Code, http.StatusCreated)
+	}
+	if got := recorder.Header().Get("Content-Encoding"); got != encodingGzip {
+		t.Errorf("Handler() content encoding = %s, want %s", got, encodingGzip)
+	}
+}
+
+// informationalRecorder records the informational statuses written apart from the final one
+type informationalRecorder struct {
+	*httptest.ResponseRecorder
+	informational []int
+}
+
+func (r *informationalRecorder) WriteHeader(status int) {
+	if status < http.StatusOK {
+		r.informational = append(r.informational, status)
+		return
+	}
+	r.ResponseRecorder.WriteHeader(status)
+}
@@ -0,0 +1,153 @@
+package compression
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/andybalholm/brotli"
+)
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// responseWriter buffers the response till the minimum size is reached, after which it decides whether
+// the response should be compressed
+type responseWriter struct {
+	http.ResponseWriter
+
+	encoding     string
+	minSize      int
+	contentTypes []string
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder encoder
+}
+
+func newResponseWriter(w http.ResponseWriter, encoding string, minSize int, contentTypes []string) *responseWriter {
+	return &responseWriter{ResponseWriter: w, encoding: encoding, minSize: minSize, contentTypes: contentTypes}
+}
+
+// WriteHeader stores the status code. It is sent to the client once we know if the response will be compressed.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+
+	// Informational responses precede the final one, so they are passed on without deciding anything
+	if status >= 100 && status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+
+	// Responses without a body can be sent right away
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.minSize {
+			return len(p), nil
+		}
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends whatever has been buffered so far to the client
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		_ = w.start()
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hands over the underlying connection. It can only be used before anything has been written.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok || w.decided {
+		return nil, nil, errors.New("response writer cannot be hijacked")
+	}
+	w.decided = true
+	return hijacker.Hijack()
+}
+
+// Close flushes the buffered data and completes the compressed stream
+func (w *responseWriter) Close() {
+	if !w.decided {
+		_ = w.start()
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+	}
+}
+
+// start decides whether to compress the response based on what has been buffered and writes it out
+func (w *responseWriter) start() error {
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	compress := len(w.buf) >= w.minSize && header.Get("Content-Encoding") == "" && matchContentType(header.Get("Content-Type"), w.contentTypes)
+	w.decide(compress)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+	return err
+}
+
+func (w *responseWriter) decide(compress bool) {
+	w.decided = true
+
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+	if compress {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+
+		switch w.encoding {
+		case encodingBrotli:
+			w.encoder = brotli.NewWriter(w.ResponseWriter)
+		default:
+			w.encoder = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -3,6 +3,7 @@ package global
 import (
 	"github.com/spaceuptech/space-cloud/gateway/managers"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/caching"
+	"github.com/spaceuptech/space-cloud/gateway/modules/global/compression"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/letsencrypt"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/metrics"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/routing"
@@ -14,6 +15,7 @@ type Global struct {
 	metrics     *metrics.Module
 	routing     *routing.Routing
 	caching     *caching.Cache
+	compression *compression.Compression
 }
 
 // New creates a new global object
@@ -32,13 +34,14 @@ func New(clusterID, nodeID string, isDev bool, managers *managers.Managers) (*Gl
 
 	// Initialise the routing module
 	r := routing.New()
+	r.SetMetricsModule(m)
 
 	// Initialise the caching module
 	c := caching.Init(clusterID, nodeID)
 	c.SetAdminModule(managers.Admin())
 	r.SetCachingModule(c)
 
-	return &Global{letsencrypt: le, metrics: m, routing: r, caching: c}, nil
+	return &Global{letsencrypt: le, metrics: m, routing: r, caching: c, compression: compression.New()}, nil
 }
 
 // LetsEncrypt returns the letsencrypt module
@@ -60,3 +63,8 @@ func (g *Global) Routing() *routing.Routing {
 func (g *Global) Caching() *caching.Cache {
 	return g.caching
 }
+
+// Compression returns the compression module
+func (g *Global) Compression() *compression.Compression {
+	return g.compression
+}
@@ -14,6 +14,8 @@ const (
 	fileModule          = "file"
 	databaseModule      = "db"
 	remoteServiceModule = "remote-service" // aka remote service
+	ingressMirrorModule = "ingress-mirror"
+	ingressTargetModule = "ingress-target"
 	notApplicable       = "na"
 )
 
@@ -61,6 +63,25 @@ func parseFileKey(key string) (module, project, fileStoreType string) {
 	return v[0], v[1], v[2]
 }
 
+// ingressMirrorKey identifies the metrics of the mirror of an ingress route. A struct is used instead of a string
+// key since route ids and targets can contain `:`.
+type ingressMirrorKey struct {
+	project, routeID, target, status string
+}
+
+func generateIngressMirrorKey(project, routeID, target, status string) ingressMirrorKey {
+	return ingressMirrorKey{project: project, routeID: routeID, target: target, status: status}
+}
+
+// ingressTargetKey identifies the metrics of the target of an ingress route
+type ingressTargetKey struct {
+	project, routeID, target string
+}
+
+func generateIngressTargetKey(project, routeID, target string) ingressTargetKey {
+	return ingressTargetKey{project: project, routeID: routeID, target: target}
+}
+
 func (m *Module) createFileDocuments(key string, metrics *metricOperations, t string) []interface{} {
 	docs := make([]interface{}, 0)
 	module, projectName, storeType := parseFileKey(key)
@@ -123,6 +144,25 @@ func (m *Module) createFunctionDocument(key string, count uint64, t string) []in
 	return docs
 }
 
+func (m *Module) createIngressTargetDocument(key ingressTargetKey, value *metricInflight, t string) []interface{} {
+	// The document is created even if no request started since the last flush, so that the inflight gauge drops
+	// back to zero once the outstanding requests complete
+	doc := m.createDocument(key.project, key.target, key.routeID, ingressTargetModule, "requests", value.count, t).(map[string]interface{})
+	doc["inflight"] = value.inflight
+	doc["peak_inflight"] = value.peak
+	return []interface{}{doc}
+}
+
+func (m *Module) createIngressMirrorDocument(key ingressMirrorKey, value *metricLatency, t string) []interface{} {
+	docs := make([]interface{}, 0)
+	if value.count > 0 {
+		doc := m.createDocument(key.project, key.target, key.routeID, ingressMirrorModule, model.OperationType(key.status), value.count, t).(map[string]interface{})
+		doc["latency"] = value.latency / value.count
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
 func (m *Module) createDocument(project, driver, subType, module string, op model.OperationType, count uint64, t string) interface{} {
 	return map[string]interface{}{
 		"id":         ksuid.New().String(),
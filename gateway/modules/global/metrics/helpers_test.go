@@ -288,3 +288,123 @@ func TestModule_createFunctionDocument(t *testing.T) {
 		})
 	}
 }
+
+func TestModule_createIngressMirrorDocument(t *testing.T) {
+	type args struct {
+		key   ingressMirrorKey
+		value *metricLatency
+		t     string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []interface{}
+	}{
+		{
+			name: "valid test case",
+			args: args{
+				key:   generateIngressMirrorKey("project", "route:v1", "orders-v2:8080", "200"),
+				value: &metricLatency{count: 4, latency: 100},
+				t:     mock.Anything,
+			},
+			want: []interface{}{
+				map[string]interface{}{"project_id": "project", "module": ingressMirrorModule, "type": model.OperationType("200"), "sub_type": "route:v1", "ts": mock.Anything, "count": uint64(4), "latency": uint64(25), "driver": "orders-v2:8080", "node_id": "nodeID", "cluster_id": "clusterID"},
+			},
+		},
+		{
+			name: "valid test case count is zero",
+			args: args{
+				key:   generateIngressMirrorKey("project", "route", "orders-v2:8080", "error"),
+				value: &metricLatency{},
+				t:     mock.Anything,
+			},
+			want: []interface{}{},
+		},
+	}
+	m, _ := New("clusterID", "nodeID", false, admin.New("", "clusterID", true, &config.AdminUser{}), &syncman.Manager{}, false)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.createIngressMirrorDocument(tt.args.key, tt.args.value, tt.args.t)
+			if len(got) != len(tt.want) {
+				t.Errorf("createIngressMirrorDocument() want & got length mismatch got = %v want = %v", len(got), len(tt.want))
+			}
+			for index, value := range tt.want {
+				for key, wantValue := range value.(map[string]interface{}) {
+					if key == "id" {
+						continue
+					}
+					gotValue, ok := got[index].(map[string]interface{})[key]
+					if !ok {
+						t.Errorf("createIngressMirrorDocument() key = %s doesn't exist in result", key)
+						continue
+					}
+					if !reflect.DeepEqual(gotValue, wantValue) {
+						t.Errorf("createIngressMirrorDocument() key %v got value = %v %T want = %v %T", key, gotValue, gotValue, wantValue, wantValue)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestModule_createIngressTargetDocument(t *testing.T) {
+	type args struct {
+		key   ingressTargetKey
+		value *metricInflight
+		t     string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []interface{}
+	}{
+		{
+			name: "valid test case",
+			args: args{
+				key:   generateIngressTargetKey("project", "route:v1", "orders:8080"),
+				value: &metricInflight{count: 10, inflight: 3, peak: 5},
+				t:     mock.Anything,
+			},
+			want: []interface{}{
+				map[string]interface{}{"project_id": "project", "module": ingressTargetModule, "type": model.OperationType("requests"), "sub_type": "route:v1", "ts": mock.Anything, "count": uint64(10), "inflight": int64(3), "peak_inflight": uint64(5), "driver": "orders:8080", "node_id": "nodeID", "cluster_id": "clusterID"},
+			},
+		},
+		{
+			name: "only completed requests since the last flush",
+			args: args{
+				key:   generateIngressTargetKey("project", "route", "orders:8080"),
+				value: &metricInflight{inflight: 0},
+				t:     mock.Anything,
+			},
+			want: []interface{}{
+				map[string]interface{}{"project_id": "project", "module": ingressTargetModule, "type": model.OperationType("requests"), "sub_type": "route", "ts": mock.Anything, "count": uint64(0), "inflight": int64(0), "peak_inflight": uint64(0), "driver": "orders:8080", "node_id": "nodeID", "cluster_id": "clusterID"},
+			},
+		},
+	}
+	m, _ := New("clusterID", "nodeID", false, admin.New("", "clusterID", true, &config.AdminUser{}), &syncman.Manager{}, false)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.createIngressTargetDocument(tt.args.key, tt.args.value, tt.args.t)
+			if len(got) != len(tt.want) {
+				t.Errorf("createIngressTargetDocument() want & got length mismatch got = %v want = %v", len(got), len(tt.want))
+			}
+			for index, value := range tt.want {
+				for key, wantValue := range value.(map[string]interface{}) {
+					if key == "id" {
+						continue
+					}
+					gotValue, ok := got[index].(map[string]interface{})[key]
+					if !ok {
+						t.Errorf("createIngressTargetDocument() key = %s doesn't exist in result", key)
+						continue
+					}
+					if !reflect.DeepEqual(gotValue, wantValue) {
+						t.Errorf("createIngressTargetDocument() key %v got value = %v %T want = %v %T", key, gotValue, gotValue, wantValue, wantValue)
+					}
+				}
+			}
+		})
+	}
+}
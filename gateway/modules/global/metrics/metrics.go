@@ -60,6 +60,8 @@ type metrics struct {
 	fileStore metricOperations // key -> storeType value -> *metricOperations
 	eventing  uint64
 	function  uint64
+	mirror    metricLatency  // key -> ingressMirrorKey
+	target    metricInflight // key -> ingressTargetKey
 }
 
 type metricOperations struct {
@@ -69,3 +71,14 @@ type metricOperations struct {
 	delete uint64
 	list   uint64
 }
+
+type metricLatency struct {
+	count   uint64
+	latency uint64 // Total latency in milliseconds
+}
+
+type metricInflight struct {
+	count    uint64
+	inflight int64  // Outstanding requests as of the last request which started or completed
+	peak     uint64 // Maximum number of outstanding requests observed
+}
@@ -90,6 +90,62 @@ func (m *Module) AddFileOperation(project, storeType string, op model.OperationT
 	}
 }
 
+// AddIngressMirrorRequest records the status and latency of a request copied to the mirror of an ingress route
+func (m *Module) AddIngressMirrorRequest(project, routeID, target, status string, latency time.Duration) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	// Return if the metrics module is disabled
+	if m.isMetricDisabled {
+		return
+	}
+
+	metricsTemp, _ := m.projects.LoadOrStore(generateIngressMirrorKey(project, routeID, target, status), newMetrics())
+	metrics := metricsTemp.(*metrics)
+	atomic.AddUint64(&metrics.mirror.count, uint64(1))
+	atomic.AddUint64(&metrics.mirror.latency, uint64(latency.Milliseconds()))
+}
+
+// AddIngressTargetRequest counts the requests proxied to the target of an ingress route along with the peak outstanding requests
+func (m *Module) AddIngressTargetRequest(project, routeID, target string, inflight int64) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	// Return if the metrics module is disabled
+	if m.isMetricDisabled {
+		return
+	}
+
+	metricsTemp, _ := m.projects.LoadOrStore(generateIngressTargetKey(project, routeID, target), newMetrics())
+	metrics := metricsTemp.(*metrics)
+	atomic.AddUint64(&metrics.target.count, uint64(1))
+	metrics.target.setInflight(inflight)
+}
+
+// SetIngressTargetInflight records the outstanding requests of the target of an ingress route once a request to it completes
+func (m *Module) SetIngressTargetInflight(project, routeID, target string, inflight int64) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	// Return if the metrics module is disabled
+	if m.isMetricDisabled {
+		return
+	}
+
+	metricsTemp, _ := m.projects.LoadOrStore(generateIngressTargetKey(project, routeID, target), newMetrics())
+	metricsTemp.(*metrics).target.setInflight(inflight)
+}
+
+func (i *metricInflight) setInflight(inflight int64) {
+	atomic.StoreInt64(&i.inflight, inflight)
+	for {
+		peak := atomic.LoadUint64(&i.peak)
+		if uint64(inflight) <= peak || atomic.CompareAndSwapUint64(&i.peak, peak, uint64(inflight)) {
+			break
+		}
+	}
+}
+
 // LoadMetrics loads the metrics
 // NOTE: test not written for below function
 func (m *Module) LoadMetrics() []interface{} {
@@ -106,15 +162,22 @@ func (m *Module) LoadMetrics() []interface{} {
 
 		// Load the project and metrics object
 		metrics := value.(*metrics)
-		switch getModuleName(key.(string)) {
-		case eventingModule:
-			metricDocs = append(metricDocs, m.createEventDocument(key.(string), metrics.eventing, t)...)
-		case fileModule:
-			metricDocs = append(metricDocs, m.createFileDocuments(key.(string), &metrics.fileStore, t)...)
-		case databaseModule:
-			metricDocs = append(metricDocs, m.createCrudDocuments(key.(string), &metrics.crud, t)...)
-		case remoteServiceModule:
-			metricDocs = append(metricDocs, m.createFunctionDocument(key.(string), metrics.function, t)...)
+		switch k := key.(type) {
+		case ingressTargetKey:
+			metricDocs = append(metricDocs, m.createIngressTargetDocument(k, &metrics.target, t)...)
+		case ingressMirrorKey:
+			metricDocs = append(metricDocs, m.createIngressMirrorDocument(k, &metrics.mirror, t)...)
+		case string:
+			switch getModuleName(k) {
+			case eventingModule:
+				metricDocs = append(metricDocs, m.createEventDocument(k, metrics.eventing, t)...)
+			case fileModule:
+				metricDocs = append(metricDocs, m.createFileDocuments(k, &metrics.fileStore, t)...)
+			case databaseModule:
+				metricDocs = append(metricDocs, m.createCrudDocuments(k, &metrics.crud, t)...)
+			case remoteServiceModule:
+				metricDocs = append(metricDocs, m.createFunctionDocument(k, metrics.function, t)...)
+			}
 		}
 		// Delete the project
 		m.projects.Delete(key)
@@ -0,0 +1,170 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// selectTarget picks the target of the route based on the load balancing strategy configured
+func (r *Routing) selectTarget(ctx context.Context, route *config.Route, request *http.Request, claims interface{}) (config.RouteTarget, error) {
+	lb := route.LoadBalancer
+	if lb == nil {
+		return route.SelectTarget(ctx, -1) // pass a -ve weight to randomly generate
+	}
+
+	switch lb.Strategy {
+	case "", config.LoadBalancingWeighted:
+		return route.SelectTarget(ctx, -1)
+
+	case config.LoadBalancingRoundRobin:
+		targets, err := getActiveTargets(ctx, route)
+		if err != nil {
+			return config.RouteTarget{}, err
+		}
+
+		counterTemp, _ := r.roundRobin.LoadOrStore(getRouteKey(route), new(uint64))
+		n := atomic.AddUint64(counterTemp.(*uint64), 1) - 1
+		return targets[n%uint64(len(targets))], nil
+
+	case config.LoadBalancingLeastRequests:
+		targets, err := getActiveTargets(ctx, route)
+		if err != nil {
+			return config.RouteTarget{}, err
+		}
+
+		// Collect all the targets having the least number of outstanding requests
+		least := make([]config.RouteTarget, 0)
+		var min int64 = math.MaxInt64
+		for _, target := range targets {
+			count := r.getInflightRequests(route, target)
+			if count < min {
+				min = count
+				least = least[:0]
+			}
+			if count == min {
+				least = append(least, target)
+			}
+		}
+		return least[rand.Intn(len(least))], nil
+
+	case config.LoadBalancingConsistentHash:
+		value := getHashValue(request, claims, lb)
+		if value == "" {
+			// Fallback to the weighted strategy if the request doesn't have the value to be hashed
+			helpers.Logger.LogDebug(helpers.GetRequestID(ctx), fmt.Sprintf("Hash value (%s) not found in request for route (%s). Falling back to weighted strategy", lb.HashKey, route.ID), nil)
+			return route.SelectTarget(ctx, -1)
+		}
+
+		targets, err := getActiveTargets(ctx, route)
+		if err != nil {
+			return config.RouteTarget{}, err
+		}
+		return selectTargetByHash(targets, value), nil
+
+	default:
+		return config.RouteTarget{}, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Invalid load balancing strategy (%s) provided for route (%s)", lb.Strategy, route.ID), nil, nil)
+	}
+}
+
+// getActiveTargets returns the targets which haven't been assigned a weight of zero
+func getActiveTargets(ctx context.Context, route *config.Route) ([]config.RouteTarget, error) {
+	targets := make([]config.RouteTarget, 0)
+	for _, target := range route.Targets {
+		if target.Weight > 0 {
+			targets = append(targets, target)
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("No target found for route (%s) - make sure you have defined atleast one target with proper weights", route.Source.URL), nil, nil)
+	}
+	return targets, nil
+}
+
+func getHashValue(request *http.Request, claims interface{}, lb *config.LoadBalancer) string {
+	switch lb.HashOn {
+	case config.HashOnHeader:
+		return request.Header.Get(lb.HashKey)
+
+	case config.HashOnCookie:
+		cookie, err := request.Cookie(lb.HashKey)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+
+	case config.HashOnClaim:
+		value, err := utils.LoadValue("auth."+lb.HashKey, map[string]interface{}{"auth": claims})
+		if err != nil || value == nil {
+			return ""
+		}
+		return fmt.Sprintf("%v", value)
+	}
+
+	return ""
+}
+
+// selectTargetByHash uses rendezvous hashing to pick a target. Only the clients of a removed target get remapped
+// when the list of targets change. The weights of the targets are respected as well.
+func selectTargetByHash(targets []config.RouteTarget, value string) config.RouteTarget {
+	var selected config.RouteTarget
+	maxScore := -1.0
+	for _, target := range targets {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(fmt.Sprintf("%s:%d/%s", target.Host, target.Port, value)))
+
+		// Convert the hash to a float in the range (0, 1)
+		u := (float64(h.Sum64()>>11) + 0.5) / (1 << 53)
+		score := float64(target.Weight) / -math.Log(u)
+		if score > maxScore {
+			maxScore = score
+			selected = target
+		}
+	}
+	return selected
+}
+
+// trackRequest increments the outstanding requests of a target. The function returned must be called once the request is complete.
+func (r *Routing) trackRequest(route *config.Route, target config.RouteTarget) func() {
+	counterTemp, _ := r.inflight.LoadOrStore(getTargetKey(route, target), new(int64))
+	counter := counterTemp.(*int64)
+	count := atomic.AddInt64(counter, 1)
+
+	targetAddr := fmt.Sprintf("%s:%d", target.Host, target.Port)
+	if r.metrics != nil {
+		r.metrics.AddIngressTargetRequest(route.Project, route.ID, targetAddr, count)
+	}
+
+	return func() {
+		count := atomic.AddInt64(counter, -1)
+		if r.metrics != nil {
+			r.metrics.SetIngressTargetInflight(route.Project, route.ID, targetAddr, count)
+		}
+	}
+}
+
+func (r *Routing) getInflightRequests(route *config.Route, target config.RouteTarget) int64 {
+	counterTemp, ok := r.inflight.Load(getTargetKey(route, target))
+	if !ok {
+		return 0
+	}
+	return atomic.LoadInt64(counterTemp.(*int64))
+}
+
+func getRouteKey(route *config.Route) string {
+	return fmt.Sprintf("%s---%s", route.Project, route.ID)
+}
+
+func getTargetKey(route *config.Route, target config.RouteTarget) string {
+	return fmt.Sprintf("%s---%s---%s:%d", route.Project, route.ID, target.Host, target.Port)
+}
@@ -0,0 +1,113 @@
+package routing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+func TestRouting_selectTarget(t *testing.T) {
+	targets := []config.RouteTarget{
+		{Host: "v1", Port: 8080, Weight: 50},
+		{Host: "v2", Port: 8080, Weight: 50},
+		{Host: "disabled", Port: 8080, Weight: 0},
+	}
+
+	t.Run("round robin", func(t *testing.T) {
+		r := New()
+		route := &config.Route{ID: "rr", Project: "project", Targets: targets, LoadBalancer: &config.LoadBalancer{Strategy: config.LoadBalancingRoundRobin}}
+
+		want := []string{"v1", "v2", "v1", "v2"}
+		for i, host := range want {
+			got, err := r.selectTarget(context.Background(), route, httptest.NewRequest(http.MethodGet, "/", nil), nil)
+			if err != nil {
+				t.Fatalf("selectTarget() unexpected error - %v", err)
+			}
+			if got.Host != host {
+				t.Errorf("selectTarget() call %d got = %s, want %s", i, got.Host, host)
+			}
+		}
+	})
+
+	t.Run("least requests", func(t *testing.T) {
+		r := New()
+		metrics := &mockMetrics{}
+		r.SetMetricsModule(metrics)
+		route := &config.Route{ID: "lr", Project: "project", Targets: targets, LoadBalancer: &config.LoadBalancer{Strategy: config.LoadBalancingLeastRequests}}
+
+		done := r.trackRequest(route, targets[0])
+		got, err := r.selectTarget(context.Background(), route, httptest.NewRequest(http.MethodGet, "/", nil), nil)
+		if err != nil {
+			t.Fatalf("selectTarget() unexpected error - %v", err)
+		}
+		if got.Host != "v2" {
+			t.Errorf("selectTarget() got = %s, want v2", got.Host)
+		}
+
+		done()
+		if count := r.getInflightRequests(route, targets[0]); count != 0 {
+			t.Errorf("trackRequest() outstanding requests after completion = %d, want 0", count)
+		}
+		if !reflect.DeepEqual(metrics.inflight, []int64{1, 0}) {
+			t.Errorf("trackRequest() reported inflight requests = %v, want [1 0]", metrics.inflight)
+		}
+	})
+
+	t.Run("consistent hash", func(t *testing.T) {
+		lbs := []*config.LoadBalancer{
+			{Strategy: config.LoadBalancingConsistentHash, HashOn: config.HashOnHeader, HashKey: "X-User"},
+			{Strategy: config.LoadBalancingConsistentHash, HashOn: config.HashOnCookie, HashKey: "session"},
+			{Strategy: config.LoadBalancingConsistentHash, HashOn: config.HashOnClaim, HashKey: "id"},
+		}
+		for _, lb := range lbs {
+			r := New()
+			route := &config.Route{ID: "ch", Project: "project", Targets: targets, LoadBalancer: lb}
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("X-User", "user-1")
+			request.AddCookie(&http.Cookie{Name: "session", Value: "user-1"})
+			claims := map[string]interface{}{"id": "user-1"}
+
+			first, err := r.selectTarget(context.Background(), route, request, claims)
+			if err != nil {
+				t.Fatalf("selectTarget() unexpected error - %v", err)
+			}
+			if first.Host == "disabled" {
+				t.Errorf("selectTarget() selected a target with zero weight")
+			}
+			for i := 0; i < 10; i++ {
+				got, _ := r.selectTarget(context.Background(), route, request, claims)
+				if got != first {
+					t.Errorf("selectTarget() hashing on (%s) is not sticky got = %v, want %v", lb.HashOn, got, first)
+				}
+			}
+		}
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		r := New()
+		route := &config.Route{ID: "invalid", Targets: targets, LoadBalancer: &config.LoadBalancer{Strategy: "random"}}
+		if _, err := r.selectTarget(context.Background(), route, httptest.NewRequest(http.MethodGet, "/", nil), nil); err == nil {
+			t.Errorf("selectTarget() expected error for invalid strategy")
+		}
+	})
+}
+
+func Test_selectTargetByHash(t *testing.T) {
+	targets := []config.RouteTarget{{Host: "v1", Port: 80, Weight: 50}, {Host: "v2", Port: 80, Weight: 50}, {Host: "v3", Port: 80, Weight: 50}}
+
+	// Removing a target must only remap the clients of that target
+	for _, value := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		before := selectTargetByHash(targets, value)
+		if before.Host == "v3" {
+			continue
+		}
+		if after := selectTargetByHash(targets[:2], value); after != before {
+			t.Errorf("selectTargetByHash() remapped value (%s) from %s to %s", value, before.Host, after.Host)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package routing
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+// h2cDialer dials the targets of the h2c client. The http2 transport doesn't pass the context of the request
+// to DialTLS, so the dial is bounded by the timeout of the dialer instead.
+var h2cDialer = &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+
+// h2cClient is used to proxy requests to targets which speak http2 without tls (h2c / grpc)
+var h2cClient = http.Client{
+	Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return h2cDialer.Dial(network, addr)
+		},
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// h2Client is used to proxy requests to targets which speak http2 over tls (grpcs)
+var h2Client = http.Client{
+	Transport: &http2.Transport{},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// getHTTPClient returns the client to be used for the scheme of the selected target
+func getHTTPClient(scheme string) *http.Client {
+	switch scheme {
+	case config.RouteSchemeH2C, config.RouteSchemeGRPC:
+		return &h2cClient
+	case config.RouteSchemeGRPCS:
+		return &h2Client
+	default:
+		return &httpClient
+	}
+}
+
+// getURLScheme returns the scheme to be set in the url of the proxied request
+func getURLScheme(scheme string) string {
+	switch scheme {
+	case "":
+		return "http"
+	case config.RouteSchemeH2C, config.RouteSchemeGRPC:
+		return "http"
+	case config.RouteSchemeGRPCS:
+		return "https"
+	default:
+		return scheme
+	}
+}
+
+func isGRPCRequest(request *http.Request) bool {
+	return strings.HasPrefix(request.Header.Get("Content-Type"), "application/grpc")
+}
+
+// makeGRPCArguments exposes the grpc service, method and metadata of the request to the route rules
+func makeGRPCArguments(request *http.Request) map[string]interface{} {
+	// The path of a grpc call is always of the form `/package.Service/Method`
+	var service, method string
+	arr := strings.Split(strings.TrimPrefix(request.URL.Path, "/"), "/")
+	if len(arr) == 2 {
+		service, method = arr[0], arr[1]
+	}
+
+	// Metadata keys are always lower case in grpc
+	metadata := make(map[string]interface{}, len(request.Header))
+	for k, v := range request.Header {
+		k = strings.ToLower(k)
+		if strings.HasSuffix(k, "-bin") || len(v) == 0 {
+			continue
+		}
+		metadata[k] = v[0]
+	}
+
+	return map[string]interface{}{"service": service, "method": method, "metadata": metadata}
+}
+
+// Status codes of grpc as defined in https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
+const (
+	grpcStatusInvalidArgument  = 3
+	grpcStatusPermissionDenied = 7
+	grpcStatusUnimplemented    = 12
+	grpcStatusInternal         = 13
+	grpcStatusUnavailable      = 14
+)
+
+func getGRPCStatus(httpStatus int) int {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return grpcStatusInvalidArgument
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return grpcStatusPermissionDenied
+	case http.StatusNotFound:
+		return grpcStatusUnimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return grpcStatusUnavailable
+	default:
+		return grpcStatusInternal
+	}
+}
+
+// writeGRPCError sends a trailers-only grpc response since grpc clients don't understand http error codes
+func writeGRPCError(writer http.ResponseWriter, httpStatus int, err error) {
+	writer.Header().Set("Content-Type", "application/grpc")
+	writer.Header().Set("Grpc-Status", strconv.Itoa(getGRPCStatus(httpStatus)))
+	writer.Header().Set("Grpc-Message", url.PathEscape(err.Error()))
+	writer.WriteHeader(http.StatusOK)
+}
+
+// copyTrailers copies the trailers of the upstream response to the client. It must be called
+// after the body has been read completely.
+func copyTrailers(writer http.ResponseWriter, response *http.Response) {
+	for k, v := range response.Trailer {
+		writer.Header()[http.TrailerPrefix+k] = v
+	}
+}
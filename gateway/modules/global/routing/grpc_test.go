@@ -0,0 +1,105 @@
+package routing
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+func Test_makeGRPCArguments(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/helloworld.Greeter/SayHello", nil)
+	request.Header.Set("Content-Type", "application/grpc")
+	request.Header.Set("X-User-Id", "1")
+	request.Header.Set("Trace-Bin", "AAEC")
+
+	want := map[string]interface{}{
+		"service":  "helloworld.Greeter",
+		"method":   "SayHello",
+		"metadata": map[string]interface{}{"content-type": "application/grpc", "x-user-id": "1"},
+	}
+	if got := makeGRPCArguments(request); !reflect.DeepEqual(got, want) {
+		t.Errorf("makeGRPCArguments() = %v, want %v", got, want)
+	}
+}
+
+func Test_writeGRPCError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writeGRPCError(recorder, http.StatusForbidden, errors.New("access denied"))
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("writeGRPCError() status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if got := recorder.Header().Get("Grpc-Status"); got != "7" {
+		t.Errorf("writeGRPCError() grpc status = %s, want 7", got)
+	}
+	if got := recorder.Header().Get("Grpc-Message"); got != "access%20denied" {
+		t.Errorf("writeGRPCError() grpc message = %s, want access%%20denied", got)
+	}
+}
+
+func Test_getURLScheme(t *testing.T) {
+	tests := []struct{ scheme, want string }{
+		{scheme: "", want: "http"},
+		{scheme: "https", want: "https"},
+		{scheme: config.RouteSchemeH2C, want: "http"},
+		{scheme: config.RouteSchemeGRPC, want: "http"},
+		{scheme: config.RouteSchemeGRPCS, want: "https"},
+	}
+	for _, tt := range tests {
+		if got := getURLScheme(tt.scheme); got != tt.want {
+			t.Errorf("getURLScheme(%s) = %s, want %s", tt.scheme, got, tt.want)
+		}
+	}
+}
+
+func TestH2CProxyPreservesTrailers(t *testing.T) {
+	upstream := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			w.WriteHeader(http.StatusHTTPVersionNotSupported)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("payload"))
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	port, _ := strconv.Atoi(u.Port())
+	target := config.RouteTarget{Host: u.Hostname(), Port: int32(port), Scheme: config.RouteSchemeGRPC, Weight: 100}
+
+	request := httptest.NewRequest(http.MethodPost, "/helloworld.Greeter/SayHello", strings.NewReader(""))
+	request.Header.Set("Content-Type", "application/grpc")
+	setRequest(request, target, request.URL.Path)
+
+	response, err := getHTTPClient(target.Scheme).Do(request)
+	if err != nil {
+		t.Fatalf("h2c request failed - %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	_, _ = copyResponse(recorder, response.Body, isStreamingResponse(response))
+	copyTrailers(recorder, response)
+	_ = response.Body.Close()
+
+	body, _ := ioutil.ReadAll(recorder.Result().Body)
+	if string(body) != "payload" {
+		t.Errorf("h2c proxy got body (%s), want (payload)", string(body))
+	}
+	if got := recorder.Result().Trailer.Get("Grpc-Status"); got != "0" {
+		t.Errorf("h2c proxy got trailer grpc-status (%s), want (0)", got)
+	}
+}
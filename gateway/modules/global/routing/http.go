@@ -2,14 +2,15 @@ package routing
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spaceuptech/helpers"
 
@@ -41,15 +42,13 @@ func (r *Routing) HandleRoutes(modules modulesInterface) http.HandlerFunc {
 		// Select a route based on host and url
 		route, err := r.selectRoute(request.Context(), host, request.Method, url)
 		if err != nil {
-			writer.WriteHeader(http.StatusBadRequest)
-			_ = json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+			writeError(writer, request, http.StatusBadRequest, err)
 			return
 		}
 
 		token, claims, status, err := r.modifyRequest(request.Context(), modules, route, request)
 		if err != nil {
-			writer.WriteHeader(status)
-			_ = json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+			writeError(writer, request, status, err)
 			return
 		}
 
@@ -61,12 +60,24 @@ func (r *Routing) HandleRoutes(modules modulesInterface) http.HandlerFunc {
 
 		// Proxy the request
 
-		if err := setRequest(request.Context(), request, route, url); err != nil {
-			writer.WriteHeader(http.StatusInternalServerError)
-			_ = json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+		target, err := r.selectTarget(request.Context(), route, request, claims)
+		if err != nil {
+			writeError(writer, request, http.StatusInternalServerError, err)
 			_ = helpers.Logger.LogError(helpers.GetRequestID(request.Context()), fmt.Sprintf("Failed set request for route (%v)", route), err, nil)
 			return
 		}
+		setRequest(request, target, url)
+
+		// Websockets and other protocol upgrades cannot go through the http client. Rules and
+		// header modifications have already been applied to the handshake by now.
+		if isUpgradeRequest(request) {
+			defer r.trackRequest(route, target)()
+			r.proxyUpgrade(request.Context(), writer, request, route, token, claims)
+			return
+		}
+
+		// Send a copy of the request to the mirrors of this route
+		r.mirrorRequest(request.Context(), request, route)
 
 		var redisKey string
 		if route.IsRouteCacheable && request.Method == http.MethodGet {
@@ -89,6 +100,14 @@ func (r *Routing) HandleRoutes(modules modulesInterface) http.HandlerFunc {
 				for k, v := range result.Headers {
 					writer.Header()[k] = v
 				}
+				setCacheHeaders(writer.Header(), result)
+
+				// The client already has the latest copy of the response
+				if utils.IsETagMatch(request.Header.Get("If-None-Match"), result.ETag) {
+					writer.WriteHeader(http.StatusNotModified)
+					return
+				}
+
 				writer.WriteHeader(http.StatusOK)
 				n, err := io.Copy(writer, ioutil.NopCloser(bytes.NewBuffer(result.Body)))
 				if err != nil {
@@ -100,19 +119,20 @@ func (r *Routing) HandleRoutes(modules modulesInterface) http.HandlerFunc {
 			redisKey = key
 		}
 
-		// TODO: Use http2 client if that was the incoming request protocol
-		response, err := httpClient.Do(request)
+		// Keep track of the outstanding requests of the target till the response has been copied
+		defer r.trackRequest(route, target)()
+
+		// Targets speaking http2 (h2c / grpc) need a http2 client
+		response, err := getHTTPClient(target.Scheme).Do(request)
 		if err != nil {
-			writer.WriteHeader(http.StatusInternalServerError)
-			_ = json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+			writeError(writer, request, http.StatusInternalServerError, err)
 			_ = helpers.Logger.LogError(helpers.GetRequestID(request.Context()), fmt.Sprintf("Failed to make request for route (%v)", route), err, nil)
 			return
 		}
 		defer utils.CloseTheCloser(response.Body)
 
 		if err := r.modifyResponse(request.Context(), response, route, token, claims); err != nil {
-			writer.WriteHeader(http.StatusInternalServerError)
-			_ = json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+			writeError(writer, request, http.StatusInternalServerError, err)
 			return
 		}
 
@@ -142,10 +162,20 @@ func (r *Routing) HandleRoutes(modules modulesInterface) http.HandlerFunc {
 				if err != nil {
 					_ = helpers.Logger.LogError(helpers.GetRequestID(request.Context()), fmt.Sprintf("Failed to copy upstream (%s) response to downstream", request.URL.String()), err, nil)
 				}
-				if err := r.caching.SetIngressRouteKey(request.Context(), redisKey, &config.ReadCacheOptions{TTL: int64(duration)}, &model.CacheIngressRoute{Headers: response.Header, Body: data}); err != nil {
+				cacheEntry := &model.CacheIngressRoute{Headers: response.Header.Clone(), Body: data}
+				if err := r.caching.SetIngressRouteKey(request.Context(), redisKey, &config.ReadCacheOptions{TTL: int64(duration)}, cacheEntry); err != nil {
 					_ = helpers.Logger.LogError(helpers.GetRequestID(request.Context()), fmt.Sprintf("Failed to copy upstream (%s) response to downstream", request.URL.String()), err, nil)
 				}
 				response.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+
+				setCacheHeaders(response.Header, cacheEntry)
+				if response.StatusCode == http.StatusOK && utils.IsETagMatch(request.Header.Get("If-None-Match"), cacheEntry.ETag) {
+					for k, v := range response.Header {
+						writer.Header()[k] = v
+					}
+					writer.WriteHeader(http.StatusNotModified)
+					return
+				}
 			}
 		}
 
@@ -155,16 +185,43 @@ func (r *Routing) HandleRoutes(modules modulesInterface) http.HandlerFunc {
 		}
 		writer.WriteHeader(response.StatusCode)
 
-		// Copy the body
-		n, err := io.Copy(writer, response.Body)
+		// Copy the body. Streaming responses (eg. server sent events) are flushed as they arrive.
+		n, err := copyResponse(writer, response.Body, isStreamingResponse(response))
 		if err != nil {
 			_ = helpers.Logger.LogError(helpers.GetRequestID(request.Context()), fmt.Sprintf("Failed to copy upstream (%s) response to downstream", request.URL.String()), err, nil)
 		}
 
+		// Trailers are only available once the body has been read. Grpc relies on them for the call status.
+		copyTrailers(writer, response)
+
 		helpers.Logger.LogDebug(helpers.GetRequestID(request.Context()), fmt.Sprintf("Successfully copied %d bytes from upstream server (%s)", n, request.URL.String()), nil)
 	}
 }
 
+// setCacheHeaders sets the entity tag and the time left till the cached response expires
+func setCacheHeaders(header http.Header, entry *model.CacheIngressRoute) {
+	if entry.ETag != "" {
+		header.Set("ETag", entry.ETag)
+	}
+	if entry.ExpiresAt > 0 {
+		maxAge := entry.ExpiresAt - time.Now().Unix()
+		if maxAge < 0 {
+			maxAge = 0
+		}
+		header.Set("Cache-Control", fmt.Sprintf("max-age=%d", maxAge))
+	}
+}
+
+func writeError(writer http.ResponseWriter, request *http.Request, status int, err error) {
+	if isGRPCRequest(request) {
+		writeGRPCError(writer, status, err)
+		return
+	}
+
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+}
+
 func getHostAndURL(request *http.Request) (string, string) {
 	return strings.Split(request.Host, ":")[0], request.URL.Path
 }
@@ -180,27 +237,18 @@ func rewriteURL(url string, route *config.Route) string {
 	return url
 }
 
-func setRequest(ctx context.Context, request *http.Request, route *config.Route, url string) error {
+func setRequest(request *http.Request, target config.RouteTarget, url string) {
 	// http: Request.RequestURI can't be set in client requests.
 	// http://golang.org/src/pkg/net/http/client.go
 	request.RequestURI = ""
 
 	// Change the request with the destination host, port and url
-	target, err := route.SelectTarget(ctx, -1) // pass a -ve weight to randomly generate
-	if err != nil {
-		return err
-	}
-
 	request.Host = target.Host
-	request.URL.Host = fmt.Sprintf("%s:%d", target.Host, target.Port)
+	request.URL.Host = net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port)))
 	request.URL.Path = url
 
-	// Set the url scheme to http
-	if target.Scheme == "" {
-		target.Scheme = "http"
-	}
-	request.URL.Scheme = target.Scheme
-	return nil
+	// Set the url scheme to http if not provided. The http2 schemes get mapped to http / https.
+	request.URL.Scheme = getURLScheme(target.Scheme)
 }
 
 func prepareHeaders(headers config.Headers, state map[string]interface{}) config.Headers {
@@ -1,7 +1,6 @@
 package routing
 
 import (
-	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -87,7 +86,7 @@ func Test_rewriteURL(t *testing.T) {
 func Test_setRequest(t *testing.T) {
 	type args struct {
 		request *http.Request
-		route   *config.Route
+		target  config.RouteTarget
 		url     string
 	}
 	tests := []struct {
@@ -99,12 +98,10 @@ func Test_setRequest(t *testing.T) {
 			name: "set request",
 			args: args{
 				url: "/abc",
-				route: &config.Route{
-					Targets: []config.RouteTarget{{
-						Host:   "spacecloud.com",
-						Port:   8080,
-						Weight: 100,
-					}},
+				target: config.RouteTarget{
+					Host:   "spacecloud.com",
+					Port:   8080,
+					Weight: 100,
 				},
 				request: &http.Request{
 					URL: &url.URL{},
@@ -119,11 +116,33 @@ func Test_setRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "set request with an ipv6 target",
+			args: args{
+				url: "/abc",
+				target: config.RouteTarget{
+					Host:   "::1",
+					Port:   8080,
+					Weight: 100,
+				},
+				request: &http.Request{
+					URL: &url.URL{},
+				},
+			},
+			want: &http.Request{
+				Host: "::1",
+				URL: &url.URL{
+					Host:   "[::1]:8080",
+					Path:   "/abc",
+					Scheme: "http",
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_ = setRequest(context.Background(), tt.args.request, tt.args.route, tt.args.url)
+			setRequest(tt.args.request, tt.args.target, tt.args.url)
 			if !reflect.DeepEqual(tt.args.request, tt.want) {
 				t.Errorf("Routing.addProjectRoutes(): wanted - %v; got - %v", tt.want, tt.args.request)
 
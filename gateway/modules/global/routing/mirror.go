@@ -0,0 +1,83 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// mirrorTimeout is the maximum time we wait for a mirror to respond
+const mirrorTimeout = 30 * time.Second
+
+// mirrorRequest sends an asynchronous copy of the request to the mirrors selected for this route. It must be called
+// before the request is proxied to the actual target since the body of the request gets buffered here.
+func (r *Routing) mirrorRequest(ctx context.Context, request *http.Request, route *config.Route) {
+	mirrors := route.SelectMirrors()
+	if len(mirrors) == 0 {
+		return
+	}
+
+	// Requests of unknown length are streams which cannot be buffered
+	if request.ContentLength < 0 {
+		helpers.Logger.LogDebug(helpers.GetRequestID(ctx), fmt.Sprintf("Skipping mirrors of route (%s) as request body is a stream", route.ID), nil)
+		return
+	}
+
+	var body []byte
+	if request.Body != nil {
+		data, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to read request body for mirrors of route (%s)", route.ID), err, nil)
+			return
+		}
+		body = data
+		request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	}
+
+	for _, mirror := range mirrors {
+		// The mirror must not be cancelled once the original request completes
+		mirrorCtx, cancel := context.WithTimeout(context.Background(), mirrorTimeout)
+
+		req := request.Clone(mirrorCtx)
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+		req.Host = mirror.Host
+		req.URL.Host = net.JoinHostPort(mirror.Host, strconv.Itoa(int(mirror.Port)))
+		req.URL.Scheme = getURLScheme(mirror.Scheme)
+
+		go func(mirror config.RouteMirror) {
+			defer cancel()
+			r.sendMirrorRequest(helpers.GetRequestID(ctx), req, route, mirror)
+		}(mirror)
+	}
+}
+
+func (r *Routing) sendMirrorRequest(requestID string, req *http.Request, route *config.Route, mirror config.RouteMirror) {
+	target := net.JoinHostPort(mirror.Host, strconv.Itoa(int(mirror.Port)))
+
+	start := time.Now()
+	status := "error"
+	res, err := getHTTPClient(mirror.Scheme).Do(req)
+	if err != nil {
+		helpers.Logger.LogDebug(requestID, fmt.Sprintf("Unable to send request to mirror (%s) of route (%s)", target, route.ID), map[string]interface{}{"error": err.Error()})
+	} else {
+		// Discard the response of the mirror
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		utils.CloseTheCloser(res.Body)
+		status = strconv.Itoa(res.StatusCode)
+	}
+
+	if r.metrics != nil {
+		r.metrics.AddIngressMirrorRequest(route.Project, route.ID, target, status, time.Since(start))
+	}
+}
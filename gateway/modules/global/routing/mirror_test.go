@@ -0,0 +1,91 @@
+package routing
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+type mockMetrics struct {
+	sync.Mutex
+	calls    []string
+	inflight []int64
+	done     chan struct{}
+}
+
+func (m *mockMetrics) AddIngressMirrorRequest(project, routeID, target, status string, latency time.Duration) {
+	m.Lock()
+	m.calls = append(m.calls, strings.Join([]string{project, routeID, target, status}, "/"))
+	m.Unlock()
+	m.done <- struct{}{}
+}
+
+func (m *mockMetrics) AddIngressTargetRequest(project, routeID, target string, inflight int64) {
+	m.Lock()
+	m.inflight = append(m.inflight, inflight)
+	m.Unlock()
+}
+
+func (m *mockMetrics) SetIngressTargetInflight(project, routeID, target string, inflight int64) {
+	m.Lock()
+	m.inflight = append(m.inflight, inflight)
+	m.Unlock()
+}
+
+func TestRouting_mirrorRequest(t *testing.T) {
+	bodies := make(chan string, 1)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(data)
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer mirror.Close()
+
+	u, _ := url.Parse(mirror.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	m := &mockMetrics{done: make(chan struct{}, 2)}
+	r := New()
+	r.SetMetricsModule(m)
+
+	route := &config.Route{
+		ID:      "route",
+		Project: "project",
+		Mirrors: []config.RouteMirror{{Host: u.Hostname(), Port: int32(port), Percentage: 100}, {Host: "skipped", Port: 80, Percentage: 0}},
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/orders", strings.NewReader(`{"id":1}`))
+	request.RequestURI = ""
+	r.mirrorRequest(request.Context(), request, route)
+
+	// The original request must still have its body
+	data, _ := ioutil.ReadAll(request.Body)
+	if string(data) != `{"id":1}` {
+		t.Errorf("mirrorRequest() original body = %s", string(data))
+	}
+
+	select {
+	case body := <-bodies:
+		if body != `{"id":1}` {
+			t.Errorf("mirrorRequest() mirror got body = %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("mirrorRequest() mirror did not receive the request")
+	}
+
+	<-m.done
+	m.Lock()
+	defer m.Unlock()
+	want := "project/route/" + u.Host + "/418"
+	if len(m.calls) != 1 || m.calls[0] != want {
+		t.Errorf("mirrorRequest() recorded metrics = %v, want [%s]", m.calls, want)
+	}
+}
@@ -42,6 +42,9 @@ func (r *Routing) modifyRequest(ctx context.Context, modules modulesInterface, r
 	}
 
 	args := map[string]interface{}{"params": params, "query": makeQueryArguments(req)}
+	if isGRPCRequest(req) {
+		args["grpc"] = makeGRPCArguments(req)
+	}
 	auth, err := a.AuthorizeRequest(ctx, route.Rule, route.Project, token, args)
 	if err != nil {
 		return "", nil, http.StatusForbidden, err
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/model"
@@ -16,7 +17,12 @@ type Routing struct {
 	routes       config.Routes
 	globalConfig *config.GlobalRoutesConfig
 	caching      cachingInterface
+	metrics      metricsInterface
 	goTemplates  map[string]*template.Template
+
+	// Load balancing state
+	roundRobin sync.Map // key -> project---routeID; value -> *uint64
+	inflight   sync.Map // key -> project---routeID---host:port; value -> *int64
 }
 
 // New creates a new instance of the routing module
@@ -29,6 +35,17 @@ func (r *Routing) SetCachingModule(c cachingInterface) {
 	r.caching = c
 }
 
+// SetMetricsModule sets metrics module
+func (r *Routing) SetMetricsModule(m metricsInterface) {
+	r.metrics = m
+}
+
+type metricsInterface interface {
+	AddIngressMirrorRequest(project, routeID, target, status string, latency time.Duration)
+	AddIngressTargetRequest(project, routeID, target string, inflight int64)
+	SetIngressTargetInflight(project, routeID, target string, inflight int64)
+}
+
 type cachingInterface interface {
 	SetIngressRouteKey(ctx context.Context, redisKey string, cache *config.ReadCacheOptions, result *model.CacheIngressRoute) error
 	GetIngressRoute(ctx context.Context, routeID string, cacheOptions []interface{}) (string, bool, *model.CacheIngressRoute, error)
@@ -0,0 +1,174 @@
+package routing
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// isUpgradeRequest checks if the client wants to switch protocols (eg. websockets)
+func isUpgradeRequest(request *http.Request) bool {
+	return request.Header.Get("Upgrade") != "" && headerHasToken(request.Header, "Connection", "upgrade")
+}
+
+// isStreamingResponse checks if the upstream response needs to be flushed to the client as it arrives
+func isStreamingResponse(response *http.Response) bool {
+	if strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+		return true
+	}
+
+	// Responses without a known length (chunked) are streamed as well
+	return response.ContentLength == -1
+}
+
+func headerHasToken(header http.Header, key, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(key)] {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copyResponse copies the upstream body to the client. Each chunk is flushed immediately if the response is a stream.
+func copyResponse(writer http.ResponseWriter, body io.Reader, stream bool) (int64, error) {
+	flusher, ok := writer.(http.Flusher)
+	if !stream || !ok {
+		return io.Copy(writer, body)
+	}
+
+	var written int64
+	buf := make([]byte, 32*1024)
+	for {
+		nr, readErr := body.Read(buf)
+		if nr > 0 {
+			nw, err := writer.Write(buf[:nr])
+			written += int64(nw)
+			if err != nil {
+				return written, err
+			}
+			flusher.Flush()
+		}
+		if readErr == io.EOF {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
+
+func dialTarget(ctx context.Context, addr, scheme string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	switch scheme {
+	case "https", "wss":
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			// The address doesn't have a port
+			host = addr
+		}
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		return tlsDialer.DialContext(ctx, "tcp", addr)
+	default:
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+}
+
+// proxyUpgrade forwards a protocol upgrade request to the upstream target. Once the upstream agrees to switch
+// protocols, the client connection is hijacked and bytes are piped in both directions till either side closes.
+func (r *Routing) proxyUpgrade(ctx context.Context, writer http.ResponseWriter, request *http.Request, route *config.Route, token string, claims interface{}) {
+	upstream, err := dialTarget(ctx, request.URL.Host, request.URL.Scheme)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Failed to dial upstream for route (%v)", route.ID), err, nil)
+		_ = helpers.Response.SendErrorResponse(ctx, writer, http.StatusBadGateway, err)
+		return
+	}
+	defer utils.CloseTheCloser(upstream)
+
+	// Send the handshake to the upstream server
+	if err := request.Write(upstream); err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Failed to write upgrade request for route (%v)", route.ID), err, nil)
+		_ = helpers.Response.SendErrorResponse(ctx, writer, http.StatusBadGateway, err)
+		return
+	}
+
+	upstreamReader := bufio.NewReader(upstream)
+	response, err := http.ReadResponse(upstreamReader, request)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Failed to read upgrade response for route (%v)", route.ID), err, nil)
+		_ = helpers.Response.SendErrorResponse(ctx, writer, http.StatusBadGateway, err)
+		return
+	}
+	defer utils.CloseTheCloser(response.Body)
+
+	if err := r.modifyResponse(ctx, response, route, token, claims); err != nil {
+		_ = helpers.Response.SendErrorResponse(ctx, writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The upstream refused to switch protocols. Simply forward whatever it responded with.
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		for k, v := range response.Header {
+			writer.Header()[k] = v
+		}
+		writer.WriteHeader(response.StatusCode)
+		if _, err := io.Copy(writer, response.Body); err != nil {
+			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Failed to copy upstream (%s) response to downstream", request.URL.String()), err, nil)
+		}
+		return
+	}
+
+	hijacker, ok := writer.(http.Hijacker)
+	if !ok {
+		_ = helpers.Response.SendErrorResponse(ctx, writer, http.StatusInternalServerError, fmt.Errorf("response writer does not support protocol upgrades"))
+		return
+	}
+
+	client, clientBuf, err := hijacker.Hijack()
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Failed to hijack client connection for route (%v)", route.ID), err, nil)
+		return
+	}
+	defer utils.CloseTheCloser(client)
+
+	// Complete the handshake with the client
+	if _, err := fmt.Fprintf(clientBuf, "HTTP/1.1 %s\r\n", response.Status); err != nil {
+		return
+	}
+	if err := response.Header.Write(clientBuf); err != nil {
+		return
+	}
+	if _, err := clientBuf.WriteString("\r\n"); err != nil {
+		return
+	}
+	if err := clientBuf.Flush(); err != nil {
+		return
+	}
+
+	helpers.Logger.LogDebug(helpers.GetRequestID(ctx), fmt.Sprintf("Switched protocols to (%s) for route (%s)", response.Header.Get("Upgrade"), route.ID), nil)
+
+	// Pipe the data in both directions. Buffered readers are used so that no bytes read ahead during the handshake are lost.
+	errCh := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(upstream, clientBuf)
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(client, upstreamReader)
+		errCh <- err
+	}()
+	<-errCh
+}
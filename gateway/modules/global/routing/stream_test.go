@@ -0,0 +1,171 @@
+package routing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+func Test_isUpgradeRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   bool
+	}{
+		{
+			name:   "websocket upgrade",
+			header: http.Header{"Connection": []string{"Upgrade"}, "Upgrade": []string{"websocket"}},
+			want:   true,
+		},
+		{
+			name:   "upgrade as one of many connection tokens",
+			header: http.Header{"Connection": []string{"keep-alive, Upgrade"}, "Upgrade": []string{"websocket"}},
+			want:   true,
+		},
+		{
+			name:   "upgrade header without connection token",
+			header: http.Header{"Connection": []string{"keep-alive"}, "Upgrade": []string{"websocket"}},
+			want:   false,
+		},
+		{
+			name:   "plain request",
+			header: http.Header{},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUpgradeRequest(&http.Request{Header: tt.header}); got != tt.want {
+				t.Errorf("isUpgradeRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isStreamingResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *http.Response
+		want     bool
+	}{
+		{
+			name:     "server sent events",
+			response: &http.Response{Header: http.Header{"Content-Type": []string{"text/event-stream; charset=utf-8"}}, ContentLength: 100},
+			want:     true,
+		},
+		{
+			name:     "chunked response",
+			response: &http.Response{Header: http.Header{"Content-Type": []string{"application/json"}}, ContentLength: -1},
+			want:     true,
+		},
+		{
+			name:     "response of known length",
+			response: &http.Response{Header: http.Header{"Content-Type": []string{"application/json"}}, ContentLength: 10},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isStreamingResponse(tt.response); got != tt.want {
+				t.Errorf("isStreamingResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_copyResponse(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	n, err := copyResponse(recorder, strings.NewReader("data: hello\n\n"), true)
+	if err != nil {
+		t.Fatalf("copyResponse() unexpected error - %v", err)
+	}
+	if n != 13 || recorder.Body.String() != "data: hello\n\n" {
+		t.Errorf("copyResponse() copied (%d) bytes - %s", n, recorder.Body.String())
+	}
+	if !recorder.Flushed {
+		t.Errorf("copyResponse() did not flush the streaming response")
+	}
+}
+
+func TestRouting_proxyUpgrade(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Route") != "modified" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(msgType, data); err != nil {
+				return
+			}
+		}
+	}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	port, _ := strconv.Atoi(u.Port())
+	route := &config.Route{ID: "ws", Targets: []config.RouteTarget{{Host: u.Hostname(), Port: int32(port), Weight: 100}}}
+	route.Modify.ResponseHeaders = config.Headers{{Key: "X-Proxied", Value: "true"}}
+
+	r := New()
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.Header.Set("X-Route", "modified")
+		setRequest(req, route.Targets[0], req.URL.Path)
+		r.proxyUpgrade(context.Background(), w, req, route, "", nil)
+	}))
+	defer gateway.Close()
+
+	conn, res, err := websocket.DefaultDialer.Dial(strings.Replace(gateway.URL, "http", "ws", 1)+"/socket", nil)
+	if err != nil {
+		t.Fatalf("proxyUpgrade() could not establish websocket connection - %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if res.Header.Get("X-Proxied") != "true" {
+		t.Errorf("proxyUpgrade() response headers not modified - %v", res.Header)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatalf("proxyUpgrade() could not write message - %v", err)
+	}
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("proxyUpgrade() could not read message - %v", err)
+	}
+	if string(data) != "ping" {
+		t.Errorf("proxyUpgrade() got message (%s), want (ping)", string(data))
+	}
+}
+
+func Test_dialTarget(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	// A cancelled context stops the dial for both plain and tls targets
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, scheme := range []string{"http", "https", "wss"} {
+		if conn, err := dialTarget(ctx, addr, scheme); err == nil {
+			_ = conn.Close()
+			t.Errorf("dialTarget() with scheme (%s) expected error for cancelled context", scheme)
+		}
+	}
+}
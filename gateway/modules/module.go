@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"os"
+
 	"github.com/spaceuptech/space-cloud/gateway/managers"
 	"github.com/spaceuptech/space-cloud/gateway/modules/auth"
 	"github.com/spaceuptech/space-cloud/gateway/modules/crud"
@@ -12,6 +14,7 @@ import (
 	"github.com/spaceuptech/space-cloud/gateway/modules/schema"
 	"github.com/spaceuptech/space-cloud/gateway/modules/userman"
 	"github.com/spaceuptech/space-cloud/gateway/utils/graphql"
+	"github.com/spaceuptech/space-cloud/gateway/utils/pubsub"
 )
 
 // Module is an object that sets up the modules
@@ -53,6 +56,14 @@ func newModule(projectID, clusterID, nodeID string, managers *managers.Managers,
 	a := auth.Init(clusterID, nodeID, c, adminMan, integrationMan)
 	a.SetMakeHTTPRequest(syncMan.MakeHTTPRequest)
 
+	// Revocations of jwt and the openid connect nonces used by admins are stored in redis to share them with the other gateways
+	authPubsubClient, err := pubsub.New(projectID, os.Getenv("REDIS_CONN"))
+	if err != nil {
+		return nil, err
+	}
+	a.SetPubsubClient(authPubsubClient)
+	adminMan.SetPubsubClient(authPubsubClient)
+
 	fn := functions.Init(clusterID, a, syncMan, integrationMan, metrics.AddFunctionOperation)
 	fn.SetCachingModule(globalMods.Caching())
 	f := filestore.Init(a, metrics.AddFileOperation)
@@ -73,6 +84,8 @@ func newModule(projectID, clusterID, nodeID string, managers *managers.Managers,
 	}
 
 	u := userman.Init(c, a)
+	u.SetEventingModule(e)
+	u.SetPubsubClient(authPubsubClient)
 	graphqlMan := graphql.New(a, c, fn, s)
 
 	return &Module{auth: a, db: c, user: u, file: f, functions: fn, realtime: rt, eventing: e, graphql: graphqlMan, schema: s, Managers: managers, GlobalMods: globalMods}, nil
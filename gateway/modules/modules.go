@@ -50,7 +50,14 @@ func (m *Modules) SetInitialProjectConfig(ctx context.Context, projects config.P
 			}
 		}
 
-		if err := module.SetInitialProjectConfig(ctx, config.Projects{projectID: project}); err != nil {
+		// The db and file store modules resolve the placeholders in their config themselves
+		resolved := *project
+		if err := m.interpolate(ctx, projectID, &resolved); err != nil {
+			return err
+		}
+		resolved.DatabaseConfigs, resolved.FileStoreConfig = project.DatabaseConfigs, project.FileStoreConfig
+
+		if err := module.SetInitialProjectConfig(ctx, config.Projects{projectID: &resolved}); err != nil {
 			return err
 		}
 	}
@@ -66,6 +73,9 @@ func (m *Modules) SetProjectConfig(ctx context.Context, config *config.ProjectCo
 			return err
 		}
 	}
+	if err := m.interpolate(ctx, config.ID, &config); err != nil {
+		return err
+	}
 	return module.SetProjectConfig(ctx, config)
 }
 
@@ -75,6 +85,9 @@ func (m *Modules) SetDatabaseConfig(ctx context.Context, projectID string, datab
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &schemaConfigs, &ruleConfigs, &prepConfigs); err != nil {
+		return err
+	}
 	return module.SetDatabaseConfig(ctx, projectID, databaseConfigs, schemaConfigs, ruleConfigs, prepConfigs)
 }
 
@@ -84,6 +97,9 @@ func (m *Modules) SetDatabaseSchemaConfig(ctx context.Context, projectID string,
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &schemaConfigs); err != nil {
+		return err
+	}
 	return module.SetDatabaseSchemaConfig(ctx, projectID, schemaConfigs)
 }
 
@@ -93,6 +109,9 @@ func (m *Modules) SetDatabaseRulesConfig(ctx context.Context, projectID string,
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &ruleConfigs); err != nil {
+		return err
+	}
 	return module.SetDatabaseRulesConfig(ctx, projectID, ruleConfigs)
 }
 
@@ -102,6 +121,9 @@ func (m *Modules) SetDatabasePreparedQueryConfig(ctx context.Context, projectID
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &prepConfigs); err != nil {
+		return err
+	}
 	return module.SetDatabasePreparedQueryConfig(ctx, prepConfigs)
 }
 
@@ -120,6 +142,9 @@ func (m *Modules) SetFileStoreSecurityRuleConfig(ctx context.Context, projectID
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &fileStoreRules); err != nil {
+		return err
+	}
 	module.SetFileStoreSecurityRuleConfig(ctx, projectID, fileStoreRules)
 	return nil
 }
@@ -130,6 +155,9 @@ func (m *Modules) SetEventingConfig(ctx context.Context, projectID string, event
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &eventingConfig, &secureObj, &eventingSchemas, &eventingTriggers); err != nil {
+		return err
+	}
 	return module.SetEventingConfig(ctx, projectID, eventingConfig, secureObj, eventingSchemas, eventingTriggers)
 }
 
@@ -139,6 +167,9 @@ func (m *Modules) SetEventingSchemaConfig(ctx context.Context, projectID string,
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &eventingSchemas); err != nil {
+		return err
+	}
 	return module.SetEventingSchemaConfig(ctx, eventingSchemas)
 }
 
@@ -148,6 +179,9 @@ func (m *Modules) SetEventingTriggerConfig(ctx context.Context, projectID string
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &eventingTriggers); err != nil {
+		return err
+	}
 	return module.SetEventingTriggerConfig(ctx, eventingTriggers)
 }
 
@@ -157,15 +191,45 @@ func (m *Modules) SetEventingRuleConfig(ctx context.Context, projectID string, s
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &secureObj); err != nil {
+		return err
+	}
 	return module.SetEventingRuleConfig(ctx, secureObj)
 }
 
+// SetSecurityPolicyConfig sets the rego policies used by the security rules
+func (m *Modules) SetSecurityPolicyConfig(ctx context.Context, projectID string, policies config.SecurityPolicies) error {
+	module, err := m.loadModule(projectID)
+	if err != nil {
+		return err
+	}
+	if err := m.interpolate(ctx, projectID, &policies); err != nil {
+		return err
+	}
+	return module.SetSecurityPolicyConfig(ctx, policies)
+}
+
+// SetAPIKeyConfig sets the api keys of the project
+func (m *Modules) SetAPIKeyConfig(ctx context.Context, projectID string, apiKeys config.APIKeys) error {
+	module, err := m.loadModule(projectID)
+	if err != nil {
+		return err
+	}
+	if err := m.interpolate(ctx, projectID, &apiKeys); err != nil {
+		return err
+	}
+	return module.SetAPIKeyConfig(ctx, apiKeys)
+}
+
 // SetUsermanConfig set the config of the userman module
 func (m *Modules) SetUsermanConfig(ctx context.Context, projectID string, auth config.Auths) error {
 	module, err := m.loadModule(projectID)
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &auth); err != nil {
+		return err
+	}
 	return module.SetUsermanConfig(ctx, projectID, auth)
 }
 
@@ -175,6 +239,9 @@ func (m *Modules) SetLetsencryptConfig(ctx context.Context, projectID string, c
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &c); err != nil {
+		return err
+	}
 	return module.SetLetsencryptConfig(ctx, projectID, c)
 }
 
@@ -184,6 +251,9 @@ func (m *Modules) SetIngressRouteConfig(ctx context.Context, projectID string, r
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &routes); err != nil {
+		return err
+	}
 	return module.SetIngressRouteConfig(ctx, projectID, routes)
 }
 
@@ -193,6 +263,9 @@ func (m *Modules) SetIngressGlobalRouteConfig(ctx context.Context, projectID str
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &c); err != nil {
+		return err
+	}
 	return module.SetIngressGlobalRouteConfig(ctx, projectID, c)
 }
 
@@ -202,9 +275,26 @@ func (m *Modules) SetRemoteServiceConfig(ctx context.Context, projectID string,
 	if err != nil {
 		return err
 	}
+	if err := m.interpolate(ctx, projectID, &services); err != nil {
+		return err
+	}
 	return module.SetRemoteServiceConfig(ctx, projectID, services)
 }
 
+// interpolate resolves the placeholders in the config of a project before it is passed on to the modules.
+// The config stored by syncman keeps the placeholders.
+func (m *Modules) interpolate(ctx context.Context, projectID string, ptrs ...interface{}) error {
+	getSecret := func(secretName, key string) (string, error) {
+		return m.Managers.Sync().GetSecrets(projectID, secretName, key)
+	}
+	for _, ptr := range ptrs {
+		if err := config.Interpolate(ctx, ptr, getSecret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *Modules) projects() *config.Config {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
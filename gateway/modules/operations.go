@@ -7,18 +7,22 @@ import (
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	schemaHelpers "github.com/spaceuptech/space-cloud/gateway/modules/schema/helpers"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
 )
 
 // SetInitialProjectConfig sets the config all modules
 func (m *Module) SetInitialProjectConfig(ctx context.Context, projects config.Projects) error {
 	for projectID, project := range projects {
+		aesKeys, err := utils.NewProjectAESKeyRing(project.ProjectConfig)
+		if err != nil {
+			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to create aes key ring of project", err, nil)
+		}
+
 		helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Setting config of db module", nil)
+		m.db.SetProjectAESKey(aesKeys)
 		if err := m.db.SetConfig(projectID, project.DatabaseConfigs); err != nil {
 			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to set db module config", err, nil)
 		}
-		if err := m.db.SetProjectAESKey(project.ProjectConfig.AESKey); err != nil {
-			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to set aes key for db module config", err, nil)
-		}
 
 		schemaDoc, err := schemaHelpers.Parser(project.DatabaseSchemas)
 		if err != nil {
@@ -40,6 +44,10 @@ func (m *Module) SetInitialProjectConfig(ctx context.Context, projects config.Pr
 		if err := m.auth.SetConfig(ctx, project.FileStoreConfig.StoreType, project.ProjectConfig, project.DatabaseRules, project.DatabasePreparedQueries, project.FileStoreRules, project.RemoteService, project.EventingRules); err != nil {
 			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to set auth module config", err, nil)
 		}
+		if err := m.auth.SetSecurityPolicies(ctx, project.SecurityPolicies); err != nil {
+			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to set security policies of auth module", err, nil)
+		}
+		m.auth.SetAPIKeys(project.APIKeys)
 
 		helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Setting config of functions module", nil)
 		if err := m.functions.SetConfig(projectID, project.RemoteService); err != nil {
@@ -48,9 +56,7 @@ func (m *Module) SetInitialProjectConfig(ctx context.Context, projects config.Pr
 
 		helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Setting config of user management module", nil)
 		m.user.SetConfig(project.Auths)
-		if err := m.user.SetProjectAESKey(project.ProjectConfig.AESKey); err != nil {
-			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to set aes key for user module config", err, nil)
-		}
+		m.user.SetProjectAESKey(aesKeys)
 
 		helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Setting config of file storage module", nil)
 		if err := m.file.SetConfig(projectID, project.FileStoreConfig); err != nil {
@@ -71,15 +77,11 @@ func (m *Module) SetInitialProjectConfig(ctx context.Context, projects config.Pr
 		if err := m.realtime.SetConfig(project.DatabaseConfigs, project.DatabaseRules, project.DatabaseSchemas); err != nil {
 			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to set realtime module config", err, nil)
 		}
-		if err := m.realtime.SetProjectAESKey(project.ProjectConfig.AESKey); err != nil {
-			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to set aes key for realtime module config", err, nil)
-		}
+		m.realtime.SetProjectAESKey(aesKeys)
 
 		helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Setting config of graphql module", nil)
 		m.graphql.SetConfig(projectID)
-		if err := m.graphql.SetProjectAESKey(project.ProjectConfig.AESKey); err != nil {
-			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to set aes key for graphql module config", err, nil)
-		}
+		m.graphql.SetProjectAESKey(aesKeys)
 
 		helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Setting config of lets encrypt module", nil)
 		if err := m.GlobalMods.LetsEncrypt().SetProjectDomains(projectID, project.LetsEncrypt); err != nil {
@@ -103,10 +105,13 @@ func (m *Module) SetProjectConfig(ctx context.Context, p *config.ProjectConfig)
 	if err := m.auth.SetProjectConfig(p); err != nil {
 		return err
 	}
-	_ = m.db.SetProjectAESKey(p.AESKey)
-	_ = m.realtime.SetProjectAESKey(p.AESKey)
-	_ = m.user.SetProjectAESKey(p.AESKey)
-	_ = m.graphql.SetProjectAESKey(p.AESKey)
+
+	// The aes key ring has already been validated by the auth module
+	aesKeys := m.auth.GetAESKeyRing()
+	m.db.SetProjectAESKey(aesKeys)
+	m.realtime.SetProjectAESKey(aesKeys)
+	m.user.SetProjectAESKey(aesKeys)
+	m.graphql.SetProjectAESKey(aesKeys)
 	m.graphql.SetConfig(p.ID)
 	return nil
 }
@@ -239,6 +244,19 @@ func (m *Module) SetEventingRuleConfig(ctx context.Context, secureObj config.Eve
 	return nil
 }
 
+// SetSecurityPolicyConfig sets the rego policies used by the security rules
+func (m *Module) SetSecurityPolicyConfig(ctx context.Context, policies config.SecurityPolicies) error {
+	helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Setting security policies of auth module", nil)
+	return m.auth.SetSecurityPolicies(ctx, policies)
+}
+
+// SetAPIKeyConfig sets the api keys of the project
+func (m *Module) SetAPIKeyConfig(ctx context.Context, apiKeys config.APIKeys) error {
+	helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Setting api keys of auth module", nil)
+	m.auth.SetAPIKeys(apiKeys)
+	return nil
+}
+
 // SetUsermanConfig set the config of the userman module
 func (m *Module) SetUsermanConfig(ctx context.Context, _ string, auth config.Auths) error {
 	helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Setting config of user management module", nil)
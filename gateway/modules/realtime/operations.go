@@ -50,7 +50,7 @@ func (m *Module) DoRealtimeSubscribe(ctx context.Context, clientID string, data
 		return nil, err
 	}
 
-	_ = authHelpers.PostProcessMethod(ctx, m.aesKey, actions, result)
+	_ = authHelpers.PostProcessMethod(ctx, m.aesKeys, actions, result)
 
 	feedData := make([]*model.FeedData, 0)
 	array, ok := result.([]interface{})
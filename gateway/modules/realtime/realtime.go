@@ -1,7 +1,6 @@
 package realtime
 
 import (
-	"encoding/base64"
 	"os"
 	"sync"
 
@@ -9,6 +8,7 @@ import (
 	"github.com/spaceuptech/space-cloud/gateway/managers/syncman"
 	"github.com/spaceuptech/space-cloud/gateway/model"
 	"github.com/spaceuptech/space-cloud/gateway/modules/global/metrics"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
 	"github.com/spaceuptech/space-cloud/gateway/utils/pubsub"
 )
 
@@ -37,7 +37,7 @@ type Module struct {
 	pubsubClient *pubsub.Module
 
 	// Auth module
-	aesKey []byte
+	aesKeys *utils.AESKeyRing
 }
 
 // Init creates a new instance of the realtime module
@@ -107,17 +107,12 @@ func (m *Module) SetDatabaseSchemas(databaseSchemas config.DatabaseSchemas) {
 	m.eventing.SetRealtimeTriggers(generateEventRules(m.dbConfigs, m.dbRules, m.dbSchemas, m.project, url))
 }
 
-// SetProjectAESKey set aes key
-func (m *Module) SetProjectAESKey(aesKey string) error {
+// SetProjectAESKey set aes key ring
+func (m *Module) SetProjectAESKey(aesKeys *utils.AESKeyRing) {
 	m.Lock()
 	defer m.Unlock()
 
-	decodedAESKey, err := base64.StdEncoding.DecodeString(aesKey)
-	if err != nil {
-		return err
-	}
-	m.aesKey = decodedAESKey
-	return nil
+	m.aesKeys = aesKeys
 }
 
 // CloseConfig close the rules and secret key required by the realtime block
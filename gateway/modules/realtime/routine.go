@@ -35,13 +35,13 @@ func (m *Module) helperSendFeed(ctx context.Context, data *model.FeedData) {
 
 			switch data.Type {
 			case utils.RealtimeDelete:
-				_ = authHelpers.PostProcessMethod(ctx, m.aesKey, query.actions, dataPoint.Payload)
+				_ = authHelpers.PostProcessMethod(ctx, m.aesKeys, query.actions, dataPoint.Payload)
 				query.sendFeed(dataPoint)
 				m.metrics.AddDBOperation(m.project, data.DBType, data.Group, 1, model.Read)
 
 			case utils.RealtimeInsert, utils.RealtimeUpdate:
 				if utils.Validate(model.DefaultValidate, query.whereObj, data.Payload) {
-					_ = authHelpers.PostProcessMethod(ctx, m.aesKey, query.actions, dataPoint.Payload)
+					_ = authHelpers.PostProcessMethod(ctx, m.aesKeys, query.actions, dataPoint.Payload)
 					query.sendFeed(dataPoint)
 					m.metrics.AddDBOperation(m.project, data.DBType, data.Group, 1, model.Read)
 				}
@@ -0,0 +1,327 @@
+package userman
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// Fields of the users table managed by admins. SQL databases need these columns for the admin operations.
+const (
+	userFieldDisabled              = "disabled"
+	userFieldPasswordResetRequired = "passwordResetRequired"
+)
+
+const (
+	defaultUserSearchLimit = 20
+	maxUserSearchLimit     = 100
+)
+
+// CreateUser creates a user on behalf of an admin. Users created without a password are mailed a password reset
+// token to set one.
+func (m *Module) CreateUser(ctx context.Context, dbAlias, project, actor string, req *model.UserAdminRequest) (int, map[string]interface{}, error) {
+	stub, err := m.getEmailStub()
+	if err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	if req.Email == "" {
+		return http.StatusBadRequest, nil, errors.New("Email not provided")
+	}
+	if req.Pass == "" && stub.Mailer == nil {
+		return http.StatusBadRequest, nil, errors.New("Password not provided and no mailer has been configured to invite the user")
+	}
+	if _, _, err := m.readUser(ctx, dbAlias, project, map[string]interface{}{"email": req.Email}); err == nil {
+		return http.StatusConflict, nil, errors.New("User with provided email already exists")
+	}
+
+	idField, err := m.getIDField(dbAlias)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	role := req.Role
+	if role == "" {
+		role = "user"
+	}
+	userObj := map[string]interface{}{idField: uuid.NewV1().String(), "email": req.Email, "name": req.Name, "role": role, "pass": "", userFieldDisabled: false}
+	if req.Pass != "" {
+		hash, err := m.hashPassword(req.Pass)
+		if err != nil {
+			return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Failed to hash password", err, nil)
+		}
+		userObj["pass"] = hash
+	}
+	if stub.Mailer != nil {
+		userObj[userFieldVerified] = false
+	}
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": "users"}
+	reqParams := model.RequestParams{Resource: "db-create", Op: "access", Attributes: attr}
+	if err := m.crud.Create(ctx, dbAlias, "users", &model.CreateRequest{Operation: utils.One, Document: userObj}, reqParams); err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Failed to create user account", err, nil)
+	}
+
+	// The reset token verifies the email as well, hence invited users only get that one
+	if stub.Mailer != nil {
+		kind := verificationToken
+		if req.Pass == "" {
+			kind = passwordResetToken
+		}
+		_ = m.mailUserToken(ctx, dbAlias, project, stub, idField, userObj, kind)
+	}
+
+	sanitizeUser(userObj)
+	m.auditUserAction(ctx, dbAlias, project, actor, utils.EventUserCreated, userObj[idField], map[string]interface{}{"email": req.Email, "role": role})
+	return http.StatusOK, map[string]interface{}{"user": userObj}, nil
+}
+
+// SetUserDisabled disables or enables a user. Disabling a user revokes the tokens and the sessions issued to the
+// user, and the user can no longer sign in till enabled again.
+func (m *Module) SetUserDisabled(ctx context.Context, dbAlias, project, actor, id string, disabled bool) (int, error) {
+	status, userObj, idField, err := m.readUserByID(ctx, dbAlias, project, id)
+	if err != nil {
+		return status, err
+	}
+
+	if err := m.updateUser(ctx, dbAlias, project, idField, userObj[idField], map[string]interface{}{userFieldDisabled: disabled}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	eventType := utils.EventUserEnabled
+	if disabled {
+		eventType = utils.EventUserDisabled
+		if err := m.revokeUser(ctx, dbAlias, project, idField, id); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	m.auditUserAction(ctx, dbAlias, project, actor, eventType, userObj[idField], map[string]interface{}{"email": userObj["email"]})
+	return http.StatusOK, nil
+}
+
+// ForcePasswordReset makes the user reset the password before signing in again. The tokens and the sessions of
+// the user get revoked and a password reset token is mailed to the user if a mailer has been configured.
+func (m *Module) ForcePasswordReset(ctx context.Context, dbAlias, project, actor, id string) (int, error) {
+	stub, err := m.getEmailStub()
+	if err != nil {
+		return http.StatusNotFound, err
+	}
+
+	status, userObj, idField, err := m.readUserByID(ctx, dbAlias, project, id)
+	if err != nil {
+		return status, err
+	}
+
+	if err := m.updateUser(ctx, dbAlias, project, idField, userObj[idField], map[string]interface{}{userFieldPasswordResetRequired: true}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := m.revokeUser(ctx, dbAlias, project, idField, id); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if stub.Mailer != nil {
+		if err := m.mailUserToken(ctx, dbAlias, project, stub, idField, userObj, passwordResetToken); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	m.auditUserAction(ctx, dbAlias, project, actor, utils.EventUserPasswordResetForced, userObj[idField], map[string]interface{}{"email": userObj["email"]})
+	return http.StatusOK, nil
+}
+
+// SetUserRole changes the role of a user. The tokens of the user get revoked since they carry the old role;
+// sessions pick up the new role on refreshing.
+func (m *Module) SetUserRole(ctx context.Context, dbAlias, project, actor, id, role string) (int, error) {
+	if role == "" {
+		return http.StatusBadRequest, errors.New("Role not provided")
+	}
+
+	status, userObj, idField, err := m.readUserByID(ctx, dbAlias, project, id)
+	if err != nil {
+		return status, err
+	}
+
+	if err := m.updateUser(ctx, dbAlias, project, idField, userObj[idField], map[string]interface{}{"role": role}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := m.auth.RevokeSubject(ctx, id); err != nil {
+		return http.StatusInternalServerError, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to revoke tokens of user", err, nil)
+	}
+
+	m.auditUserAction(ctx, dbAlias, project, actor, utils.EventUserRoleChanged, userObj[idField], map[string]interface{}{"email": userObj["email"], "oldRole": userObj["role"], "role": role})
+	return http.StatusOK, nil
+}
+
+// SearchUsers returns a page of the users matching the filters provided
+func (m *Module) SearchUsers(ctx context.Context, dbAlias, project string, req *model.UserSearchRequest) (int, map[string]interface{}, error) {
+	if !m.IsEnabled() {
+		return http.StatusNotFound, nil, errors.New("This feature isn't enabled")
+	}
+
+	find := getUserSearchFind(req)
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultUserSearchLimit
+	}
+	if limit > maxUserSearchLimit {
+		limit = maxUserSearchLimit
+	}
+	skip := req.Skip
+	if skip < 0 {
+		skip = 0
+	}
+
+	// One more user than the limit is read to find out if there is another page
+	readLimit := limit + 1
+	attr := map[string]string{"project": project, "db": dbAlias, "col": "users"}
+	reqParams := model.RequestParams{Resource: "db-read", Op: "access", Attributes: attr}
+	readReq := &model.ReadRequest{Find: find, Operation: utils.All, Options: &model.ReadOptions{Sort: []string{"email"}, Skip: &skip, Limit: &readLimit}}
+	result, _, err := m.crud.Read(ctx, dbAlias, "users", readReq, reqParams)
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to search users", err, nil)
+	}
+
+	docs, _ := result.([]interface{})
+	hasMore := int64(len(docs)) > limit
+	if hasMore {
+		docs = docs[:limit]
+	}
+	for _, doc := range docs {
+		if userObj, ok := doc.(map[string]interface{}); ok {
+			sanitizeUser(userObj)
+		}
+	}
+	return http.StatusOK, map[string]interface{}{"users": docs, "limit": limit, "skip": skip, "hasMore": hasMore}, nil
+}
+
+// getUserSearchFind returns the find clause for the filters of the user search. Users created before the disabled
+// field existed have it set to null in sql databases, which doesn't match a not equal to clause.
+func getUserSearchFind(req *model.UserSearchRequest) map[string]interface{} {
+	find := map[string]interface{}{}
+	if req.Email != "" {
+		find["email"] = map[string]interface{}{"$regex": "^" + regexp.QuoteMeta(req.Email)}
+	}
+	if req.Role != "" {
+		find["role"] = req.Role
+	}
+	if req.Disabled != nil {
+		if *req.Disabled {
+			find[userFieldDisabled] = true
+		} else {
+			find["$or"] = []interface{}{
+				map[string]interface{}{userFieldDisabled: map[string]interface{}{"$ne": true}},
+				map[string]interface{}{userFieldDisabled: nil},
+			}
+		}
+	}
+	return find
+}
+
+func (m *Module) readUserByID(ctx context.Context, dbAlias, project, id string) (int, map[string]interface{}, string, error) {
+	if !m.IsEnabled() {
+		return http.StatusNotFound, nil, "", errors.New("This feature isn't enabled")
+	}
+	if id == "" {
+		return http.StatusBadRequest, nil, "", errors.New("User id not provided")
+	}
+
+	idField, err := m.getIDField(dbAlias)
+	if err != nil {
+		return http.StatusInternalServerError, nil, "", err
+	}
+	userObj, _, err := m.readUser(ctx, dbAlias, project, map[string]interface{}{idField: id})
+	if err != nil {
+		return http.StatusNotFound, nil, "", errors.New("User not found")
+	}
+	return http.StatusOK, userObj, idField, nil
+}
+
+// revokeUser revokes all the tokens issued to the user till now along with the sessions of the user
+func (m *Module) revokeUser(ctx context.Context, dbAlias, project, idField, id string) error {
+	if err := m.auth.RevokeSubject(ctx, id); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to revoke tokens of user", err, nil)
+	}
+	if !m.isSessionsEnabled() {
+		return nil
+	}
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": sessionsTable}
+	reqParams := model.RequestParams{Resource: "db-read", Op: "access", Attributes: attr}
+	readReq := &model.ReadRequest{Find: map[string]interface{}{sessionFieldUserID: id, sessionFieldRevoked: false}, Operation: utils.All}
+	result, _, err := m.crud.Read(ctx, dbAlias, sessionsTable, readReq, reqParams)
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to read sessions", err, nil)
+	}
+
+	docs, _ := result.([]interface{})
+	for _, doc := range docs {
+		session, ok := doc.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sessionID, _ := session[idField].(string)
+		if err := m.updateSession(ctx, dbAlias, project, idField, sessionID, map[string]interface{}{sessionFieldRevoked: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSessionsEnabled checks if any method creates sessions
+func (m *Module) isSessionsEnabled() bool {
+	m.RLock()
+	defer m.RUnlock()
+
+	for _, s := range m.methods {
+		if s.Sessions != nil && s.Sessions.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// auditUserAction logs the action of the admin and queues an event of the same type for it. The actions aren't
+// recorded in the config audit trail of the sync manager as that trail only tracks config resources with their
+// before and after values; triggers on the user events can store the actions wherever they need to be retained.
+func (m *Module) auditUserAction(ctx context.Context, dbAlias, project, actor, action string, userID interface{}, data map[string]interface{}) {
+	helpers.Logger.LogInfo(helpers.GetRequestID(ctx), "User management action performed by admin", map[string]interface{}{"action": action, "actor": actor, "userId": userID, "dbAlias": dbAlias})
+
+	m.RLock()
+	eventing := m.eventing
+	m.RUnlock()
+	if eventing == nil {
+		return
+	}
+
+	token, err := m.auth.GetInternalAccessToken(ctx)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to queue user management event", err, nil)
+		return
+	}
+
+	payload := map[string]interface{}{"id": userID, "dbAlias": dbAlias, "actor": actor, "time": time.Now().UTC().Format(time.RFC3339)}
+	for k, v := range data {
+		payload[k] = v
+	}
+	if _, err := eventing.QueueEvent(ctx, project, token, &model.QueueEventRequest{Type: action, Payload: payload}); err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to queue user management event", err, map[string]interface{}{"type": action})
+	}
+}
+
+// isDisabled checks if the user has been disabled by an admin
+func isDisabled(userObj map[string]interface{}) bool {
+	return isTrue(userObj[userFieldDisabled])
+}
+
+// isPasswordResetRequired checks if an admin has forced the user to reset the password
+func isPasswordResetRequired(userObj map[string]interface{}) bool {
+	return isTrue(userObj[userFieldPasswordResetRequired])
+}
@@ -0,0 +1,190 @@
+package userman
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+func newAdminModule() (*Module, *mockCrud, *mockAuth, *mockEventing) {
+	crud := &mockCrud{}
+	auth := &mockAuth{}
+	eventing := &mockEventing{}
+	m := Init(crud, auth)
+	m.SetEventingModule(eventing)
+	m.SetConfig(config.Auths{"email": {ID: "email", Enabled: true, Sessions: &config.Sessions{Enabled: true}}})
+	return m, crud, auth, eventing
+}
+
+func TestModule_SetUserDisabled(t *testing.T) {
+	ctx := context.Background()
+	m, crud, auth, eventing := newAdminModule()
+
+	status, result, err := m.CreateUser(ctx, "db", "project", "admin", &model.UserAdminRequest{Email: "jon@example.com", Pass: "1234"})
+	if status != http.StatusOK {
+		t.Fatalf("CreateUser() status = %v, error = %v", status, err)
+	}
+	id := result["user"].(map[string]interface{})["id"].(string)
+	if _, p := result["user"].(map[string]interface{})["pass"]; p || crud.users[0]["role"] != "user" {
+		t.Errorf("CreateUser() result = %v, want the default role without the password", result)
+	}
+	if status, _, _ := m.CreateUser(ctx, "db", "project", "admin", &model.UserAdminRequest{Email: "jon@example.com", Pass: "1234"}); status != http.StatusConflict {
+		t.Errorf("CreateUser() status = %v for an existing email, want %v", status, http.StatusConflict)
+	}
+
+	status, result, err = m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil)
+	if status != http.StatusOK {
+		t.Fatalf("EmailSignIn() status = %v, error = %v", status, err)
+	}
+	token, refreshToken := result["token"].(string), result["refreshToken"].(string)
+
+	if status, err := m.SetUserDisabled(ctx, "db", "project", "admin", id, true); status != http.StatusOK {
+		t.Fatalf("SetUserDisabled() status = %v, error = %v", status, err)
+	}
+	if _, err := auth.ParseToken(ctx, token); err == nil {
+		t.Errorf("SetUserDisabled() did not revoke the tokens of the user")
+	}
+	if !isRevoked(crud.sessions[0]) {
+		t.Errorf("SetUserDisabled() did not revoke the sessions of the user")
+	}
+	if status, _, _ := m.RefreshSession(ctx, "db", "project", refreshToken, nil); status == http.StatusOK {
+		t.Errorf("RefreshSession() succeeded for a disabled user")
+	}
+	if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil); status != http.StatusForbidden {
+		t.Errorf("EmailSignIn() status = %v for a disabled user, want %v", status, http.StatusForbidden)
+	}
+
+	if status, err := m.SetUserDisabled(ctx, "db", "project", "admin", id, false); status != http.StatusOK {
+		t.Fatalf("SetUserDisabled() status = %v, error = %v", status, err)
+	}
+	if status, _, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil); status != http.StatusOK {
+		t.Errorf("EmailSignIn() status = %v, error = %v after enabling the user", status, err)
+	}
+
+	if status, err := m.SetUserDisabled(ctx, "db", "project", "admin", "unknown", true); status != http.StatusNotFound {
+		t.Errorf("SetUserDisabled() status = %v, error = %v for an unknown user", status, err)
+	}
+
+	wantEvents := []string{utils.EventUserCreated, utils.EventUserDisabled, utils.EventUserEnabled}
+	if len(eventing.mails) != len(wantEvents) {
+		t.Fatalf("Queued events = %v, want %v", eventing.mails, wantEvents)
+	}
+	for i, payload := range eventing.mails {
+		if payload["id"] != id || payload["actor"] != "admin" {
+			t.Errorf("Event (%s) payload = %v", wantEvents[i], payload)
+		}
+	}
+}
+
+func TestModule_ForcePasswordReset(t *testing.T) {
+	ctx := context.Background()
+	m, _, auth, _ := newAdminModule()
+
+	_, result, _ := m.EmailSignUp(ctx, "db", "project", "jon@example.com", "Jon", "1234", "user", nil)
+	token := result["token"].(string)
+	id := result["user"].(map[string]interface{})["id"].(string)
+
+	if status, err := m.ForcePasswordReset(ctx, "db", "project", "admin", id); status != http.StatusOK {
+		t.Fatalf("ForcePasswordReset() status = %v, error = %v", status, err)
+	}
+	if _, err := auth.ParseToken(ctx, token); err == nil {
+		t.Errorf("ForcePasswordReset() did not revoke the tokens of the user")
+	}
+	if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil); status != http.StatusForbidden {
+		t.Errorf("EmailSignIn() status = %v before resetting the password, want %v", status, http.StatusForbidden)
+	}
+}
+
+func TestModule_SetUserRole(t *testing.T) {
+	ctx := context.Background()
+	m, crud, auth, eventing := newAdminModule()
+
+	_, result, _ := m.EmailSignUp(ctx, "db", "project", "jon@example.com", "Jon", "1234", "user", nil)
+	token, refreshToken := result["token"].(string), result["refreshToken"].(string)
+	id := result["user"].(map[string]interface{})["id"].(string)
+
+	if status, _ := m.SetUserRole(ctx, "db", "project", "admin", id, ""); status != http.StatusBadRequest {
+		t.Errorf("SetUserRole() status = %v without a role, want %v", status, http.StatusBadRequest)
+	}
+	if status, err := m.SetUserRole(ctx, "db", "project", "admin", id, "editor"); status != http.StatusOK {
+		t.Fatalf("SetUserRole() status = %v, error = %v", status, err)
+	}
+	if _, err := auth.ParseToken(ctx, token); err == nil {
+		t.Errorf("SetUserRole() did not revoke the tokens carrying the old role")
+	}
+	if payload := eventing.mails[0]; payload["oldRole"] != "user" || payload["role"] != "editor" {
+		t.Errorf("SetUserRole() event payload = %v", payload)
+	}
+
+	// Sessions survive the change and pick up the new role
+	status, result, err := m.RefreshSession(ctx, "db", "project", refreshToken, nil)
+	if status != http.StatusOK {
+		t.Fatalf("RefreshSession() status = %v, error = %v", status, err)
+	}
+	if claims := auth.tokens[result["token"].(string)]; claims["role"] != "editor" || crud.users[0]["role"] != "editor" {
+		t.Errorf("RefreshSession() token claims = %v, want the new role", claims)
+	}
+}
+
+func TestModule_SearchUsers(t *testing.T) {
+	ctx := context.Background()
+	m, crud, _, _ := newAdminModule()
+	for _, role := range []string{"user", "admin", "user", "user"} {
+		crud.users = append(crud.users, map[string]interface{}{"id": role + string(rune('a'+len(crud.users))), "role": role, "pass": "hash"})
+	}
+
+	tests := []struct {
+		name        string
+		req         *model.UserSearchRequest
+		wantUsers   int
+		wantHasMore bool
+	}{
+		{name: "all users", req: &model.UserSearchRequest{}, wantUsers: 4},
+		{name: "filtered by role", req: &model.UserSearchRequest{Role: "user"}, wantUsers: 3},
+		{name: "page smaller than the results", req: &model.UserSearchRequest{Role: "user", Limit: 2}, wantUsers: 2, wantHasMore: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, result, err := m.SearchUsers(ctx, "db", "project", tt.req)
+			if status != http.StatusOK {
+				t.Fatalf("SearchUsers() status = %v, error = %v", status, err)
+			}
+			users := result["users"].([]interface{})
+			if len(users) != tt.wantUsers || result["hasMore"] != tt.wantHasMore {
+				t.Errorf("SearchUsers() returned (%d) users with hasMore = %v, want (%d) users with hasMore = %v", len(users), result["hasMore"], tt.wantUsers, tt.wantHasMore)
+			}
+			for _, user := range users {
+				if _, p := user.(map[string]interface{})["pass"]; p {
+					t.Errorf("SearchUsers() returned the password hash of a user")
+				}
+			}
+		})
+	}
+}
+
+func Test_getUserSearchFind(t *testing.T) {
+	enabled, disabled := false, true
+	tests := []struct {
+		name string
+		req  *model.UserSearchRequest
+		user map[string]interface{}
+		want bool
+	}{
+		{name: "enabled user", req: &model.UserSearchRequest{Disabled: &enabled}, user: map[string]interface{}{userFieldDisabled: false}, want: true},
+		{name: "user created before the disabled field existed", req: &model.UserSearchRequest{Disabled: &enabled}, user: map[string]interface{}{userFieldDisabled: nil}, want: true},
+		{name: "disabled user", req: &model.UserSearchRequest{Disabled: &enabled}, user: map[string]interface{}{userFieldDisabled: true}, want: false},
+		{name: "disabled users only", req: &model.UserSearchRequest{Disabled: &disabled}, user: map[string]interface{}{userFieldDisabled: nil}, want: false},
+		{name: "other filters still apply", req: &model.UserSearchRequest{Disabled: &enabled, Role: "admin"}, user: map[string]interface{}{"role": "user", userFieldDisabled: nil}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.Validate(string(model.Postgres), getUserSearchFind(tt.req), tt.user); got != tt.want {
+				t.Errorf("getUserSearchFind() matched = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
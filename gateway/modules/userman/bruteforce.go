@@ -0,0 +1,437 @@
+package userman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils/pubsub"
+)
+
+const (
+	defaultMaxAccountAttempts = 5
+	defaultMaxIPAttempts      = 20
+	defaultAttemptWindow      = 15 * time.Minute
+	defaultLockoutDuration    = 15 * time.Minute
+	defaultMaxSignInDelay     = 5 * time.Second
+
+	// signInBaseDelay is the delay after the first failure. It doubles with every subsequent failure.
+	signInBaseDelay = 250 * time.Millisecond
+)
+
+// bruteForcePolicy is the parsed brute force config of the email method
+type bruteForcePolicy struct {
+	maxAccountAttempts int64
+	maxIPAttempts      int64
+	window             time.Duration
+	lockout            time.Duration
+	maxDelay           time.Duration
+	eventType          string
+}
+
+// attemptStore keeps the counters of failed sign in attempts along with the locks
+type attemptStore interface {
+	// increment increments the counter and forgets it once no failures happen for the window
+	increment(ctx context.Context, key string, window time.Duration) (int64, error)
+	count(ctx context.Context, key string) (int64, error)
+	lock(ctx context.Context, key string, d time.Duration) error
+	// lockedFor returns the time for which the key remains locked
+	lockedFor(ctx context.Context, key string) (time.Duration, error)
+	reset(ctx context.Context, keys ...string) error
+}
+
+// SetPubsubClient makes the failed sign in attempts get counted in redis so that they are shared by all gateways.
+// The attempts are counted in memory while redis is unavailable.
+func (m *Module) SetPubsubClient(client *pubsub.Module) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.attempts = &fallbackAttemptStore{primary: &redisAttemptStore{client: client}, fallback: newMemoryAttemptStore()}
+}
+
+// UnlockSignIn clears the lock and the failed attempts of an account or an ip
+func (m *Module) UnlockSignIn(ctx context.Context, dbAlias, email, ip string) error {
+	if email == "" && ip == "" {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Either the email or the ip to be unlocked must be provided", nil, nil)
+	}
+
+	keys := []string{}
+	if email != "" {
+		key := accountAttemptsKey(dbAlias, email)
+		keys = append(keys, key, lockKey(key))
+	}
+	if ip != "" {
+		key := ipAttemptsKey(ip)
+		keys = append(keys, key, lockKey(key))
+	}
+
+	if err := m.getAttemptStore().reset(ctx, keys...); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to unlock sign in", err, nil)
+	}
+	return nil
+}
+
+// checkSignInAttempts refuses the sign in while the account or the ip is locked and delays it progressively for
+// every recent failure. Errors of the store are only logged as it falls back to memory when redis is unavailable.
+func (m *Module) checkSignInAttempts(ctx context.Context, policy *bruteForcePolicy, dbAlias, email, ip string) (int, error) {
+	if policy == nil {
+		return http.StatusOK, nil
+	}
+	store := m.getAttemptStore()
+
+	account := accountAttemptsKey(dbAlias, email)
+	keys := []string{account}
+	if ip != "" {
+		keys = append(keys, ipAttemptsKey(ip))
+	}
+	for _, key := range keys {
+		d, err := store.lockedFor(ctx, lockKey(key))
+		if err != nil {
+			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to check sign in lock", err, nil)
+			continue
+		}
+		if d > 0 {
+			return http.StatusTooManyRequests, fmt.Errorf("Too many failed sign in attempts, try again in %s", d.Round(time.Second))
+		}
+	}
+
+	failures, err := store.count(ctx, account)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to read failed sign in attempts", err, nil)
+		return http.StatusOK, nil
+	}
+	if delay := signInDelay(failures, policy.maxDelay); delay > 0 {
+		select {
+		case <-ctx.Done():
+			return http.StatusRequestTimeout, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// recordFailedSignIn counts the failure and locks the account or the ip once they run out of attempts. An event
+// is queued when an existing account gets locked.
+func (m *Module) recordFailedSignIn(ctx context.Context, policy *bruteForcePolicy, dbAlias, project, email, ip string, userExists bool) {
+	if policy == nil {
+		return
+	}
+
+	if m.incrementAttempts(ctx, policy, accountAttemptsKey(dbAlias, email), policy.maxAccountAttempts) && userExists {
+		m.queueLockoutEvent(ctx, policy, project, email, ip)
+	}
+	if ip != "" {
+		m.incrementAttempts(ctx, policy, ipAttemptsKey(ip), policy.maxIPAttempts)
+	}
+}
+
+// resetSignInAttempts forgets the failures of the account once the user signs in. The failures of the ip are
+// retained so that an attacker cannot reset them with an account of their own.
+func (m *Module) resetSignInAttempts(ctx context.Context, policy *bruteForcePolicy, dbAlias, email string) {
+	if policy == nil {
+		return
+	}
+
+	if err := m.getAttemptStore().reset(ctx, accountAttemptsKey(dbAlias, email)); err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to reset failed sign in attempts", err, nil)
+	}
+}
+
+// incrementAttempts increments the failures of the key and reports whether the key got locked
+func (m *Module) incrementAttempts(ctx context.Context, policy *bruteForcePolicy, key string, max int64) bool {
+	store := m.getAttemptStore()
+
+	n, err := store.increment(ctx, key, policy.window)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to count failed sign in attempt", err, nil)
+		return false
+	}
+	if n < max {
+		return false
+	}
+
+	if err := store.lock(ctx, lockKey(key), policy.lockout); err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to lock sign in", err, nil)
+		return false
+	}
+	_ = store.reset(ctx, key)
+	helpers.Logger.LogWarn(helpers.GetRequestID(ctx), "Sign in locked due to too many failed attempts", map[string]interface{}{"key": key})
+	return true
+}
+
+func (m *Module) queueLockoutEvent(ctx context.Context, policy *bruteForcePolicy, project, email, ip string) {
+	m.RLock()
+	eventing := m.eventing
+	m.RUnlock()
+
+	if policy.eventType == "" || eventing == nil {
+		return
+	}
+
+	token, err := m.auth.GetInternalAccessToken(ctx)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to queue lockout event", err, nil)
+		return
+	}
+	payload := map[string]interface{}{"email": email, "ip": ip, "lockedUntil": time.Now().Add(policy.lockout).UTC().Format(time.RFC3339)}
+	if _, err := eventing.QueueEvent(ctx, project, token, &model.QueueEventRequest{Type: policy.eventType, Payload: payload}); err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to queue lockout event", err, nil)
+	}
+}
+
+// getBruteForcePolicy returns the brute force policy of the email method. The policy is nil if it isn't enabled.
+func (m *Module) getBruteForcePolicy() (*bruteForcePolicy, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	s, p := m.methods["email"]
+	if !p || s.BruteForce == nil || !s.BruteForce.Enabled {
+		return nil, nil
+	}
+	c := s.BruteForce
+
+	policy := &bruteForcePolicy{maxAccountAttempts: defaultMaxAccountAttempts, maxIPAttempts: defaultMaxIPAttempts, eventType: c.LockoutEventType}
+	if c.MaxAccountAttempts > 0 {
+		policy.maxAccountAttempts = int64(c.MaxAccountAttempts)
+	}
+	if c.MaxIPAttempts > 0 {
+		policy.maxIPAttempts = int64(c.MaxIPAttempts)
+	}
+
+	var err error
+	if policy.window, err = parseTTL(c.Window, defaultAttemptWindow); err != nil {
+		return nil, err
+	}
+	if policy.lockout, err = parseTTL(c.LockoutDuration, defaultLockoutDuration); err != nil {
+		return nil, err
+	}
+	if policy.maxDelay, err = parseTTL(c.MaxDelay, defaultMaxSignInDelay); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (m *Module) getAttemptStore() attemptStore {
+	m.RLock()
+	defer m.RUnlock()
+	return m.attempts
+}
+
+// signInDelay returns the delay for the number of recent failures
+func signInDelay(failures int64, maxDelay time.Duration) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	delay := signInBaseDelay
+	for i := int64(1); i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func accountAttemptsKey(dbAlias, email string) string {
+	return "signin-attempts:account:" + dbAlias + ":" + strings.ToLower(email)
+}
+
+func ipAttemptsKey(ip string) string {
+	return "signin-attempts:ip:" + ip
+}
+
+func lockKey(key string) string {
+	return "signin-lock:" + strings.TrimPrefix(key, "signin-attempts:")
+}
+
+// memoryAttemptStore keeps the attempts in memory. It is used when redis isn't available.
+type memoryAttemptStore struct {
+	mutex     sync.Mutex
+	entries   map[string]*attemptEntry
+	lastPurge time.Time
+}
+
+type attemptEntry struct {
+	count     int64
+	expiresAt time.Time
+}
+
+func newMemoryAttemptStore() *memoryAttemptStore {
+	return &memoryAttemptStore{entries: map[string]*attemptEntry{}, lastPurge: time.Now()}
+}
+
+func (s *memoryAttemptStore) increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.purge(now)
+
+	entry := s.get(key, now)
+	if entry == nil {
+		entry = new(attemptEntry)
+		s.entries[key] = entry
+	}
+	entry.count++
+	entry.expiresAt = now.Add(window)
+	return entry.count, nil
+}
+
+func (s *memoryAttemptStore) count(ctx context.Context, key string) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry := s.get(key, time.Now()); entry != nil {
+		return entry.count, nil
+	}
+	return 0, nil
+}
+
+func (s *memoryAttemptStore) lock(ctx context.Context, key string, d time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[key] = &attemptEntry{count: 1, expiresAt: time.Now().Add(d)}
+	return nil
+}
+
+func (s *memoryAttemptStore) lockedFor(ctx context.Context, key string) (time.Duration, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if entry := s.get(key, now); entry != nil {
+		return entry.expiresAt.Sub(now), nil
+	}
+	return 0, nil
+}
+
+func (s *memoryAttemptStore) reset(ctx context.Context, keys ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *memoryAttemptStore) get(key string, now time.Time) *attemptEntry {
+	entry, p := s.entries[key]
+	if !p || !now.Before(entry.expiresAt) {
+		return nil
+	}
+	return entry
+}
+
+// purge removes the expired entries once a minute so that the store doesn't grow indefinitely
+func (s *memoryAttemptStore) purge(now time.Time) {
+	if now.Sub(s.lastPurge) < time.Minute {
+		return
+	}
+	s.lastPurge = now
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// fallbackAttemptStore uses the fallback store whenever the primary store fails so that the lockout keeps working
+// while redis is unavailable. The attempts and locks recorded in the fallback store are honoured even after the
+// primary store recovers.
+type fallbackAttemptStore struct {
+	primary  attemptStore
+	fallback attemptStore
+}
+
+func (s *fallbackAttemptStore) increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	n, err := s.primary.increment(ctx, key, window)
+	if err != nil {
+		logPrimaryStoreError(ctx, err)
+		return s.fallback.increment(ctx, key, window)
+	}
+	fallback, _ := s.fallback.count(ctx, key)
+	return n + fallback, nil
+}
+
+func (s *fallbackAttemptStore) count(ctx context.Context, key string) (int64, error) {
+	fallback, _ := s.fallback.count(ctx, key)
+	n, err := s.primary.count(ctx, key)
+	if err != nil {
+		logPrimaryStoreError(ctx, err)
+	}
+	return n + fallback, nil
+}
+
+func (s *fallbackAttemptStore) lock(ctx context.Context, key string, d time.Duration) error {
+	if err := s.primary.lock(ctx, key, d); err != nil {
+		logPrimaryStoreError(ctx, err)
+		return s.fallback.lock(ctx, key, d)
+	}
+	return nil
+}
+
+func (s *fallbackAttemptStore) lockedFor(ctx context.Context, key string) (time.Duration, error) {
+	fallback, _ := s.fallback.lockedFor(ctx, key)
+	d, err := s.primary.lockedFor(ctx, key)
+	if err != nil {
+		logPrimaryStoreError(ctx, err)
+	}
+	if fallback > d {
+		d = fallback
+	}
+	return d, nil
+}
+
+func (s *fallbackAttemptStore) reset(ctx context.Context, keys ...string) error {
+	_ = s.fallback.reset(ctx, keys...)
+	return s.primary.reset(ctx, keys...)
+}
+
+func logPrimaryStoreError(ctx context.Context, err error) {
+	helpers.Logger.LogWarn(helpers.GetRequestID(ctx), "Unable to reach redis, counting failed sign in attempts in memory", map[string]interface{}{"error": err.Error()})
+}
+
+// redisAttemptStore keeps the attempts in redis so that they are shared by all gateways of the cluster
+type redisAttemptStore struct {
+	client *pubsub.Module
+}
+
+func (s *redisAttemptStore) increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return s.client.IncrementKey(ctx, key, window)
+}
+
+func (s *redisAttemptStore) count(ctx context.Context, key string) (int64, error) {
+	value, err := s.client.GetProjectKey(ctx, key)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid failed sign in attempts stored in redis")
+	}
+	return n, nil
+}
+
+func (s *redisAttemptStore) lock(ctx context.Context, key string, d time.Duration) error {
+	return s.client.SetProjectKey(ctx, key, "1", d)
+}
+
+func (s *redisAttemptStore) lockedFor(ctx context.Context, key string) (time.Duration, error) {
+	return s.client.GetProjectKeyTTL(ctx, key)
+}
+
+func (s *redisAttemptStore) reset(ctx context.Context, keys ...string) error {
+	return s.client.DeleteProjectKeys(ctx, keys...)
+}
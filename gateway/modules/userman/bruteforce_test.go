@@ -0,0 +1,164 @@
+package userman
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+func newBruteForceModule(t *testing.T) (*Module, *mockEventing) {
+	eventing := &mockEventing{}
+	m := Init(&mockCrud{}, &mockAuth{})
+	m.SetEventingModule(eventing)
+	m.SetConfig(config.Auths{"email": {ID: "email", Enabled: true, BruteForce: &config.BruteForce{
+		Enabled:            true,
+		MaxAccountAttempts: 3,
+		MaxIPAttempts:      5,
+		MaxDelay:           "1ms",
+		LockoutEventType:   "user-locked",
+	}}})
+
+	if status, _, err := m.EmailSignUp(context.Background(), "db", "project", "jon@example.com", "Jon", "1234", "user", nil); status != http.StatusOK {
+		t.Fatalf("EmailSignUp() status = %v, error = %v", status, err)
+	}
+	return m, eventing
+}
+
+func TestModule_EmailSignIn_lockout(t *testing.T) {
+	ctx := context.Background()
+	m, eventing := newBruteForceModule(t)
+	device := &model.SessionDevice{IP: "1.2.3.4"}
+
+	for i := 0; i < 3; i++ {
+		if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "wrong", device); status != http.StatusUnauthorized {
+			t.Fatalf("EmailSignIn() status = %v for attempt (%d), want %v", status, i, http.StatusUnauthorized)
+		}
+	}
+
+	// The account is locked even for the correct password
+	if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", device); status != http.StatusTooManyRequests {
+		t.Errorf("EmailSignIn() status = %v for a locked account, want %v", status, http.StatusTooManyRequests)
+	}
+	if len(eventing.mails) != 1 || eventing.mails[0]["email"] != "jon@example.com" || eventing.mails[0]["ip"] != "1.2.3.4" {
+		t.Errorf("EmailSignIn() queued events = %v, want a single lockout event", eventing.mails)
+	}
+
+	if err := m.UnlockSignIn(ctx, "db", "jon@example.com", ""); err != nil {
+		t.Fatalf("UnlockSignIn() error = %v", err)
+	}
+	if status, _, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", device); status != http.StatusOK {
+		t.Errorf("EmailSignIn() status = %v, error = %v after unlocking", status, err)
+	}
+	if err := m.UnlockSignIn(ctx, "db", "", ""); err == nil {
+		t.Errorf("UnlockSignIn() did not return an error when neither the email nor the ip is provided")
+	}
+}
+
+func TestModule_EmailSignIn_ipLockout(t *testing.T) {
+	ctx := context.Background()
+	m, eventing := newBruteForceModule(t)
+	device := &model.SessionDevice{IP: "1.2.3.4"}
+
+	// Failures for unknown accounts count towards the ip but do not queue any event
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"} {
+		if status, _, _ := m.EmailSignIn(ctx, "db", "project", email, "wrong", device); status != http.StatusNotFound {
+			t.Fatalf("EmailSignIn() status = %v for an unknown account, want %v", status, http.StatusNotFound)
+		}
+	}
+	if len(eventing.mails) != 0 {
+		t.Errorf("EmailSignIn() queued events = %v for unknown accounts", eventing.mails)
+	}
+
+	if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", device); status != http.StatusTooManyRequests {
+		t.Errorf("EmailSignIn() status = %v from a locked ip, want %v", status, http.StatusTooManyRequests)
+	}
+	if status, _, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", &model.SessionDevice{IP: "5.6.7.8"}); status != http.StatusOK {
+		t.Errorf("EmailSignIn() status = %v, error = %v from another ip", status, err)
+	}
+
+	if err := m.UnlockSignIn(ctx, "db", "", "1.2.3.4"); err != nil {
+		t.Fatalf("UnlockSignIn() error = %v", err)
+	}
+	if status, _, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", device); status != http.StatusOK {
+		t.Errorf("EmailSignIn() status = %v, error = %v after unlocking the ip", status, err)
+	}
+}
+
+func TestModule_EmailSignIn_resetsAttempts(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newBruteForceModule(t)
+
+	// A successful sign in forgets the earlier failures of the account
+	for i := 0; i < 3; i++ {
+		_, _, _ = m.EmailSignIn(ctx, "db", "project", "jon@example.com", "wrong", nil)
+		_, _, _ = m.EmailSignIn(ctx, "db", "project", "jon@example.com", "wrong", nil)
+		if status, _, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil); status != http.StatusOK {
+			t.Fatalf("EmailSignIn() status = %v, error = %v in round (%d)", status, err, i)
+		}
+	}
+}
+
+func Test_signInDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int64
+		maxDelay time.Duration
+		want     time.Duration
+	}{
+		{name: "no failures", failures: 0, maxDelay: time.Second, want: 0},
+		{name: "first failure", failures: 1, maxDelay: time.Second, want: 250 * time.Millisecond},
+		{name: "third failure", failures: 3, maxDelay: 5 * time.Second, want: time.Second},
+		{name: "capped", failures: 10, maxDelay: 5 * time.Second, want: 5 * time.Second},
+		{name: "capped below the base delay", failures: 1, maxDelay: time.Millisecond, want: time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signInDelay(tt.failures, tt.maxDelay); got != tt.want {
+				t.Errorf("signInDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// failingAttemptStore mimics redis being unavailable
+type failingAttemptStore struct{}
+
+func (failingAttemptStore) increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func (failingAttemptStore) count(ctx context.Context, key string) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func (failingAttemptStore) lock(ctx context.Context, key string, d time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func (failingAttemptStore) lockedFor(ctx context.Context, key string) (time.Duration, error) {
+	return 0, errors.New("connection refused")
+}
+
+func (failingAttemptStore) reset(ctx context.Context, keys ...string) error {
+	return errors.New("connection refused")
+}
+
+func TestModule_EmailSignIn_lockoutWithoutRedis(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newBruteForceModule(t)
+	m.attempts = &fallbackAttemptStore{primary: failingAttemptStore{}, fallback: newMemoryAttemptStore()}
+
+	for i := 0; i < 3; i++ {
+		if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "wrong", nil); status != http.StatusUnauthorized {
+			t.Fatalf("EmailSignIn() status = %v for attempt (%d), want %v", status, i, http.StatusUnauthorized)
+		}
+	}
+	if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil); status != http.StatusTooManyRequests {
+		t.Errorf("EmailSignIn() status = %v for an account locked while redis is unavailable, want %v", status, http.StatusTooManyRequests)
+	}
+}
@@ -0,0 +1,164 @@
+package userman
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// mockCrud keeps the users and sessions tables in memory
+type mockCrud struct {
+	users    []map[string]interface{}
+	sessions []map[string]interface{}
+	readErr  error
+
+	// afterRead is invoked after every read to mimic the changes made by concurrent requests
+	afterRead func()
+}
+
+func (c *mockCrud) table(col string) *[]map[string]interface{} {
+	if col == sessionsTable {
+		return &c.sessions
+	}
+	return &c.users
+}
+
+func (c *mockCrud) GetDBType(dbAlias string) (string, error) {
+	return string(model.Postgres), nil
+}
+
+func (c *mockCrud) Read(ctx context.Context, dbAlias, col string, req *model.ReadRequest, params model.RequestParams) (interface{}, *model.SQLMetaData, error) {
+	if c.readErr != nil {
+		return nil, nil, c.readErr
+	}
+	docs := []interface{}{}
+	for _, doc := range c.find(col, req.Find) {
+		// Return a copy like a database would
+		copied := map[string]interface{}{}
+		for k, v := range doc {
+			copied[k] = v
+		}
+		docs = append(docs, copied)
+	}
+	if c.afterRead != nil {
+		c.afterRead()
+	}
+	if req.Operation == utils.All {
+		return docs, nil, nil
+	}
+	if len(docs) == 0 {
+		return nil, nil, errors.New("document not found")
+	}
+	return docs[0], nil, nil
+}
+
+func (c *mockCrud) find(col string, find map[string]interface{}) []map[string]interface{} {
+	docs := []map[string]interface{}{}
+	for _, doc := range *c.table(col) {
+		matched := true
+		for k, v := range find {
+			if doc[k] != v {
+				matched = false
+			}
+		}
+		if matched {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+func (c *mockCrud) Create(ctx context.Context, dbAlias, col string, req *model.CreateRequest, params model.RequestParams) error {
+	doc := map[string]interface{}{}
+	for k, v := range req.Document.(map[string]interface{}) {
+		doc[k] = v
+	}
+	t := c.table(col)
+	*t = append(*t, doc)
+	return nil
+}
+
+func (c *mockCrud) Update(ctx context.Context, dbAlias, col string, req *model.UpdateRequest, params model.RequestParams) error {
+	n, err := c.UpdateAndCount(ctx, dbAlias, col, req, params)
+	if err == nil && n == 0 {
+		return errors.New("document not found")
+	}
+	return err
+}
+
+func (c *mockCrud) UpdateAndCount(ctx context.Context, dbAlias, col string, req *model.UpdateRequest, params model.RequestParams) (int64, error) {
+	docs := c.find(col, req.Find)
+	if req.Operation != utils.All && len(docs) > 1 {
+		docs = docs[:1]
+	}
+	for _, doc := range docs {
+		for k, v := range req.Update["$set"].(map[string]interface{}) {
+			doc[k] = v
+		}
+	}
+	return int64(len(docs)), nil
+}
+
+// mockAuth issues tokens which are simply looked up while parsing
+type mockAuth struct {
+	model.AuthUserInterface
+	tokens  map[string]model.TokenClaims
+	revoked map[string]bool
+}
+
+func (a *mockAuth) GetInternalAccessToken(ctx context.Context) (string, error) {
+	return "internal-token", nil
+}
+
+func (a *mockAuth) CreateToken(ctx context.Context, tokenClaims model.TokenClaims) (string, error) {
+	if a.tokens == nil {
+		a.tokens = map[string]model.TokenClaims{}
+	}
+	token := "token-" + tokenClaims["id"].(string)
+	a.tokens[token] = tokenClaims
+	return token, nil
+}
+
+func (a *mockAuth) CreateTokenWithTTL(ctx context.Context, tokenClaims model.TokenClaims, ttl time.Duration) (string, error) {
+	tokenClaims["exp"] = time.Now().Add(ttl).Unix()
+	return a.CreateToken(ctx, tokenClaims)
+}
+
+func (a *mockAuth) ParseToken(ctx context.Context, token string) (map[string]interface{}, error) {
+	claims, p := a.tokens[token]
+	if !p {
+		return nil, errors.New("invalid token")
+	}
+	if sid, _ := claims["sid"].(string); a.revoked[sid] {
+		return nil, errors.New("session of the token has been revoked")
+	}
+	return claims, nil
+}
+
+// RevokeSubject forgets the tokens issued to the subject so that they can no longer be parsed
+func (a *mockAuth) RevokeSubject(ctx context.Context, subject string) error {
+	for token, claims := range a.tokens {
+		if claims["id"] == subject {
+			delete(a.tokens, token)
+		}
+	}
+	return nil
+}
+
+func (a *mockAuth) RevokeTokenID(ctx context.Context, jti string, expiresAt time.Time) error {
+	if a.revoked == nil {
+		a.revoked = map[string]bool{}
+	}
+	a.revoked[jti] = true
+	return nil
+}
+
+func (a *mockAuth) IsUpdateOpAuthorised(ctx context.Context, project, dbType, col, token string, req *model.UpdateRequest) (model.RequestParams, error) {
+	if _, err := a.ParseToken(ctx, token); err != nil {
+		return model.RequestParams{}, err
+	}
+	return model.RequestParams{}, nil
+}
@@ -0,0 +1,173 @@
+package userman
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/smtp"
+	"strings"
+	"text/template"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+// The templates of the emails sent by user management
+const (
+	mailTemplateVerifyEmail   = "verify-email"
+	mailTemplateResetPassword = "reset-password"
+)
+
+var defaultMailTemplates = map[string]*config.MailTemplate{
+	mailTemplateVerifyEmail: {
+		Subject: "Verify your email",
+		Body:    "Hi {{.name}},\n\nUse the token below to verify your email. It expires at {{.expiry}}.\n\n{{.token}}\n",
+	},
+	mailTemplateResetPassword: {
+		Subject: "Reset your password",
+		Body:    "Hi {{.name}},\n\nUse the token below to reset your password. It expires at {{.expiry}}.\n\n{{.token}}\n",
+	},
+}
+
+type mail struct {
+	To       string                 `json:"to"`
+	Subject  string                 `json:"subject"`
+	Body     string                 `json:"body"`
+	IsHTML   bool                   `json:"isHtml"`
+	Template string                 `json:"template"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+// mailer delivers the emails of user management
+type mailer interface {
+	send(ctx context.Context, m *mail) error
+}
+
+type smtpMailer struct {
+	config *config.Mailer
+}
+
+func (s *smtpMailer) send(ctx context.Context, m *mail) error {
+	// Line breaks in the address could be used to inject headers
+	if strings.ContainsAny(m.To, "\r\n") {
+		return fmt.Errorf("invalid email address (%s) provided", m.To)
+	}
+
+	contentType := "text/plain"
+	if m.IsHTML {
+		contentType = "text/html"
+	}
+
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(msg, "To: %s\r\n", m.To)
+	fmt.Fprintf(msg, "Subject: %s\r\n", m.Subject)
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: %s; charset=UTF-8\r\n\r\n", contentType)
+	msg.WriteString(m.Body)
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+	return smtp.SendMail(fmt.Sprintf("%s:%d", s.config.Host, s.config.Port), auth, s.config.From, []string{m.To}, msg.Bytes())
+}
+
+// eventingMailer queues an event with the rendered email so that a service of the user can send it
+type eventingMailer struct {
+	project   string
+	eventType string
+	eventing  model.EventingUserInterface
+	auth      model.AuthUserInterface
+}
+
+func (e *eventingMailer) send(ctx context.Context, m *mail) error {
+	token, err := e.auth.GetInternalAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.eventing.QueueEvent(ctx, e.project, token, &model.QueueEventRequest{Type: e.eventType, Payload: map[string]interface{}{
+		"to":       m.To,
+		"subject":  m.Subject,
+		"body":     m.Body,
+		"isHtml":   m.IsHTML,
+		"template": m.Template,
+		"data":     m.Data,
+	}})
+	return err
+}
+
+func (m *Module) getMailer(project string, c *config.Mailer) (mailer, error) {
+	if c == nil {
+		return nil, errors.New("mailer has not been configured for the email method")
+	}
+
+	switch c.Type {
+	case "smtp":
+		if c.Host == "" || c.Port == 0 || c.From == "" {
+			return nil, errors.New("host, port and from address are required by the smtp mailer")
+		}
+		return &smtpMailer{config: c}, nil
+
+	case "eventing":
+		m.RLock()
+		eventing := m.eventing
+		m.RUnlock()
+
+		if eventing == nil || c.EventType == "" {
+			return nil, errors.New("eventing mailer requires the eventing module and an event type")
+		}
+		return &eventingMailer{project: project, eventType: c.EventType, eventing: eventing, auth: m.auth}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid mailer type (%s) provided", c.Type)
+	}
+}
+
+// renderMail renders the email from the template configured for the mailer or the default one
+func renderMail(c *config.Mailer, templateName, to string, data map[string]interface{}) (*mail, error) {
+	tmpl, p := c.Templates[templateName]
+	if !p {
+		tmpl = defaultMailTemplates[templateName]
+	}
+
+	subject, err := executeMailTemplate(templateName+"-subject", tmpl.Subject, data, false)
+	if err != nil {
+		return nil, err
+	}
+	body, err := executeMailTemplate(templateName+"-body", tmpl.Body, data, tmpl.IsHTML)
+	if err != nil {
+		return nil, err
+	}
+
+	// Line breaks in the subject could be used to inject headers
+	subject = strings.NewReplacer("\r", "", "\n", " ").Replace(subject)
+	return &mail{To: to, Subject: subject, Body: body, IsHTML: tmpl.IsHTML, Template: templateName, Data: data}, nil
+}
+
+// executeMailTemplate renders the template. Html templates escape the values so that the data of the user, like
+// the name, cannot inject markup in the email.
+func executeMailTemplate(name, text string, data map[string]interface{}, isHTML bool) (string, error) {
+	var t interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+	var err error
+	if isHTML {
+		t, err = htmltemplate.New(name).Parse(text)
+	} else {
+		t, err = template.New(name).Parse(text)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid mail template (%s) provided - %v", name, err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("unable to execute mail template (%s) - %v", name, err)
+	}
+	return buf.String(), nil
+}
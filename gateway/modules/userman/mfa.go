@@ -0,0 +1,428 @@
+package userman
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// Fields of the users table used by multi factor authentication. The secrets are stored encrypted with the aes
+// key of the project and only the hashes of the recovery codes and the challenge token are stored.
+const (
+	userFieldMFAEnabled           = "mfaEnabled"
+	userFieldMFASecret            = "mfaSecret"
+	userFieldMFAPendingSecret     = "mfaPendingSecret"
+	userFieldMFALastStep          = "mfaLastStep"
+	userFieldMFARecoveryCodes     = "mfaRecoveryCodes"
+	userFieldMFAChallenge         = "mfaChallenge"
+	userFieldMFAChallengeExpiry   = "mfaChallengeExpiry"
+	userFieldMFAChallengeAttempts = "mfaChallengeAttempts"
+	userFieldMFAChallengeMethod   = "mfaChallengeMethod" // sign in method the challenge was created by
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaMaxChallengeAttempts = 5
+	mfaRecoveryCodeCount    = 10
+)
+
+// Authentication method references (RFC 8176) emitted in the `amr` claim
+const (
+	amrPassword = "pwd"
+	amrOTP      = "otp"
+	amrMFA      = "mfa"
+)
+
+var mfaChallengeToken = &userTokenKind{
+	tokenField:  userFieldMFAChallenge,
+	expiryField: userFieldMFAChallengeExpiry,
+	defaultTTL:  mfaChallengeTTL,
+	ttl:         func(stub *config.AuthStub) string { return "" },
+}
+
+// EnrollMFA generates a new totp secret for the user the token belongs to. The secret only gets used once the
+// user activates it by verifying a code generated from it. Users who have already enabled multi factor
+// authentication need to disable it first, which requires the current factor.
+func (m *Module) EnrollMFA(ctx context.Context, token, dbAlias, project string) (int, map[string]interface{}, error) {
+	stub, err := m.getMFAStub()
+	if err != nil {
+		return http.StatusNotFound, nil, err
+	}
+
+	status, userObj, idField, err := m.readUserOfToken(ctx, token, dbAlias, project)
+	if err != nil {
+		return status, nil, err
+	}
+	if isTrue(userObj[userFieldMFAEnabled]) {
+		return http.StatusConflict, nil, errors.New("Multi factor authentication is already enabled for the user")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate totp secret", err, nil)
+	}
+	encrypted, err := m.encryptMFASecret(secret)
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to encrypt totp secret", err, nil)
+	}
+	if err := m.updateUser(ctx, dbAlias, project, idField, userObj[idField], map[string]interface{}{userFieldMFAPendingSecret: encrypted}); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	issuer := stub.MFA.Issuer
+	if issuer == "" {
+		issuer = project
+	}
+	email, _ := userObj["email"].(string)
+	return http.StatusOK, map[string]interface{}{"secret": secret, "uri": totpURI(issuer, email, secret)}, nil
+}
+
+// ActivateMFA enables multi factor authentication for the user once the code generated from the enrolled secret
+// is verified. The recovery codes returned are never shown again.
+func (m *Module) ActivateMFA(ctx context.Context, token, dbAlias, project, code string) (int, map[string]interface{}, error) {
+	if _, err := m.getMFAStub(); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+
+	status, userObj, idField, err := m.readUserOfToken(ctx, token, dbAlias, project)
+	if err != nil {
+		return status, nil, err
+	}
+	if isTrue(userObj[userFieldMFAEnabled]) {
+		return http.StatusConflict, nil, errors.New("Multi factor authentication is already enabled for the user")
+	}
+
+	pending, _ := userObj[userFieldMFAPendingSecret].(string)
+	if pending == "" {
+		return http.StatusBadRequest, nil, errors.New("User has not enrolled for multi factor authentication")
+	}
+	secret, err := m.decryptMFASecret(pending)
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to decrypt totp secret", err, nil)
+	}
+
+	step, ok := verifyTOTP(secret, code, time.Now(), 0)
+	if !ok {
+		return http.StatusUnauthorized, nil, errors.New("Invalid code provided")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate recovery codes", err, nil)
+	}
+
+	set := map[string]interface{}{
+		userFieldMFAEnabled:       true,
+		userFieldMFASecret:        pending,
+		userFieldMFAPendingSecret: "",
+		userFieldMFALastStep:      step,
+		userFieldMFARecoveryCodes: strings.Join(hashes, ","),
+	}
+	if err := m.updateUser(ctx, dbAlias, project, idField, userObj[idField], set); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, map[string]interface{}{"recoveryCodes": codes}, nil
+}
+
+// DisableMFA disables multi factor authentication for the user the token belongs to. Either a totp code or a
+// recovery code is required. Failed codes count towards the lockout of the account like failed sign ins.
+func (m *Module) DisableMFA(ctx context.Context, token, dbAlias, project, code string) (int, error) {
+	if _, err := m.getMFAStub(); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	status, userObj, idField, err := m.readUserOfToken(ctx, token, dbAlias, project)
+	if err != nil {
+		return status, err
+	}
+	if !isTrue(userObj[userFieldMFAEnabled]) {
+		return http.StatusBadRequest, errors.New("Multi factor authentication is not enabled for the user")
+	}
+
+	policy, err := m.getBruteForcePolicy()
+	if err != nil {
+		return http.StatusInternalServerError, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid brute force config provided", err, nil)
+	}
+	email, _ := userObj["email"].(string)
+	if status, err := m.checkSignInAttempts(ctx, policy, dbAlias, email, ""); err != nil {
+		return status, err
+	}
+
+	set, err := m.verifyMFACode(ctx, userObj, code)
+	if err != nil {
+		m.recordFailedSignIn(ctx, policy, dbAlias, project, email, "", true)
+		return http.StatusUnauthorized, err
+	}
+
+	// The code used gets recorded along with disabling, and only if no other request has used a code meanwhile
+	set[userFieldMFAEnabled] = false
+	set[userFieldMFASecret] = ""
+	set[userFieldMFARecoveryCodes] = ""
+	if status, err := m.updateMFAState(ctx, dbAlias, project, idField, userObj, set); err != nil {
+		return status, err
+	}
+	return http.StatusOK, nil
+}
+
+// VerifyMFAChallenge completes the sign in started with the password once the code is verified. Either a totp
+// code or a recovery code can be provided. The challenge is discarded after a few failed attempts, while the
+// failures also count towards the lockout of the account so that guesses aren't unlimited across challenges.
+func (m *Module) VerifyMFAChallenge(ctx context.Context, dbAlias, project, challengeToken, code string, device *model.SessionDevice) (int, map[string]interface{}, error) {
+	if _, err := m.getMFAStub(); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+
+	status, userObj, idField, err := m.readUserToken(ctx, dbAlias, project, challengeToken, mfaChallengeToken)
+	if err != nil {
+		return status, nil, err
+	}
+	if isDisabled(userObj) {
+		return http.StatusForbidden, nil, errors.New("User has been disabled")
+	}
+
+	ip := ""
+	if device != nil {
+		ip = device.IP
+	}
+	policy, err := m.getBruteForcePolicy()
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid brute force config provided", err, nil)
+	}
+	email, _ := userObj["email"].(string)
+	if status, err := m.checkSignInAttempts(ctx, policy, dbAlias, email, ip); err != nil {
+		return status, nil, err
+	}
+
+	set, err := m.verifyMFACode(ctx, userObj, code)
+	if err != nil {
+		m.recordFailedSignIn(ctx, policy, dbAlias, project, email, ip, true)
+		attempts := getInt64(userObj[userFieldMFAChallengeAttempts]) + 1
+		failed := map[string]interface{}{userFieldMFAChallengeAttempts: attempts}
+		if attempts >= mfaMaxChallengeAttempts {
+			failed[userFieldMFAChallenge] = ""
+			failed[userFieldMFAChallengeExpiry] = ""
+		}
+		_ = m.updateUser(ctx, dbAlias, project, idField, userObj[idField], failed)
+		return http.StatusUnauthorized, nil, err
+	}
+
+	// The challenge is single use
+	set[userFieldMFAChallengeAttempts] = 0
+	if status, err := m.consumeUserToken(ctx, dbAlias, project, idField, userObj[idField], challengeToken, mfaChallengeToken, set); err != nil {
+		return status, nil, err
+	}
+	m.resetSignInAttempts(ctx, policy, dbAlias, email)
+
+	// The tokens are issued for the method the user signed in with before the challenge. Only the email
+	// method involves a password.
+	method, _ := userObj[userFieldMFAChallengeMethod].(string)
+	if method == "" {
+		method = "email"
+	}
+	amr := []string{amrOTP, amrMFA}
+	if method == "email" {
+		amr = append([]string{amrPassword}, amr...)
+	}
+
+	sanitizeUser(userObj)
+	claims := model.TokenClaims{"email": userObj["email"], "id": userObj[idField], "role": userObj["role"]}
+	setAMRClaims(claims, amr...)
+	result, err := m.issueTokens(ctx, dbAlias, project, method, claims, device)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	result["user"] = userObj
+	return http.StatusOK, result, nil
+}
+
+// isMFARequired checks if the user needs to verify a code after signing in with the password
+func (m *Module) isMFARequired(userObj map[string]interface{}) bool {
+	_, err := m.getMFAStub()
+	return err == nil && isTrue(userObj[userFieldMFAEnabled])
+}
+
+// createMFAChallenge stores the hash of a new challenge token for the user who has signed in with the method provided
+func (m *Module) createMFAChallenge(ctx context.Context, dbAlias, project, method, idField string, userObj map[string]interface{}) (string, error) {
+	token, err := generateRandomToken()
+	if err != nil {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate mfa challenge", err, nil)
+	}
+
+	set := map[string]interface{}{
+		userFieldMFAChallenge:         utils.HashString(token),
+		userFieldMFAChallengeExpiry:   time.Now().Add(mfaChallengeTTL).UTC().Format(time.RFC3339),
+		userFieldMFAChallengeAttempts: 0,
+		userFieldMFAChallengeMethod:   method,
+	}
+	if err := m.updateUser(ctx, dbAlias, project, idField, userObj[idField], set); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// verifyMFACode verifies a totp code or a recovery code of the user. The fields to be updated so that the code
+// cannot be used again are returned.
+func (m *Module) verifyMFACode(ctx context.Context, userObj map[string]interface{}, code string) (map[string]interface{}, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, errors.New("Code not provided")
+	}
+
+	if len(code) == totpDigits {
+		encrypted, _ := userObj[userFieldMFASecret].(string)
+		secret, err := m.decryptMFASecret(encrypted)
+		if err != nil {
+			return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to decrypt totp secret", err, nil)
+		}
+		step, ok := verifyTOTP(secret, code, time.Now(), getInt64(userObj[userFieldMFALastStep]))
+		if !ok {
+			return nil, errors.New("Invalid code provided")
+		}
+		return map[string]interface{}{userFieldMFALastStep: step}, nil
+	}
+
+	// Recovery codes get removed once used
+	stored, _ := userObj[userFieldMFARecoveryCodes].(string)
+	hash := utils.HashString(normalizeRecoveryCode(code))
+	remaining := []string{}
+	matched := false
+	for _, h := range strings.Split(stored, ",") {
+		if h == "" {
+			continue
+		}
+		if !matched && h == hash {
+			matched = true
+			continue
+		}
+		remaining = append(remaining, h)
+	}
+	if !matched {
+		return nil, errors.New("Invalid code provided")
+	}
+	return map[string]interface{}{userFieldMFARecoveryCodes: strings.Join(remaining, ",")}, nil
+}
+
+// updateMFAState updates the user only if the last totp step and the recovery codes haven't changed since the user
+// was read, so that concurrent requests cannot use the same code twice
+func (m *Module) updateMFAState(ctx context.Context, dbAlias, project, idField string, userObj, set map[string]interface{}) (int, error) {
+	find := map[string]interface{}{idField: userObj[idField]}
+	for _, field := range []string{userFieldMFALastStep, userFieldMFARecoveryCodes} {
+		if value, p := userObj[field]; p {
+			find[field] = value
+		}
+	}
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": "users"}
+	reqParams := model.RequestParams{Resource: "db-update", Op: "access", Attributes: attr}
+	n, err := m.crud.UpdateAndCount(ctx, dbAlias, "users", &model.UpdateRequest{Find: find, Operation: utils.All, Update: map[string]interface{}{"$set": set}}, reqParams)
+	if err != nil {
+		return http.StatusInternalServerError, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to update user", err, nil)
+	}
+	if n == 0 {
+		return http.StatusUnauthorized, errors.New("Invalid code provided")
+	}
+	return http.StatusOK, nil
+}
+
+// readUserOfToken reads the user the token has been issued to
+func (m *Module) readUserOfToken(ctx context.Context, token, dbAlias, project string) (int, map[string]interface{}, string, error) {
+	claims, err := m.auth.ParseToken(ctx, token)
+	if err != nil {
+		return http.StatusUnauthorized, nil, "", err
+	}
+
+	idField, err := m.getIDField(dbAlias)
+	if err != nil {
+		return http.StatusInternalServerError, nil, "", err
+	}
+	userObj, _, err := m.readUser(ctx, dbAlias, project, map[string]interface{}{idField: claims["id"]})
+	if err != nil {
+		return http.StatusNotFound, nil, "", errors.New("User not found")
+	}
+	return http.StatusOK, userObj, idField, nil
+}
+
+func (m *Module) getMFAStub() (*config.AuthStub, error) {
+	stub, err := m.getEmailStub()
+	if err != nil {
+		return nil, err
+	}
+	if stub.MFA == nil || !stub.MFA.Enabled {
+		return nil, errors.New("Multi factor authentication is not enabled")
+	}
+	return stub, nil
+}
+
+func (m *Module) encryptMFASecret(secret string) (string, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.aesKeys.Encrypt(secret)
+}
+
+func (m *Module) decryptMFASecret(value string) (string, error) {
+	if value == "" {
+		return "", errors.New("totp secret not found")
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+	return m.aesKeys.Decrypt(value)
+}
+
+// generateRecoveryCodes returns the recovery codes along with their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, mfaRecoveryCodeCount)
+	hashes := make([]string, mfaRecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = utils.HashString(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// setAMRClaims sets the methods used to authenticate the user in the token claims. Security rules can require
+// multi factor authentication by matching on the `mfa` claim.
+func setAMRClaims(claims model.TokenClaims, amr ...string) {
+	mfa := false
+	for _, method := range amr {
+		if method == amrMFA {
+			mfa = true
+		}
+	}
+	claims["amr"] = amr
+	claims["mfa"] = mfa
+}
+
+// getInt64 reads a number stored in the database. Databases differ in the types numbers are returned as.
+func getInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
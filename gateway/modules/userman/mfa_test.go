@@ -0,0 +1,214 @@
+package userman
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+func TestModule_MFA(t *testing.T) {
+	ctx := context.Background()
+	crud := &mockCrud{}
+	auth := &mockAuth{}
+	m := Init(crud, auth)
+	m.SetConfig(config.Auths{"email": {ID: "email", Enabled: true, MFA: &config.MFA{Enabled: true, Issuer: "Acme"}}})
+	aesKeys, _ := utils.NewAESKeyRing([]byte("0123456789abcdef0123456789abcdef"), nil)
+	m.SetProjectAESKey(aesKeys)
+
+	status, result, err := m.EmailSignUp(ctx, "db", "project", "jon@example.com", "Jon", "1234", "user", nil)
+	if status != http.StatusOK {
+		t.Fatalf("EmailSignUp() status = %v, error = %v", status, err)
+	}
+	token := result["token"].(string)
+
+	status, enrollment, err := m.EnrollMFA(ctx, token, "db", "project")
+	if status != http.StatusOK {
+		t.Fatalf("EnrollMFA() status = %v, error = %v", status, err)
+	}
+	secret := enrollment["secret"].(string)
+	if want := "otpauth://totp/Acme:jon@example.com?algorithm=SHA1&digits=6&issuer=Acme&period=30&secret=" + secret; enrollment["uri"] != want {
+		t.Errorf("EnrollMFA() uri = %v, want %v", enrollment["uri"], want)
+	}
+	if crud.users[0][userFieldMFAPendingSecret] == secret {
+		t.Errorf("EnrollMFA() stored the totp secret in plain text")
+	}
+
+	step := time.Now().Unix() / totpPeriod
+	code := func(s int64) string {
+		c, _ := totpCode(secret, s)
+		return c
+	}
+
+	if status, _, _ := m.ActivateMFA(ctx, token, "db", "project", "abcdef"); status != http.StatusUnauthorized {
+		t.Errorf("ActivateMFA() status = %v for an invalid code, want %v", status, http.StatusUnauthorized)
+	}
+	status, activation, err := m.ActivateMFA(ctx, token, "db", "project", code(step))
+	if status != http.StatusOK {
+		t.Fatalf("ActivateMFA() status = %v, error = %v", status, err)
+	}
+	recoveryCodes := activation["recoveryCodes"].([]string)
+	if len(recoveryCodes) != mfaRecoveryCodeCount {
+		t.Fatalf("ActivateMFA() returned (%d) recovery codes, want %d", len(recoveryCodes), mfaRecoveryCodeCount)
+	}
+
+	// The secret of a user who has enabled multi factor authentication cannot be replaced without disabling it
+	if status, _, _ := m.EnrollMFA(ctx, token, "db", "project"); status != http.StatusConflict {
+		t.Errorf("EnrollMFA() status = %v when mfa is already enabled, want %v", status, http.StatusConflict)
+	}
+	if status, _, _ := m.ActivateMFA(ctx, token, "db", "project", code(step+1)); status != http.StatusConflict {
+		t.Errorf("ActivateMFA() status = %v when mfa is already enabled, want %v", status, http.StatusConflict)
+	}
+
+	signIn := func() string {
+		status, result, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil)
+		if status != http.StatusOK || result["mfaRequired"] != true {
+			t.Fatalf("EmailSignIn() status = %v, error = %v, result = %v, want an mfa challenge", status, err, result)
+		}
+		if _, p := result["token"]; p {
+			t.Fatalf("EmailSignIn() returned a token before the mfa challenge was verified")
+		}
+		return result["challengeToken"].(string)
+	}
+
+	// The code used for activation cannot be replayed
+	challenge := signIn()
+	if status, _, _ := m.VerifyMFAChallenge(ctx, "db", "project", challenge, code(step), nil); status != http.StatusUnauthorized {
+		t.Errorf("VerifyMFAChallenge() status = %v for a replayed code, want %v", status, http.StatusUnauthorized)
+	}
+	status, result, err = m.VerifyMFAChallenge(ctx, "db", "project", challenge, code(step+1), nil)
+	if status != http.StatusOK {
+		t.Fatalf("VerifyMFAChallenge() status = %v, error = %v", status, err)
+	}
+	claims := auth.tokens[result["token"].(string)]
+	if claims["mfa"] != true {
+		t.Errorf("VerifyMFAChallenge() token claims = %v, want the mfa claim", claims)
+	}
+	if _, p := result["user"].(map[string]interface{})[userFieldMFASecret]; p {
+		t.Errorf("VerifyMFAChallenge() returned the totp secret of the user")
+	}
+	if status, _, _ := m.VerifyMFAChallenge(ctx, "db", "project", challenge, code(step+1), nil); status != http.StatusUnauthorized {
+		t.Errorf("VerifyMFAChallenge() status = %v for a challenge which has already been used", status)
+	}
+
+	// Recovery codes are single use
+	if status, _, err := m.VerifyMFAChallenge(ctx, "db", "project", signIn(), recoveryCodes[0], nil); status != http.StatusOK {
+		t.Errorf("VerifyMFAChallenge() status = %v, error = %v with a recovery code", status, err)
+	}
+	if status, _, _ := m.VerifyMFAChallenge(ctx, "db", "project", signIn(), recoveryCodes[0], nil); status != http.StatusUnauthorized {
+		t.Errorf("VerifyMFAChallenge() status = %v with a used recovery code, want %v", status, http.StatusUnauthorized)
+	}
+
+	// The challenge is discarded after too many failed attempts
+	challenge = signIn()
+	for i := 0; i < mfaMaxChallengeAttempts; i++ {
+		_, _, _ = m.VerifyMFAChallenge(ctx, "db", "project", challenge, "invalid-code", nil)
+	}
+	if status, _, _ := m.VerifyMFAChallenge(ctx, "db", "project", challenge, recoveryCodes[1], nil); status != http.StatusUnauthorized {
+		t.Errorf("VerifyMFAChallenge() status = %v after too many failed attempts, want %v", status, http.StatusUnauthorized)
+	}
+
+	if status, err := m.DisableMFA(ctx, token, "db", "project", recoveryCodes[2]); status != http.StatusOK {
+		t.Fatalf("DisableMFA() status = %v, error = %v", status, err)
+	}
+	status, result, err = m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil)
+	if status != http.StatusOK || result["token"] == nil {
+		t.Fatalf("EmailSignIn() status = %v, error = %v, result = %v after disabling mfa", status, err, result)
+	}
+	if claims := auth.tokens[result["token"].(string)]; claims["mfa"] != false {
+		t.Errorf("EmailSignIn() token claims = %v, want the mfa claim to be false", claims)
+	}
+}
+
+func TestModule_EnrollMFA_disabled(t *testing.T) {
+	m := Init(&mockCrud{}, &mockAuth{})
+	m.SetConfig(config.Auths{"email": {ID: "email", Enabled: true}})
+
+	if status, _, err := m.EnrollMFA(context.Background(), "token", "db", "project"); status != http.StatusNotFound {
+		t.Errorf("EnrollMFA() status = %v, error = %v when mfa is not enabled", status, err)
+	}
+}
+
+func TestModule_VerifyMFAChallenge_lockout(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newBruteForceModule(t)
+	m.SetConfig(config.Auths{"email": {ID: "email", Enabled: true, MFA: &config.MFA{Enabled: true}, BruteForce: &config.BruteForce{Enabled: true, MaxAccountAttempts: 3, MaxDelay: "1ms"}}})
+	aesKeys, _ := utils.NewAESKeyRing([]byte("0123456789abcdef0123456789abcdef"), nil)
+	m.SetProjectAESKey(aesKeys)
+
+	crud := m.crud.(*mockCrud)
+	crud.users[0][userFieldMFAEnabled] = true
+	crud.users[0][userFieldMFARecoveryCodes] = utils.HashString(normalizeRecoveryCode("abcde-fghij"))
+
+	// Every sign in gets a fresh challenge, but the failures across challenges lock the account
+	for i := 0; i < 3; i++ {
+		status, result, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil)
+		if status != http.StatusOK {
+			t.Fatalf("EmailSignIn() status = %v, error = %v for attempt (%d)", status, err, i)
+		}
+		if status, _, _ := m.VerifyMFAChallenge(ctx, "db", "project", result["challengeToken"].(string), "invalid-code", nil); status != http.StatusUnauthorized {
+			t.Fatalf("VerifyMFAChallenge() status = %v for attempt (%d), want %v", status, i, http.StatusUnauthorized)
+		}
+	}
+	if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil); status != http.StatusTooManyRequests {
+		t.Errorf("EmailSignIn() status = %v after too many failed mfa codes, want %v", status, http.StatusTooManyRequests)
+	}
+}
+
+func TestModule_DisableMFA_concurrent(t *testing.T) {
+	ctx := context.Background()
+	crud := &mockCrud{}
+	auth := &mockAuth{}
+	m := Init(crud, auth)
+	m.SetConfig(config.Auths{"email": {ID: "email", Enabled: true, MFA: &config.MFA{Enabled: true}}})
+	aesKeys, _ := utils.NewAESKeyRing([]byte("0123456789abcdef0123456789abcdef"), nil)
+	m.SetProjectAESKey(aesKeys)
+
+	token, _ := auth.CreateToken(ctx, map[string]interface{}{"id": "1"})
+	crud.users = []map[string]interface{}{{"id": "1", "email": "jon@example.com", userFieldMFAEnabled: true, userFieldMFARecoveryCodes: utils.HashString(normalizeRecoveryCode("abcde-fghij"))}}
+
+	// Another request uses the same recovery code right after this one has read the user
+	crud.afterRead = func() {
+		crud.users[0][userFieldMFARecoveryCodes] = ""
+		crud.afterRead = nil
+	}
+	if status, _ := m.DisableMFA(ctx, token, "db", "project", "abcde-fghij"); status != http.StatusUnauthorized {
+		t.Errorf("DisableMFA() status = %v for a recovery code used concurrently, want %v", status, http.StatusUnauthorized)
+	}
+	if crud.users[0][userFieldMFAEnabled] != true {
+		t.Errorf("DisableMFA() disabled mfa with a recovery code used concurrently")
+	}
+}
+
+func TestModule_VerifyMFAChallenge_concurrent(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newBruteForceModule(t)
+	m.SetConfig(config.Auths{"email": {ID: "email", Enabled: true, MFA: &config.MFA{Enabled: true}}})
+	aesKeys, _ := utils.NewAESKeyRing([]byte("0123456789abcdef0123456789abcdef"), nil)
+	m.SetProjectAESKey(aesKeys)
+
+	crud := m.crud.(*mockCrud)
+	recoveryCodes := utils.HashString(normalizeRecoveryCode("abcde-fghij"))
+	crud.users[0][userFieldMFAEnabled] = true
+	crud.users[0][userFieldMFARecoveryCodes] = recoveryCodes
+
+	status, result, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil)
+	if status != http.StatusOK {
+		t.Fatalf("EmailSignIn() status = %v, error = %v", status, err)
+	}
+
+	// Another request verifies the same challenge right after this one has read the user
+	crud.afterRead = func() {
+		crud.users[0][userFieldMFAChallenge] = ""
+		crud.afterRead = nil
+	}
+	if status, result, _ := m.VerifyMFAChallenge(ctx, "db", "project", result["challengeToken"].(string), "abcde-fghij", nil); status != http.StatusUnauthorized || result != nil {
+		t.Errorf("VerifyMFAChallenge() status = %v for a challenge verified concurrently, want %v", status, http.StatusUnauthorized)
+	}
+	if crud.users[0][userFieldMFARecoveryCodes] != recoveryCodes {
+		t.Errorf("VerifyMFAChallenge() used up the recovery code of a challenge verified concurrently")
+	}
+}
@@ -0,0 +1,271 @@
+package userman
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spaceuptech/helpers"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+	"github.com/spaceuptech/space-cloud/gateway/utils/oidc"
+)
+
+var defaultOIDCScopes = []string{"openid", "email", "profile"}
+
+// GetOIDCAppRedirectURL returns the url of the app the user is sent to after signing in with the provider
+func (m *Module) GetOIDCAppRedirectURL(method string) string {
+	m.RLock()
+	defer m.RUnlock()
+
+	if s, p := m.methods[method]; p {
+		return s.AppRedirectURL
+	}
+	return ""
+}
+
+// OIDCLogin returns the url of the provider the user needs to be redirected to for signing in. The login state
+// returned must be presented back on the callback.
+func (m *Module) OIDCLogin(ctx context.Context, method string) (int, string, *model.OIDCLoginState, error) {
+	stub, err := m.getOIDCStub(ctx, method)
+	if err != nil {
+		return http.StatusNotFound, "", nil, err
+	}
+
+	provider, err := m.oidcProviders.Get(ctx, stub.Issuer, false)
+	if err != nil {
+		return http.StatusBadGateway, "", nil, err
+	}
+
+	state := &model.OIDCLoginState{}
+	for _, v := range []*string{&state.State, &state.CodeVerifier, &state.Nonce} {
+		if *v, err = generateRandomToken(); err != nil {
+			return http.StatusInternalServerError, "", nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate login state for openid connect", err, nil)
+		}
+	}
+
+	scopes := stub.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultOIDCScopes
+	}
+
+	challenge := sha256.Sum256([]byte(state.CodeVerifier))
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", stub.ClientID)
+	query.Set("redirect_uri", stub.RedirectURL)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state.State)
+	query.Set("nonce", state.Nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	authURL := provider.AuthorizationEndpoint
+	if strings.Contains(authURL, "?") {
+		authURL += "&" + query.Encode()
+	} else {
+		authURL += "?" + query.Encode()
+	}
+	return http.StatusOK, authURL, state, nil
+}
+
+// OIDCCallback exchanges the authorization code received from the provider for an id token and signs in the
+// user it belongs to. Users are linked by their email and get created if they don't exist.
+func (m *Module) OIDCCallback(ctx context.Context, dbAlias, project, method, code, state string, loginState *model.OIDCLoginState, device *model.SessionDevice) (int, map[string]interface{}, error) {
+	stub, err := m.getOIDCStub(ctx, method)
+	if err != nil {
+		return http.StatusNotFound, nil, err
+	}
+
+	if loginState == nil || state == "" || loginState.State != state {
+		return http.StatusUnauthorized, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid state received in openid connect callback", nil, nil)
+	}
+	if code == "" {
+		return http.StatusBadRequest, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Authorization code not received in openid connect callback", nil, nil)
+	}
+
+	provider, err := m.oidcProviders.Get(ctx, stub.Issuer, false)
+	if err != nil {
+		return http.StatusBadGateway, nil, err
+	}
+
+	idToken, err := exchangeOIDCCode(ctx, provider, stub, code, loginState.CodeVerifier)
+	if err != nil {
+		return http.StatusUnauthorized, nil, err
+	}
+
+	claims, err := m.verifyIDToken(ctx, stub, idToken, loginState.Nonce)
+	if err != nil {
+		return http.StatusUnauthorized, nil, err
+	}
+
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return http.StatusUnauthorized, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Id token does not contain the email of the user, make sure the (email) scope is requested", nil, nil)
+	}
+	// Users are linked by email so an unverified email could be used to take over an account. Providers
+	// which don't tell if the email has been verified aren't trusted either.
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return http.StatusForbidden, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Email of the user has not been verified by the provider", nil, nil)
+	}
+	name, _ := claims["name"].(string)
+
+	return m.signInOIDCUser(ctx, dbAlias, project, method, email, name, device)
+}
+
+func (m *Module) signInOIDCUser(ctx context.Context, dbAlias, project, method, email, name string, device *model.SessionDevice) (int, map[string]interface{}, error) {
+	actualDbType, err := m.crud.GetDBType(dbAlias)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	idField := "id"
+	if actualDbType == string(model.Mongo) || actualDbType == string(model.EmbeddedDB) {
+		idField = "_id"
+	}
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": "users"}
+	reqParams := model.RequestParams{Resource: "db-read", Op: "access", Attributes: attr}
+	// The user is read as a list so that a user which doesn't exist can be told apart from a failed read
+	limit := int64(1)
+	readReq := &model.ReadRequest{Find: map[string]interface{}{"email": email}, Operation: utils.All, Options: &model.ReadOptions{Limit: &limit}}
+	result, _, err := m.crud.Read(ctx, dbAlias, "users", readReq, reqParams)
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to read user", err, nil)
+	}
+
+	var userObj map[string]interface{}
+	if docs, _ := result.([]interface{}); len(docs) > 0 {
+		if userObj, _ = docs[0].(map[string]interface{}); userObj == nil {
+			return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid user received from the database", nil, nil)
+		}
+	}
+
+	// A local account whose email hasn't been verified could have been signed up by someone else in order to take
+	// over the account once its owner signs in with the provider. Hence it only gets linked if it has no password.
+	if hash, _ := userObj["pass"].(string); userObj != nil && hash != "" && !isVerified(userObj) {
+		return http.StatusForbidden, nil, errors.New("An account with this email exists, verify its email before signing in with the provider")
+	}
+
+	// Create the user on the first sign in. The user doesn't have a password and hence cannot sign in by email.
+	// The email has been verified by the provider.
+	if userObj == nil {
+		userObj = map[string]interface{}{idField: uuid.NewV1().String(), "email": email, "name": name, "role": "user", "pass": "", userFieldVerified: true}
+
+		reqParams.Resource = "db-create"
+		createReq := &model.CreateRequest{Operation: utils.One, Document: userObj}
+		if err := m.crud.Create(ctx, dbAlias, "users", createReq, reqParams); err != nil {
+			return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Failed to create user account", err, nil)
+		}
+	}
+
+	// The linked account is subject to the same checks as signing in by email
+	if isDisabled(userObj) {
+		return http.StatusForbidden, nil, errors.New("User has been disabled")
+	}
+	if isPasswordResetRequired(userObj) {
+		return http.StatusForbidden, nil, errors.New("Password needs to be reset before signing in")
+	}
+	if stub, err := m.getEmailStub(); err == nil && stub.RequireVerifiedEmail && !isVerified(userObj) {
+		return http.StatusForbidden, nil, errors.New("Email has not been verified")
+	}
+
+	// Users who have enabled multi factor authentication get a challenge instead of a token
+	if m.isMFARequired(userObj) {
+		challengeToken, err := m.createMFAChallenge(ctx, dbAlias, project, method, idField, userObj)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+		return http.StatusOK, map[string]interface{}{"mfaRequired": true, "challengeToken": challengeToken}, nil
+	}
+
+	// Delete password from user
+	sanitizeUser(userObj)
+
+	res, err := m.issueTokens(ctx, dbAlias, project, method, map[string]interface{}{"email": email, "id": userObj[idField], "role": userObj["role"]}, device)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	res["user"] = userObj
+	return http.StatusOK, res, nil
+}
+
+func (m *Module) getOIDCStub(ctx context.Context, method string) (*config.AuthStub, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	s, p := m.methods[method]
+	if !p || !s.Enabled || s.Issuer == "" {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("OpenID connect provider (%s) is not enabled", method), nil, nil)
+	}
+	return s, nil
+}
+
+// verifyIDToken verifies the id token received from the provider along with the nonce of the login state
+func (m *Module) verifyIDToken(ctx context.Context, stub *config.AuthStub, idToken, nonce string) (map[string]interface{}, error) {
+	claims, err := m.oidcProviders.VerifyIDToken(ctx, stub.Issuer, stub.ClientID, idToken)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Nonce of id token does not match the login state", nil, nil)
+	}
+	return claims, nil
+}
+
+func exchangeOIDCCode(ctx context.Context, provider *oidc.Provider, stub *config.AuthStub, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", stub.RedirectURL)
+	form.Set("client_id", stub.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if stub.Secret != "" {
+		form.Set("client_secret", stub.Secret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to exchange authorization code with openid connect provider", err, nil)
+	}
+	defer utils.CloseTheCloser(res.Body)
+
+	body := struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid response received on exchanging authorization code", err, nil)
+	}
+	if res.StatusCode != http.StatusOK || body.IDToken == "" {
+		return "", helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to exchange authorization code - %s %s", body.Error, body.ErrorDescription), nil, map[string]interface{}{"statusCode": res.StatusCode})
+	}
+	return body.IDToken, nil
+}
+
+func generateRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
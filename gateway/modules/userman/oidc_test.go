@@ -0,0 +1,273 @@
+package userman
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+// mockIssuer is a minimal openid connect provider which issues id tokens for the codes it hands out
+type mockIssuer struct {
+	server     *httptest.Server
+	key        *rsa.PrivateKey
+	kid        string
+	challenges map[string]string // code -> code challenge
+	nonces     map[string]string // code -> nonce
+	claims     func(claims jwt.MapClaims)
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unable to generate key for mock issuer - %v", err)
+	}
+	m := &mockIssuer{key: key, kid: "mock-key", challenges: map[string]string{}, nonces: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{map[string]interface{}{
+			"kty": "RSA",
+			"kid": m.kid,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		code := r.PostForm.Get("code")
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if challenge, p := m.challenges[code]; !p || challenge != base64.RawURLEncoding.EncodeToString(verifier[:]) || r.PostForm.Get("client_secret") != "client-secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":            m.server.URL,
+			"aud":            "client-id",
+			"sub":            "provider-user",
+			"email":          "jon@example.com",
+			"email_verified": true,
+			"name":           "Jon",
+			"nonce":          m.nonces[code],
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		}
+		if m.claims != nil {
+			m.claims(claims)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = m.kid
+		idToken, _ := token.SignedString(key)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "token_type": "Bearer"})
+	})
+	m.server = httptest.NewServer(mux)
+	return m
+}
+
+// authorize mimics the user approving the sign in at the provider and returns the code
+func (m *mockIssuer) authorize(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid authorization url (%s) - %v", authURL, err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "client-id" || query.Get("redirect_uri") != "http://localhost:4122/callback" {
+		t.Fatalf("Invalid authorization url (%s)", authURL)
+	}
+
+	code := "code-" + query.Get("state")
+	m.challenges[code] = query.Get("code_challenge")
+	m.nonces[code] = query.Get("nonce")
+	return code
+}
+
+func TestModule_OIDC(t *testing.T) {
+	issuer := newMockIssuer(t)
+	defer issuer.server.Close()
+
+	tests := []struct {
+		name       string
+		users      []map[string]interface{}
+		readErr    error
+		email      *config.AuthStub
+		claims     func(claims jwt.MapClaims)
+		stateFunc  func(state string) string
+		wantStatus int
+		wantID     string
+		wantMFA    bool
+	}{
+		{
+			name:       "new user gets created",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "existing user gets linked by email",
+			users:      []map[string]interface{}{{"id": "existing", "email": "jon@example.com", "role": "admin", "pass": "hash", "verified": true}},
+			wantStatus: http.StatusOK,
+			wantID:     "existing",
+		},
+		{
+			name:       "existing user without a password gets linked even if unverified",
+			users:      []map[string]interface{}{{"id": "existing", "email": "jon@example.com", "role": "user", "pass": ""}},
+			wantStatus: http.StatusOK,
+			wantID:     "existing",
+		},
+		{
+			name:       "existing user with a password and an unverified email is not linked",
+			users:      []map[string]interface{}{{"id": "existing", "email": "jon@example.com", "role": "admin", "pass": "hash"}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "existing user who needs to reset the password",
+			users:      []map[string]interface{}{{"id": "existing", "email": "jon@example.com", "role": "user", "pass": "hash", "verified": true, "passwordResetRequired": true}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "existing user without a verified email when verification is required",
+			users:      []map[string]interface{}{{"id": "existing", "email": "jon@example.com", "role": "user", "pass": ""}},
+			email:      &config.AuthStub{ID: "email", Enabled: true, RequireVerifiedEmail: true},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "existing user who has enabled mfa gets a challenge",
+			users:      []map[string]interface{}{{"id": "existing", "email": "jon@example.com", "role": "user", "pass": "hash", "verified": true, "mfaEnabled": true}},
+			email:      &config.AuthStub{ID: "email", Enabled: true, MFA: &config.MFA{Enabled: true}},
+			wantStatus: http.StatusOK,
+			wantID:     "existing",
+			wantMFA:    true,
+		},
+		{
+			name:       "state mismatch",
+			stateFunc:  func(state string) string { return "forged" },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "nonce mismatch",
+			claims:     func(claims jwt.MapClaims) { claims["nonce"] = "replayed" },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "token issued for another client",
+			claims:     func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "expired id token",
+			claims:     func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "unverified email",
+			claims:     func(claims jwt.MapClaims) { claims["email_verified"] = false },
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "email without verification status",
+			claims:     func(claims jwt.MapClaims) { delete(claims, "email_verified") },
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "user cannot be read",
+			readErr:    errors.New("database unavailable"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer.claims = tt.claims
+			crud := &mockCrud{users: tt.users, readErr: tt.readErr}
+			m := Init(crud, &mockAuth{})
+			auths := config.Auths{"google": {
+				ID:          "google",
+				Enabled:     true,
+				Secret:      "client-secret",
+				Issuer:      issuer.server.URL,
+				ClientID:    "client-id",
+				RedirectURL: "http://localhost:4122/callback",
+			}}
+			if tt.email != nil {
+				auths["email"] = tt.email
+			}
+			m.SetConfig(auths)
+
+			ctx := context.Background()
+			_, authURL, loginState, err := m.OIDCLogin(ctx, "google")
+			if err != nil {
+				t.Fatalf("OIDCLogin() error = %v", err)
+			}
+			code := issuer.authorize(t, authURL)
+
+			state := loginState.State
+			if tt.stateFunc != nil {
+				state = tt.stateFunc(state)
+			}
+			status, result, err := m.OIDCCallback(ctx, "db", "project", "google", code, state, loginState, nil)
+			if status != tt.wantStatus {
+				t.Fatalf("OIDCCallback() status = %v, want %v, error = %v", status, tt.wantStatus, err)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if len(crud.users) != len(tt.users) {
+					t.Errorf("OIDCCallback() created a user even though the sign in failed")
+				}
+				return
+			}
+
+			if len(crud.users) != 1 {
+				t.Fatalf("OIDCCallback() resulted in (%d) users, want 1", len(crud.users))
+			}
+			id := crud.users[0]["id"].(string)
+			if tt.wantID != "" && id != tt.wantID {
+				t.Errorf("OIDCCallback() signed in user (%s), want (%s)", id, tt.wantID)
+			}
+			if tt.wantMFA {
+				if result["mfaRequired"] != true || result["challengeToken"] == nil || result["token"] != nil {
+					t.Errorf("OIDCCallback() = %v, want an mfa challenge", result)
+				}
+				if crud.users[0]["mfaChallengeMethod"] != "google" {
+					t.Errorf("OIDCCallback() challenge method = %v, want google", crud.users[0]["mfaChallengeMethod"])
+				}
+				return
+			}
+			if result["token"] != "token-"+id {
+				t.Errorf("OIDCCallback() token = %v, want %v", result["token"], "token-"+id)
+			}
+			if _, p := result["user"].(map[string]interface{})["pass"]; p {
+				t.Errorf("OIDCCallback() returned the password of the user")
+			}
+		})
+	}
+}
+
+func TestModule_OIDCLogin_disabled(t *testing.T) {
+	m := Init(&mockCrud{}, &mockAuth{})
+	m.SetConfig(config.Auths{"email": {ID: "email", Enabled: true}})
+
+	if status, _, _, err := m.OIDCLogin(context.Background(), "email"); err == nil || status != http.StatusNotFound {
+		t.Errorf("OIDCLogin() status = %v, error = %v for a method which isn't an openid connect provider", status, err)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"net/http"
 
 	"github.com/spaceuptech/helpers"
-	"golang.org/x/crypto/bcrypt"
 
 	uuid "github.com/satori/go.uuid"
 
@@ -50,10 +49,10 @@ func (m *Module) Profile(ctx context.Context, token, dbAlias, project, id string
 		return http.StatusInternalServerError, nil, err
 	}
 
-	_ = authHelpers.PostProcessMethod(ctx, m.aesKey, actions, res)
+	_ = authHelpers.PostProcessMethod(ctx, m.aesKeys, actions, res)
 
 	// Delete password from user object
-	delete(res.(map[string]interface{}), "pass")
+	sanitizeUser(res.(map[string]interface{}))
 
 	return http.StatusOK, res.(map[string]interface{}), nil
 }
@@ -80,13 +79,13 @@ func (m *Module) Profiles(ctx context.Context, token, dbAlias, project string) (
 		return http.StatusInternalServerError, nil, err
 	}
 
-	_ = authHelpers.PostProcessMethod(ctx, m.aesKey, actions, res)
+	_ = authHelpers.PostProcessMethod(ctx, m.aesKeys, actions, res)
 
 	// Delete password from user object
 	if usersArray, ok := res.([]interface{}); ok {
 		for _, user := range usersArray {
 			userObj := user.(map[string]interface{})
-			delete(userObj, "pass")
+			sanitizeUser(userObj)
 		}
 	}
 
@@ -94,12 +93,25 @@ func (m *Module) Profiles(ctx context.Context, token, dbAlias, project string) (
 }
 
 // EmailSignIn signins the user and returns a JWT token
-func (m *Module) EmailSignIn(ctx context.Context, dbAlias, project, email, password string) (int, map[string]interface{}, error) {
+func (m *Module) EmailSignIn(ctx context.Context, dbAlias, project, email, password string, device *model.SessionDevice) (int, map[string]interface{}, error) {
 	// Allow this feature only if the email sign in function is enabled
 	if !m.IsActive("email") {
 		return http.StatusNotFound, nil, errors.New("Email sign in feature is not enabled")
 	}
 
+	// Refuse sign ins while the account or the ip is locked due to failed attempts
+	ip := ""
+	if device != nil {
+		ip = device.IP
+	}
+	policy, err := m.getBruteForcePolicy()
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid brute force config provided", err, nil)
+	}
+	if status, err := m.checkSignInAttempts(ctx, policy, dbAlias, email, ip); err != nil {
+		return status, nil, err
+	}
+
 	// Create read request
 	attr := map[string]string{"project": project, "db": dbAlias, "col": "users"}
 	reqParams := model.RequestParams{Resource: "db-read", Op: "access", Attributes: attr}
@@ -107,19 +119,37 @@ func (m *Module) EmailSignIn(ctx context.Context, dbAlias, project, email, passw
 
 	user, _, err := m.crud.Read(ctx, dbAlias, "users", readReq, reqParams)
 	if err != nil {
+		m.recordFailedSignIn(ctx, policy, dbAlias, project, email, ip, false)
 		return http.StatusNotFound, nil, errors.New("User not found")
 	}
 
 	userObj := user.(map[string]interface{})
 
 	// Compares if the given password is correct
-	err = bcrypt.CompareHashAndPassword([]byte(userObj["pass"].(string)), []byte(password))
-	if err != nil {
+	hash, _ := userObj["pass"].(string)
+	matched, err := utils.VerifyHash(hash, password)
+	if err != nil || !matched {
+		m.recordFailedSignIn(ctx, policy, dbAlias, project, email, ip, true)
 		return http.StatusUnauthorized, nil, errors.New("Given credentials are not correct")
 	}
 
-	// Delete password from user
-	delete(userObj, "pass")
+	// The failures of users who have enabled multi factor authentication are only forgotten once they verify the code
+	if !m.isMFARequired(userObj) {
+		m.resetSignInAttempts(ctx, policy, dbAlias, email)
+	}
+
+	// Refuse users who have been disabled or need to reset their password by an admin
+	if isDisabled(userObj) {
+		return http.StatusForbidden, nil, errors.New("User has been disabled")
+	}
+	if isPasswordResetRequired(userObj) {
+		return http.StatusForbidden, nil, errors.New("Password needs to be reset before signing in")
+	}
+
+	// Refuse users who haven't verified their email if required
+	if stub, err := m.getEmailStub(); err == nil && stub.RequireVerifiedEmail && !isVerified(userObj) {
+		return http.StatusForbidden, nil, errors.New("Email has not been verified")
+	}
 
 	req := map[string]interface{}{}
 	req["email"] = email
@@ -127,23 +157,44 @@ func (m *Module) EmailSignIn(ctx context.Context, dbAlias, project, email, passw
 	if err != nil {
 		return 0, nil, err
 	}
-	// Create a token
+	idField := "id"
 	if actualDbType == string(model.Mongo) || actualDbType == string(model.EmbeddedDB) {
-		req["id"] = userObj["_id"]
-	} else {
-		req["id"] = userObj["id"]
+		idField = "_id"
+	}
+
+	// Upgrade the hash of the password if it wasn't generated with the configured algorithm. This is how legacy
+	// sha256 hashes get replaced.
+	if utils.GetHashAlgorithm(hash) != m.getHashAlgorithm("email") {
+		m.upgradePasswordHash(ctx, dbAlias, project, idField, userObj[idField], password)
+	}
+
+	// Users who have enabled multi factor authentication get a challenge instead of a token
+	if m.isMFARequired(userObj) {
+		challengeToken, err := m.createMFAChallenge(ctx, dbAlias, project, "email", idField, userObj)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+		return http.StatusOK, map[string]interface{}{"mfaRequired": true, "challengeToken": challengeToken}, nil
 	}
+
+	// Delete password from user
+	sanitizeUser(userObj)
+
+	// Create a token
+	req["id"] = userObj[idField]
 	req["role"] = userObj["role"]
+	setAMRClaims(req, amrPassword)
 
-	token, err := m.auth.CreateToken(ctx, req)
+	result, err := m.issueTokens(ctx, dbAlias, project, "email", req, device)
 	if err != nil {
-		return http.StatusInternalServerError, nil, errors.New("Failed to create a JWT token")
+		return http.StatusInternalServerError, nil, err
 	}
-	return http.StatusOK, map[string]interface{}{"user": user, "token": token}, nil
+	result["user"] = user
+	return http.StatusOK, result, nil
 }
 
 // EmailSignUp signs up a user and return a JWT token
-func (m *Module) EmailSignUp(ctx context.Context, dbAlias, project, email, name, password, role string) (int, map[string]interface{}, error) {
+func (m *Module) EmailSignUp(ctx context.Context, dbAlias, project, email, name, password, role string, device *model.SessionDevice) (int, map[string]interface{}, error) {
 	// Allow this feature only if the email sign in function is enabled
 	if !m.IsActive("email") {
 		return http.StatusNotFound, nil, errors.New("Email sign in feature is not enabled")
@@ -151,7 +202,7 @@ func (m *Module) EmailSignUp(ctx context.Context, dbAlias, project, email, name,
 
 	// Hash the password that's in the request
 	var err error
-	password, err = hashPassword(password)
+	password, err = m.hashPassword(password)
 	if err != nil {
 		helpers.Logger.LogInfo(helpers.GetRequestID(ctx), fmt.Sprintf("Error %v ", err), nil)
 		return http.StatusInternalServerError, nil, errors.New("Failed to hash password")
@@ -183,6 +234,12 @@ func (m *Module) EmailSignUp(ctx context.Context, dbAlias, project, email, name,
 		req["id"] = id.String()
 	}
 
+	// Users need to verify their email if a mailer has been configured
+	stub, _ := m.getEmailStub()
+	if stub != nil && stub.Mailer != nil {
+		req[userFieldVerified] = false
+	}
+
 	reqParams.Resource = "db-create"
 	createReq := &model.CreateRequest{Operation: utils.One, Document: req}
 	err = m.crud.Create(ctx, dbAlias, "users", createReq, reqParams)
@@ -191,6 +248,15 @@ func (m *Module) EmailSignUp(ctx context.Context, dbAlias, project, email, name,
 		return http.StatusInternalServerError, nil, errors.New("Failed to create user account")
 	}
 
+	if stub != nil && stub.Mailer != nil {
+		idField := "id"
+		if actualDbType == string(model.Mongo) || actualDbType == string(model.EmbeddedDB) {
+			idField = "_id"
+		}
+		// The user can always ask for the verification email again
+		_ = m.mailUserToken(ctx, dbAlias, project, stub, idField, req, verificationToken)
+	}
+
 	delete(req, "pass")
 
 	// Create a new token Object
@@ -198,12 +264,14 @@ func (m *Module) EmailSignUp(ctx context.Context, dbAlias, project, email, name,
 		"email": email,
 		"role":  role,
 		"id":    id.String()}
+	setAMRClaims(tokenObj, amrPassword)
 
-	token, err := m.auth.CreateToken(ctx, tokenObj)
+	result, err := m.issueTokens(ctx, dbAlias, project, "email", tokenObj, device)
 	if err != nil {
-		return http.StatusInternalServerError, nil, errors.New("Failed to create a JWT token")
+		return http.StatusInternalServerError, nil, err
 	}
-	return http.StatusOK, map[string]interface{}{"user": req, "token": token}, nil
+	result["user"] = req
+	return http.StatusOK, result, nil
 }
 
 // EmailEditProfile allows the user to edit a profile
@@ -238,7 +306,7 @@ func (m *Module) EmailEditProfile(ctx context.Context, token, dbAlias, project,
 	}
 	if password != "" {
 		var err1 error
-		password, err1 = hashPassword(password)
+		password, err1 = m.hashPassword(password)
 		if err1 != nil {
 			helpers.Logger.LogInfo(helpers.GetRequestID(ctx), fmt.Sprintf("Error %v", err1), nil)
 			return http.StatusInternalServerError, nil, errors.New("Failed to hash password")
@@ -254,6 +322,17 @@ func (m *Module) EmailEditProfile(ctx context.Context, token, dbAlias, project,
 		return http.StatusForbidden, nil, err
 	}
 
+	// Users need to verify their email again once it changes. Tokens mailed to the old email get invalidated.
+	emailChanged := false
+	if email != "" {
+		if current, _, err := m.readUser(ctx, dbAlias, project, map[string]interface{}{idString: id}); err != nil || current["email"] != email {
+			emailChanged = true
+			set[userFieldVerified] = false
+			set[userFieldVerifyToken] = ""
+			set[userFieldVerifyTokenExpiry] = ""
+		}
+	}
+
 	err = m.crud.Update(ctx, dbAlias, "users", req, reqParams)
 	if err != nil {
 		return http.StatusInternalServerError, nil, err
@@ -268,8 +347,13 @@ func (m *Module) EmailEditProfile(ctx context.Context, token, dbAlias, project,
 
 	userObj := user.(map[string]interface{})
 
+	// The user can always ask for the verification email again
+	if stub, _ := m.getEmailStub(); emailChanged && stub != nil && stub.Mailer != nil {
+		_ = m.mailUserToken(ctx, dbAlias, project, stub, idString, userObj, verificationToken)
+	}
+
 	// Delete password from user
-	delete(userObj, "pass")
+	sanitizeUser(userObj)
 
 	req1 := map[string]interface{}{}
 	req1["email"] = userObj["email"]
@@ -283,17 +367,37 @@ func (m *Module) EmailEditProfile(ctx context.Context, token, dbAlias, project,
 	return http.StatusOK, map[string]interface{}{"user": user, "token": token1}, nil
 }
 
-func hashPassword(pwd string) (string, error) {
+func (m *Module) hashPassword(pwd string) (string, error) {
 	// Generates a new hash from the given password
-	hash, err := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.MinCost)
+	hash, err := utils.HashValue(m.getHashAlgorithm("email"), pwd)
 	if err != nil {
 		return "", err
 	}
 
 	// Checks if the hash is correct for the given password
-	err = bcrypt.CompareHashAndPassword(hash, []byte(pwd))
+	matched, err := utils.VerifyHash(hash, pwd)
 	if err != nil {
 		return "", err
 	}
-	return string(hash), nil
+	if !matched {
+		return "", errors.New("generated hash does not match the password")
+	}
+	return hash, nil
+}
+
+// upgradePasswordHash replaces the stored hash of the user's password with one generated by the configured
+// algorithm. Failures are only logged since the user has already been authenticated.
+func (m *Module) upgradePasswordHash(ctx context.Context, dbAlias, project, idField string, id interface{}, password string) {
+	hash, err := m.hashPassword(password)
+	if err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to upgrade hash of password", err, nil)
+		return
+	}
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": "users"}
+	reqParams := model.RequestParams{Resource: "db-update", Op: "access", Attributes: attr}
+	req := &model.UpdateRequest{Find: map[string]interface{}{idField: id}, Operation: utils.One, Update: map[string]interface{}{"$set": map[string]interface{}{"pass": hash}}}
+	if err := m.crud.Update(ctx, dbAlias, "users", req, reqParams); err != nil {
+		_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to upgrade hash of password", err, nil)
+	}
 }
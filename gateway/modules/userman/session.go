@@ -0,0 +1,407 @@
+package userman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// sessionsTable stores the sessions of the users. A session is the family of refresh tokens issued from a single
+// sign in; every refresh rotates the token and only the hash of the latest one is stored.
+const sessionsTable = "sessions"
+
+// Fields of the sessions table
+const (
+	sessionFieldUserID       = "userId"
+	sessionFieldMethod       = "method"
+	sessionFieldRefreshToken = "refreshToken"
+	sessionFieldUserAgent    = "userAgent"
+	sessionFieldIP           = "ip"
+	sessionFieldCreatedAt    = "createdAt"
+	sessionFieldLastUsedAt   = "lastUsedAt"
+	sessionFieldExpiresAt    = "expiresAt"
+	sessionFieldRevoked      = "revoked"
+	sessionFieldAMR          = "amr" // space separated methods the user authenticated with
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	defaultMaxSessionAge   = 90 * 24 * time.Hour
+)
+
+// RefreshSession exchanges the refresh token for a new access token and a new refresh token. Presenting a refresh
+// token which has already been exchanged means it has leaked, in which case the whole session gets revoked. The
+// session cannot be refreshed beyond its max age, after which the user has to sign in again.
+func (m *Module) RefreshSession(ctx context.Context, dbAlias, project, refreshToken string, device *model.SessionDevice) (int, map[string]interface{}, error) {
+	sessionID, secret, ok := splitRefreshToken(refreshToken)
+	if !ok {
+		return http.StatusUnauthorized, nil, errors.New("Invalid refresh token provided")
+	}
+
+	session, idField, err := m.readSession(ctx, dbAlias, project, sessionID)
+	if err != nil {
+		return http.StatusUnauthorized, nil, errors.New("Invalid refresh token provided")
+	}
+
+	method, _ := session[sessionFieldMethod].(string)
+	sessions, accessTTL, refreshTTL, err := m.getSessionsConfig(method)
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid sessions config provided", err, map[string]interface{}{"method": method})
+	}
+	if sessions == nil {
+		return http.StatusUnauthorized, nil, errors.New("Sessions are not enabled for the sign in method")
+	}
+	maxAge, err := parseTTL(sessions.MaxAge, defaultMaxSessionAge)
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid sessions config provided", err, map[string]interface{}{"method": method})
+	}
+
+	if isRevoked(session) || isExpired(session) {
+		return http.StatusUnauthorized, nil, errors.New("Session has expired")
+	}
+	createdAtString, _ := session[sessionFieldCreatedAt].(string)
+	createdAt, err := time.Parse(time.RFC3339, createdAtString)
+	if err != nil || time.Now().After(createdAt.Add(maxAge)) {
+		return http.StatusUnauthorized, nil, errors.New("Session has expired")
+	}
+
+	hash := utils.HashString(secret)
+	if current, _ := session[sessionFieldRefreshToken].(string); current != hash {
+		return m.handleRefreshTokenReuse(ctx, dbAlias, project, idField, sessionID, accessTTL)
+	}
+
+	// The claims are built afresh so that changes to the user are picked up
+	userObj, userIDField, err := m.readUser(ctx, dbAlias, project, map[string]interface{}{idField: session[sessionFieldUserID]})
+	if err != nil {
+		_ = m.revokeSession(ctx, dbAlias, project, idField, sessionID, accessTTL)
+		return http.StatusUnauthorized, nil, errors.New("User of the session does not exist")
+	}
+	if isDisabled(userObj) {
+		_ = m.revokeSession(ctx, dbAlias, project, idField, sessionID, accessTTL)
+		return http.StatusForbidden, nil, errors.New("User has been disabled")
+	}
+
+	newSecret, err := generateRandomToken()
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate refresh token", err, nil)
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(refreshTTL)
+	if maxExpiresAt := createdAt.Add(maxAge); expiresAt.After(maxExpiresAt) {
+		expiresAt = maxExpiresAt
+	}
+	set := map[string]interface{}{
+		sessionFieldRefreshToken: utils.HashString(newSecret),
+		sessionFieldLastUsedAt:   now.Format(time.RFC3339),
+		sessionFieldExpiresAt:    expiresAt.UTC().Format(time.RFC3339),
+	}
+	if device != nil {
+		set[sessionFieldUserAgent] = device.UserAgent
+		set[sessionFieldIP] = device.IP
+	}
+
+	// The refresh token is only rotated if it hasn't been rotated by a concurrent request in the meantime
+	attr := map[string]string{"project": project, "db": dbAlias, "col": sessionsTable}
+	reqParams := model.RequestParams{Resource: "db-update", Op: "access", Attributes: attr}
+	req := &model.UpdateRequest{Find: map[string]interface{}{idField: sessionID, sessionFieldRefreshToken: hash}, Operation: utils.All, Update: map[string]interface{}{"$set": set}}
+	n, err := m.crud.UpdateAndCount(ctx, dbAlias, sessionsTable, req, reqParams)
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to update session", err, nil)
+	}
+	if n == 0 {
+		return m.handleRefreshTokenReuse(ctx, dbAlias, project, idField, sessionID, accessTTL)
+	}
+
+	claims := model.TokenClaims{"id": userObj[userIDField], "email": userObj["email"], "role": userObj["role"]}
+	if amr, _ := session[sessionFieldAMR].(string); amr != "" {
+		setAMRClaims(claims, strings.Fields(amr)...)
+	}
+	token, err := m.createAccessToken(ctx, claims, sessionID, accessTTL)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, map[string]interface{}{"token": token, "refreshToken": sessionID + "." + newSecret, "expiresIn": int64(accessTTL.Seconds()), "sessionId": sessionID}, nil
+}
+
+// handleRefreshTokenReuse revokes the session whose refresh token has been presented after it was exchanged
+func (m *Module) handleRefreshTokenReuse(ctx context.Context, dbAlias, project, idField, sessionID string, accessTTL time.Duration) (int, map[string]interface{}, error) {
+	helpers.Logger.LogWarn(helpers.GetRequestID(ctx), "Refresh token reuse detected, revoking the session", map[string]interface{}{"sessionId": sessionID})
+	if err := m.revokeSession(ctx, dbAlias, project, idField, sessionID, accessTTL); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusUnauthorized, nil, errors.New("Refresh token has already been used")
+}
+
+// Logout revokes the session the token was issued for. Tokens which weren't issued for a session are revoked by
+// their id.
+func (m *Module) Logout(ctx context.Context, token, dbAlias, project string) (int, error) {
+	claims, err := m.auth.ParseToken(ctx, token)
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+
+	sessionID, _ := claims["sid"].(string)
+	if sessionID == "" {
+		jti, _ := claims["jti"].(string)
+		if jti == "" {
+			return http.StatusBadRequest, errors.New("Token cannot be revoked as it does not have the (jti) claim")
+		}
+		exp, _ := claims["exp"].(float64)
+		if err := m.auth.RevokeTokenID(ctx, jti, time.Unix(int64(exp), 0)); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusOK, nil
+	}
+
+	return m.RevokeSession(ctx, token, dbAlias, project, sessionID)
+}
+
+// ListSessions returns the active sessions of the user the token belongs to
+func (m *Module) ListSessions(ctx context.Context, token, dbAlias, project string) (int, []interface{}, error) {
+	claims, err := m.auth.ParseToken(ctx, token)
+	if err != nil {
+		return http.StatusUnauthorized, nil, err
+	}
+
+	idField, err := m.getIDField(dbAlias)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": sessionsTable}
+	reqParams := model.RequestParams{Resource: "db-read", Op: "access", Attributes: attr}
+	readReq := &model.ReadRequest{Find: map[string]interface{}{sessionFieldUserID: claims["id"], sessionFieldRevoked: false}, Operation: utils.All}
+	result, _, err := m.crud.Read(ctx, dbAlias, sessionsTable, readReq, reqParams)
+	if err != nil {
+		return http.StatusInternalServerError, nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to read sessions", err, nil)
+	}
+
+	docs, _ := result.([]interface{})
+	sessions := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		session, ok := doc.(map[string]interface{})
+		if !ok || isRevoked(session) || isExpired(session) {
+			continue
+		}
+		sessions = append(sessions, map[string]interface{}{
+			"id":                   session[idField],
+			sessionFieldMethod:     session[sessionFieldMethod],
+			sessionFieldUserAgent:  session[sessionFieldUserAgent],
+			sessionFieldIP:         session[sessionFieldIP],
+			sessionFieldCreatedAt:  session[sessionFieldCreatedAt],
+			sessionFieldLastUsedAt: session[sessionFieldLastUsedAt],
+			sessionFieldExpiresAt:  session[sessionFieldExpiresAt],
+			"current":              session[idField] == claims["sid"],
+		})
+	}
+	return http.StatusOK, sessions, nil
+}
+
+// RevokeSession revokes a session of the user the token belongs to along with the access tokens issued for it
+func (m *Module) RevokeSession(ctx context.Context, token, dbAlias, project, sessionID string) (int, error) {
+	claims, err := m.auth.ParseToken(ctx, token)
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+
+	session, idField, err := m.readSession(ctx, dbAlias, project, sessionID)
+	if err != nil || session[sessionFieldUserID] != claims["id"] {
+		return http.StatusNotFound, errors.New("Session not found")
+	}
+
+	method, _ := session[sessionFieldMethod].(string)
+	_, accessTTL, _, err := m.getSessionsConfig(method)
+	if err != nil {
+		accessTTL = defaultAccessTokenTTL
+	}
+	if err := m.revokeSession(ctx, dbAlias, project, idField, sessionID, accessTTL); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// issueTokens creates the tokens handed out on signing in. A session is created along with a refresh token if
+// sessions are enabled for the method.
+func (m *Module) issueTokens(ctx context.Context, dbAlias, project, method string, claims model.TokenClaims, device *model.SessionDevice) (map[string]interface{}, error) {
+	sessions, accessTTL, refreshTTL, err := m.getSessionsConfig(method)
+	if err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid sessions config provided", err, map[string]interface{}{"method": method})
+	}
+	if sessions == nil {
+		token, err := m.auth.CreateToken(ctx, claims)
+		if err != nil {
+			return nil, errors.New("Failed to create a JWT token")
+		}
+		return map[string]interface{}{"token": token}, nil
+	}
+	maxAge, err := parseTTL(sessions.MaxAge, defaultMaxSessionAge)
+	if err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid sessions config provided", err, map[string]interface{}{"method": method})
+	}
+	if refreshTTL > maxAge {
+		refreshTTL = maxAge
+	}
+
+	idField, err := m.getIDField(dbAlias)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateRandomToken()
+	if err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate refresh token", err, nil)
+	}
+
+	now := time.Now().UTC()
+	sessionID := uuid.NewV4().String()
+	session := map[string]interface{}{
+		idField:                  sessionID,
+		sessionFieldUserID:       claims["id"],
+		sessionFieldMethod:       method,
+		sessionFieldRefreshToken: utils.HashString(secret),
+		sessionFieldCreatedAt:    now.Format(time.RFC3339),
+		sessionFieldLastUsedAt:   now.Format(time.RFC3339),
+		sessionFieldExpiresAt:    now.Add(refreshTTL).Format(time.RFC3339),
+		sessionFieldRevoked:      false,
+	}
+	if device != nil {
+		session[sessionFieldUserAgent] = device.UserAgent
+		session[sessionFieldIP] = device.IP
+	}
+	if amr, ok := claims["amr"].([]string); ok {
+		session[sessionFieldAMR] = strings.Join(amr, " ")
+	}
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": sessionsTable}
+	reqParams := model.RequestParams{Resource: "db-create", Op: "access", Attributes: attr}
+	if err := m.crud.Create(ctx, dbAlias, sessionsTable, &model.CreateRequest{Operation: utils.One, Document: session}, reqParams); err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to create session", err, nil)
+	}
+
+	token, err := m.createAccessToken(ctx, claims, sessionID, accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"token": token, "refreshToken": sessionID + "." + secret, "expiresIn": int64(accessTTL.Seconds()), "sessionId": sessionID}, nil
+}
+
+func (m *Module) createAccessToken(ctx context.Context, claims model.TokenClaims, sessionID string, ttl time.Duration) (string, error) {
+	tokenClaims := make(model.TokenClaims, len(claims)+2)
+	for k, v := range claims {
+		tokenClaims[k] = v
+	}
+	tokenClaims["sid"] = sessionID
+
+	token, err := m.auth.CreateTokenWithTTL(ctx, tokenClaims, ttl)
+	if err != nil {
+		return "", errors.New("Failed to create a JWT token")
+	}
+	return token, nil
+}
+
+// revokeSession marks the session as revoked and revokes the access tokens issued for it. The revocation of the
+// access tokens is retained till the last one of them expires.
+func (m *Module) revokeSession(ctx context.Context, dbAlias, project, idField, sessionID string, accessTTL time.Duration) error {
+	if err := m.updateSession(ctx, dbAlias, project, idField, sessionID, map[string]interface{}{sessionFieldRevoked: true}); err != nil {
+		return err
+	}
+	return m.auth.RevokeTokenID(ctx, sessionID, time.Now().Add(accessTTL))
+}
+
+func (m *Module) readSession(ctx context.Context, dbAlias, project, sessionID string) (map[string]interface{}, string, error) {
+	idField, err := m.getIDField(dbAlias)
+	if err != nil {
+		return nil, "", err
+	}
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": sessionsTable}
+	reqParams := model.RequestParams{Resource: "db-read", Op: "access", Attributes: attr}
+	readReq := &model.ReadRequest{Find: map[string]interface{}{idField: sessionID}, Operation: utils.One}
+	result, _, err := m.crud.Read(ctx, dbAlias, sessionsTable, readReq, reqParams)
+	if err != nil {
+		return nil, "", err
+	}
+	session, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, "", errors.New("Session not found")
+	}
+	return session, idField, nil
+}
+
+func (m *Module) updateSession(ctx context.Context, dbAlias, project, idField, sessionID string, set map[string]interface{}) error {
+	attr := map[string]string{"project": project, "db": dbAlias, "col": sessionsTable}
+	reqParams := model.RequestParams{Resource: "db-update", Op: "access", Attributes: attr}
+	req := &model.UpdateRequest{Find: map[string]interface{}{idField: sessionID}, Operation: utils.One, Update: map[string]interface{}{"$set": set}}
+	if err := m.crud.Update(ctx, dbAlias, sessionsTable, req, reqParams); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to update session", err, nil)
+	}
+	return nil
+}
+
+// getSessionsConfig returns the sessions config of the method along with the token lifetimes. The config is nil
+// if sessions aren't enabled for the method.
+func (m *Module) getSessionsConfig(method string) (*config.Sessions, time.Duration, time.Duration, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	s, p := m.methods[method]
+	if !p || s.Sessions == nil || !s.Sessions.Enabled {
+		return nil, defaultAccessTokenTTL, defaultRefreshTokenTTL, nil
+	}
+
+	accessTTL, err := parseTTL(s.Sessions.AccessTokenTTL, defaultAccessTokenTTL)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	refreshTTL, err := parseTTL(s.Sessions.RefreshTokenTTL, defaultRefreshTokenTTL)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return s.Sessions, accessTTL, refreshTTL, nil
+}
+
+func parseTTL(value string, defaultTTL time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultTTL, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("ttl (%s) must be positive", value)
+	}
+	return d, nil
+}
+
+// splitRefreshToken splits the refresh token into the id of the session and the secret
+func splitRefreshToken(token string) (string, string, bool) {
+	arr := strings.SplitN(token, ".", 2)
+	if len(arr) != 2 || arr[0] == "" || arr[1] == "" {
+		return "", "", false
+	}
+	return arr[0], arr[1], true
+}
+
+// isRevoked checks if the session has been revoked. Some databases return booleans as numbers.
+func isRevoked(session map[string]interface{}) bool {
+	return isTrue(session[sessionFieldRevoked])
+}
+
+func isExpired(session map[string]interface{}) bool {
+	expiresAt, _ := session[sessionFieldExpiresAt].(string)
+	t, err := time.Parse(time.RFC3339, expiresAt)
+	return err != nil || time.Now().After(t)
+}
@@ -0,0 +1,179 @@
+package userman
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+func newSessionModule(sessions *config.Sessions) (*Module, *mockCrud, *mockAuth) {
+	crud := &mockCrud{}
+	auth := &mockAuth{}
+	m := Init(crud, auth)
+	m.SetConfig(config.Auths{"email": {ID: "email", Enabled: true, Sessions: sessions}})
+	return m, crud, auth
+}
+
+func TestModule_RefreshSession(t *testing.T) {
+	ctx := context.Background()
+	m, crud, _ := newSessionModule(&config.Sessions{Enabled: true, AccessTokenTTL: "5m"})
+
+	status, result, err := m.EmailSignUp(ctx, "db", "project", "jon@example.com", "Jon", "1234", "user", &model.SessionDevice{UserAgent: "laptop", IP: "1.2.3.4"})
+	if status != http.StatusOK {
+		t.Fatalf("EmailSignUp() status = %v, error = %v", status, err)
+	}
+	if result["expiresIn"] != int64(300) {
+		t.Errorf("EmailSignUp() expiresIn = %v, want 300", result["expiresIn"])
+	}
+	first := result["refreshToken"].(string)
+	if len(crud.sessions) != 1 || crud.sessions[0][sessionFieldRefreshToken] == first || crud.sessions[0][sessionFieldUserAgent] != "laptop" {
+		t.Fatalf("EmailSignUp() created sessions = %v", crud.sessions)
+	}
+
+	status, result, err = m.RefreshSession(ctx, "db", "project", first, &model.SessionDevice{UserAgent: "laptop", IP: "5.6.7.8"})
+	if status != http.StatusOK {
+		t.Fatalf("RefreshSession() status = %v, error = %v", status, err)
+	}
+	second := result["refreshToken"].(string)
+	if second == first || result["sessionId"] != crud.sessions[0]["id"] || crud.sessions[0][sessionFieldIP] != "5.6.7.8" {
+		t.Fatalf("RefreshSession() did not rotate the refresh token of the session, result = %v", result)
+	}
+
+	// Reusing the first refresh token revokes the whole session including the latest refresh token
+	if status, _, _ := m.RefreshSession(ctx, "db", "project", first, nil); status != http.StatusUnauthorized {
+		t.Errorf("RefreshSession() status = %v on reusing a refresh token, want %v", status, http.StatusUnauthorized)
+	}
+	if !isRevoked(crud.sessions[0]) {
+		t.Errorf("RefreshSession() did not revoke the session on reusing a refresh token")
+	}
+	if status, _, _ := m.RefreshSession(ctx, "db", "project", second, nil); status != http.StatusUnauthorized {
+		t.Errorf("RefreshSession() status = %v with the latest refresh token of a revoked session, want %v", status, http.StatusUnauthorized)
+	}
+
+	for _, token := range []string{"", "invalid", "unknown.secret"} {
+		if status, _, _ := m.RefreshSession(ctx, "db", "project", token, nil); status != http.StatusUnauthorized {
+			t.Errorf("RefreshSession() status = %v for refresh token (%s), want %v", status, token, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestModule_ListSessions(t *testing.T) {
+	ctx := context.Background()
+	m, crud, auth := newSessionModule(&config.Sessions{Enabled: true})
+	if status, _, err := m.EmailSignUp(ctx, "db", "project", "jon@example.com", "Jon", "1234", "user", &model.SessionDevice{UserAgent: "laptop"}); status != http.StatusOK {
+		t.Fatalf("EmailSignUp() status = %v, error = %v", status, err)
+	}
+	status, result, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", &model.SessionDevice{UserAgent: "phone"})
+	if status != http.StatusOK {
+		t.Fatalf("EmailSignIn() status = %v, error = %v", status, err)
+	}
+	token := result["token"].(string)
+	phoneRefreshToken := result["refreshToken"].(string)
+
+	// Sessions of other users must neither be listed nor revoked
+	crud.sessions = append(crud.sessions, map[string]interface{}{"id": "other", sessionFieldUserID: "other-user", sessionFieldRevoked: false, sessionFieldExpiresAt: crud.sessions[0][sessionFieldExpiresAt]})
+	if status, _ := m.RevokeSession(ctx, token, "db", "project", "other"); status != http.StatusNotFound {
+		t.Errorf("RevokeSession() status = %v for the session of another user, want %v", status, http.StatusNotFound)
+	}
+
+	status, sessions, err := m.ListSessions(ctx, token, "db", "project")
+	if status != http.StatusOK || len(sessions) != 2 {
+		t.Fatalf("ListSessions() status = %v, error = %v, sessions = %v", status, err, sessions)
+	}
+	for _, s := range sessions {
+		session := s.(map[string]interface{})
+		if isCurrent := session[sessionFieldUserAgent] == "phone"; session["current"] != isCurrent {
+			t.Errorf("ListSessions() current = %v for session = %v", session["current"], session)
+		}
+		if _, p := session[sessionFieldRefreshToken]; p {
+			t.Errorf("ListSessions() returned the refresh token hash of the session")
+		}
+	}
+
+	laptopSessionID := crud.sessions[0]["id"].(string)
+	if status, err := m.RevokeSession(ctx, token, "db", "project", laptopSessionID); status != http.StatusOK {
+		t.Fatalf("RevokeSession() status = %v, error = %v", status, err)
+	}
+	if _, sessions, _ := m.ListSessions(ctx, token, "db", "project"); len(sessions) != 1 {
+		t.Errorf("ListSessions() returned (%d) sessions after revoking one, want 1", len(sessions))
+	}
+
+	// Logging out revokes the current session and the access tokens issued for it
+	if status, err := m.Logout(ctx, token, "db", "project"); status != http.StatusOK {
+		t.Fatalf("Logout() status = %v, error = %v", status, err)
+	}
+	if _, err := auth.ParseToken(ctx, token); err == nil {
+		t.Errorf("Logout() did not revoke the access token of the session")
+	}
+	if status, _, _ := m.RefreshSession(ctx, "db", "project", phoneRefreshToken, nil); status != http.StatusUnauthorized {
+		t.Errorf("RefreshSession() status = %v after logging out, want %v", status, http.StatusUnauthorized)
+	}
+}
+
+func TestModule_Sessions_disabled(t *testing.T) {
+	ctx := context.Background()
+	m, crud, _ := newSessionModule(nil)
+
+	status, result, err := m.EmailSignUp(ctx, "db", "project", "jon@example.com", "Jon", "1234", "user", nil)
+	if status != http.StatusOK {
+		t.Fatalf("EmailSignUp() status = %v, error = %v", status, err)
+	}
+	if _, p := result["refreshToken"]; p || len(crud.sessions) != 0 {
+		t.Errorf("EmailSignUp() created a session even though sessions are disabled")
+	}
+	if _, err := parseTTL("-1h", defaultAccessTokenTTL); err == nil {
+		t.Errorf("parseTTL() accepted a negative ttl")
+	}
+}
+
+func TestModule_RefreshSession_concurrent(t *testing.T) {
+	ctx := context.Background()
+	m, crud, _ := newSessionModule(&config.Sessions{Enabled: true})
+
+	status, result, err := m.EmailSignUp(ctx, "db", "project", "jon@example.com", "Jon", "1234", "user", nil)
+	if status != http.StatusOK {
+		t.Fatalf("EmailSignUp() status = %v, error = %v", status, err)
+	}
+
+	// Another request exchanges the same refresh token right after this one has read the session
+	crud.afterRead = func() {
+		crud.sessions[0][sessionFieldRefreshToken] = "rotated"
+		crud.afterRead = nil
+	}
+	if status, _, _ := m.RefreshSession(ctx, "db", "project", result["refreshToken"].(string), nil); status != http.StatusUnauthorized {
+		t.Errorf("RefreshSession() status = %v for a refresh token exchanged concurrently, want %v", status, http.StatusUnauthorized)
+	}
+	if !isRevoked(crud.sessions[0]) {
+		t.Errorf("RefreshSession() did not revoke the session on a refresh token exchanged concurrently")
+	}
+}
+
+func TestModule_RefreshSession_maxAge(t *testing.T) {
+	ctx := context.Background()
+	m, crud, _ := newSessionModule(&config.Sessions{Enabled: true, RefreshTokenTTL: "24h", MaxAge: "36h"})
+
+	status, result, err := m.EmailSignUp(ctx, "db", "project", "jon@example.com", "Jon", "1234", "user", nil)
+	if status != http.StatusOK {
+		t.Fatalf("EmailSignUp() status = %v, error = %v", status, err)
+	}
+
+	// Refreshing a session created a day ago must not extend it beyond its max age
+	createdAt := time.Now().Add(-24 * time.Hour).UTC()
+	crud.sessions[0][sessionFieldCreatedAt] = createdAt.Format(time.RFC3339)
+	status, result, err = m.RefreshSession(ctx, "db", "project", result["refreshToken"].(string), nil)
+	if status != http.StatusOK {
+		t.Fatalf("RefreshSession() status = %v, error = %v", status, err)
+	}
+	if want := createdAt.Add(36 * time.Hour).Format(time.RFC3339); crud.sessions[0][sessionFieldExpiresAt] != want {
+		t.Errorf("RefreshSession() expiresAt = %v, want %v", crud.sessions[0][sessionFieldExpiresAt], want)
+	}
+
+	crud.sessions[0][sessionFieldCreatedAt] = time.Now().Add(-37 * time.Hour).UTC().Format(time.RFC3339)
+	if status, _, _ := m.RefreshSession(ctx, "db", "project", result["refreshToken"].(string), nil); status != http.StatusUnauthorized {
+		t.Errorf("RefreshSession() status = %v for a session older than its max age, want %v", status, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,59 @@
+package userman
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_totpCode(t *testing.T) {
+	// Test vectors of RFC 6238 truncated to six digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(secret, tt.unix/totpPeriod)
+		if err != nil {
+			t.Fatalf("totpCode() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCode() at (%d) = %v, want %v", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func Test_verifyTOTP(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / totpPeriod
+	code := func(s int64) string {
+		c, _ := totpCode(secret, s)
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		want     bool
+	}{
+		{name: "current code", code: code(step), want: true},
+		{name: "previous code within skew", code: code(step - 1), want: true},
+		{name: "code outside skew", code: code(step - 2), want: false},
+		{name: "code already used", code: code(step), lastStep: step, want: false},
+		{name: "invalid length", code: "12345", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := verifyTOTP(secret, tt.code, now, tt.lastStep); got != tt.want {
+				t.Errorf("verifyTOTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
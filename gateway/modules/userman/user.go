@@ -1,11 +1,12 @@
 package userman
 
 import (
-	"encoding/base64"
 	"sync"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+	"github.com/spaceuptech/space-cloud/gateway/utils/oidc"
 )
 
 // Module is responsible for user management
@@ -16,12 +17,28 @@ type Module struct {
 	auth    model.AuthUserInterface
 
 	// auth module
-	aesKey []byte
+	aesKeys *utils.AESKeyRing
+
+	// Used to send emails via an eventing trigger
+	eventing model.EventingUserInterface
+
+	// Failed sign in attempts of the email method
+	attempts attemptStore
+
+	// Discovery documents and keys of the openid connect issuers
+	oidcProviders oidc.Providers
 }
 
 // Init creates a new instance of the user management object
 func Init(crud model.CrudUserInterface, auth model.AuthUserInterface) *Module {
-	return &Module{crud: crud, auth: auth}
+	return &Module{crud: crud, auth: auth, attempts: newMemoryAttemptStore()}
+}
+
+// SetEventingModule sets the eventing module
+func (m *Module) SetEventingModule(eventing model.EventingUserInterface) {
+	m.Lock()
+	defer m.Unlock()
+	m.eventing = eventing
 }
 
 // SetConfig sets the config required by the user management module
@@ -53,15 +70,21 @@ func (m *Module) IsEnabled() bool {
 	return len(m.methods) > 0
 }
 
-// SetProjectAESKey set aes key
-func (m *Module) SetProjectAESKey(aesKey string) error {
+// SetProjectAESKey set aes key ring
+func (m *Module) SetProjectAESKey(aesKeys *utils.AESKeyRing) {
 	m.Lock()
 	defer m.Unlock()
 
-	decodedAESKey, err := base64.StdEncoding.DecodeString(aesKey)
-	if err != nil {
-		return err
+	m.aesKeys = aesKeys
+}
+
+// getHashAlgorithm returns the algorithm used to hash the passwords of a given method
+func (m *Module) getHashAlgorithm(method string) string {
+	m.RLock()
+	defer m.RUnlock()
+
+	if s, p := m.methods[method]; p && s.HashAlgorithm != "" {
+		return s.HashAlgorithm
 	}
-	m.aesKey = decodedAESKey
-	return nil
+	return utils.HashAlgorithmBcrypt
 }
@@ -0,0 +1,289 @@
+package userman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// Fields of the users table used by email verification and password reset. Only the hash of the tokens is stored.
+const (
+	userFieldVerified          = "verified"
+	userFieldVerifyToken       = "verifyToken"
+	userFieldVerifyTokenExpiry = "verifyTokenExpiry"
+	userFieldResetToken        = "resetToken"
+	userFieldResetTokenExpiry  = "resetTokenExpiry"
+)
+
+// userTokenKind describes a single use token mailed to the user
+type userTokenKind struct {
+	tokenField  string
+	expiryField string
+	template    string
+	defaultTTL  time.Duration
+	ttl         func(stub *config.AuthStub) string
+}
+
+var (
+	verificationToken = &userTokenKind{
+		tokenField:  userFieldVerifyToken,
+		expiryField: userFieldVerifyTokenExpiry,
+		template:    mailTemplateVerifyEmail,
+		defaultTTL:  24 * time.Hour,
+		ttl:         func(stub *config.AuthStub) string { return stub.VerificationTokenTTL },
+	}
+	passwordResetToken = &userTokenKind{
+		tokenField:  userFieldResetToken,
+		expiryField: userFieldResetTokenExpiry,
+		template:    mailTemplateResetPassword,
+		defaultTTL:  1 * time.Hour,
+		ttl:         func(stub *config.AuthStub) string { return stub.PasswordResetTokenTTL },
+	}
+)
+
+// SendVerificationEmail mails a token to the user which can be used to verify the email. The request succeeds
+// even if a user with the email doesn't exist so that the registered emails cannot be discovered.
+func (m *Module) SendVerificationEmail(ctx context.Context, dbAlias, project, email string) (int, error) {
+	return m.sendUserToken(ctx, dbAlias, project, email, verificationToken)
+}
+
+// VerifyEmail marks the email of the user the verification token was issued to as verified
+func (m *Module) VerifyEmail(ctx context.Context, dbAlias, project, token string) (int, error) {
+	status, userObj, idField, err := m.readUserToken(ctx, dbAlias, project, token, verificationToken)
+	if err != nil {
+		return status, err
+	}
+	return m.consumeUserToken(ctx, dbAlias, project, idField, userObj[idField], token, verificationToken, map[string]interface{}{userFieldVerified: true})
+}
+
+// SendPasswordResetEmail mails a token to the user which can be used to reset the password. The request succeeds
+// even if a user with the email doesn't exist so that the registered emails cannot be discovered.
+func (m *Module) SendPasswordResetEmail(ctx context.Context, dbAlias, project, email string) (int, error) {
+	return m.sendUserToken(ctx, dbAlias, project, email, passwordResetToken)
+}
+
+// ResetPassword sets the password of the user the reset token was issued to. The email is considered to be
+// verified as well since the token could only have been received on it.
+func (m *Module) ResetPassword(ctx context.Context, dbAlias, project, token, password string) (int, error) {
+	if password == "" {
+		return http.StatusBadRequest, errors.New("New password not provided")
+	}
+
+	status, userObj, idField, err := m.readUserToken(ctx, dbAlias, project, token, passwordResetToken)
+	if err != nil {
+		return status, err
+	}
+
+	hash, err := m.hashPassword(password)
+	if err != nil {
+		return http.StatusInternalServerError, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Failed to hash password", err, nil)
+	}
+
+	set := map[string]interface{}{"pass": hash, userFieldVerified: true}
+	if isPasswordResetRequired(userObj) {
+		set[userFieldPasswordResetRequired] = false
+	}
+	if status, err := m.consumeUserToken(ctx, dbAlias, project, idField, userObj[idField], token, passwordResetToken, set); err != nil {
+		return status, err
+	}
+
+	// Whoever knew the old password must not be able to keep using the sessions they created with it
+	if err := m.revokeUser(ctx, dbAlias, project, idField, fmt.Sprintf("%v", userObj[idField])); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (m *Module) sendUserToken(ctx context.Context, dbAlias, project, email string, kind *userTokenKind) (int, error) {
+	stub, err := m.getEmailStub()
+	if err != nil {
+		return http.StatusNotFound, err
+	}
+	if email == "" {
+		return http.StatusBadRequest, errors.New("Email not provided")
+	}
+
+	userObj, idField, err := m.readUser(ctx, dbAlias, project, map[string]interface{}{"email": email})
+	if err != nil {
+		helpers.Logger.LogDebug(helpers.GetRequestID(ctx), "Not sending email as user does not exist", map[string]interface{}{"template": kind.template})
+		return http.StatusOK, nil
+	}
+
+	if err := m.mailUserToken(ctx, dbAlias, project, stub, idField, userObj, kind); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// mailUserToken generates a new token for the user, stores its hash and mails it to the user
+func (m *Module) mailUserToken(ctx context.Context, dbAlias, project string, stub *config.AuthStub, idField string, userObj map[string]interface{}, kind *userTokenKind) error {
+	ttl := kind.defaultTTL
+	if v := kind.ttl(stub); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid token ttl configured for the email method", err, map[string]interface{}{"ttl": v})
+		}
+		ttl = d
+	}
+
+	mailer, err := m.getMailer(project, stub.Mailer)
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to send email", err, nil)
+	}
+
+	token, err := generateRandomToken()
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to generate token", err, nil)
+	}
+	expiry := time.Now().Add(ttl).UTC().Format(time.RFC3339)
+
+	email, _ := userObj["email"].(string)
+	name, _ := userObj["name"].(string)
+	mail, err := renderMail(stub.Mailer, kind.template, email, map[string]interface{}{"token": token, "expiry": expiry, "email": email, "name": name})
+	if err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to render email", err, nil)
+	}
+
+	// The token is stored before sending the mail so that it's usable as soon as the mail is received
+	set := map[string]interface{}{kind.tokenField: utils.HashString(token), kind.expiryField: expiry}
+	if err := m.updateUser(ctx, dbAlias, project, idField, userObj[idField], set); err != nil {
+		return err
+	}
+
+	if err := mailer.send(ctx, mail); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to send email", err, map[string]interface{}{"template": kind.template})
+	}
+	return nil
+}
+
+// readUserToken returns the user the token was issued to if the token hasn't expired
+func (m *Module) readUserToken(ctx context.Context, dbAlias, project, token string, kind *userTokenKind) (int, map[string]interface{}, string, error) {
+	if _, err := m.getEmailStub(); err != nil {
+		return http.StatusNotFound, nil, "", err
+	}
+	if token == "" {
+		return http.StatusBadRequest, nil, "", errors.New("Token not provided")
+	}
+
+	userObj, idField, err := m.readUser(ctx, dbAlias, project, map[string]interface{}{kind.tokenField: utils.HashString(token)})
+	if err != nil {
+		return http.StatusUnauthorized, nil, "", errors.New("Invalid token provided")
+	}
+
+	expiryString, _ := userObj[kind.expiryField].(string)
+	expiry, err := time.Parse(time.RFC3339, expiryString)
+	if err != nil || time.Now().After(expiry) {
+		return http.StatusUnauthorized, nil, "", errors.New("Token has expired")
+	}
+	return http.StatusOK, userObj, idField, nil
+}
+
+// consumeUserToken clears the token read by readUserToken along with applying the updates provided. The update only
+// goes through if the token is still set on the user so that concurrent requests cannot use the same token twice.
+func (m *Module) consumeUserToken(ctx context.Context, dbAlias, project, idField string, id interface{}, token string, kind *userTokenKind, set map[string]interface{}) (int, error) {
+	set[kind.tokenField] = ""
+	set[kind.expiryField] = ""
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": "users"}
+	reqParams := model.RequestParams{Resource: "db-update", Op: "access", Attributes: attr}
+	find := map[string]interface{}{idField: id, kind.tokenField: utils.HashString(token)}
+	n, err := m.crud.UpdateAndCount(ctx, dbAlias, "users", &model.UpdateRequest{Find: find, Operation: utils.All, Update: map[string]interface{}{"$set": set}}, reqParams)
+	if err != nil {
+		return http.StatusInternalServerError, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to update user", err, nil)
+	}
+	if n == 0 {
+		return http.StatusUnauthorized, errors.New("Invalid token provided")
+	}
+	return http.StatusOK, nil
+}
+
+func (m *Module) readUser(ctx context.Context, dbAlias, project string, find map[string]interface{}) (map[string]interface{}, string, error) {
+	idField, err := m.getIDField(dbAlias)
+	if err != nil {
+		return nil, "", err
+	}
+
+	attr := map[string]string{"project": project, "db": dbAlias, "col": "users"}
+	reqParams := model.RequestParams{Resource: "db-read", Op: "access", Attributes: attr}
+	user, _, err := m.crud.Read(ctx, dbAlias, "users", &model.ReadRequest{Find: find, Operation: utils.One}, reqParams)
+	if err != nil {
+		return nil, "", err
+	}
+	userObj, ok := user.(map[string]interface{})
+	if !ok {
+		return nil, "", errors.New("User not found")
+	}
+	return userObj, idField, nil
+}
+
+func (m *Module) updateUser(ctx context.Context, dbAlias, project, idField string, id interface{}, set map[string]interface{}) error {
+	attr := map[string]string{"project": project, "db": dbAlias, "col": "users"}
+	reqParams := model.RequestParams{Resource: "db-update", Op: "access", Attributes: attr}
+	req := &model.UpdateRequest{Find: map[string]interface{}{idField: id}, Operation: utils.One, Update: map[string]interface{}{"$set": set}}
+	if err := m.crud.Update(ctx, dbAlias, "users", req, reqParams); err != nil {
+		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "Unable to update user", err, nil)
+	}
+	return nil
+}
+
+func (m *Module) getIDField(dbAlias string) (string, error) {
+	actualDbType, err := m.crud.GetDBType(dbAlias)
+	if err != nil {
+		return "", err
+	}
+	if actualDbType == string(model.Mongo) || actualDbType == string(model.EmbeddedDB) {
+		return "_id", nil
+	}
+	return "id", nil
+}
+
+func (m *Module) getEmailStub() (*config.AuthStub, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	s, p := m.methods["email"]
+	if !p || !s.Enabled {
+		return nil, errors.New("Email sign in feature is not enabled")
+	}
+	return s, nil
+}
+
+// sanitizeUser removes the password, the token hashes and the mfa secrets from the user object
+func sanitizeUser(userObj map[string]interface{}) {
+	for _, field := range []string{
+		"pass", userFieldVerifyToken, userFieldVerifyTokenExpiry, userFieldResetToken, userFieldResetTokenExpiry,
+		userFieldMFASecret, userFieldMFAPendingSecret, userFieldMFALastStep, userFieldMFARecoveryCodes,
+		userFieldMFAChallenge, userFieldMFAChallengeExpiry, userFieldMFAChallengeAttempts, userFieldMFAChallengeMethod,
+	} {
+		delete(userObj, field)
+	}
+}
+
+// isVerified checks if the email of the user has been verified
+func isVerified(userObj map[string]interface{}) bool {
+	return isTrue(userObj[userFieldVerified])
+}
+
+// isTrue checks if a boolean field read from the database is set. Some databases return booleans as numbers.
+func isTrue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case int:
+		return v != 0
+	case float64:
+		return v != 0
+	default:
+		return false
+	}
+}
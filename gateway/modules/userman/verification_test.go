@@ -0,0 +1,294 @@
+package userman
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+// mockEventing records the mails queued by the eventing mailer
+type mockEventing struct {
+	mails []map[string]interface{}
+}
+
+func (e *mockEventing) QueueEvent(ctx context.Context, project, token string, req *model.QueueEventRequest) (interface{}, error) {
+	e.mails = append(e.mails, req.Payload.(map[string]interface{}))
+	return nil, nil
+}
+
+// lastToken returns the token mailed to the user in the last mail
+func (e *mockEventing) lastToken(t *testing.T) string {
+	if len(e.mails) == 0 {
+		t.Fatalf("No mail has been sent")
+	}
+	return e.mails[len(e.mails)-1]["data"].(map[string]interface{})["token"].(string)
+}
+
+func newVerificationModule() (*Module, *mockCrud, *mockEventing) {
+	crud := &mockCrud{}
+	eventing := &mockEventing{}
+	m := Init(crud, &mockAuth{})
+	m.SetEventingModule(eventing)
+	m.SetConfig(config.Auths{"email": {
+		ID:                   "email",
+		Enabled:              true,
+		RequireVerifiedEmail: true,
+		Mailer:               &config.Mailer{Type: "eventing", EventType: "send-mail"},
+	}})
+	return m, crud, eventing
+}
+
+func TestModule_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	m, crud, eventing := newVerificationModule()
+
+	if status, _, err := m.EmailSignUp(ctx, "db", "project", "jon@example.com", "Jon", "1234", "user", nil); status != http.StatusOK {
+		t.Fatalf("EmailSignUp() status = %v, error = %v", status, err)
+	}
+	if len(eventing.mails) != 1 || eventing.mails[0]["to"] != "jon@example.com" || eventing.mails[0]["template"] != mailTemplateVerifyEmail {
+		t.Fatalf("EmailSignUp() sent mails = %v, want a single verification mail", eventing.mails)
+	}
+	token := eventing.lastToken(t)
+	if crud.users[0][userFieldVerifyToken] == token {
+		t.Errorf("EmailSignUp() stored the verification token in plain text")
+	}
+
+	if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil); status != http.StatusForbidden {
+		t.Errorf("EmailSignIn() status = %v for an unverified user, want %v", status, http.StatusForbidden)
+	}
+
+	if status, err := m.VerifyEmail(ctx, "db", "project", "invalid"); status != http.StatusUnauthorized {
+		t.Errorf("VerifyEmail() status = %v, error = %v for an invalid token", status, err)
+	}
+	if status, err := m.VerifyEmail(ctx, "db", "project", token); status != http.StatusOK {
+		t.Fatalf("VerifyEmail() status = %v, error = %v", status, err)
+	}
+	if status, _ := m.VerifyEmail(ctx, "db", "project", token); status != http.StatusUnauthorized {
+		t.Errorf("VerifyEmail() status = %v for a token which has already been used", status)
+	}
+
+	status, result, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "1234", nil)
+	if status != http.StatusOK {
+		t.Fatalf("EmailSignIn() status = %v, error = %v for a verified user", status, err)
+	}
+	if _, p := result["user"].(map[string]interface{})[userFieldVerifyToken]; p {
+		t.Errorf("EmailSignIn() returned the verification token hash of the user")
+	}
+}
+
+func TestModule_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	m, crud, eventing := newVerificationModule()
+	crud.users = []map[string]interface{}{{"id": "1", "email": "jon@example.com", "role": "user", "pass": "old"}}
+
+	// Unknown emails must not be disclosed
+	if status, err := m.SendPasswordResetEmail(ctx, "db", "project", "arya@example.com"); status != http.StatusOK || len(eventing.mails) != 0 {
+		t.Fatalf("SendPasswordResetEmail() status = %v, error = %v, mails = %v for an unknown email", status, err, eventing.mails)
+	}
+
+	if status, err := m.SendPasswordResetEmail(ctx, "db", "project", "jon@example.com"); status != http.StatusOK {
+		t.Fatalf("SendPasswordResetEmail() status = %v, error = %v", status, err)
+	}
+	token := eventing.lastToken(t)
+
+	if status, _ := m.ResetPassword(ctx, "db", "project", token, ""); status != http.StatusBadRequest {
+		t.Errorf("ResetPassword() status = %v without a password, want %v", status, http.StatusBadRequest)
+	}
+	if status, err := m.ResetPassword(ctx, "db", "project", token, "5678"); status != http.StatusOK {
+		t.Fatalf("ResetPassword() status = %v, error = %v", status, err)
+	}
+	if status, _ := m.ResetPassword(ctx, "db", "project", token, "5678"); status != http.StatusUnauthorized {
+		t.Errorf("ResetPassword() status = %v for a token which has already been used", status)
+	}
+
+	if status, _, _ := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "old", nil); status != http.StatusUnauthorized {
+		t.Errorf("EmailSignIn() status = %v with the old password, want %v", status, http.StatusUnauthorized)
+	}
+	if status, _, err := m.EmailSignIn(ctx, "db", "project", "jon@example.com", "5678", nil); status != http.StatusOK {
+		t.Errorf("EmailSignIn() status = %v, error = %v with the new password", status, err)
+	}
+}
+
+func TestModule_ResetPassword_expired(t *testing.T) {
+	ctx := context.Background()
+	m, crud, eventing := newVerificationModule()
+	crud.users = []map[string]interface{}{{"id": "1", "email": "jon@example.com", "role": "user", "pass": "old"}}
+
+	if status, err := m.SendPasswordResetEmail(ctx, "db", "project", "jon@example.com"); status != http.StatusOK {
+		t.Fatalf("SendPasswordResetEmail() status = %v, error = %v", status, err)
+	}
+	crud.users[0][userFieldResetTokenExpiry] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+
+	if status, _ := m.ResetPassword(ctx, "db", "project", eventing.lastToken(t), "5678"); status != http.StatusUnauthorized {
+		t.Errorf("ResetPassword() status = %v for an expired token, want %v", status, http.StatusUnauthorized)
+	}
+}
+
+func TestModule_EmailEditProfile_email(t *testing.T) {
+	tests := []struct {
+		name         string
+		email        string
+		wantVerified bool
+		wantMails    int
+	}{
+		{name: "changed email needs to be verified again", email: "jon@example.org", wantMails: 1},
+		{name: "same email stays verified", email: "jon@example.com", wantVerified: true},
+		{name: "email not provided", wantVerified: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, crud, eventing := newVerificationModule()
+			m.auth.(*mockAuth).tokens = map[string]model.TokenClaims{"token-1": {"id": "1"}}
+			crud.users = []map[string]interface{}{{"id": "1", "email": "jon@example.com", "name": "Jon", "role": "user", "verified": true, userFieldVerifyToken: "old-hash"}}
+
+			status, _, err := m.EmailEditProfile(ctx, "token-1", "db", "project", "1", tt.email, "Jon Snow", "")
+			if status != http.StatusOK {
+				t.Fatalf("EmailEditProfile() status = %v, error = %v", status, err)
+			}
+			if got := isVerified(crud.users[0]); got != tt.wantVerified {
+				t.Errorf("EmailEditProfile() verified = %v, want %v", got, tt.wantVerified)
+			}
+			if len(eventing.mails) != tt.wantMails {
+				t.Fatalf("EmailEditProfile() sent (%d) mails, want (%d)", len(eventing.mails), tt.wantMails)
+			}
+			if tt.wantMails == 0 {
+				return
+			}
+			if to := eventing.mails[0]["to"]; to != tt.email {
+				t.Errorf("EmailEditProfile() mailed the verification token to (%v), want (%s)", to, tt.email)
+			}
+			if status, err := m.VerifyEmail(ctx, "db", "project", eventing.lastToken(t)); status != http.StatusOK || !isVerified(crud.users[0]) {
+				t.Errorf("VerifyEmail() status = %v, error = %v for the token mailed after changing the email", status, err)
+			}
+		})
+	}
+}
+
+func Test_renderMail(t *testing.T) {
+	data := map[string]interface{}{"name": "Jon", "token": "abc", "expiry": "soon"}
+	tests := []struct {
+		name        string
+		mailer      *config.Mailer
+		data        map[string]interface{}
+		wantSubject string
+		wantBody    string
+		wantHTML    bool
+		wantErr     bool
+	}{
+		{
+			name:        "default template",
+			mailer:      &config.Mailer{},
+			wantSubject: "Verify your email",
+			wantBody:    "abc",
+		},
+		{
+			name: "custom template",
+			mailer: &config.Mailer{Templates: map[string]*config.MailTemplate{
+				mailTemplateVerifyEmail: {Subject: "Welcome {{.name}}", Body: "<a href=\"https://example.com/verify?token={{.token}}\">Verify</a>", IsHTML: true},
+			}},
+			wantSubject: "Welcome Jon",
+			wantBody:    "token=abc",
+			wantHTML:    true,
+		},
+		{
+			name: "values are escaped in html templates",
+			mailer: &config.Mailer{Templates: map[string]*config.MailTemplate{
+				mailTemplateVerifyEmail: {Subject: "Welcome {{.name}}", Body: "<p>Hi {{.name}}</p>", IsHTML: true},
+			}},
+			data:        map[string]interface{}{"name": "<a href=\"https://evil.example.com\">Jon</a>", "token": "abc"},
+			wantSubject: "Welcome <a href=\"https://evil.example.com\">Jon</a>",
+			wantBody:    "<p>Hi &lt;a href=&#34;https://evil.example.com&#34;&gt;Jon&lt;/a&gt;</p>",
+			wantHTML:    true,
+		},
+		{
+			name: "line breaks are stripped from the subject",
+			mailer: &config.Mailer{Templates: map[string]*config.MailTemplate{
+				mailTemplateVerifyEmail: {Subject: "Hi\r\nBcc: eve@example.com", Body: "{{.token}}"},
+			}},
+			wantSubject: "Hi Bcc: eve@example.com",
+			wantBody:    "abc",
+		},
+		{
+			name: "invalid template",
+			mailer: &config.Mailer{Templates: map[string]*config.MailTemplate{
+				mailTemplateVerifyEmail: {Subject: "{{.name", Body: "{{.token}}"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.data == nil {
+				tt.data = data
+			}
+			got, err := renderMail(tt.mailer, mailTemplateVerifyEmail, "jon@example.com", tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderMail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Subject != tt.wantSubject {
+				t.Errorf("renderMail() subject = %q, want %q", got.Subject, tt.wantSubject)
+			}
+			if !strings.Contains(got.Body, tt.wantBody) {
+				t.Errorf("renderMail() body = %q, want it to contain %q", got.Body, tt.wantBody)
+			}
+			if got.IsHTML != tt.wantHTML {
+				t.Errorf("renderMail() isHtml = %v, want %v", got.IsHTML, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func TestModule_ResetPassword_revokesSessions(t *testing.T) {
+	ctx := context.Background()
+	m, crud, eventing := newVerificationModule()
+	auth := m.auth.(*mockAuth)
+	auth.tokens = map[string]model.TokenClaims{"token-1": {"id": "1"}}
+	crud.users = []map[string]interface{}{{"id": "1", "email": "jon@example.com", "role": "user", "pass": "old"}}
+	crud.sessions = []map[string]interface{}{{"id": "s1", sessionFieldUserID: "1", sessionFieldRevoked: false}}
+	m.methods["email"].Sessions = &config.Sessions{Enabled: true}
+
+	if status, err := m.SendPasswordResetEmail(ctx, "db", "project", "jon@example.com"); status != http.StatusOK {
+		t.Fatalf("SendPasswordResetEmail() status = %v, error = %v", status, err)
+	}
+	if status, err := m.ResetPassword(ctx, "db", "project", eventing.lastToken(t), "5678"); status != http.StatusOK {
+		t.Fatalf("ResetPassword() status = %v, error = %v", status, err)
+	}
+
+	if _, err := auth.ParseToken(ctx, "token-1"); err == nil {
+		t.Errorf("ResetPassword() did not revoke the tokens issued with the old password")
+	}
+	if crud.sessions[0][sessionFieldRevoked] != true {
+		t.Errorf("ResetPassword() did not revoke the sessions created with the old password")
+	}
+}
+
+func TestModule_ResetPassword_concurrent(t *testing.T) {
+	ctx := context.Background()
+	m, crud, eventing := newVerificationModule()
+	crud.users = []map[string]interface{}{{"id": "1", "email": "jon@example.com", "role": "user", "pass": "old"}}
+
+	if status, err := m.SendPasswordResetEmail(ctx, "db", "project", "jon@example.com"); status != http.StatusOK {
+		t.Fatalf("SendPasswordResetEmail() status = %v, error = %v", status, err)
+	}
+
+	// Another request consumes the token right after this one has read the user
+	crud.afterRead = func() {
+		crud.users[0][userFieldResetToken] = ""
+		crud.afterRead = nil
+	}
+	if status, _ := m.ResetPassword(ctx, "db", "project", eventing.lastToken(t), "5678"); status != http.StatusUnauthorized {
+		t.Errorf("ResetPassword() status = %v for a token consumed concurrently, want %v", status, http.StatusUnauthorized)
+	}
+	if crud.users[0]["pass"] != "old" {
+		t.Errorf("ResetPassword() changed the password with a token consumed concurrently")
+	}
+}
@@ -91,6 +91,52 @@ func HandleAdminLogin(adminMan *admin.Manager) http.HandlerFunc {
 	}
 }
 
+// HandleAdminOIDCNonce creates the endpoint which returns the nonce to be sent to the openid connect provider
+func HandleAdminOIDCNonce(adminMan *admin.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+
+		status, nonce, err := adminMan.CreateOIDCNonce(ctx)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+
+		_ = helpers.Response.SendResponse(ctx, w, http.StatusOK, map[string]interface{}{"nonce": nonce})
+	}
+}
+
+// HandleAdminOIDCLogin creates the endpoint for admins to sign in with the id token of an openid connect provider
+func HandleAdminOIDCLogin(adminMan *admin.Manager) http.HandlerFunc {
+
+	type Request struct {
+		IDToken string `json:"idToken"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+
+		defer utils.CloseTheCloser(r.Body)
+
+		// Load the request from the body
+		req := new(Request)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		status, token, err := adminMan.LoginWithOIDC(ctx, req.IDToken)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+
+		_ = helpers.Response.SendResponse(ctx, w, http.StatusOK, map[string]interface{}{"token": token})
+	}
+}
+
 // HandleRefreshToken creates the refresh-token endpoint
 func HandleRefreshToken(adminMan *admin.Manager, syncMan *syncman.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/managers/admin"
+	"github.com/spaceuptech/space-cloud/gateway/managers/syncman"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// HandleGetConfigAudit returns the handler to query the audit trail of config changes
+func HandleGetConfigAudit(adminMan *admin.Manager, syncMan *syncman.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		// Get the JWT token from header
+		token := utils.GetTokenFromHeader(r)
+
+		query := r.URL.Query()
+		q := &model.ConfigAuditQuery{
+			Project:      query.Get("project"),
+			ResourceType: query.Get("resourceType"),
+			ResourceID:   query.Get("resourceId"),
+			Actor:        query.Get("actor"),
+			RequestID:    query.Get("requestId"),
+			Since:        query.Get("since"),
+			Until:        query.Get("until"),
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+
+		if v := query.Get("limit"); v != "" {
+			limit, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+				return
+			}
+			q.Limit = limit
+		}
+
+		// Check if the request is authorised. The trail of all projects can only be read with a permission on all of them.
+		reqParams, err := adminMan.IsTokenValid(ctx, token, "config-audit", "read", map[string]string{"project": q.Project})
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusUnauthorized, err)
+			return
+		}
+
+		reqParams = utils.ExtractRequestParams(r, reqParams, nil)
+		status, entries, err := syncMan.GetConfigAudit(ctx, q, reqParams)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: entries})
+	}
+}
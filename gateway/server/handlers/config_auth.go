@@ -85,7 +85,7 @@ func HandleGetUserManagement(adminMan *admin.Manager, syncMan *syncman.Manager)
 			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
 			return
 		}
-		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: providers})
+		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: config.RedactConfig(providers)})
 	}
 }
 
@@ -78,7 +78,7 @@ func HandleGetCacheConfig(adminMan *admin.Manager, syncMan *syncman.Manager) htt
 			return
 		}
 
-		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: []interface{}{cacheConfig}})
+		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: []interface{}{config.RedactConfig(cacheConfig)}})
 	}
 }
 
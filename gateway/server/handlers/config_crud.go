@@ -205,7 +205,7 @@ func HandleGetDatabaseConfig(adminMan *admin.Manager, syncMan *syncman.Manager)
 			return
 		}
 
-		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: dbConfig})
+		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: config.RedactConfig(dbConfig)})
 	}
 }
 
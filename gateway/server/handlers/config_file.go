@@ -83,7 +83,7 @@ func HandleGetFileStore(adminMan *admin.Manager, syncMan *syncman.Manager) http.
 			return
 		}
 
-		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: fileConfig})
+		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: config.RedactConfig(fileConfig)})
 	}
 }
 
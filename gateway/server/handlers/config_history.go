@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/managers/admin"
+	"github.com/spaceuptech/space-cloud/gateway/managers/syncman"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// HandleGetConfigHistory returns the handler to get the revisions of a resource, or of all the resources of a project
+func HandleGetConfigHistory(adminMan *admin.Manager, syncMan *syncman.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		// Get the JWT token from header
+		token := utils.GetTokenFromHeader(r)
+
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		resourceID := r.URL.Query().Get("resourceId")
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+
+		// Check if the request is authorised
+		reqParams, err := adminMan.IsTokenValid(ctx, token, "config-history", "read", map[string]string{"project": projectID})
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusUnauthorized, err)
+			return
+		}
+
+		reqParams = utils.ExtractRequestParams(r, reqParams, nil)
+		status, revisions, err := syncMan.GetConfigHistory(ctx, projectID, resourceID, resourcePermission(ctx, adminMan, token, projectID, "read"), reqParams)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: revisions})
+	}
+}
+
+// HandleRollbackConfig returns the handler to roll back a resource to a revision, or the entire project to an earlier time
+func HandleRollbackConfig(adminMan *admin.Manager, syncMan *syncman.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		// Get the JWT token from header
+		token := utils.GetTokenFromHeader(r)
+
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+
+		// Load the body of the request
+		req := new(model.ConfigRollbackRequest)
+		defer utils.CloseTheCloser(r.Body)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			_ = helpers.Response.SendErrorResponse(r.Context(), w, http.StatusBadRequest, err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+
+		// Check if the request is authorised
+		reqParams, err := adminMan.IsTokenValid(ctx, token, "config-history", "modify", map[string]string{"project": projectID})
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusUnauthorized, err)
+			return
+		}
+
+		reqParams = utils.ExtractRequestParams(r, reqParams, req)
+
+		var status int
+		switch {
+		case req.ResourceID != "":
+			status, err = syncMan.RollbackResource(ctx, projectID, req.ResourceID, req.Revision, resourcePermission(ctx, adminMan, token, projectID, "modify"), reqParams)
+		case req.Time != "":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, req.Time)
+			if err != nil {
+				_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+				return
+			}
+			status, err = syncMan.RollbackProject(ctx, projectID, t, resourcePermission(ctx, adminMan, token, projectID, "modify"), reqParams)
+		default:
+			status, err = http.StatusBadRequest, errors.New("either a resource id and revision or a time must be provided")
+		}
+		if err != nil {
+			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), "Failed to rollback config", err, nil)
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+
+		_ = helpers.Response.SendOkayResponse(ctx, status, w)
+	}
+}
+
+// resourcePermission checks the permission of the token on the resources of a type. The config history of a resource
+// reveals, and rolling it back changes, the resource itself.
+func resourcePermission(ctx context.Context, adminMan *admin.Manager, token, projectID, op string) syncman.ResourcePermission {
+	return func(resourceType config.Resource) error {
+		_, err := adminMan.IsTokenValid(ctx, token, string(resourceType), op, map[string]string{"project": projectID})
+		return err
+	}
+}
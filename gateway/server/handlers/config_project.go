@@ -45,7 +45,7 @@ func HandleGetProjectConfig(adminMan *admin.Manager, syncMan *syncman.Manager) h
 			return
 		}
 
-		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: project})
+		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: config.RedactConfig(project)})
 	}
 }
 
@@ -144,7 +144,7 @@ func HandleGetClusterConfig(adminMan *admin.Manager, syncMan *syncman.Manager) h
 			return
 		}
 
-		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: clusterConfig})
+		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: config.RedactConfig(clusterConfig)})
 	}
 }
 
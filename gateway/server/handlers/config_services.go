@@ -87,7 +87,7 @@ func HandleGetService(adminMan *admin.Manager, syncMan *syncman.Manager) http.Ha
 			return
 		}
 
-		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: services})
+		_ = helpers.Response.SendResponse(ctx, w, status, model.Response{Result: config.RedactConfig(services)})
 	}
 }
 
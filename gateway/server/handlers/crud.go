@@ -67,7 +67,7 @@ func HandleCrudPreparedQuery(modules *modules.Modules) http.HandlerFunc {
 		}
 
 		// function to do postProcessing on result
-		_ = authHelpers.PostProcessMethod(ctx, auth.GetAESKey(), actions, result)
+		_ = authHelpers.PostProcessMethod(ctx, auth.GetAESKeyRing(), actions, result)
 
 		// Give positive acknowledgement
 		_ = helpers.Response.SendResponse(ctx, w, http.StatusOK, map[string]interface{}{"result": result})
@@ -199,7 +199,7 @@ func HandleCrudRead(modules *modules.Modules) http.HandlerFunc {
 		}
 
 		// function to do postProcessing on result
-		_ = authHelpers.PostProcessMethod(ctx, auth.GetAESKey(), actions, result)
+		_ = authHelpers.PostProcessMethod(ctx, auth.GetAESKeyRing(), actions, result)
 
 		// Give positive acknowledgement
 		_ = helpers.Response.SendResponse(ctx, w, http.StatusOK, map[string]interface{}{"result": result})
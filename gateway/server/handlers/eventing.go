@@ -51,8 +51,8 @@ func HandleAdminQueueEvent(adminMan *admin.Manager, modules *modules.Modules) ht
 		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 		defer cancel()
 
-		// Get the JWT token from header
-		if err := adminMan.CheckIfAdmin(ctx, utils.GetTokenFromHeader(r)); err != nil {
+		// Check if the admin is allowed to queue events in the project
+		if _, err := adminMan.IsTokenValid(ctx, utils.GetTokenFromHeader(r), "eventing-queue", "modify", map[string]string{"project": projectID}); err != nil {
 			_ = helpers.Response.SendErrorResponse(r.Context(), w, http.StatusForbidden, err)
 			return
 		}
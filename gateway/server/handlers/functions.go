@@ -68,14 +68,29 @@ func HandleFunctionCall(modules *modules.Modules) http.HandlerFunc {
 
 		reqParams = utils.ExtractRequestParams(r, reqParams, req)
 
-		status, result, err := functions.CallWithContext(ctx, serviceID, function, token, reqParams, &req)
+		status, result, etag, err := functions.CallWithETag(ctx, serviceID, function, token, reqParams, &req)
 		if err != nil {
 			_ = helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Receieved error from service call (%s:%s)", serviceID, function), err, nil)
 			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
 			return
 		}
 
-		_ = authHelpers.PostProcessMethod(ctx, auth.GetAESKey(), actions, result)
+		_ = authHelpers.PostProcessMethod(ctx, auth.GetAESKeyRing(), actions, result)
+
+		// Let the client revalidate cached responses using the entity tag
+		if etag != "" && status == http.StatusOK {
+			w.Header().Set("ETag", etag)
+			if req.Cache.TTL > 0 {
+				w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", req.Cache.TTL))
+			} else {
+				w.Header().Set("Cache-Control", "private, no-cache")
+			}
+
+			if utils.IsETagMatch(r.Header.Get("If-None-Match"), etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
 
 		_ = helpers.Response.SendResponse(ctx, w, status, result)
 	}
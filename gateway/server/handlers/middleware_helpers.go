@@ -76,6 +76,13 @@ func (counter *ResponseWriterCounter) Hijack() (net.Conn, *bufio.ReadWriter, err
 	return counter.ResponseWriter.(http.Hijacker).Hijack()
 }
 
+// Flush flushes the buffered data of the response writer, if supported
+func (counter *ResponseWriterCounter) Flush() {
+	if flusher, ok := counter.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // Count function return counted bytes
 func (counter *ResponseWriterCounter) Count() uint64 {
 	return atomic.LoadUint64(&counter.count)
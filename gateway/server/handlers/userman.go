@@ -2,17 +2,25 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/spaceuptech/helpers"
 
+	"github.com/spaceuptech/space-cloud/gateway/model"
 	"github.com/spaceuptech/space-cloud/gateway/modules"
+	"github.com/spaceuptech/space-cloud/gateway/modules/userman"
 	"github.com/spaceuptech/space-cloud/gateway/utils"
 )
 
+// oidcStateCookie holds the login state of the user while signing in with an openid connect provider
+const oidcStateCookie = "sc-oidc-state"
+
 // HandleProfile returns the handler for fetching single user profile
 func HandleProfile(modules *modules.Modules) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -107,7 +115,7 @@ func HandleEmailSignIn(modules *modules.Modules) http.HandlerFunc {
 		_ = json.NewDecoder(r.Body).Decode(&req)
 		defer utils.CloseTheCloser(r.Body)
 
-		status, result, err := userManagement.EmailSignIn(ctx, dbAlias, projectID, req["email"].(string), req["pass"].(string))
+		status, result, err := userManagement.EmailSignIn(ctx, dbAlias, projectID, req["email"].(string), req["pass"].(string), getSessionDevice(r))
 
 		if err != nil {
 			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
@@ -142,7 +150,7 @@ func HandleEmailSignUp(modules *modules.Modules) http.HandlerFunc {
 		_ = json.NewDecoder(r.Body).Decode(&req)
 		defer utils.CloseTheCloser(r.Body)
 
-		status, result, err := userManagement.EmailSignUp(ctx, dbAlias, projectID, req["email"].(string), req["name"].(string), req["pass"].(string), req["role"].(string))
+		status, result, err := userManagement.EmailSignUp(ctx, dbAlias, projectID, req["email"].(string), req["name"].(string), req["pass"].(string), req["role"].(string), getSessionDevice(r))
 		if err != nil {
 			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
 			return
@@ -189,3 +197,436 @@ func HandleEmailEditProfile(modules *modules.Modules) http.HandlerFunc {
 		_ = helpers.Response.SendResponse(ctx, w, status, result)
 	}
 }
+
+// HandleRevokeToken returns the handler to revoke the token used to make the request
+func HandleRevokeToken(modules *modules.Modules) http.HandlerFunc {
+	return handleRevoke(modules, false)
+}
+
+// HandleRevokeAllTokens returns the handler to revoke all the tokens issued to the subject of the token used to make the request
+func HandleRevokeAllTokens(modules *modules.Modules) http.HandlerFunc {
+	return handleRevoke(modules, true)
+}
+
+// HandleSendVerificationEmail returns the handler to mail a verification token to the user
+func HandleSendVerificationEmail(modules *modules.Modules) http.HandlerFunc {
+	return handleUserTokenRequest(modules, func(ctx context.Context, user *userman.Module, dbAlias, projectID string, req *userTokenRequest) (int, error) {
+		return user.SendVerificationEmail(ctx, dbAlias, projectID, req.Email)
+	})
+}
+
+// HandleVerifyEmail returns the handler to verify the email of the user with the token mailed to the user
+func HandleVerifyEmail(modules *modules.Modules) http.HandlerFunc {
+	return handleUserTokenRequest(modules, func(ctx context.Context, user *userman.Module, dbAlias, projectID string, req *userTokenRequest) (int, error) {
+		return user.VerifyEmail(ctx, dbAlias, projectID, req.Token)
+	})
+}
+
+// HandleSendPasswordResetEmail returns the handler to mail a password reset token to the user
+func HandleSendPasswordResetEmail(modules *modules.Modules) http.HandlerFunc {
+	return handleUserTokenRequest(modules, func(ctx context.Context, user *userman.Module, dbAlias, projectID string, req *userTokenRequest) (int, error) {
+		return user.SendPasswordResetEmail(ctx, dbAlias, projectID, req.Email)
+	})
+}
+
+// HandleResetPassword returns the handler to reset the password of the user with the token mailed to the user
+func HandleResetPassword(modules *modules.Modules) http.HandlerFunc {
+	return handleUserTokenRequest(modules, func(ctx context.Context, user *userman.Module, dbAlias, projectID string, req *userTokenRequest) (int, error) {
+		return user.ResetPassword(ctx, dbAlias, projectID, req.Token, req.Pass)
+	})
+}
+
+type userTokenRequest struct {
+	Email string `json:"email"`
+	Token string `json:"token"`
+	Pass  string `json:"pass"`
+}
+
+func handleUserTokenRequest(modules *modules.Modules, fn func(ctx context.Context, user *userman.Module, dbAlias, projectID string, req *userTokenRequest) (int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		dbAlias := vars["dbAlias"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+		defer utils.CloseTheCloser(r.Body)
+
+		userManagement, err := modules.User(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Load the request from the body
+		req := new(userTokenRequest)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		status, err := fn(ctx, userManagement, dbAlias, projectID, req)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+		_ = helpers.Response.SendOkayResponse(ctx, status, w)
+	}
+}
+
+// HandleOIDCLogin returns the handler which redirects the user to the openid connect provider for signing in
+func HandleOIDCLogin(modules *modules.Modules) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		provider := vars["provider"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+
+		userManagement, err := modules.User(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		status, authURL, loginState, err := userManagement.OIDCLogin(ctx, provider)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+
+		data, _ := json.Marshal(loginState)
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookie,
+			Value:    base64.RawURLEncoding.EncodeToString(data),
+			Path:     oidcCookiePath(projectID, vars["dbAlias"], provider),
+			MaxAge:   10 * 60,
+			HttpOnly: true,
+			Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
+
+// HandleOIDCCallback returns the handler which signs in the user once the openid connect provider redirects back
+func HandleOIDCCallback(modules *modules.Modules) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		dbAlias := vars["dbAlias"]
+		provider := vars["provider"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+
+		userManagement, err := modules.User(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		query := r.URL.Query()
+		if e := query.Get("error"); e != "" {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusUnauthorized, errors.New(e+" "+query.Get("error_description")))
+			return
+		}
+
+		// The login state is only good for a single attempt
+		var loginState *model.OIDCLoginState
+		if cookie, err := r.Cookie(oidcStateCookie); err == nil {
+			if data, err := base64.RawURLEncoding.DecodeString(cookie.Value); err == nil {
+				loginState = new(model.OIDCLoginState)
+				if err := json.Unmarshal(data, loginState); err != nil {
+					loginState = nil
+				}
+			}
+		}
+		http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: oidcCookiePath(projectID, dbAlias, provider), MaxAge: -1, HttpOnly: true})
+
+		status, result, err := userManagement.OIDCCallback(ctx, dbAlias, projectID, provider, query.Get("code"), query.Get("state"), loginState, getSessionDevice(r))
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+
+		// Hand over the token to the app if configured
+		if appURL := userManagement.GetOIDCAppRedirectURL(provider); appURL != "" {
+			fragment := url.Values{"token": []string{result["token"].(string)}}
+			if refreshToken, ok := result["refreshToken"].(string); ok {
+				fragment.Set("refreshToken", refreshToken)
+			}
+			http.Redirect(w, r, appURL+"#"+fragment.Encode(), http.StatusFound)
+			return
+		}
+		_ = helpers.Response.SendResponse(ctx, w, status, result)
+	}
+}
+
+func oidcCookiePath(projectID, dbAlias, provider string) string {
+	return "/v1/api/" + projectID + "/auth/" + dbAlias + "/oidc/" + provider
+}
+
+// HandleRefreshSession returns the handler to exchange a refresh token for a new access token and refresh token
+func HandleRefreshSession(modules *modules.Modules) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		dbAlias := vars["dbAlias"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+		defer utils.CloseTheCloser(r.Body)
+
+		userManagement, err := modules.User(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Load the request from the body
+		req := struct {
+			RefreshToken string `json:"refreshToken"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		status, result, err := userManagement.RefreshSession(ctx, dbAlias, projectID, req.RefreshToken, getSessionDevice(r))
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+		_ = helpers.Response.SendResponse(ctx, w, status, result)
+	}
+}
+
+// HandleLogout returns the handler to revoke the session of the token used to make the request
+func HandleLogout(modules *modules.Modules) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		dbAlias := vars["dbAlias"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+		defer utils.CloseTheCloser(r.Body)
+
+		userManagement, err := modules.User(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		status, err := userManagement.Logout(ctx, utils.GetTokenFromHeader(r), dbAlias, projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+		_ = helpers.Response.SendOkayResponse(ctx, status, w)
+	}
+}
+
+// HandleListSessions returns the handler to list the active sessions of the user making the request
+func HandleListSessions(modules *modules.Modules) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		dbAlias := vars["dbAlias"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+		defer utils.CloseTheCloser(r.Body)
+
+		userManagement, err := modules.User(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		status, sessions, err := userManagement.ListSessions(ctx, utils.GetTokenFromHeader(r), dbAlias, projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+		_ = helpers.Response.SendResponse(ctx, w, status, map[string]interface{}{"sessions": sessions})
+	}
+}
+
+// HandleRevokeSession returns the handler to revoke a session of the user making the request
+func HandleRevokeSession(modules *modules.Modules) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		dbAlias := vars["dbAlias"]
+		sessionID := vars["id"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+		defer utils.CloseTheCloser(r.Body)
+
+		userManagement, err := modules.User(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		status, err := userManagement.RevokeSession(ctx, utils.GetTokenFromHeader(r), dbAlias, projectID, sessionID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+		_ = helpers.Response.SendOkayResponse(ctx, status, w)
+	}
+}
+
+// HandleEnrollMFA returns the handler to generate a totp secret for the user making the request
+func HandleEnrollMFA(modules *modules.Modules) http.HandlerFunc {
+	return handleMFARequest(modules, func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID string, req *mfaRequest) (int, interface{}, error) {
+		return user.EnrollMFA(ctx, utils.GetTokenFromHeader(r), dbAlias, projectID)
+	})
+}
+
+// HandleActivateMFA returns the handler to enable multi factor authentication once the user verifies a code
+func HandleActivateMFA(modules *modules.Modules) http.HandlerFunc {
+	return handleMFARequest(modules, func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID string, req *mfaRequest) (int, interface{}, error) {
+		return user.ActivateMFA(ctx, utils.GetTokenFromHeader(r), dbAlias, projectID, req.Code)
+	})
+}
+
+// HandleDisableMFA returns the handler to disable multi factor authentication for the user making the request
+func HandleDisableMFA(modules *modules.Modules) http.HandlerFunc {
+	return handleMFARequest(modules, func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID string, req *mfaRequest) (int, interface{}, error) {
+		status, err := user.DisableMFA(ctx, utils.GetTokenFromHeader(r), dbAlias, projectID, req.Code)
+		return status, nil, err
+	})
+}
+
+// HandleVerifyMFAChallenge returns the handler to complete the sign in of a user who has enabled multi factor authentication
+func HandleVerifyMFAChallenge(modules *modules.Modules) http.HandlerFunc {
+	return handleMFARequest(modules, func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID string, req *mfaRequest) (int, interface{}, error) {
+		return user.VerifyMFAChallenge(ctx, dbAlias, projectID, req.ChallengeToken, req.Code, getSessionDevice(r))
+	})
+}
+
+type mfaRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"` // either a totp code or a recovery code
+}
+
+func handleMFARequest(modules *modules.Modules, fn func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID string, req *mfaRequest) (int, interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		dbAlias := vars["dbAlias"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+		defer utils.CloseTheCloser(r.Body)
+
+		userManagement, err := modules.User(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Load the request from the body. The body is optional for enrollment.
+		req := new(mfaRequest)
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		status, result, err := fn(ctx, userManagement, r, dbAlias, projectID, req)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+		if result == nil {
+			_ = helpers.Response.SendOkayResponse(ctx, status, w)
+			return
+		}
+		_ = helpers.Response.SendResponse(ctx, w, status, result)
+	}
+}
+
+func getSessionDevice(r *http.Request) *model.SessionDevice {
+	return &model.SessionDevice{UserAgent: r.UserAgent(), IP: utils.GetClientIP(r)}
+}
+
+// HandleGetJWKS returns the handler to publish the keys used to sign the tokens of a project
+func HandleGetJWKS(modules *modules.Modules) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+
+		auth, err := modules.Auth(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Let consumers cache the key set for a while. New keys get published well before they are used.
+		w.Header().Set("Cache-Control", "max-age=300")
+		_ = helpers.Response.SendResponse(ctx, w, http.StatusOK, auth.GetJWKS())
+	}
+}
+
+func handleRevoke(modules *modules.Modules, allTokens bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the path parameters
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+
+		// Create a context of execution
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+		defer utils.CloseTheCloser(r.Body)
+
+		auth, err := modules.Auth(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Get the JWT token from header
+		token := utils.GetTokenFromHeader(r)
+
+		if allTokens {
+			err = auth.RevokeSubjectOfToken(ctx, token)
+		} else {
+			err = auth.RevokeToken(ctx, token)
+		}
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusUnauthorized, err)
+			return
+		}
+
+		_ = helpers.Response.SendOkayResponse(ctx, http.StatusOK, w)
+	}
+}
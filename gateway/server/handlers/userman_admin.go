@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/managers/admin"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+	"github.com/spaceuptech/space-cloud/gateway/modules"
+	"github.com/spaceuptech/space-cloud/gateway/modules/userman"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// HandleSearchUsers returns the handler for admins to search the users of a project page by page
+func HandleSearchUsers(adminMan *admin.Manager, modules *modules.Modules) http.HandlerFunc {
+	return handleUserAdminRequest(adminMan, modules, "read", func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID, actor string) (int, interface{}, error) {
+		query := r.URL.Query()
+		req := &model.UserSearchRequest{Email: query.Get("email"), Role: query.Get("role")}
+		if v := query.Get("disabled"); v != "" {
+			disabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return http.StatusBadRequest, nil, err
+			}
+			req.Disabled = &disabled
+		}
+		for key, ptr := range map[string]*int64{"limit": &req.Limit, "skip": &req.Skip} {
+			if v := query.Get(key); v != "" {
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					return http.StatusBadRequest, nil, err
+				}
+				*ptr = n
+			}
+		}
+		return user.SearchUsers(ctx, dbAlias, projectID, req)
+	})
+}
+
+// HandleCreateUser returns the handler for admins to create a user
+func HandleCreateUser(adminMan *admin.Manager, modules *modules.Modules) http.HandlerFunc {
+	return handleUserAdminRequest(adminMan, modules, "modify", func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID, actor string) (int, interface{}, error) {
+		req := new(model.UserAdminRequest)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+		return user.CreateUser(ctx, dbAlias, projectID, actor, req)
+	})
+}
+
+// HandleDisableUser returns the handler for admins to disable a user
+func HandleDisableUser(adminMan *admin.Manager, modules *modules.Modules) http.HandlerFunc {
+	return handleUserAdminRequest(adminMan, modules, "modify", func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID, actor string) (int, interface{}, error) {
+		status, err := user.SetUserDisabled(ctx, dbAlias, projectID, actor, mux.Vars(r)["id"], true)
+		return status, nil, err
+	})
+}
+
+// HandleEnableUser returns the handler for admins to enable a disabled user
+func HandleEnableUser(adminMan *admin.Manager, modules *modules.Modules) http.HandlerFunc {
+	return handleUserAdminRequest(adminMan, modules, "modify", func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID, actor string) (int, interface{}, error) {
+		status, err := user.SetUserDisabled(ctx, dbAlias, projectID, actor, mux.Vars(r)["id"], false)
+		return status, nil, err
+	})
+}
+
+// HandleForcePasswordReset returns the handler for admins to make a user reset the password
+func HandleForcePasswordReset(adminMan *admin.Manager, modules *modules.Modules) http.HandlerFunc {
+	return handleUserAdminRequest(adminMan, modules, "modify", func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID, actor string) (int, interface{}, error) {
+		status, err := user.ForcePasswordReset(ctx, dbAlias, projectID, actor, mux.Vars(r)["id"])
+		return status, nil, err
+	})
+}
+
+// HandleSetUserRole returns the handler for admins to change the role of a user
+func HandleSetUserRole(adminMan *admin.Manager, modules *modules.Modules) http.HandlerFunc {
+	return handleUserAdminRequest(adminMan, modules, "modify", func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID, actor string) (int, interface{}, error) {
+		req := new(model.UserAdminRequest)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+		status, err := user.SetUserRole(ctx, dbAlias, projectID, actor, mux.Vars(r)["id"], req.Role)
+		return status, nil, err
+	})
+}
+
+func handleUserAdminRequest(adminMan *admin.Manager, modules *modules.Modules, op string, fn func(ctx context.Context, user *userman.Module, r *http.Request, dbAlias, projectID, actor string) (int, interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		// Get the JWT token from header
+		token := utils.GetTokenFromHeader(r)
+
+		vars := mux.Vars(r)
+		projectID := vars["project"]
+		dbAlias := vars["dbAlias"]
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(utils.DefaultContextTime)*time.Second)
+		defer cancel()
+		defer utils.CloseTheCloser(r.Body)
+
+		// Check if the request is authorised
+		reqParams, err := adminMan.IsTokenValid(ctx, token, "auth-user", op, map[string]string{"project": projectID})
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusUnauthorized, err)
+			return
+		}
+		actor, _ := reqParams.Claims["id"].(string)
+
+		userManagement, err := modules.User(projectID)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		status, result, err := fn(ctx, userManagement, r, dbAlias, projectID, actor)
+		if err != nil {
+			_ = helpers.Response.SendErrorResponse(ctx, w, status, err)
+			return
+		}
+		if result == nil {
+			_ = helpers.Response.SendOkayResponse(ctx, status, w)
+			return
+		}
+		_ = helpers.Response.SendResponse(ctx, w, status, result)
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/segmentio/ksuid"
 	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/managers/admin"
+	"github.com/spaceuptech/space-cloud/gateway/utils"
 )
 
 func loggerMiddleWare(next http.Handler) http.Handler {
@@ -26,7 +30,32 @@ func loggerMiddleWare(next http.Handler) http.Handler {
 		}
 
 		helpers.Logger.LogInfo(requestID, "Request", map[string]interface{}{"method": r.Method, "url": r.URL.Path, "queryVars": r.URL.Query(), "body": string(reqBody)})
-		next.ServeHTTP(w, r.WithContext(helpers.CreateContext(r)))
+		ctx := utils.SetClientIPInContext(helpers.CreateContext(r), utils.GetClientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+	})
+}
+
+// adminActorMiddleware stores the admin making a request to one of the admin endpoints in the context so that
+// the changes made by it can be recorded in the audit trail
+func adminActorMiddleware(adminMan *admin.Manager, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdminPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
 
+		actor := adminMan.GetActor(r.Context(), utils.GetTokenFromHeader(r))
+		next.ServeHTTP(w, r.WithContext(utils.SetActorInContext(r.Context(), actor)))
 	})
 }
+
+// isAdminPath checks if the path belongs to an endpoint which accepts admin tokens
+func isAdminPath(path string) bool {
+	for _, prefix := range []string{"/v1/config/", "/v1/external/", "/v1/runner/"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(path, "/v1/api/") && strings.HasSuffix(path, "/eventing/admin-queue")
+}
@@ -32,6 +32,8 @@ func (s *Server) routes(profiler bool, staticPath string, restrictedHosts []stri
 	// Initialize the routes for config management
 	router.Methods(http.MethodGet).Path("/v1/config/env").HandlerFunc(handlers.HandleLoadEnv(s.managers.Admin(), s.managers.Sync()))
 	router.Methods(http.MethodPost).Path("/v1/config/login").HandlerFunc(handlers.HandleAdminLogin(s.managers.Admin()))
+	router.Methods(http.MethodGet).Path("/v1/config/login/oidc/nonce").HandlerFunc(handlers.HandleAdminOIDCNonce(s.managers.Admin()))
+	router.Methods(http.MethodPost).Path("/v1/config/login/oidc").HandlerFunc(handlers.HandleAdminOIDCLogin(s.managers.Admin()))
 	router.Methods(http.MethodGet).Path("/v1/config/refresh-token").HandlerFunc(handlers.HandleRefreshToken(s.managers.Admin(), s.managers.Sync()))
 	router.Methods(http.MethodGet).Path("/v1/config/projects/{project}").HandlerFunc(handlers.HandleGetProjectConfig(s.managers.Admin(), s.managers.Sync()))
 	router.Methods(http.MethodPost).Path("/v1/config/projects/{project}").HandlerFunc(handlers.HandleApplyProject(s.managers.Admin(), s.managers.Sync()))
@@ -73,6 +75,25 @@ func (s *Server) routes(profiler bool, staticPath string, restrictedHosts []stri
 	router.Methods(http.MethodPost).Path("/v1/config/projects/{project}/file-storage/rules/{id}").HandlerFunc(handlers.HandleSetFileRule(s.managers.Admin(), s.managers.Sync()))
 	router.Methods(http.MethodDelete).Path("/v1/config/projects/{project}/file-storage/rules/{id}").HandlerFunc(handlers.HandleDeleteFileRule(s.managers.Admin(), s.managers.Sync()))
 
+	router.Methods(http.MethodGet).Path("/v1/config/projects/{project}/security/policies").HandlerFunc(handlers.HandleGetSecurityPolicies(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodPost).Path("/v1/config/projects/{project}/security/policies/{id}").HandlerFunc(handlers.HandleSetSecurityPolicy(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodDelete).Path("/v1/config/projects/{project}/security/policies/{id}").HandlerFunc(handlers.HandleDeleteSecurityPolicy(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodGet).Path("/v1/config/projects/{project}/security/api-keys").HandlerFunc(handlers.HandleGetAPIKeys(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodPost).Path("/v1/config/projects/{project}/security/api-keys/{id}").HandlerFunc(handlers.HandleSetAPIKey(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodPost).Path("/v1/config/projects/{project}/security/api-keys/{id}/rotate").HandlerFunc(handlers.HandleRotateAPIKey(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodDelete).Path("/v1/config/projects/{project}/security/api-keys/{id}").HandlerFunc(handlers.HandleDeleteAPIKey(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodPost).Path("/v1/external/projects/{project}/security/simulate").HandlerFunc(handlers.HandleSimulateSecurityRule(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodPost).Path("/v1/external/projects/{project}/security/revoke").HandlerFunc(handlers.HandleRevokeTokens(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodPost).Path("/v1/external/projects/{project}/auth/{dbAlias}/unlock").HandlerFunc(handlers.HandleUnlockSignIn(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodGet).Path("/v1/external/projects/{project}/auth/{dbAlias}/users").HandlerFunc(handlers.HandleSearchUsers(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodPost).Path("/v1/external/projects/{project}/auth/{dbAlias}/users").HandlerFunc(handlers.HandleCreateUser(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodPost).Path("/v1/external/projects/{project}/auth/{dbAlias}/users/{id}/disable").HandlerFunc(handlers.HandleDisableUser(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodPost).Path("/v1/external/projects/{project}/auth/{dbAlias}/users/{id}/enable").HandlerFunc(handlers.HandleEnableUser(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodPost).Path("/v1/external/projects/{project}/auth/{dbAlias}/users/{id}/force-password-reset").HandlerFunc(handlers.HandleForcePasswordReset(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodPost).Path("/v1/external/projects/{project}/auth/{dbAlias}/users/{id}/role").HandlerFunc(handlers.HandleSetUserRole(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodPost).Path("/v1/external/projects/{project}/security/re-encrypt").HandlerFunc(handlers.HandleStartReEncryption(s.managers.Admin(), s.modules))
+	router.Methods(http.MethodGet).Path("/v1/external/projects/{project}/security/re-encrypt/{id}").HandlerFunc(handlers.HandleGetReEncryptionJob(s.managers.Admin(), s.modules))
+
 	router.Methods(http.MethodGet).Path("/v1/external/projects/{project}/database/{dbAlias}/connection-state").HandlerFunc(handlers.HandleGetDatabaseConnectionState(s.managers.Admin(), s.modules))
 	router.Methods(http.MethodGet).Path("/v1/external/projects/{project}/database/{dbAlias}/list-collections").HandlerFunc(handlers.HandleGetAllTableNames(s.managers.Admin(), s.modules))
 	router.Methods(http.MethodGet).Path("/v1/config/projects/{project}/database/collections/rules").HandlerFunc(handlers.HandleGetTableRules(s.managers.Admin(), s.managers.Sync()))
@@ -102,7 +123,10 @@ func (s *Server) routes(profiler bool, staticPath string, restrictedHosts []stri
 	router.Methods(http.MethodPost).Path("/v1/config/projects/{project}/routing/ingress/{id}").HandlerFunc(handlers.HandleSetProjectRoute(s.managers.Admin(), s.managers.Sync()))
 	router.Methods(http.MethodDelete).Path("/v1/config/projects/{project}/routing/ingress/{id}").HandlerFunc(handlers.HandleDeleteProjectRoute(s.managers.Admin(), s.managers.Sync()))
 
-	router.Methods(http.MethodPost).Path("/v1/config/batch-apply").HandlerFunc(handlers.HandleBatchApplyConfig(s.managers.Admin()))
+	router.Methods(http.MethodGet).Path("/v1/config/projects/{project}/history").HandlerFunc(handlers.HandleGetConfigHistory(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodPost).Path("/v1/config/projects/{project}/rollback").HandlerFunc(handlers.HandleRollbackConfig(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodGet).Path("/v1/config/audit").HandlerFunc(handlers.HandleGetConfigAudit(s.managers.Admin(), s.managers.Sync()))
+	router.Methods(http.MethodPost).Path("/v1/config/batch-apply").HandlerFunc(handlers.HandleBatchApplyConfig())
 
 	// Health check
 	router.Methods(http.MethodGet).Path("/v1/api/health-check").HandlerFunc(handlers.HandleHealthCheck(s.managers.Sync()))
@@ -137,9 +161,26 @@ func (s *Server) routes(profiler bool, staticPath string, restrictedHosts []stri
 	crudRouter.HandleFunc("/aggr", handlers.HandleCrudAggregate(s.modules))
 
 	// Initialize the routes for the user management operations
+	router.Methods(http.MethodGet).Path("/v1/api/{project}/.well-known/jwks.json").HandlerFunc(handlers.HandleGetJWKS(s.modules))
+	router.Methods(http.MethodPost).Path("/v1/api/{project}/auth/revoke").HandlerFunc(handlers.HandleRevokeToken(s.modules))
+	router.Methods(http.MethodPost).Path("/v1/api/{project}/auth/revoke-all").HandlerFunc(handlers.HandleRevokeAllTokens(s.modules))
 	userRouter := router.PathPrefix("/v1/api/{project}/auth/{dbAlias}").Subrouter()
 	userRouter.Methods(http.MethodPost).Path("/email/signin").HandlerFunc(handlers.HandleEmailSignIn(s.modules))
 	userRouter.Methods(http.MethodPost).Path("/email/signup").HandlerFunc(handlers.HandleEmailSignUp(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/email/send-verification").HandlerFunc(handlers.HandleSendVerificationEmail(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/email/verify").HandlerFunc(handlers.HandleVerifyEmail(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/email/forgot-password").HandlerFunc(handlers.HandleSendPasswordResetEmail(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/email/reset-password").HandlerFunc(handlers.HandleResetPassword(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/email/mfa/enroll").HandlerFunc(handlers.HandleEnrollMFA(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/email/mfa/activate").HandlerFunc(handlers.HandleActivateMFA(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/email/mfa/disable").HandlerFunc(handlers.HandleDisableMFA(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/email/mfa/verify").HandlerFunc(handlers.HandleVerifyMFAChallenge(s.modules))
+	userRouter.Methods(http.MethodGet).Path("/oidc/{provider}/login").HandlerFunc(handlers.HandleOIDCLogin(s.modules))
+	userRouter.Methods(http.MethodGet).Path("/oidc/{provider}/callback").HandlerFunc(handlers.HandleOIDCCallback(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/refresh").HandlerFunc(handlers.HandleRefreshSession(s.modules))
+	userRouter.Methods(http.MethodPost).Path("/logout").HandlerFunc(handlers.HandleLogout(s.modules))
+	userRouter.Methods(http.MethodGet).Path("/sessions").HandlerFunc(handlers.HandleListSessions(s.modules))
+	userRouter.Methods(http.MethodDelete).Path("/sessions/{id}").HandlerFunc(handlers.HandleRevokeSession(s.modules))
 	userRouter.Methods(http.MethodGet).Path("/profile/{id}").HandlerFunc(handlers.HandleProfile(s.modules))
 	userRouter.Methods(http.MethodGet).Path("/profiles").HandlerFunc(handlers.HandleProfiles(s.modules))
 	userRouter.Methods(http.MethodPost).Path("/edit_profile/{id}").HandlerFunc(handlers.HandleEmailEditProfile(s.modules))
@@ -193,5 +234,5 @@ func (s *Server) routes(profiler bool, staticPath string, restrictedHosts []stri
 
 	// Add handler for routing module
 	router.PathPrefix("/").HandlerFunc(s.modules.Routing().HandleRoutes(s.modules))
-	return s.restrictDomainMiddleware(restrictedHosts, router)
+	return s.restrictDomainMiddleware(restrictedHosts, adminActorMiddleware(s.managers.Admin(), router))
 }
@@ -8,6 +8,8 @@ import (
 	"strconv"
 
 	"github.com/spaceuptech/helpers"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/managers"
@@ -56,6 +58,8 @@ func (s *Server) Start(profiler bool, staticPath string, port int, restrictedHos
 	if err := s.managers.Sync().Start(port); err != nil {
 		return err
 	}
+	// Stop the background routines of the sync manager once the gateway stops serving
+	defer s.managers.Sync().Close()
 
 	// Allow cors
 	corsObj := utils.CreateCorsObject()
@@ -63,7 +67,7 @@ func (s *Server) Start(profiler bool, staticPath string, port int, restrictedHos
 	if s.ssl != nil && s.ssl.Enabled {
 
 		// Setup the handler
-		handler := corsObj.Handler(loggerMiddleWare(s.routes(profiler, staticPath, restrictedHosts)))
+		handler := corsObj.Handler(loggerMiddleWare(s.modules.Compression().Handler(s.routes(profiler, staticPath, restrictedHosts))))
 		handler = s.modules.LetsEncrypt().LetsEncryptHTTPChallengeHandler(handler)
 
 		// Add existing certificates if any
@@ -84,9 +88,12 @@ func (s *Server) Start(profiler bool, staticPath string, port int, restrictedHos
 		}()
 	}
 
-	handler := corsObj.Handler(loggerMiddleWare(s.routes(profiler, staticPath, restrictedHosts)))
+	handler := corsObj.Handler(loggerMiddleWare(s.modules.Compression().Handler(s.routes(profiler, staticPath, restrictedHosts))))
 	handler = s.modules.LetsEncrypt().LetsEncryptHTTPChallengeHandler(handler)
 
+	// Accept cleartext http2 connections as well so that grpc calls can be proxied by the routing module
+	handler = h2c.NewHandler(handler, &http2.Server{})
+
 	helpers.Logger.LogInfo(helpers.GetRequestID(context.TODO()), "Starting http server on port: "+strconv.Itoa(port), nil)
 
 	if staticPath != "" {
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+// aesGCMPrefix marks the values encrypted in aes gcm mode. Such values have the format `v2:<kid>:<base64(nonce + ciphertext)>`.
+// Values without the prefix are considered to be encrypted with the legacy key in aes cfb mode.
+const aesGCMPrefix = "v2:"
+
+// AESKeyRing encrypts values with the primary key of the ring in aes gcm mode. Values encrypted with any of the keys
+// in the ring or with the legacy key can be decrypted.
+type AESKeyRing struct {
+	legacyKey  []byte
+	primaryKID string
+	keys       map[string]cipher.AEAD
+}
+
+// NewProjectAESKeyRing creates the aes key ring of a project
+func NewProjectAESKeyRing(projectConfig *config.ProjectConfig) (*AESKeyRing, error) {
+	legacyKey, err := base64.StdEncoding.DecodeString(projectConfig.AESKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewAESKeyRing(legacyKey, projectConfig.AESKeys)
+}
+
+// NewAESKeyRing creates a key ring out of the keys provided. The legacy key is used in aes cfb mode if the ring
+// doesn't have any keys.
+func NewAESKeyRing(legacyKey []byte, keys []*config.AESKey) (*AESKeyRing, error) {
+	k := &AESKeyRing{legacyKey: legacyKey, keys: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if key.KID == "" || strings.Contains(key.KID, ":") {
+			return nil, fmt.Errorf("invalid kid (%s) provided for aes key - kid must be non empty and cannot contain (:)", key.KID)
+		}
+		if _, p := k.keys[key.KID]; p {
+			return nil, fmt.Errorf("aes key with kid (%s) provided more than once", key.KID)
+		}
+
+		decodedKey, err := base64.StdEncoding.DecodeString(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("aes key (%s) is not base64 encoded - %v", key.KID, err)
+		}
+		block, err := aes.NewCipher(decodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid aes key (%s) provided - %v", key.KID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[key.KID] = aead
+
+		if key.IsPrimary {
+			if k.primaryKID != "" {
+				return nil, errors.New("only one aes key can be primary")
+			}
+			k.primaryKID = key.KID
+		}
+	}
+
+	if len(keys) > 0 && k.primaryKID == "" {
+		return nil, errors.New("one of the aes keys must be primary")
+	}
+	return k, nil
+}
+
+// PrimaryKID returns the kid of the key used to encrypt new values. It is empty if the legacy key is being used.
+func (k *AESKeyRing) PrimaryKID() string {
+	if k == nil {
+		return ""
+	}
+	return k.primaryKID
+}
+
+// Encrypt encrypts a value with the primary key. The legacy key is used if the ring doesn't have any keys.
+func (k *AESKeyRing) Encrypt(value string) (string, error) {
+	if k == nil || (k.primaryKID == "" && len(k.legacyKey) == 0) {
+		return "", errors.New("aes key has not been configured")
+	}
+
+	if k.primaryKID == "" {
+		if len(k.legacyKey) < aes.BlockSize {
+			return "", aes.KeySizeError(len(k.legacyKey))
+		}
+		return Encrypt(k.legacyKey, value)
+	}
+
+	aead := k.keys[k.primaryKID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	// The kid is authenticated along with the value so that it cannot be swapped
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(k.primaryKID))
+	return aesGCMPrefix + k.primaryKID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value encrypted with any of the keys in the ring or the legacy key
+func (k *AESKeyRing) Decrypt(value string) (string, error) {
+	if k == nil {
+		return "", errors.New("aes key has not been configured")
+	}
+
+	if !strings.HasPrefix(value, aesGCMPrefix) {
+		if len(k.legacyKey) == 0 {
+			return "", errors.New("legacy aes key has not been configured")
+		}
+
+		decodedValue, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", err
+		}
+		if len(k.legacyKey) < aes.BlockSize {
+			return "", aes.KeySizeError(len(k.legacyKey))
+		}
+		decrypted := make([]byte, len(decodedValue))
+		if err := decryptAESCFB(decrypted, decodedValue, k.legacyKey, k.legacyKey[:aes.BlockSize]); err != nil {
+			return "", err
+		}
+		return string(decrypted), nil
+	}
+
+	arr := strings.SplitN(strings.TrimPrefix(value, aesGCMPrefix), ":", 2)
+	if len(arr) != 2 {
+		return "", errors.New("invalid aes gcm value provided")
+	}
+	kid, encoded := arr[0], arr[1]
+
+	aead, p := k.keys[kid]
+	if !p {
+		return "", fmt.Errorf("aes key (%s) used to encrypt the value is not present in the key ring", kid)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid aes gcm value provided")
+	}
+
+	decrypted, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(kid))
+	if err != nil {
+		return "", err
+	}
+	return string(decrypted), nil
+}
+
+// IsEncryptedWithPrimaryKey checks if the value has been encrypted with the primary key of the ring
+func (k *AESKeyRing) IsEncryptedWithPrimaryKey(value string) bool {
+	if k == nil || k.primaryKID == "" {
+		return !strings.HasPrefix(value, aesGCMPrefix)
+	}
+	return strings.HasPrefix(value, aesGCMPrefix+k.primaryKID+":")
+}
+
+func decryptAESCFB(dst, src, key, iv []byte) error {
+	aesBlockDecrypter, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aesDecrypter := cipher.NewCFBDecrypter(aesBlockDecrypter, iv)
+	aesDecrypter.XORKeyStream(dst, src)
+	return nil
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+func TestAESKeyRing(t *testing.T) {
+	legacyKey := base64DecodeString("Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=")
+	oldKeys := []*config.AESKey{{KID: "k1", Key: "MWJkOTE5ZjVmMGRjNGZiMjg4MDQ0NjQ5MDE0ZWM2MDQ=", IsPrimary: true}}
+	newKeys := []*config.AESKey{
+		{KID: "k1", Key: "MWJkOTE5ZjVmMGRjNGZiMjg4MDQ0NjQ5MDE0ZWM2MDQ="},
+		{KID: "k2", Key: "Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g=", IsPrimary: true},
+	}
+
+	legacyRing, err := NewAESKeyRing(legacyKey, nil)
+	if err != nil {
+		t.Fatalf("NewAESKeyRing() error = %v", err)
+	}
+	oldRing, err := NewAESKeyRing(legacyKey, oldKeys)
+	if err != nil {
+		t.Fatalf("NewAESKeyRing() error = %v", err)
+	}
+	newRing, err := NewAESKeyRing(legacyKey, newKeys)
+	if err != nil {
+		t.Fatalf("NewAESKeyRing() error = %v", err)
+	}
+
+	legacyValue, _ := legacyRing.Encrypt("username1")
+	if strings.HasPrefix(legacyValue, aesGCMPrefix) {
+		t.Errorf("Encrypt() of legacy ring = %s, want aes cfb value", legacyValue)
+	}
+
+	first, _ := oldRing.Encrypt("username1")
+	second, _ := oldRing.Encrypt("username1")
+	if first == second {
+		t.Errorf("Encrypt() produced the same value for equal plaintexts")
+	}
+	if !strings.HasPrefix(first, "v2:k1:") {
+		t.Errorf("Encrypt() = %s, want value with kid (k1)", first)
+	}
+
+	tests := []struct {
+		name          string
+		ring          *AESKeyRing
+		value         string
+		want          string
+		wantErr       bool
+		wantIsPrimary bool
+	}{
+		{name: "legacy value with legacy ring", ring: legacyRing, value: legacyValue, want: "username1", wantIsPrimary: true},
+		{name: "legacy value with new ring", ring: newRing, value: legacyValue, want: "username1"},
+		{name: "value of older key with new ring", ring: newRing, value: first, want: "username1"},
+		{name: "value of primary key", ring: oldRing, value: first, want: "username1", wantIsPrimary: true},
+		{name: "value of unknown key", ring: oldRing, value: "v2:k3:AAAA", wantErr: true},
+		{name: "tampered value", ring: oldRing, value: first[:len(first)-4] + "AAAA", wantErr: true, wantIsPrimary: true},
+		{name: "swapped kid", ring: newRing, value: "v2:k2:" + strings.TrimPrefix(first, "v2:k1:"), wantErr: true, wantIsPrimary: true},
+		{name: "gcm value with legacy ring", ring: legacyRing, value: first, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ring.Decrypt(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Decrypt() = %v, want %v", got, tt.want)
+			}
+			if isPrimary := tt.ring.IsEncryptedWithPrimaryKey(tt.value); isPrimary != tt.wantIsPrimary {
+				t.Errorf("IsEncryptedWithPrimaryKey() = %v, want %v", isPrimary, tt.wantIsPrimary)
+			}
+		})
+	}
+}
+
+func TestNewAESKeyRing(t *testing.T) {
+	key := "Olw6AhA/GzSxfhwKLxO7JJsUL6VUwwGEFTgxzoZPy9g="
+	tests := []struct {
+		name    string
+		keys    []*config.AESKey
+		wantErr bool
+	}{
+		{name: "no keys"},
+		{name: "valid keys", keys: []*config.AESKey{{KID: "k1", Key: key}, {KID: "k2", Key: key, IsPrimary: true}}},
+		{name: "no primary key", keys: []*config.AESKey{{KID: "k1", Key: key}}, wantErr: true},
+		{name: "multiple primary keys", keys: []*config.AESKey{{KID: "k1", Key: key, IsPrimary: true}, {KID: "k2", Key: key, IsPrimary: true}}, wantErr: true},
+		{name: "duplicate kid", keys: []*config.AESKey{{KID: "k1", Key: key, IsPrimary: true}, {KID: "k1", Key: key}}, wantErr: true},
+		{name: "kid with colon", keys: []*config.AESKey{{KID: "k:1", Key: key, IsPrimary: true}}, wantErr: true},
+		{name: "invalid key length", keys: []*config.AESKey{{KID: "k1", Key: "c2hvcnQ=", IsPrimary: true}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAESKeyRing(nil, tt.keys); (err != nil) != tt.wantErr {
+				t.Errorf("NewAESKeyRing() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -29,6 +29,9 @@ func ApplySpec(ctx context.Context, token, hostAddr string, specObj *model.SpecO
 		return err
 	}
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	// Forward the request id so that the changes made by the spec can be traced back to the request
+	req.Header.Set(helpers.HeaderRequestID, helpers.GetRequestID(ctx))
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return helpers.Logger.LogError(helpers.GetRequestID(ctx), "error while applying service unable to send http request", err, nil)
@@ -153,6 +153,24 @@ const (
 	EventFileDelete string = "FILE_DELETE"
 )
 
+// Events fired when an admin manages the users of a project
+const (
+	// EventUserCreated is fired when an admin creates a user
+	EventUserCreated string = "USER_CREATED"
+
+	// EventUserDisabled is fired when an admin disables a user
+	EventUserDisabled string = "USER_DISABLED"
+
+	// EventUserEnabled is fired when an admin enables a disabled user
+	EventUserEnabled string = "USER_ENABLED"
+
+	// EventUserPasswordResetForced is fired when an admin forces a user to reset the password
+	EventUserPasswordResetForced string = "USER_PASSWORD_RESET_FORCED"
+
+	// EventUserRoleChanged is fired when an admin changes the role of a user
+	EventUserRoleChanged string = "USER_ROLE_CHANGED"
+)
+
 const (
 	// EventStatusIntent signifies that the event hasn't been staged yet
 	EventStatusIntent string = "intent"
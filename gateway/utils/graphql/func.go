@@ -59,7 +59,7 @@ func (graph *Module) execFuncCall(ctx context.Context, token string, field *ast.
 		}
 
 		_, result, err := graph.functions.CallWithContext(ctx2, serviceName, funcName, token, reqParams, &model.FunctionsRequest{Params: params, Timeout: timeout, Cache: cacheConfig})
-		_ = authHelpers.PostProcessMethod(ctx, graph.aesKey, actions, result)
+		_ = authHelpers.PostProcessMethod(ctx, graph.aesKeys, actions, result)
 		cb(result, err)
 	}()
 }
@@ -2,7 +2,6 @@ package graphql
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"sync"
@@ -25,7 +24,7 @@ type Module struct {
 	schema    SchemaInterface
 
 	// 	Auth module
-	aesKey []byte
+	aesKeys *utils.AESKeyRing
 }
 
 // New creates a new GraphQL module
@@ -38,14 +37,9 @@ func (graph *Module) SetConfig(project string) {
 	graph.project = project
 }
 
-// SetProjectAESKey sets aes key
-func (graph *Module) SetProjectAESKey(aesKey string) error {
-	decodedAESKey, err := base64.StdEncoding.DecodeString(aesKey)
-	if err != nil {
-		return err
-	}
-	graph.aesKey = decodedAESKey
-	return nil
+// SetProjectAESKey sets aes key ring
+func (graph *Module) SetProjectAESKey(aesKeys *utils.AESKeyRing) {
+	graph.aesKeys = aesKeys
 }
 
 // GetProjectID sets the project configuration
@@ -71,7 +71,7 @@ func (graph *Module) execLinkedReadRequest(ctx context.Context, field *ast.Field
 
 		// Post process only if joins were not enabled
 		if isPostProcessingEnabled(req.PostProcess) && len(req.Options.Join) == 0 {
-			_ = authHelpers.PostProcessMethod(ctx, graph.aesKey, req.PostProcess[col], result)
+			_ = authHelpers.PostProcessMethod(ctx, graph.aesKeys, req.PostProcess[col], result)
 		}
 
 		cb(dbAlias, col, result, err)
@@ -149,7 +149,7 @@ func (graph *Module) execReadRequest(ctx context.Context, field *ast.Field, toke
 
 		// Post process only if joins were not enabled
 		if isPostProcessingEnabled(req.PostProcess) && len(req.Options.Join) == 0 {
-			_ = authHelpers.PostProcessMethod(ctx, graph.aesKey, req.PostProcess[col], result)
+			_ = authHelpers.PostProcessMethod(ctx, graph.aesKeys, req.PostProcess[col], result)
 		}
 
 		cb(dbAlias, col, result, err)
@@ -237,7 +237,7 @@ func (graph *Module) execPreparedQueryRequest(ctx context.Context, field *ast.Fi
 			val := store["_query"]
 			val.(*utils.Array).Append(structs.Map(metaData))
 		}
-		_ = authHelpers.PostProcessMethod(ctx, graph.aesKey, actions, result)
+		_ = authHelpers.PostProcessMethod(ctx, graph.aesKeys, actions, result)
 		cb(dbAlias, id, result, err)
 	}()
 }
@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The algorithms which can be used to hash values
+const (
+	// HashAlgorithmSHA256 is the legacy algorithm which stores the unsalted sha256 digest as base64
+	HashAlgorithmSHA256   = "sha256"
+	HashAlgorithmBcrypt   = "bcrypt"
+	HashAlgorithmArgon2id = "argon2id"
+	HashAlgorithmScrypt   = "scrypt"
+)
+
+const (
+	hashSaltLength = 16
+	hashKeyLength  = 32
+
+	argon2Time    = 1
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+
+	scryptLogN = 15
+	scryptR    = 8
+	scryptP    = 1
+)
+
+// The maximum parameters a hash being verified can have. Hashes can be provided by the users of the
+// verify hash rule, so the parameters have to be bounded to keep them from exhausting cpu and memory.
+const (
+	hashMaxKeyLength = 64
+
+	bcryptMaxCost = 16
+
+	argon2MaxTime    = 10
+	argon2MaxMemory  = 256 * 1024
+	argon2MaxThreads = 16
+
+	scryptMaxLogN   = 20
+	scryptMaxR      = 32
+	scryptMaxP      = 4
+	scryptMaxMemory = 64 << 20 // scrypt uses 128 * N * r bytes
+)
+
+// HashValue hashes the value with the algorithm provided. All algorithms apart from sha256 use a random salt
+// per value and encode the parameters they were run with in the result. The legacy sha256 algorithm is used
+// if the algorithm is empty.
+func HashValue(algorithm, value string) (string, error) {
+	switch algorithm {
+	case "", HashAlgorithmSHA256:
+		return HashString(value), nil
+
+	case HashAlgorithmBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(value), bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+
+	case HashAlgorithmArgon2id:
+		salt, err := generateSalt()
+		if err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(value), salt, argon2Time, argon2Memory, argon2Threads, hashKeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads, encodeHashSegment(salt), encodeHashSegment(key)), nil
+
+	case HashAlgorithmScrypt:
+		salt, err := generateSalt()
+		if err != nil {
+			return "", err
+		}
+		key, err := scrypt.Key([]byte(value), salt, 1<<scryptLogN, scryptR, scryptP, hashKeyLength)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", scryptLogN, scryptR, scryptP, encodeHashSegment(salt), encodeHashSegment(key)), nil
+
+	default:
+		return "", fmt.Errorf("invalid hash algorithm (%s) provided", algorithm)
+	}
+}
+
+// GetHashAlgorithm returns the algorithm a hash was generated with. Hashes which aren't in the modular crypt
+// format are considered to be legacy sha256 hashes.
+func GetHashAlgorithm(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return HashAlgorithmBcrypt
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return HashAlgorithmArgon2id
+	case strings.HasPrefix(hash, "$scrypt$"):
+		return HashAlgorithmScrypt
+	default:
+		return HashAlgorithmSHA256
+	}
+}
+
+// VerifyHash checks if the value matches the hash provided. The algorithm is detected from the hash itself.
+// An error is returned only if the hash is malformed.
+func VerifyHash(hash, value string) (bool, error) {
+	switch GetHashAlgorithm(hash) {
+	case HashAlgorithmBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return false, err
+		}
+		if cost > bcryptMaxCost {
+			return false, fmt.Errorf("bcrypt cost (%d) exceeds the maximum allowed", cost)
+		}
+		err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(value))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+
+	case HashAlgorithmArgon2id:
+		// Format - $argon2id$v=<version>$m=<memory>,t=<time>,p=<threads>$<salt>$<key>
+		arr := strings.Split(hash, "$")
+		if len(arr) != 6 {
+			return false, errors.New("invalid argon2id hash provided")
+		}
+		var version int
+		if _, err := fmt.Sscanf(arr[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return false, errors.New("unsupported argon2id version provided")
+		}
+		var memory, time uint32
+		var threads uint8
+		if _, err := fmt.Sscanf(arr[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+			return false, fmt.Errorf("invalid argon2id parameters provided - %v", err)
+		}
+		if time < 1 || time > argon2MaxTime || threads < 1 || threads > argon2MaxThreads || memory < 8*uint32(threads) || memory > argon2MaxMemory {
+			return false, errors.New("argon2id parameters provided are out of the allowed range")
+		}
+		salt, key, err := decodeHashSegments(arr[4], arr[5])
+		if err != nil {
+			return false, err
+		}
+		computed := argon2.IDKey([]byte(value), salt, time, memory, threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(computed, key) == 1, nil
+
+	case HashAlgorithmScrypt:
+		// Format - $scrypt$ln=<log2(N)>,r=<r>,p=<p>$<salt>$<key>
+		arr := strings.Split(hash, "$")
+		if len(arr) != 5 {
+			return false, errors.New("invalid scrypt hash provided")
+		}
+		var logN uint
+		var r, p int
+		if _, err := fmt.Sscanf(arr[2], "ln=%d,r=%d,p=%d", &logN, &r, &p); err != nil {
+			return false, errors.New("invalid scrypt parameters provided")
+		}
+		if logN < 1 || logN > scryptMaxLogN || r < 1 || r > scryptMaxR || p < 1 || p > scryptMaxP || 128*(1<<logN)*r > scryptMaxMemory {
+			return false, errors.New("scrypt parameters provided are out of the allowed range")
+		}
+		salt, key, err := decodeHashSegments(arr[3], arr[4])
+		if err != nil {
+			return false, err
+		}
+		computed, err := scrypt.Key([]byte(value), salt, 1<<logN, r, p, len(key))
+		if err != nil {
+			return false, err
+		}
+		return subtle.ConstantTimeCompare(computed, key) == 1, nil
+
+	default:
+		return subtle.ConstantTimeCompare([]byte(HashString(value)), []byte(hash)) == 1, nil
+	}
+}
+
+func generateSalt() ([]byte, error) {
+	salt := make([]byte, hashSaltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+func encodeHashSegment(b []byte) string {
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+func decodeHashSegments(encodedSalt, encodedKey string) ([]byte, []byte, error) {
+	salt, err := base64.RawStdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid salt provided in hash - %v", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) == 0 || len(key) > hashMaxKeyLength {
+		return nil, nil, errors.New("invalid key provided in hash")
+	}
+	return salt, key, nil
+}
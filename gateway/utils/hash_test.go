@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestHashValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		wantAlgo  string
+		wantErr   bool
+	}{
+		{name: "legacy sha256 by default", algorithm: "", wantAlgo: HashAlgorithmSHA256},
+		{name: "bcrypt", algorithm: HashAlgorithmBcrypt, wantAlgo: HashAlgorithmBcrypt},
+		{name: "argon2id", algorithm: HashAlgorithmArgon2id, wantAlgo: HashAlgorithmArgon2id},
+		{name: "scrypt", algorithm: HashAlgorithmScrypt, wantAlgo: HashAlgorithmScrypt},
+		{name: "unknown algorithm", algorithm: "md5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := HashValue(tt.algorithm, "password")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HashValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := GetHashAlgorithm(hash); got != tt.wantAlgo {
+				t.Errorf("GetHashAlgorithm() = %v, want %v", got, tt.wantAlgo)
+			}
+			if ok, err := VerifyHash(hash, "password"); err != nil || !ok {
+				t.Errorf("VerifyHash() = (%v, %v) for the correct value", ok, err)
+			}
+			if ok, err := VerifyHash(hash, "wrong-password"); err != nil || ok {
+				t.Errorf("VerifyHash() = (%v, %v) for an incorrect value", ok, err)
+			}
+
+			// Salted algorithms should never produce the same hash twice
+			if tt.wantAlgo != HashAlgorithmSHA256 {
+				if again, _ := HashValue(tt.algorithm, "password"); again == hash {
+					t.Errorf("HashValue() produced the same hash twice for a salted algorithm")
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		hash    string
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{name: "legacy sha256 hash", hash: HashString("password"), value: "password", want: true},
+		{name: "legacy sha256 mismatch", hash: HashString("password"), value: "passwords"},
+		{name: "malformed argon2id hash", hash: "$argon2id$v=19$m=65536$salt$key", value: "password", wantErr: true},
+		{name: "unsupported argon2id version", hash: "$argon2id$v=16$m=65536,t=1,p=4$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "malformed scrypt hash", hash: "$scrypt$ln=abc,r=8,p=1$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "argon2id hash with zero time", hash: "$argon2id$v=19$m=65536,t=0,p=4$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "argon2id hash with zero threads", hash: "$argon2id$v=19$m=65536,t=1,p=0$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "argon2id hash with huge memory", hash: "$argon2id$v=19$m=4294967295,t=1,p=4$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "argon2id hash with too many rounds", hash: "$argon2id$v=19$m=65536,t=1000000,p=4$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "scrypt hash with huge cost", hash: "$scrypt$ln=30,r=8,p=1$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "scrypt hash with huge block size", hash: "$scrypt$ln=15,r=100000,p=1$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "scrypt hash using too much memory", hash: "$scrypt$ln=20,r=32,p=1$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "scrypt hash with huge parallelism", hash: "$scrypt$ln=15,r=8,p=16$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "scrypt hash with zero parallelism", hash: "$scrypt$ln=15,r=8,p=0$c2FsdA$a2V5", value: "password", wantErr: true},
+		{name: "bcrypt hash with huge cost", hash: "$2a$31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", value: "password", wantErr: true},
+		{name: "scrypt hash without key", hash: "$scrypt$ln=15,r=8,p=1$c2FsdA$", value: "password", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyHash(tt.hash, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyHash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("VerifyHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,9 +2,11 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 
@@ -99,3 +101,98 @@ func CreateCorsObject() *cors.Cors {
 func CloseTheCloser(c io.Closer) {
 	_ = c.Close()
 }
+
+// GenerateETag generates an entity tag for the provided response body. A weak tag is used since the
+// body might get compressed differently for different clients.
+func GenerateETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf(`W/"%x"`, sum[:16])
+}
+
+// IsETagMatch checks if the value of the `If-None-Match` header matches the provided entity tag
+func IsETagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+type clientIPKey struct{}
+
+type actorKey struct{}
+
+// privateNetworks are the ranges from which proxies are trusted to set the `X-Forwarded-For` header
+var privateNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7"} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+func isPrivateIP(ip net.IP) bool {
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClientIP returns the ip of the client making the request. The `X-Forwarded-For` header is only
+// honoured when the request comes from a proxy in a private network, in which case the right most
+// public address is picked.
+func GetClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remoteIP := net.ParseIP(host)
+	if remoteIP == nil || !isPrivateIP(remoteIP) {
+		return host
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			continue
+		}
+		host = ip.String()
+		if !isPrivateIP(ip) {
+			break
+		}
+	}
+	return host
+}
+
+// SetClientIPInContext stores the ip of the client in the context
+func SetClientIPInContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// GetClientIPFromContext returns the ip of the client stored in the context
+func GetClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// SetActorInContext stores the id of the admin making the request in the context
+func SetActorInContext(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// GetActorFromContext returns the id of the admin stored in the context
+func GetActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
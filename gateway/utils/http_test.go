@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsETagMatch(t *testing.T) {
+	etag := GenerateETag([]byte("body"))
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{name: "header not provided", ifNoneMatch: "", want: false},
+		{name: "exact match", ifNoneMatch: etag, want: true},
+		{name: "match in list", ifNoneMatch: `"abc", ` + etag, want: true},
+		{name: "strong comparison of weak tag", ifNoneMatch: etag[2:], want: true},
+		{name: "wildcard", ifNoneMatch: "*", want: true},
+		{name: "different tag", ifNoneMatch: GenerateETag([]byte("other")), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsETagMatch(tt.ifNoneMatch, etag); got != tt.want {
+				t.Errorf("IsETagMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetClientIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{name: "direct request", remoteAddr: "203.0.113.7:4122", want: "203.0.113.7"},
+		{name: "forwarded header ignored for public peer", remoteAddr: "203.0.113.7:4122", forwardedFor: "198.51.100.1", want: "203.0.113.7"},
+		{name: "forwarded by private proxy", remoteAddr: "10.0.0.4:4122", forwardedFor: "198.51.100.1", want: "198.51.100.1"},
+		{name: "spoofed entry before real client", remoteAddr: "10.0.0.4:4122", forwardedFor: "1.2.3.4, 198.51.100.1, 10.0.0.9", want: "198.51.100.1"},
+		{name: "only private addresses", remoteAddr: "127.0.0.1:4122", forwardedFor: "192.168.1.20", want: "192.168.1.20"},
+		{name: "private peer without header", remoteAddr: "127.0.0.1:4122", want: "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if got := GetClientIP(r); got != tt.want {
+				t.Errorf("GetClientIP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
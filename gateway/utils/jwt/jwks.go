@@ -0,0 +1,76 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/segmentio/ksuid"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+const rsaKeySize = 2048
+
+// GenerateRS256Secret generates a new RS256 key pair. The secret returned isn't primary.
+func GenerateRS256Secret(now time.Time) (*config.Secret, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config.Secret{
+		Alg:        config.RS256,
+		KID:        ksuid.New().String(),
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+		CreatedAt:  now.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// GetJWKS returns the json web key set of the RS256 keys used to sign tokens. Public keys of other issuers
+// are not included.
+func (j *JWT) GetJWKS() map[string]interface{} {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	keys := make([]interface{}, 0)
+	for kid, secret := range j.staticSecrets {
+		if secret.Alg != config.RS256 {
+			continue
+		}
+
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM([]byte(secret.PublicKey))
+		if err != nil {
+			_ = helpers.Logger.LogError("jwks", "Unable to parse public key of secret while generating jwks", err, map[string]interface{}{"kid": kid})
+			continue
+		}
+
+		keys = append(keys, map[string]interface{}{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": string(config.RS256),
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		})
+	}
+
+	// Keep the order stable for the consumers caching the key set
+	sort.Slice(keys, func(i, k int) bool {
+		return keys[i].(map[string]interface{})["kid"].(string) < keys[k].(map[string]interface{})["kid"].(string)
+	})
+	return map[string]interface{}{"keys": keys}
+}
@@ -0,0 +1,55 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+)
+
+func TestJWT_GetJWKS(t *testing.T) {
+	secret, err := GenerateRS256Secret(time.Now())
+	if err != nil {
+		t.Fatalf("GenerateRS256Secret() error = %v", err)
+	}
+	secret.IsPrimary = true
+
+	j := New()
+	defer j.Close()
+	if err := j.SetSecrets([]*config.Secret{secret, {Alg: config.HS256, KID: "hs256", Secret: "mySecretKey"}}); err != nil {
+		t.Fatalf("SetSecrets() error = %v", err)
+	}
+
+	token, err := j.CreateToken(context.Background(), map[string]interface{}{"id": "1"})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	keys := j.GetJWKS()["keys"].([]interface{})
+	if len(keys) != 1 {
+		t.Fatalf("GetJWKS() returned (%d) keys, want 1", len(keys))
+	}
+	key := keys[0].(map[string]interface{})
+	if key["kid"] != secret.KID || key["alg"] != "RS256" {
+		t.Fatalf("GetJWKS() returned key = %v", key)
+	}
+
+	// The token should be verifiable with nothing but the published key
+	n, _ := base64.RawURLEncoding.DecodeString(key["n"].(string))
+	e, _ := base64.RawURLEncoding.DecodeString(key["e"].(string))
+	publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if _, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if token.Header["kid"] != secret.KID {
+			t.Errorf("CreateToken() kid = %v, want %v", token.Header["kid"], secret.KID)
+		}
+		return publicKey, nil
+	}); err != nil {
+		t.Errorf("Unable to verify token with published key - %v", err)
+	}
+}
@@ -21,6 +21,10 @@ type JWT struct {
 	jwkSecrets           map[string]*jwkSecret
 	closeJwkRoutineChan  chan struct{}
 	mapJwkKidToSecretKid map[string]string
+
+	// Revoked tokens
+	revokedTokenIDs map[string]time.Time // jti -> expiry of the token
+	revokedSubjects map[string]time.Time // subject -> time of revocation
 }
 
 type jwkSecret struct {
@@ -41,6 +45,8 @@ func New() *JWT {
 		jwkSecrets:           map[string]*jwkSecret{},
 		mapJwkKidToSecretKid: map[string]string{},
 		closeJwkRoutineChan:  ch,
+		revokedTokenIDs:      map[string]time.Time{},
+		revokedSubjects:      map[string]time.Time{},
 	}
 	go func() {
 		tick := time.NewTicker(defaultRefreshTime)
@@ -48,6 +54,7 @@ func New() *JWT {
 			select {
 			case t := <-tick.C:
 				j.fetchJWKRoutine(t)
+				j.purgeRevocations(t)
 			case <-ch:
 				return
 			}
@@ -7,17 +7,30 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/segmentio/ksuid"
 	"github.com/spaceuptech/helpers"
 
 	"github.com/spaceuptech/space-cloud/gateway/config"
 	"github.com/spaceuptech/space-cloud/gateway/model"
 )
 
-// ParseToken verifies the token
+// ParseToken verifies the token and makes sure it hasn't been revoked
 func (j *JWT) ParseToken(ctx context.Context, token string) (map[string]interface{}, error) {
 	j.lock.RLock()
 	defer j.lock.RUnlock()
 
+	claims, err := j.verifyToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := j.checkRevocation(claims); err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Token has been revoked", err, nil)
+	}
+	return claims, nil
+}
+
+func (j *JWT) verifyToken(ctx context.Context, token string) (map[string]interface{}, error) {
 	parser := jwt.Parser{}
 	parsedToken, _, err := parser.ParseUnverified(token, jwt.MapClaims{})
 	if err != nil {
@@ -66,8 +79,17 @@ func (j *JWT) ParseToken(ctx context.Context, token string) (map[string]interfac
 	return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Failed to parse token or authentication secrets not set in space cloud config", er, nil)
 }
 
+// DefaultTokenTTL is the time after which the tokens created without a ttl expire
+const DefaultTokenTTL = 30 * time.Minute
+
 // CreateToken create a token with primary secret
 func (j *JWT) CreateToken(ctx context.Context, tokenClaims model.TokenClaims) (string, error) {
+	return j.CreateTokenWithTTL(ctx, tokenClaims, DefaultTokenTTL)
+}
+
+// CreateTokenWithTTL creates a token with primary secret which expires after the ttl provided. The exp claim
+// provided by the caller is always overwritten.
+func (j *JWT) CreateTokenWithTTL(ctx context.Context, tokenClaims model.TokenClaims, ttl time.Duration) (string, error) {
 	j.lock.RLock()
 	defer j.lock.RUnlock()
 
@@ -77,8 +99,13 @@ func (j *JWT) CreateToken(ctx context.Context, tokenClaims model.TokenClaims) (s
 	}
 	var tokenString string
 	var err error
-	// Add expiry of one week
-	claims["exp"] = time.Now().Add(30 * time.Minute).Unix()
+	claims["exp"] = time.Now().Add(ttl).Unix()
+
+	// The issue time and id let the token be revoked
+	claims["iat"] = float64(time.Now().UnixNano()) / float64(time.Second)
+	if _, p := claims["jti"]; !p {
+		claims["jti"] = ksuid.New().String()
+	}
 	for _, s := range j.staticSecrets {
 		if s.IsPrimary {
 			switch s.Alg {
@@ -120,4 +147,6 @@ func (j *JWT) Close() {
 	j.staticSecrets = map[string]*config.Secret{}
 	j.jwkSecrets = map[string]*jwkSecret{}
 	j.mapJwkKidToSecretKid = map[string]string{}
+	j.revokedTokenIDs = map[string]time.Time{}
+	j.revokedSubjects = map[string]time.Time{}
 }
@@ -0,0 +1,54 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/gateway/config"
+	"github.com/spaceuptech/space-cloud/gateway/model"
+)
+
+func TestJWT_CreateToken(t *testing.T) {
+	j := New()
+	defer j.Close()
+	if err := j.SetSecrets([]*config.Secret{{Alg: config.HS256, KID: "hs256", Secret: "mySecretKey", IsPrimary: true}}); err != nil {
+		t.Fatalf("SetSecrets() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		create func(claims model.TokenClaims) (string, error)
+		want   time.Duration
+	}{
+		{
+			name:   "default ttl",
+			create: func(claims model.TokenClaims) (string, error) { return j.CreateToken(context.Background(), claims) },
+			want:   30 * time.Minute,
+		},
+		{
+			name: "ttl provided",
+			create: func(claims model.TokenClaims) (string, error) {
+				return j.CreateTokenWithTTL(context.Background(), claims, 5*time.Minute)
+			},
+			want: 5 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The expiry provided in the claims must never be honoured
+			token, err := tt.create(model.TokenClaims{"id": "1", "exp": time.Now().Add(365 * 24 * time.Hour).Unix()})
+			if err != nil {
+				t.Fatalf("CreateToken() error = %v", err)
+			}
+			claims, err := j.ParseToken(context.Background(), token)
+			if err != nil {
+				t.Fatalf("ParseToken() error = %v", err)
+			}
+			exp, _ := GetExpiry(claims)
+			if want := time.Now().Add(tt.want); exp.Before(want.Add(-time.Minute)) || exp.After(want.Add(time.Minute)) {
+				t.Errorf("CreateToken() exp = %v, want %v", exp, want)
+			}
+		})
+	}
+}
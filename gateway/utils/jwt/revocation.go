@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxTokenTTL is the longest a token is expected to be valid for. Revocations of subjects are only retained
+// this long, since all the tokens issued before the revocation would have expired by then.
+const MaxTokenTTL = 30 * 24 * time.Hour
+
+// RevokeTokenID revokes the token having the jti provided. The revocation is retained till the token expires.
+func (j *JWT) RevokeTokenID(jti string, expiresAt time.Time) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.revokedTokenIDs[jti] = expiresAt
+}
+
+// RevokeSubject revokes all the tokens of a subject which were issued till the time provided
+func (j *JWT) RevokeSubject(subject string, revokedAt time.Time) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if existing, p := j.revokedSubjects[subject]; !p || existing.Before(revokedAt) {
+		j.revokedSubjects[subject] = revokedAt
+	}
+}
+
+// GetSubject returns the subject of the claims provided. The `id` claim is used when `sub` isn't present.
+func GetSubject(claims map[string]interface{}) string {
+	if sub, ok := claims["sub"].(string); ok && sub != "" {
+		return sub
+	}
+	id, _ := claims["id"].(string)
+	return id
+}
+
+// GetExpiry returns the expiry of the claims provided
+func GetExpiry(claims map[string]interface{}) (time.Time, bool) {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
+
+// checkRevocation checks if the token has been revoked either by its jti, by the id of the session it was
+// issued for (`sid`) or by its subject. Tokens without the `iat` claim are considered revoked if their subject
+// has been revoked.
+func (j *JWT) checkRevocation(claims map[string]interface{}) error {
+	if jti, ok := claims["jti"].(string); ok {
+		if _, p := j.revokedTokenIDs[jti]; p {
+			return errors.New("token has been revoked")
+		}
+	}
+
+	if sid, ok := claims["sid"].(string); ok {
+		if _, p := j.revokedTokenIDs[sid]; p {
+			return errors.New("session of the token has been revoked")
+		}
+	}
+
+	if subject := GetSubject(claims); subject != "" {
+		if revokedAt, p := j.revokedSubjects[subject]; p {
+			// The issue time is compared at sub-second precision so that tokens issued right after the revocation stay valid
+			iat, ok := claims["iat"].(float64)
+			if !ok || iat <= float64(revokedAt.UnixNano())/float64(time.Second) {
+				return errors.New("all tokens of the subject have been revoked")
+			}
+		}
+	}
+	return nil
+}
+
+// purgeRevocations removes the revocations of tokens which have expired anyways
+func (j *JWT) purgeRevocations(t time.Time) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	for jti, expiresAt := range j.revokedTokenIDs {
+		if expiresAt.Before(t) {
+			delete(j.revokedTokenIDs, jti)
+		}
+	}
+	for subject, revokedAt := range j.revokedSubjects {
+		if revokedAt.Add(MaxTokenTTL).Before(t) {
+			delete(j.revokedSubjects, subject)
+		}
+	}
+}
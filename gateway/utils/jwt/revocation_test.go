@@ -0,0 +1,62 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJWT_checkRevocation(t *testing.T) {
+	now := time.Now()
+	j := &JWT{
+		revokedTokenIDs: map[string]time.Time{"revoked-jti": now.Add(time.Hour)},
+		revokedSubjects: map[string]time.Time{"user-1": now, "user-3": time.Unix(1600000000, int64(500*time.Millisecond))},
+	}
+
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		wantErr bool
+	}{
+		{name: "token not revoked", claims: map[string]interface{}{"id": "user-2", "jti": "jti-1", "iat": float64(now.Unix())}},
+		{name: "token revoked by jti", claims: map[string]interface{}{"id": "user-2", "jti": "revoked-jti"}, wantErr: true},
+		{name: "token issued before subject got revoked", claims: map[string]interface{}{"id": "user-1", "iat": float64(now.Add(-time.Minute).Unix())}, wantErr: true},
+		{name: "token issued after subject got revoked", claims: map[string]interface{}{"id": "user-1", "iat": float64(now.Add(time.Minute).Unix())}},
+		{name: "token issued in the same second right after subject got revoked", claims: map[string]interface{}{"id": "user-3", "iat": 1600000000.75}},
+		{name: "token issued in the same second right before subject got revoked", claims: map[string]interface{}{"id": "user-3", "iat": 1600000000.25}, wantErr: true},
+		{name: "token with whole second iat issued in the second subject got revoked", claims: map[string]interface{}{"id": "user-3", "iat": float64(1600000000)}, wantErr: true},
+		{name: "token of revoked subject without iat", claims: map[string]interface{}{"id": "user-1"}, wantErr: true},
+		{name: "subject in sub claim", claims: map[string]interface{}{"sub": "user-1", "id": "user-2"}, wantErr: true},
+		{name: "token of revoked session", claims: map[string]interface{}{"id": "user-2", "jti": "jti-2", "sid": "revoked-jti"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := j.checkRevocation(tt.claims); (err != nil) != tt.wantErr {
+				t.Errorf("checkRevocation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWT_purgeRevocations(t *testing.T) {
+	now := time.Now()
+	j := &JWT{revokedTokenIDs: map[string]time.Time{}, revokedSubjects: map[string]time.Time{}}
+	j.RevokeTokenID("expired", now.Add(-time.Minute))
+	j.RevokeTokenID("active", now.Add(time.Minute))
+	j.RevokeSubject("expired-subject", now.Add(-MaxTokenTTL-time.Minute))
+	j.RevokeSubject("active-subject", now.Add(-time.Minute))
+
+	j.purgeRevocations(now)
+
+	if _, p := j.revokedTokenIDs["expired"]; p {
+		t.Errorf("purgeRevocations() did not remove the revocation of expired token")
+	}
+	if _, p := j.revokedTokenIDs["active"]; !p {
+		t.Errorf("purgeRevocations() removed the revocation of active token")
+	}
+	if _, p := j.revokedSubjects["expired-subject"]; p {
+		t.Errorf("purgeRevocations() did not remove the revocation of subject older than the max token ttl")
+	}
+	if _, p := j.revokedSubjects["active-subject"]; !p {
+		t.Errorf("purgeRevocations() removed the revocation of active subject")
+	}
+}
@@ -0,0 +1,176 @@
+// Package oidc verifies the id tokens issued by openid connect providers. It is used by both the admins and the
+// users of a project signing in with openid connect.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/spaceuptech/helpers"
+
+	"github.com/spaceuptech/space-cloud/gateway/utils"
+)
+
+// CacheTime is the duration for which the discovery document and keys of a provider are cached
+const CacheTime = 1 * time.Hour
+
+var errKeyNotFound = errors.New("key used to sign the id token not found")
+
+// Provider is the discovery document of an openid connect issuer along with its keys
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+
+	Keys      *jwk.Set `json:"-"`
+	fetchedAt time.Time
+}
+
+// Providers caches the providers of the issuers. The zero value is ready to use.
+type Providers struct {
+	lock      sync.Mutex
+	providers map[string]*Provider
+}
+
+// Get returns the provider of the issuer. The cache is bypassed if refresh is true. The lock isn't held while
+// fetching the provider so that a slow issuer doesn't hold up the others.
+func (p *Providers) Get(ctx context.Context, issuer string, refresh bool) (*Provider, error) {
+	p.lock.Lock()
+	provider, ok := p.providers[issuer]
+	p.lock.Unlock()
+	if ok && !refresh && time.Since(provider.fetchedAt) < CacheTime {
+		return provider, nil
+	}
+
+	provider, err := fetchProvider(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.providers == nil {
+		p.providers = map[string]*Provider{}
+	}
+	p.providers[issuer] = provider
+	return provider, nil
+}
+
+// VerifyIDToken verifies the signature of the id token along with its issuer, audience and expiry. The keys of the
+// issuer are fetched again if the token has been signed by an unknown key, since the provider might have rotated them.
+func (p *Providers) VerifyIDToken(ctx context.Context, issuer, clientID, idToken string) (jwt.MapClaims, error) {
+	provider, err := p.Get(ctx, issuer, false)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, keyFunc(provider.Keys))
+	if err != nil && isKeyNotFoundError(err) {
+		if provider, err = p.Get(ctx, issuer, true); err != nil {
+			return nil, err
+		}
+		claims = jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(idToken, claims, keyFunc(provider.Keys))
+	}
+	if err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Invalid id token provided", err, nil)
+	}
+
+	if !claims.VerifyIssuer(issuer, true) {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Id token has been issued by an unknown issuer", nil, map[string]interface{}{"iss": claims["iss"]})
+	}
+	if !claims.VerifyAudience(clientID, true) {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Id token has not been issued for the configured client id", nil, map[string]interface{}{"aud": claims["aud"]})
+	}
+	if _, p := claims["exp"]; !p {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), "Id token does not have an expiry", nil, nil)
+	}
+	return claims, nil
+}
+
+// GetResource decodes the json document served at the url
+func GetResource(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseTheCloser(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status code (%d) from (%s)", res.StatusCode, url)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func fetchProvider(ctx context.Context, issuer string) (*Provider, error) {
+	provider := new(Provider)
+	if err := GetResource(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", provider); err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to discover openid connect issuer (%s)", issuer), err, nil)
+	}
+	if provider.Issuer != issuer {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Issuer (%s) in discovery document does not match the configured issuer (%s)", provider.Issuer, issuer), nil, nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.JwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to fetch keys of openid connect issuer (%s)", issuer), err, nil)
+	}
+	defer utils.CloseTheCloser(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to fetch keys of openid connect issuer (%s)", issuer), fmt.Errorf("received status code (%d)", res.StatusCode), nil)
+	}
+	if provider.Keys, err = jwk.Parse(res.Body); err != nil {
+		return nil, helpers.Logger.LogError(helpers.GetRequestID(ctx), fmt.Sprintf("Unable to parse keys of openid connect issuer (%s)", issuer), err, nil)
+	}
+
+	provider.fetchedAt = time.Now()
+	return provider, nil
+}
+
+// keyFunc returns the key the id token has been signed with. Only asymmetric algorithms are accepted.
+func keyFunc(keys *jwk.Set) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
+				return nil, fmt.Errorf("unsupported signing algorithm (%s) used for id token", token.Method.Alg())
+			}
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		arr := keys.Keys
+		if kid != "" {
+			arr = keys.LookupKeyID(kid)
+		}
+		if len(arr) == 0 {
+			return nil, errKeyNotFound
+		}
+
+		var raw interface{}
+		if err := arr[0].Raw(&raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	}
+}
+
+func isKeyNotFoundError(err error) bool {
+	var validationErr *jwt.ValidationError
+	return errors.As(err, &validationErr) && validationErr.Inner == errKeyNotFound
+}
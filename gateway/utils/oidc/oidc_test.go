@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestProviders_VerifyIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unable to generate key - %v", err)
+	}
+
+	// The kid of the key published by the issuer can be changed to mimic a key rotation
+	kid := "key-1"
+	fetches := 0
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{map[string]interface{}{
+			"kty": "RSA",
+			"kid": kid,
+			"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	idToken := func(kid string, method jwt.SigningMethod, signingKey interface{}, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		s, _ := token.SignedString(signingKey)
+		return s
+	}
+	claims := func(aud string) jwt.MapClaims {
+		return jwt.MapClaims{"iss": server.URL, "aud": aud, "email": "jon@example.com", "exp": time.Now().Add(time.Minute).Unix()}
+	}
+
+	tests := []struct {
+		name        string
+		idToken     string
+		rotateTo    string
+		wantErr     bool
+		wantFetches int
+	}{
+		{name: "valid token", idToken: idToken("key-1", jwt.SigningMethodRS256, key, claims("client-id")), wantFetches: 1},
+		{name: "cached keys are used", idToken: idToken("key-1", jwt.SigningMethodRS256, key, claims("client-id")), wantFetches: 1},
+		{name: "keys are fetched again once rotated", idToken: idToken("key-2", jwt.SigningMethodRS256, key, claims("client-id")), rotateTo: "key-2", wantFetches: 2},
+		{name: "unknown key", idToken: idToken("key-3", jwt.SigningMethodRS256, key, claims("client-id")), wantErr: true, wantFetches: 3},
+		{name: "token issued for another client", idToken: idToken("key-2", jwt.SigningMethodRS256, key, claims("other-client")), wantErr: true, wantFetches: 3},
+		{name: "symmetric algorithm", idToken: idToken("key-2", jwt.SigningMethodHS256, []byte("client-secret"), claims("client-id")), wantErr: true, wantFetches: 3},
+		{name: "token without an expiry", idToken: idToken("key-2", jwt.SigningMethodRS256, key, jwt.MapClaims{"iss": server.URL, "aud": "client-id"}), wantErr: true, wantFetches: 3},
+	}
+
+	providers := new(Providers)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.rotateTo != "" {
+				kid = tt.rotateTo
+			}
+			got, err := providers.VerifyIDToken(context.Background(), server.URL, "client-id", tt.idToken)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyIDToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fetches != tt.wantFetches {
+				t.Errorf("VerifyIDToken() fetched the provider (%d) times, want (%d)", fetches, tt.wantFetches)
+			}
+			if !tt.wantErr && got["email"] != "jon@example.com" {
+				t.Errorf("VerifyIDToken() claims = %v", got)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -130,3 +131,123 @@ func (m *Module) GetKey(ctx context.Context, key string) (string, error) {
 
 	return m.client.Get(ctx, key).Result()
 }
+
+// Publish broadcasts a message to all the subscribers of a topic without waiting for an acknowledgement
+func (m *Module) Publish(ctx context.Context, topic string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return m.client.Publish(ctx, m.getTopicName(topic), string(data)).Err()
+}
+
+// AddToSortedSet adds a member to the sorted set with the score provided. Like topics, keys of sorted sets
+// and hashes are scoped to the project.
+func (m *Module) AddToSortedSet(ctx context.Context, key, member string, score float64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.client.ZAdd(ctx, m.getTopicName(key), &redis.Z{Score: score, Member: member}).Err()
+}
+
+// GetSortedSetMembers gets the members of the sorted set having a score greater than or equal to min
+func (m *Module) GetSortedSetMembers(ctx context.Context, key string, min float64) ([]redis.Z, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.client.ZRangeByScoreWithScores(ctx, m.getTopicName(key), &redis.ZRangeBy{Min: strconv.FormatFloat(min, 'f', -1, 64), Max: "+inf"}).Result()
+}
+
+// RemoveFromSortedSetBelowScore removes the members of the sorted set having a score less than max
+func (m *Module) RemoveFromSortedSetBelowScore(ctx context.Context, key string, max float64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.client.ZRemRangeByScore(ctx, m.getTopicName(key), "-inf", "("+strconv.FormatFloat(max, 'f', -1, 64)).Err()
+}
+
+// SetHashField sets a field of the hash stored at key
+func (m *Module) SetHashField(ctx context.Context, key, field, value string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.client.HSet(ctx, m.getTopicName(key), field, value).Err()
+}
+
+// GetHash gets all the fields of the hash stored at key
+func (m *Module) GetHash(ctx context.Context, key string) (map[string]string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.client.HGetAll(ctx, m.getTopicName(key)).Result()
+}
+
+// DeleteHashFields deletes the fields provided from the hash stored at key
+func (m *Module) DeleteHashFields(ctx context.Context, key string, fields ...string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.client.HDel(ctx, m.getTopicName(key), fields...).Err()
+}
+
+// IncrementKey increments the counter stored at key and resets its ttl. The key is scoped to the project.
+func (m *Module) IncrementKey(ctx context.Context, key string, t time.Duration) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	pipe := m.client.TxPipeline()
+	incr := pipe.Incr(ctx, m.getTopicName(key))
+	pipe.Expire(ctx, m.getTopicName(key), t)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// SetProjectKey sets the value of a key scoped to the project along with a ttl
+func (m *Module) SetProjectKey(ctx context.Context, key, value string, t time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.client.Set(ctx, m.getTopicName(key), value, t).Err()
+}
+
+// GetProjectKey gets the value of a key scoped to the project. An empty value is returned if the key doesn't exist.
+func (m *Module) GetProjectKey(ctx context.Context, key string) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	value, err := m.client.Get(ctx, m.getTopicName(key)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return value, err
+}
+
+// GetProjectKeyTTL gets the remaining ttl of a key scoped to the project. Zero is returned if the key doesn't exist.
+func (m *Module) GetProjectKeyTTL(ctx context.Context, key string) (time.Duration, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ttl, err := m.client.PTTL(ctx, m.getTopicName(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// DeleteProjectKeys deletes the keys scoped to the project
+func (m *Module) DeleteProjectKeys(ctx context.Context, keys ...string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = m.getTopicName(key)
+	}
+	return m.client.Del(ctx, names...).Err()
+}
@@ -108,13 +108,19 @@ func Validate(dbType string, where map[string]interface{}, obj interface{}) bool
 				if !ok {
 					return false
 				}
+				matched := false
 				for _, val := range array {
 					value := val.(map[string]interface{})
 					if Validate(dbType, value, res) {
-						return true
+						matched = true
+						break
 					}
 				}
-				return false
+				if !matched {
+					return false
+				}
+				// The other conditions of the where clause must match as well
+				continue
 			}
 
 			val, p := res[k]
@@ -62,6 +62,15 @@ func TestValidate(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "$or along with other conditions",
+			args: args{
+				dbType: string(model.Postgres),
+				where:  map[string]interface{}{"$or": []interface{}{map[string]interface{}{"op2": 1}}, "op3": 2},
+				obj:    map[string]interface{}{"op2": 1, "op3": 3},
+			},
+			want: false,
+		},
 		{
 			name: "test4",
 			args: args{
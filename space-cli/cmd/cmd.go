@@ -11,11 +11,15 @@ import (
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/accounts"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/addons"
+	apikeys "github.com/spaceuptech/space-cloud/space-cli/cmd/modules/api-keys"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/audit"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/deploy"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/history"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/login"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/logs"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/operations"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/project"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/rules"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils"
 )
 
@@ -114,6 +118,10 @@ func GetRootCommand() *cobra.Command {
 	rootCmd.AddCommand(login.Commands()...)
 	rootCmd.AddCommand(accounts.Commands()...)
 	rootCmd.AddCommand(logs.GetSubCommands()...)
+	rootCmd.AddCommand(rules.Commands()...)
+	rootCmd.AddCommand(apikeys.Commands()...)
+	rootCmd.AddCommand(audit.Commands()...)
+	rootCmd.AddCommand(history.Commands()...)
 	rootCmd.AddCommand(completionCmd)
 	return rootCmd
 }
@@ -0,0 +1,154 @@
+package apikeys
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils"
+)
+
+// Commands is the list of commands the api-keys module exposes
+func Commands() []*cobra.Command {
+	var apiKeysCmd = &cobra.Command{
+		Use:     "api-keys",
+		Aliases: []string{"api-key"},
+		Short:   "Manage the api keys used by servers to access a project",
+	}
+
+	var createCmd = &cobra.Command{
+		Use:     "create [id]",
+		Short:   "Creates an api key and prints it. The key cannot be retrieved later on",
+		Example: "space-cli api-keys create backend --scope db:mydb:orders --scope services:payments --expires-in 720h --project myproject",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			for _, flag := range []string{"name", "scope", "allowed-ip", "expires-in", "claims"} {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					_ = utils.LogError(fmt.Sprintf("Unable to bind the flag ('%s')", flag), nil)
+				}
+			}
+		},
+		RunE: actionCreateAPIKey,
+	}
+	createCmd.Flags().StringP("name", "", "", "Name to identify the api key with")
+	createCmd.Flags().StringSliceP("scope", "", []string{}, "Scopes granted to the api key, like db:<dbAlias>:<table>:<op>, eventing:<type> or services:<service>:<endpoint>")
+	createCmd.Flags().StringSliceP("allowed-ip", "", []string{}, "IPs or CIDR ranges the api key can be used from")
+	createCmd.Flags().DurationP("expires-in", "", 0, "Duration after which the api key expires")
+	createCmd.Flags().StringP("claims", "", "", "JSON object of claims made available to the security rules")
+
+	var rotateCmd = &cobra.Command{
+		Use:               "rotate [id]",
+		Short:             "Replaces the key of an api key and prints the new one",
+		Example:           "space-cli api-keys rotate backend --project myproject",
+		RunE:              actionRotateAPIKey,
+		ValidArgsFunction: apiKeysAutoCompleteFunc,
+	}
+
+	apiKeysCmd.AddCommand(createCmd, rotateCmd)
+	return []*cobra.Command{apiKeysCmd}
+}
+
+// GetSubCommands is the list of commands the api-keys module exposes
+func GetSubCommands() []*cobra.Command {
+	var getAPIKeys = &cobra.Command{
+		Use:               "api-keys",
+		Aliases:           []string{"api-key"},
+		RunE:              actionGetAPIKeys,
+		ValidArgsFunction: apiKeysAutoCompleteFunc,
+	}
+	return []*cobra.Command{getAPIKeys}
+}
+
+// DeleteSubCommands is the list of commands the api-keys module exposes
+func DeleteSubCommands() []*cobra.Command {
+	var deleteAPIKey = &cobra.Command{
+		Use:               "api-key",
+		Aliases:           []string{"api-keys"},
+		RunE:              actionDeleteAPIKey,
+		ValidArgsFunction: apiKeysAutoCompleteFunc,
+		Example:           "space-cli delete api-key backend --project myproject",
+	}
+	return []*cobra.Command{deleteAPIKey}
+}
+
+func actionCreateAPIKey(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return utils.LogError("incorrect number of arguments. Use -h to check usage instructions", nil)
+	}
+	project, check := utils.GetProjectID()
+	if !check {
+		return utils.LogError("Project not specified in flag", nil)
+	}
+
+	spec, err := generateAPIKey(viper.GetString("name"), viper.GetStringSlice("scope"), viper.GetStringSlice("allowed-ip"), viper.GetDuration("expires-in"), viper.GetString("claims"))
+	if err != nil {
+		return err
+	}
+
+	key, err := setAPIKey(project, args[0], spec)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		utils.LogInfo(fmt.Sprintf("Api key (%s) already existed and has been updated, its key remains unchanged", args[0]))
+		return nil
+	}
+	printKey(args[0], key)
+	return nil
+}
+
+func actionRotateAPIKey(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return utils.LogError("incorrect number of arguments. Use -h to check usage instructions", nil)
+	}
+	project, check := utils.GetProjectID()
+	if !check {
+		return utils.LogError("Project not specified in flag", nil)
+	}
+
+	key, err := rotateAPIKey(project, args[0])
+	if err != nil {
+		return err
+	}
+	printKey(args[0], key)
+	return nil
+}
+
+func actionGetAPIKeys(cmd *cobra.Command, args []string) error {
+	// Get the project and url parameters
+	project, check := utils.GetProjectID()
+	if !check {
+		return utils.LogError("Project not specified in flag", nil)
+	}
+	commandName := "api-key"
+
+	params := map[string]string{}
+	if len(args) != 0 {
+		params["id"] = args[0]
+	}
+
+	objs, err := GetAPIKeys(project, commandName, params)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.PrintYaml(objs); err != nil {
+		return err
+	}
+	return nil
+}
+
+func actionDeleteAPIKey(cmd *cobra.Command, args []string) error {
+	// Get the project and url parameters
+	project, check := utils.GetProjectID()
+	if !check {
+		return utils.LogError("Project not specified in flag", nil)
+	}
+
+	prefix := ""
+	if len(args) != 0 {
+		prefix = args[0]
+	}
+
+	return deleteAPIKey(project, prefix)
+}
@@ -0,0 +1,37 @@
+package apikeys
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/model"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/filter"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/transport"
+)
+
+func deleteAPIKey(project, prefix string) error {
+
+	objs, err := GetAPIKeys(project, "api-key", map[string]string{"id": "*"})
+	if err != nil {
+		return err
+	}
+
+	apiKeys := []string{}
+	for _, spec := range objs {
+		apiKeys = append(apiKeys, spec.Meta["id"])
+	}
+
+	resourceID, err := filter.DeleteOptions(prefix, apiKeys)
+	if err != nil {
+		return err
+	}
+
+	// Delete the api key from the server
+	url := fmt.Sprintf("/v1/config/projects/%s/security/api-keys/%s", project, resourceID)
+
+	if err := transport.Client.MakeHTTPRequest(http.MethodDelete, url, map[string]string{"id": resourceID}, new(model.Response)); err != nil {
+		return err
+	}
+
+	return nil
+}
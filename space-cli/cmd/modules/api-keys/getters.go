@@ -0,0 +1,39 @@
+package apikeys
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/model"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/transport"
+)
+
+// GetAPIKeys gets the api keys of a project
+func GetAPIKeys(project, commandName string, params map[string]string) ([]*model.SpecObject, error) {
+	url := fmt.Sprintf("/v1/config/projects/%s/security/api-keys", project)
+
+	// Get the spec from the server
+	payload := new(model.Response)
+	if err := transport.Client.MakeHTTPRequest(http.MethodGet, url, params, payload); err != nil {
+		return nil, err
+	}
+
+	var objs []*model.SpecObject
+	for _, item := range payload.Result {
+		spec := item.(map[string]interface{})
+		meta := map[string]string{"project": project, "id": spec["id"].(string)}
+
+		// Delete the unwanted keys from spec. The hash is generated by the server
+		delete(spec, "id")
+		delete(spec, "hash")
+
+		// Printing the object on the screen
+		s, err := utils.CreateSpecObject("/v1/config/projects/{project}/security/api-keys/{id}", commandName, meta, spec)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, s)
+	}
+	return objs, nil
+}
@@ -0,0 +1,94 @@
+package apikeys
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/model"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/transport"
+)
+
+func TestGetAPIKeys(t *testing.T) {
+	type mockArgs struct {
+		method         string
+		args           []interface{}
+		paramsReturned []interface{}
+	}
+	type args struct {
+		project     string
+		commandName string
+		params      map[string]string
+	}
+	tests := []struct {
+		name              string
+		args              args
+		transportMockArgs []mockArgs
+		want              []*model.SpecObject
+		wantErr           bool
+	}{
+		{
+			name: "Successful test",
+			args: args{project: "myproject", commandName: "api-key", params: map[string]string{}},
+			transportMockArgs: []mockArgs{
+				{
+					method: "MakeHTTPRequest",
+					args:   []interface{}{http.MethodGet, "/v1/config/projects/myproject/security/api-keys", map[string]string{}, new(model.Response)},
+					paramsReturned: []interface{}{nil, model.Response{
+						Result: []interface{}{map[string]interface{}{
+							"id":     "backend",
+							"hash":   "3b1f",
+							"scopes": []interface{}{"db:mydb"},
+						}},
+					}},
+				},
+			},
+			want: []*model.SpecObject{
+				{
+					API:  "/v1/config/projects/{project}/security/api-keys/{id}",
+					Type: "api-key",
+					Meta: map[string]string{"id": "backend", "project": "myproject"},
+					Spec: map[string]interface{}{"scopes": []interface{}{"db:mydb"}},
+				},
+			},
+		},
+		{
+			name: "Unable to get api keys",
+			args: args{project: "myproject", commandName: "api-key", params: map[string]string{}},
+			transportMockArgs: []mockArgs{
+				{
+					method:         "MakeHTTPRequest",
+					args:           []interface{}{http.MethodGet, "/v1/config/projects/myproject/security/api-keys", map[string]string{}, new(model.Response)},
+					paramsReturned: []interface{}{errors.New("bad request"), model.Response{}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTransport := transport.MocketAuthProviders{}
+
+			for _, m := range tt.transportMockArgs {
+				mockTransport.On(m.method, m.args...).Return(m.paramsReturned...)
+			}
+
+			transport.Client = &mockTransport
+			got, err := GetAPIKeys(tt.args.project, tt.args.commandName, tt.args.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetAPIKeys() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("GetAPIKeys() len = %v, want %v", len(got), len(tt.want))
+				return
+			}
+			for i, v := range got {
+				if !reflect.DeepEqual(v, tt.want[i]) {
+					t.Errorf("GetAPIKeys() v = %v, want %v", v, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package apikeys
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils"
+)
+
+func generateAPIKey(name string, scopes, allowedIPs []string, expiresIn time.Duration, claims string) (map[string]interface{}, error) {
+	if len(scopes) == 0 {
+		return nil, utils.LogError("At least one scope should be provided for the api key", nil)
+	}
+
+	spec := map[string]interface{}{"scopes": scopes}
+	if name != "" {
+		spec["name"] = name
+	}
+	if len(allowedIPs) > 0 {
+		spec["allowedIPs"] = allowedIPs
+	}
+	if expiresIn > 0 {
+		spec["expiresAt"] = time.Now().Add(expiresIn).UTC().Format(time.RFC3339)
+	}
+	if claims != "" {
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(claims), &obj); err != nil {
+			return nil, utils.LogError("Claims of the api key should be a JSON object", err)
+		}
+		spec["claims"] = obj
+	}
+	return spec, nil
+}
+
+func setAPIKey(project, id string, spec map[string]interface{}) (string, error) {
+	return postAPIKey(fmt.Sprintf("/v1/config/projects/%s/security/api-keys/%s", project, id), spec)
+}
+
+func rotateAPIKey(project, id string) (string, error) {
+	return postAPIKey(fmt.Sprintf("/v1/config/projects/%s/security/api-keys/%s/rotate", project, id), map[string]interface{}{})
+}
+
+// postAPIKey makes the request and returns the key present in the response, if any
+func postAPIKey(url string, body interface{}) (string, error) {
+	account, token, err := utils.LoginWithSelectedAccount()
+	if err != nil {
+		return "", utils.LogError("Couldn't get account details or login token", err)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, account.ServerURL+url, bytes.NewBuffer(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer utils.CloseTheCloser(resp.Body)
+
+	v := struct {
+		Result struct {
+			Key string `json:"key"`
+		} `json:"result"`
+		Error string `json:"error"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", utils.LogError(fmt.Sprintf("received invalid status code (%d) - %s", resp.StatusCode, v.Error), nil)
+	}
+	return v.Result.Key, nil
+}
+
+func printKey(id, key string) {
+	utils.LogInfo(fmt.Sprintf("Api key (%s) - %s", id, key))
+	utils.LogInfo("Store the key safely. It cannot be retrieved again and can only be rotated")
+}
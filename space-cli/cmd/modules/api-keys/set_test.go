@@ -0,0 +1,71 @@
+package apikeys
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_generateAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyName    string
+		scopes     []string
+		allowedIPs []string
+		expiresIn  time.Duration
+		claims     string
+		want       map[string]interface{}
+		wantErr    bool
+	}{
+		{
+			name:       "all fields provided",
+			keyName:    "Backend",
+			scopes:     []string{"db:mydb", "eventing:*"},
+			allowedIPs: []string{"10.0.0.0/8"},
+			claims:     `{"role": "service"}`,
+			want: map[string]interface{}{
+				"name":       "Backend",
+				"scopes":     []string{"db:mydb", "eventing:*"},
+				"allowedIPs": []string{"10.0.0.0/8"},
+				"claims":     map[string]interface{}{"role": "service"},
+			},
+		},
+		{
+			name:   "only scopes provided",
+			scopes: []string{"*"},
+			want:   map[string]interface{}{"scopes": []string{"*"}},
+		},
+		{
+			name:    "no scopes provided",
+			wantErr: true,
+		},
+		{
+			name:    "invalid claims",
+			scopes:  []string{"*"},
+			claims:  `["role"]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generateAPIKey(tt.keyName, tt.scopes, tt.allowedIPs, tt.expiresIn, tt.claims)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("generateAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("generateAPIKey() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The expiry is computed relative to the current time
+	got, err := generateAPIKey("", []string{"*"}, nil, time.Hour, "")
+	if err != nil {
+		t.Fatalf("generateAPIKey() error = %v", err)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, got["expiresAt"].(string))
+	if err != nil || expiresAt.Before(time.Now().Add(59*time.Minute)) || expiresAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("generateAPIKey() expiresAt = %v, want an hour from now", got["expiresAt"])
+	}
+}
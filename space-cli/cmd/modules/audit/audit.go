@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/model"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/transport"
+)
+
+// auditEntry is a change made to a config resource as recorded by space cloud
+type auditEntry struct {
+	ID           string         `json:"id" yaml:"id"`
+	Time         string         `json:"time" yaml:"time"`
+	Actor        string         `json:"actor" yaml:"actor"`
+	RequestID    string         `json:"requestId" yaml:"requestId"`
+	Event        string         `json:"event" yaml:"event"`
+	ResourceID   string         `json:"resourceId" yaml:"resourceId"`
+	ResourceType string         `json:"resourceType" yaml:"resourceType"`
+	Project      string         `json:"project,omitempty" yaml:"project,omitempty"`
+	Diff         []*auditChange `json:"diff" yaml:"diff"`
+}
+
+type auditChange struct {
+	Path   string      `json:"path" yaml:"path"`
+	Before interface{} `json:"before,omitempty" yaml:"before,omitempty"`
+	After  interface{} `json:"after,omitempty" yaml:"after,omitempty"`
+}
+
+// getAuditEntries gets the entries of the audit trail matching the params
+func getAuditEntries(params map[string]string) ([]*auditEntry, error) {
+	payload := new(model.Response)
+	if err := transport.Client.MakeHTTPRequest(http.MethodGet, "/v1/config/audit", params, payload); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(payload.Result)
+	if err != nil {
+		return nil, err
+	}
+	entries := []*auditEntry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseAuditTime converts a duration relative to now or an RFC3339 timestamp to the timestamp space cloud expects
+func parseAuditTime(value string, now time.Time) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d).UTC().Format(time.RFC3339), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("expecting a duration or an RFC3339 timestamp got (%s)", value)
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+func printAuditTable(entries []*auditEntry) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Time", "Actor", "Event", "Resource Type", "Resource ID", "Changed Fields", "Request ID"})
+
+	table.SetBorder(false)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+
+	for _, entry := range entries {
+		table.Append([]string{entry.Time, entry.Actor, entry.Event, entry.ResourceType, entry.ResourceID, getChangedFields(entry), entry.RequestID})
+	}
+
+	table.Render()
+}
+
+// getChangedFields returns the paths of the fields changed, or the event itself if the resource was added or deleted
+func getChangedFields(entry *auditEntry) string {
+	paths := []string{}
+	for _, change := range entry.Diff {
+		if change.Path == "" {
+			return "-"
+		}
+		paths = append(paths, change.Path)
+	}
+	return strings.Join(paths, ", ")
+}
+
+func printAuditYaml(entries []*auditEntry) error {
+	for _, entry := range entries {
+		b, err := yaml.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(b))
+		fmt.Println("---")
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/model"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/transport"
+)
+
+func Test_getAuditEntries(t *testing.T) {
+	type mockArgs struct {
+		method         string
+		args           []interface{}
+		paramsReturned []interface{}
+	}
+	params := map[string]string{"project": "myproject", "limit": "50"}
+	tests := []struct {
+		name              string
+		transportMockArgs []mockArgs
+		want              []*auditEntry
+		wantErr           bool
+	}{
+		{
+			name: "Successful test",
+			transportMockArgs: []mockArgs{
+				{
+					method: "MakeHTTPRequest",
+					args:   []interface{}{http.MethodGet, "/v1/config/audit", params, new(model.Response)},
+					paramsReturned: []interface{}{nil, model.Response{
+						Result: []interface{}{map[string]interface{}{
+							"id":           "1",
+							"actor":        "jon",
+							"event":        "update",
+							"resourceType": "db-rule",
+							"diff":         []interface{}{map[string]interface{}{"path": "rules.read.rule", "before": "allow", "after": "deny"}},
+						}},
+					}},
+				},
+			},
+			want: []*auditEntry{{ID: "1", Actor: "jon", Event: "update", ResourceType: "db-rule", Diff: []*auditChange{{Path: "rules.read.rule", Before: "allow", After: "deny"}}}},
+		},
+		{
+			name: "Unable to get the audit trail",
+			transportMockArgs: []mockArgs{
+				{
+					method:         "MakeHTTPRequest",
+					args:           []interface{}{http.MethodGet, "/v1/config/audit", params, new(model.Response)},
+					paramsReturned: []interface{}{errors.New("bad request"), model.Response{}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTransport := transport.MocketAuthProviders{}
+
+			for _, m := range tt.transportMockArgs {
+				mockTransport.On(m.method, m.args...).Return(m.paramsReturned...)
+			}
+
+			transport.Client = &mockTransport
+			got, err := getAuditEntries(params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getAuditEntries() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getAuditEntries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseAuditTime(t *testing.T) {
+	now := time.Date(2020, 10, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty value", value: "", want: ""},
+		{name: "duration", value: "24h", want: "2020-10-04T12:00:00Z"},
+		{name: "timestamp", value: "2020-10-01T10:00:00+05:30", want: "2020-10-01T10:00:00+05:30"},
+		{name: "invalid value", value: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAuditTime(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseAuditTime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseAuditTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils"
+)
+
+// Commands is the list of commands the audit module exposes
+func Commands() []*cobra.Command {
+	var auditCmd = &cobra.Command{
+		Use:     "audit",
+		Short:   "Shows the changes made to the config, latest first",
+		Example: "space-cli audit --project myproject --resource-type db-rule --since 24h",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			for _, flag := range []string{"resource-type", "resource-id", "actor", "request-id", "since", "until", "limit", "output"} {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					_ = utils.LogError(fmt.Sprintf("Unable to bind the flag ('%s')", flag), nil)
+				}
+			}
+		},
+		RunE: actionAudit,
+	}
+	auditCmd.Flags().StringP("resource-type", "", "", "Only show the changes of resources of this type, like db-rule or security-policy")
+	auditCmd.Flags().StringP("resource-id", "", "", "Only show the changes of the resource with this id")
+	auditCmd.Flags().StringP("actor", "", "", "Only show the changes made by this admin")
+	auditCmd.Flags().StringP("request-id", "", "", "Only show the changes made by this request")
+	auditCmd.Flags().StringP("since", "", "", "Only show the changes made after this time. Accepts a duration like 24h or an RFC3339 timestamp")
+	auditCmd.Flags().StringP("until", "", "", "Only show the changes made before this time. Accepts a duration like 24h or an RFC3339 timestamp")
+	auditCmd.Flags().Int64P("limit", "", 50, "Maximum number of changes to show")
+	auditCmd.Flags().StringP("output", "o", "table", "Output format, either table or yaml")
+
+	return []*cobra.Command{auditCmd}
+}
+
+func actionAudit(cmd *cobra.Command, args []string) error {
+	params := map[string]string{"limit": fmt.Sprintf("%d", viper.GetInt64("limit"))}
+
+	// Changes of all projects are shown if a project isn't provided
+	if project := viper.GetString("project"); project != "" {
+		params["project"] = project
+	}
+	for flag, param := range map[string]string{"resource-type": "resourceType", "resource-id": "resourceId", "actor": "actor", "request-id": "requestId"} {
+		if v := viper.GetString(flag); v != "" {
+			params[param] = v
+		}
+	}
+	for _, flag := range []string{"since", "until"} {
+		v, err := parseAuditTime(viper.GetString(flag), time.Now())
+		if err != nil {
+			return utils.LogError(fmt.Sprintf("Invalid value provided for flag ('%s')", flag), err)
+		}
+		if v != "" {
+			params[flag] = v
+		}
+	}
+
+	entries, err := getAuditEntries(params)
+	if err != nil {
+		return utils.LogError("Unable to get the audit trail", err)
+	}
+
+	switch output := viper.GetString("output"); output {
+	case "table":
+		printAuditTable(entries)
+	case "yaml":
+		return printAuditYaml(entries)
+	default:
+		return utils.LogError(fmt.Sprintf("Invalid output format (%s) provided", output), nil)
+	}
+	return nil
+}
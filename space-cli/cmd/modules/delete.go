@@ -1,6 +1,7 @@
 package modules
 
 import (
+	apikeys "github.com/spaceuptech/space-cloud/space-cli/cmd/modules/api-keys"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/auth"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/database"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/eventing"
@@ -19,6 +20,7 @@ func FetchDeleteSubCommands() *cobra.Command {
 		Short:         "",
 		SilenceErrors: true,
 	}
+	deleteCmd.AddCommand(apikeys.DeleteSubCommands()...)
 	deleteCmd.AddCommand(auth.DeleteSubCommands()...)
 	deleteCmd.AddCommand(database.DeleteSubCommands()...)
 	deleteCmd.AddCommand(ingress.DeleteSubCommands()...)
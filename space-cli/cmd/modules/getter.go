@@ -4,6 +4,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	apikeys "github.com/spaceuptech/space-cloud/space-cli/cmd/modules/api-keys"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/auth"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/database"
 	"github.com/spaceuptech/space-cloud/space-cli/cmd/modules/eventing"
@@ -29,6 +30,7 @@ func FetchGetSubCommands() *cobra.Command {
 	if err != nil {
 		_ = utils.LogError("Unable to bind the flag ('filter')", nil)
 	}
+	getCmd.AddCommand(apikeys.GetSubCommands()...)
 	getCmd.AddCommand(auth.GetSubCommands()...)
 	getCmd.AddCommand(database.GetSubCommands()...)
 	getCmd.AddCommand(eventing.GetSubCommands()...)
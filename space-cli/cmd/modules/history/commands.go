@@ -0,0 +1,96 @@
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils"
+)
+
+// Commands is the list of commands the history module exposes
+func Commands() []*cobra.Command {
+	var historyCmd = &cobra.Command{
+		Use:     "history [resource-id]",
+		Short:   "Shows the revisions of a config resource, or of all the resources of the project",
+		Example: "space-cli history chicago--myproject--db-rule--mydb-users --project myproject",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlag("output", cmd.Flags().Lookup("output")); err != nil {
+				_ = utils.LogError("Unable to bind the flag ('output')", nil)
+			}
+		},
+		RunE: actionHistory,
+	}
+	historyCmd.Flags().StringP("output", "o", "table", "Output format, either table or yaml")
+
+	var rollbackCmd = &cobra.Command{
+		Use:   "rollback [resource-id]",
+		Short: "Rolls back a config resource to a revision, or the entire project to an earlier time",
+		Example: `space-cli rollback chicago--myproject--db-rule--mydb-users --revision 3 --project myproject
+space-cli rollback --to 2h --project myproject`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			for _, flag := range []string{"revision", "to"} {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					_ = utils.LogError(fmt.Sprintf("Unable to bind the flag ('%s')", flag), nil)
+				}
+			}
+		},
+		RunE: actionRollback,
+	}
+	rollbackCmd.Flags().IntP("revision", "", 0, "Revision to roll the resource back to")
+	rollbackCmd.Flags().StringP("to", "", "", "Roll the entire project back to this time. Accepts a duration like 2h or an RFC3339 timestamp")
+
+	return []*cobra.Command{historyCmd, rollbackCmd}
+}
+
+func actionHistory(cmd *cobra.Command, args []string) error {
+	project, check := utils.GetProjectID()
+	if !check {
+		return utils.LogError("Project not specified in flag", nil)
+	}
+
+	params := map[string]string{}
+	if len(args) != 0 {
+		params["resourceId"] = args[0]
+	}
+
+	revisions, err := getHistory(project, params)
+	if err != nil {
+		return utils.LogError("Unable to get the history", err)
+	}
+
+	switch output := viper.GetString("output"); output {
+	case "table":
+		printHistoryTable(revisions)
+	case "yaml":
+		return printHistoryYaml(revisions)
+	default:
+		return utils.LogError(fmt.Sprintf("Invalid output format (%s) provided", output), nil)
+	}
+	return nil
+}
+
+func actionRollback(cmd *cobra.Command, args []string) error {
+	project, check := utils.GetProjectID()
+	if !check {
+		return utils.LogError("Project not specified in flag", nil)
+	}
+
+	resourceID := ""
+	if len(args) != 0 {
+		resourceID = args[0]
+	}
+
+	req, err := generateRollbackRequest(resourceID, viper.GetInt("revision"), viper.GetString("to"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := rollback(project, req); err != nil {
+		return utils.LogError("Unable to rollback the config", err)
+	}
+	utils.LogInfo("Successfully rolled back the config")
+	return nil
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/model"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/transport"
+)
+
+// revision is a version of a config resource kept by space cloud
+type revision struct {
+	Revision   int         `json:"revision" yaml:"revision"`
+	ResourceID string      `json:"resourceId" yaml:"resourceId"`
+	Time       string      `json:"time" yaml:"time"`
+	Event      string      `json:"event" yaml:"event"`
+	Resource   interface{} `json:"resource,omitempty" yaml:"resource,omitempty"`
+}
+
+// getHistory gets the revisions of the resources of the project, oldest first
+func getHistory(project string, params map[string]string) ([]*revision, error) {
+	payload := new(model.Response)
+	if err := transport.Client.MakeHTTPRequest(http.MethodGet, fmt.Sprintf("/v1/config/projects/%s/history", project), params, payload); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(payload.Result)
+	if err != nil {
+		return nil, err
+	}
+	revisions := []*revision{}
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// generateRollbackRequest generates the body of the rollback request. A resource is rolled back to a revision
+// while the entire project is rolled back to a time, which can be a duration relative to now.
+func generateRollbackRequest(resourceID string, revision int, to string, now time.Time) (map[string]interface{}, error) {
+	if resourceID != "" {
+		if revision <= 0 {
+			return nil, utils.LogError("A revision must be provided to rollback a resource", nil)
+		}
+		return map[string]interface{}{"resourceId": resourceID, "revision": revision}, nil
+	}
+
+	if to == "" {
+		return nil, utils.LogError("Either a resource id with a revision or the time to rollback the project to must be provided", nil)
+	}
+	if d, err := time.ParseDuration(to); err == nil {
+		return map[string]interface{}{"time": now.Add(-d).UTC().Format(time.RFC3339)}, nil
+	}
+	t, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return nil, utils.LogError(fmt.Sprintf("expecting a duration or an RFC3339 timestamp got (%s)", to), nil)
+	}
+	return map[string]interface{}{"time": t.Format(time.RFC3339)}, nil
+}
+
+// rollback makes the rollback request to space cloud
+func rollback(project string, body map[string]interface{}) error {
+	account, token, err := utils.LoginWithSelectedAccount()
+	if err != nil {
+		return utils.LogError("Couldn't get account details or login token", err)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v1/config/projects/%s/rollback", account.ServerURL, project), bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseTheCloser(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		v := map[string]interface{}{}
+		_ = json.NewDecoder(resp.Body).Decode(&v)
+		return utils.LogError(fmt.Sprintf("received invalid status code (%d) - %v", resp.StatusCode, v["error"]), nil)
+	}
+	return nil
+}
+
+func printHistoryTable(revisions []*revision) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Resource ID", "Revision", "Time", "Event"})
+
+	table.SetBorder(false)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+
+	for _, r := range revisions {
+		table.Append([]string{r.ResourceID, strconv.Itoa(r.Revision), r.Time, r.Event})
+	}
+
+	table.Render()
+}
+
+func printHistoryYaml(revisions []*revision) error {
+	for _, r := range revisions {
+		b, err := yaml.Marshal(r)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(b))
+		fmt.Println("---")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package history
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/model"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/transport"
+)
+
+func Test_getHistory(t *testing.T) {
+	type mockArgs struct {
+		method         string
+		args           []interface{}
+		paramsReturned []interface{}
+	}
+	params := map[string]string{"resourceId": "chicago--myproject--db-rule--mydb-users"}
+	tests := []struct {
+		name              string
+		transportMockArgs []mockArgs
+		want              []*revision
+		wantErr           bool
+	}{
+		{
+			name: "Successful test",
+			transportMockArgs: []mockArgs{
+				{
+					method: "MakeHTTPRequest",
+					args:   []interface{}{http.MethodGet, "/v1/config/projects/myproject/history", params, new(model.Response)},
+					paramsReturned: []interface{}{nil, model.Response{
+						Result: []interface{}{
+							map[string]interface{}{"revision": 1, "resourceId": params["resourceId"], "event": "add", "resource": map[string]interface{}{"id": "users"}},
+							map[string]interface{}{"revision": 2, "resourceId": params["resourceId"], "event": "delete"},
+						},
+					}},
+				},
+			},
+			want: []*revision{
+				{Revision: 1, ResourceID: params["resourceId"], Event: "add", Resource: map[string]interface{}{"id": "users"}},
+				{Revision: 2, ResourceID: params["resourceId"], Event: "delete"},
+			},
+		},
+		{
+			name: "Unable to get the history",
+			transportMockArgs: []mockArgs{
+				{
+					method:         "MakeHTTPRequest",
+					args:           []interface{}{http.MethodGet, "/v1/config/projects/myproject/history", params, new(model.Response)},
+					paramsReturned: []interface{}{errors.New("bad request"), model.Response{}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTransport := transport.MocketAuthProviders{}
+
+			for _, m := range tt.transportMockArgs {
+				mockTransport.On(m.method, m.args...).Return(m.paramsReturned...)
+			}
+
+			transport.Client = &mockTransport
+			got, err := getHistory("myproject", params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getHistory() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getHistory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_generateRollbackRequest(t *testing.T) {
+	now := time.Date(2020, 10, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		resourceID string
+		revision   int
+		to         string
+		want       map[string]interface{}
+		wantErr    bool
+	}{
+		{name: "resource to a revision", resourceID: "id", revision: 2, want: map[string]interface{}{"resourceId": "id", "revision": 2}},
+		{name: "resource without a revision", resourceID: "id", wantErr: true},
+		{name: "project to a duration ago", to: "2h", want: map[string]interface{}{"time": "2020-10-05T10:00:00Z"}},
+		{name: "project to a timestamp", to: "2020-10-01T10:00:00+05:30", want: map[string]interface{}{"time": "2020-10-01T10:00:00+05:30"}},
+		{name: "project to an invalid time", to: "yesterday", wantErr: true},
+		{name: "nothing provided", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generateRollbackRequest(tt.resourceID, tt.revision, tt.to, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("generateRollbackRequest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("generateRollbackRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
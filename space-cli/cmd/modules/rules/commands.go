@@ -0,0 +1,62 @@
+package rules
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils"
+)
+
+// Commands is the list of commands the rules module exposes
+func Commands() []*cobra.Command {
+	var rulesCmd = &cobra.Command{
+		Use:   "rules",
+		Short: "Work with the security rules of a project",
+	}
+
+	var testCmd = &cobra.Command{
+		Use:     "test [path to rule tests file]",
+		Short:   "Evaluates security rules against the test cases provided in a file",
+		Example: "space-cli rules test rules.test.yaml --project myproject",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlag("verbose", cmd.Flags().Lookup("verbose")); err != nil {
+				_ = utils.LogError("Unable to bind the flag ('verbose')", nil)
+			}
+		},
+		RunE: actionTestRules,
+	}
+	testCmd.Flags().BoolP("verbose", "", false, "Prints the evaluation trace of every test case instead of only the failed ones")
+
+	rulesCmd.AddCommand(testCmd)
+	return []*cobra.Command{rulesCmd}
+}
+
+func actionTestRules(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return utils.LogError("incorrect number of arguments. Use -h to check usage instructions", nil)
+	}
+
+	testFile, err := readRuleTestFile(args[0])
+	if err != nil {
+		return utils.LogError("Unable to read the rule tests file", err)
+	}
+
+	// The project flag takes precedence over the one mentioned in the file
+	project := viper.GetString("project")
+	if project == "" {
+		project = testFile.Project
+	}
+	if project == "" {
+		var check bool
+		project, check = utils.GetProjectID()
+		if !check {
+			return utils.LogError("Project not specified in flag or rule tests file", nil)
+		}
+	}
+
+	simulate, err := newRuleSimulator()
+	if err != nil {
+		return err
+	}
+	return runRuleTests(project, testFile.Tests, simulate, viper.GetBool("verbose"))
+}
@@ -0,0 +1,196 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/model"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils"
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/file"
+)
+
+// ruleTestFile is the format of the file containing the rule test cases
+type ruleTestFile struct {
+	Project string          `json:"project,omitempty" yaml:"project,omitempty"`
+	Tests   []*ruleTestCase `json:"tests" yaml:"tests"`
+}
+
+// ruleTestCase describes the rule to be evaluated along with the outcome expected. The rule, ref and
+// mocks are sent to space cloud as is.
+type ruleTestCase struct {
+	Name        string                 `json:"name" yaml:"name"`
+	Rule        interface{}            `json:"rule,omitempty" yaml:"rule,omitempty"`
+	Ref         interface{}            `json:"ref,omitempty" yaml:"ref,omitempty"`
+	Args        map[string]interface{} `json:"args,omitempty" yaml:"args,omitempty"`
+	Auth        map[string]interface{} `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Mocks       interface{}            `json:"mocks,omitempty" yaml:"mocks,omitempty"`
+	ReturnWhere bool                   `json:"returnWhere,omitempty" yaml:"returnWhere,omitempty"`
+	Expect      ruleTestExpectation    `json:"expect" yaml:"expect"`
+}
+
+// ruleTestExpectation is the expected outcome of a test case. Only the fields provided are compared.
+type ruleTestExpectation struct {
+	Allowed     bool        `json:"allowed" yaml:"allowed"`
+	Error       string      `json:"error,omitempty" yaml:"error,omitempty"`
+	PostProcess interface{} `json:"postProcess,omitempty" yaml:"postProcess,omitempty"`
+	Where       interface{} `json:"where,omitempty" yaml:"where,omitempty"`
+}
+
+// ruleSimulationResult is the result returned by space cloud after simulating a rule
+type ruleSimulationResult struct {
+	Allowed     bool        `json:"allowed" yaml:"allowed"`
+	Error       string      `json:"error,omitempty" yaml:"error,omitempty"`
+	Trace       interface{} `json:"trace,omitempty" yaml:"trace,omitempty"`
+	PostProcess interface{} `json:"postProcess,omitempty" yaml:"postProcess,omitempty"`
+	Where       interface{} `json:"where,omitempty" yaml:"where,omitempty"`
+}
+
+type simulator func(project string, tc *ruleTestCase) (*ruleSimulationResult, error)
+
+func readRuleTestFile(fileName string) (*ruleTestFile, error) {
+	data, err := file.File.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	testFile := new(ruleTestFile)
+	if err := yaml.Unmarshal(data, testFile); err != nil {
+		return nil, err
+	}
+	return testFile, nil
+}
+
+// runRuleTests evaluates all the test cases and returns an error if any of them fail
+func runRuleTests(project string, tests []*ruleTestCase, simulate simulator, verbose bool) error {
+	failed := 0
+	for _, tc := range tests {
+		res, err := simulate(project, tc)
+		if err != nil {
+			failed++
+			_ = utils.LogError(fmt.Sprintf("FAIL %s - unable to simulate rule", tc.Name), err)
+			continue
+		}
+
+		if reason := checkExpectation(&tc.Expect, res); reason != "" {
+			failed++
+			_ = utils.LogError(fmt.Sprintf("FAIL %s - %s", tc.Name, reason), nil)
+			printTrace(res)
+			continue
+		}
+
+		utils.LogInfo(fmt.Sprintf("PASS %s", tc.Name))
+		if verbose {
+			printTrace(res)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rule tests failed", failed, len(tests))
+	}
+	utils.LogInfo(fmt.Sprintf("All %d rule tests passed", len(tests)))
+	return nil
+}
+
+// checkExpectation returns the reason the simulation result doesn't match the expectation
+func checkExpectation(expect *ruleTestExpectation, res *ruleSimulationResult) string {
+	if expect.Allowed != res.Allowed {
+		return fmt.Sprintf("expected allowed to be (%v) got (%v) with error (%s)", expect.Allowed, res.Allowed, res.Error)
+	}
+	if expect.Error != "" && expect.Error != res.Error {
+		return fmt.Sprintf("expected error (%s) got (%s)", expect.Error, res.Error)
+	}
+	if expect.PostProcess != nil && !reflect.DeepEqual(normalize(expect.PostProcess), normalize(res.PostProcess)) {
+		return fmt.Sprintf("expected post process actions (%v) got (%v)", expect.PostProcess, res.PostProcess)
+	}
+	if expect.Where != nil && !reflect.DeepEqual(normalize(expect.Where), normalize(res.Where)) {
+		return fmt.Sprintf("expected where clause (%v) got (%v)", expect.Where, res.Where)
+	}
+	return ""
+}
+
+// normalize converts the value to its json representation so that values read from yaml can be compared with the response
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return value
+	}
+	return v
+}
+
+func printTrace(res *ruleSimulationResult) {
+	if res.Trace == nil {
+		return
+	}
+	data, err := yaml.Marshal(res.Trace)
+	if err != nil {
+		return
+	}
+	fmt.Println(string(data))
+}
+
+// newRuleSimulator logs in with the selected account and returns a simulator which sends the test cases to space
+// cloud to be evaluated
+func newRuleSimulator() (simulator, error) {
+	account, token, err := utils.LoginWithSelectedAccount()
+	if err != nil {
+		return nil, utils.LogError("Couldn't get account details or login token", err)
+	}
+
+	return func(project string, tc *ruleTestCase) (*ruleSimulationResult, error) {
+		return simulateRule(account, token, project, tc)
+	}, nil
+}
+
+// simulateRule sends the test case to space cloud to be evaluated
+func simulateRule(account *model.Account, token, project string, tc *ruleTestCase) (*ruleSimulationResult, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"rule":        tc.Rule,
+		"ref":         tc.Ref,
+		"args":        tc.Args,
+		"auth":        tc.Auth,
+		"mocks":       tc.Mocks,
+		"returnWhere": tc.ReturnWhere,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v1/external/projects/%s/security/simulate", account.ServerURL, project)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.CloseTheCloser(resp.Body)
+
+	v := struct {
+		Result *ruleSimulationResult `json:"result"`
+		Error  string                `json:"error"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received invalid status code (%d) - %s", resp.StatusCode, v.Error)
+	}
+	if v.Result == nil {
+		return nil, fmt.Errorf("empty response received from server")
+	}
+	return v.Result, nil
+}
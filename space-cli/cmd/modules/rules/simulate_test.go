@@ -0,0 +1,130 @@
+package rules
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/spaceuptech/space-cloud/space-cli/cmd/utils/file"
+)
+
+func Test_readRuleTestFile(t *testing.T) {
+	data := []byte(`
+project: myproject
+tests:
+  - name: only admins can read
+    ref:
+      type: db-rule
+      db: mongo
+      col: users
+      op: read
+    auth:
+      role: admin
+    expect:
+      allowed: true
+      where:
+        userId:
+          $eq: "1"
+`)
+
+	mockFile := file.Mocket{}
+	mockFile.On("ReadFile").Return(data, nil)
+	file.File = &mockFile
+
+	got, err := readRuleTestFile("rules.test.yaml")
+	if err != nil {
+		t.Fatalf("readRuleTestFile() error = %v", err)
+	}
+
+	want := &ruleTestFile{
+		Project: "myproject",
+		Tests: []*ruleTestCase{{
+			Name: "only admins can read",
+			Ref:  map[string]interface{}{"type": "db-rule", "db": "mongo", "col": "users", "op": "read"},
+			Auth: map[string]interface{}{"role": "admin"},
+			Expect: ruleTestExpectation{
+				Allowed: true,
+				Where:   map[string]interface{}{"userId": map[string]interface{}{"$eq": "1"}},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readRuleTestFile() = %v, want %v", got, want)
+	}
+	mockFile.AssertExpectations(t)
+}
+
+func Test_checkExpectation(t *testing.T) {
+	tests := []struct {
+		name    string
+		expect  *ruleTestExpectation
+		res     *ruleSimulationResult
+		wantErr bool
+	}{
+		{
+			name:   "allowed as expected",
+			expect: &ruleTestExpectation{Allowed: true},
+			res:    &ruleSimulationResult{Allowed: true},
+		},
+		{
+			name:    "denied when expected to be allowed",
+			expect:  &ruleTestExpectation{Allowed: true},
+			res:     &ruleSimulationResult{Allowed: false, Error: "denied"},
+			wantErr: true,
+		},
+		{
+			name:    "error message mismatch",
+			expect:  &ruleTestExpectation{Error: "not an admin"},
+			res:     &ruleSimulationResult{Error: "denied"},
+			wantErr: true,
+		},
+		{
+			name:   "post process actions match",
+			expect: &ruleTestExpectation{Allowed: true, PostProcess: []interface{}{map[string]interface{}{"action": "force", "field": "res.age", "value": 10}}},
+			res:    &ruleSimulationResult{Allowed: true, PostProcess: []interface{}{map[string]interface{}{"action": "force", "field": "res.age", "value": float64(10)}}},
+		},
+		{
+			name:    "where clause mismatch",
+			expect:  &ruleTestExpectation{Allowed: true, Where: map[string]interface{}{"userId": map[string]interface{}{"$eq": "1"}}},
+			res:     &ruleSimulationResult{Allowed: true, Where: map[string]interface{}{"userId": map[string]interface{}{"$eq": "2"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkExpectation(tt.expect, tt.res); (got != "") != tt.wantErr {
+				t.Errorf("checkExpectation() = %v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_runRuleTests(t *testing.T) {
+	tests := []*ruleTestCase{
+		{Name: "allowed", Expect: ruleTestExpectation{Allowed: true}},
+		{Name: "denied", Expect: ruleTestExpectation{Allowed: false}},
+		{Name: "unreachable", Expect: ruleTestExpectation{Allowed: true}},
+	}
+
+	results := map[string]*ruleSimulationResult{
+		"allowed": {Allowed: true},
+		"denied":  {Allowed: true},
+	}
+	simulate := func(project string, tc *ruleTestCase) (*ruleSimulationResult, error) {
+		if project != "myproject" {
+			t.Errorf("runRuleTests() simulated rule for project (%s), want (myproject)", project)
+		}
+		res, ok := results[tc.Name]
+		if !ok {
+			return nil, errors.New("connection refused")
+		}
+		return res, nil
+	}
+
+	if err := runRuleTests("myproject", tests[:1], simulate, false); err != nil {
+		t.Errorf("runRuleTests() unexpected error = %v", err)
+	}
+	if err := runRuleTests("myproject", tests, simulate, false); err == nil || err.Error() != "2 of 3 rule tests failed" {
+		t.Errorf("runRuleTests() error = %v, want (2 of 3 rule tests failed)", err)
+	}
+}